@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
 )
@@ -28,7 +29,7 @@ func runAgentsWhoamiCmd(apiURL, credPath string) error {
 }
 
 // runAgentsLookupCmd is the flag-free business logic for agents lookup.
-func runAgentsLookupCmd(apiURL, credPath, fingerprint string) error {
+func runAgentsLookupCmd(apiURL, credPath, fingerprint, format string) error {
 	client, err := newClientFromCreds(apiURL, credPath)
 	if err != nil {
 		return err
@@ -43,7 +44,86 @@ func runAgentsLookupCmd(apiURL, credPath, fingerprint string) error {
 	if !ok {
 		return formatAPIError(res)
 	}
-	return printJSON(profile)
+	return printAgentProfile(profile, format)
+}
+
+// runAgentsLookupSelfCmd looks up the local agent's own public profile,
+// using the fingerprint from config, and warns on stderr if the directory
+// entry disagrees with the local keys.
+func runAgentsLookupSelfCmd(apiURL, credPath, format string) error {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := selfFingerprint(creds)
+	if err != nil {
+		return err
+	}
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	res, err := client.GetAgentProfile(context.Background(), moltnetapi.GetAgentProfileParams{
+		Fingerprint: fingerprint,
+	})
+	if err != nil {
+		return fmt.Errorf("agents lookup: %w", formatTransportError(err))
+	}
+	profile, ok := res.(*moltnetapi.AgentProfile)
+	if !ok {
+		return formatAPIError(res)
+	}
+	for _, problem := range selfProfileMismatches(profile, creds) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+	}
+	return printAgentProfile(profile, format)
+}
+
+// printAgentProfile prints a looked-up profile as-is ("json") or as an
+// identity card ("card"). The directory serves no attestation to verify,
+// so a card is only checked for the fingerprint/public key binding.
+func printAgentProfile(profile *moltnetapi.AgentProfile, format string) error {
+	switch format {
+	case "", "json":
+		return printJSON(profile)
+	case "card":
+	default:
+		return fmt.Errorf("agents lookup: unknown --format %q (want json or card)", format)
+	}
+	card := identityCardFromProfile(profile, time.Now())
+	if err := verifyIdentityCardBinding(card); err != nil {
+		return fmt.Errorf("agents lookup: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Note: the directory returns no self-signature; only the fingerprint/public key binding was verified")
+	return printJSON(card)
+}
+
+// selfFingerprint returns the local agent's fingerprint, deriving it from
+// the public key when the config does not record one.
+func selfFingerprint(creds *CredentialsFile) (string, error) {
+	if creds.Keys.Fingerprint != "" {
+		return creds.Keys.Fingerprint, nil
+	}
+	if creds.Keys.PublicKey == "" {
+		return "", fmt.Errorf("agents lookup: config has no fingerprint or public key — run 'moltnet config repair'")
+	}
+	pub, err := ParsePublicKey(creds.Keys.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("agents lookup: %w", err)
+	}
+	return Fingerprint(pub), nil
+}
+
+// selfProfileMismatches compares the published profile with local config.
+func selfProfileMismatches(profile *moltnetapi.AgentProfile, creds *CredentialsFile) []string {
+	var problems []string
+	if creds.Keys.PublicKey != "" && profile.PublicKey != creds.Keys.PublicKey {
+		problems = append(problems, fmt.Sprintf("published public key %s does not match local %s", profile.PublicKey, creds.Keys.PublicKey))
+	}
+	if creds.Keys.Fingerprint != "" && profile.Fingerprint != creds.Keys.Fingerprint {
+		problems = append(problems, fmt.Sprintf("published fingerprint %s does not match local %s", profile.Fingerprint, creds.Keys.Fingerprint))
+	}
+	return problems
 }
 
 // printJSON marshals v to indented JSON and writes to stdout.
@@ -51,9 +131,20 @@ func printJSON(v interface{}) error {
 	return printJSONTo(os.Stdout, v)
 }
 
-// printJSONTo marshals v to indented JSON and writes to w. Used by
-// commands whose tests inject a buffer instead of stdout.
+// printJSONTo marshals v to indented JSON, JSON Lines under --output
+// jsonl or the --template rendering under --output template, and writes to
+// w. Used by commands whose tests inject a buffer instead of stdout.
 func printJSONTo(w io.Writer, v interface{}) error {
+	switch currentOutputFormat() {
+	case outputFormatJSONL:
+		return writeJSONLines(w, v)
+	case outputFormatTable:
+		return writeTable(w, v, currentTableOptions())
+	case outputFormatTemplate:
+		if tmpl := outputTemplate.Load(); tmpl != nil {
+			return writeTemplate(w, tmpl, v)
+		}
+	}
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(v)
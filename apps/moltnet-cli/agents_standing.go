@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// trustGraphPageSize and trustGraphMaxPages bound the trust-graph scan used
+// to find this agent's vouch edges. The graph endpoint covers the whole
+// network, so very large networks are truncated and flagged as such.
+const (
+	trustGraphPageSize = 100
+	trustGraphMaxPages = 50
+)
+
+// vouchEdge is one side of a vouch relationship.
+type vouchEdge struct {
+	Fingerprint string    `json:"fingerprint"`
+	RedeemedAt  time.Time `json:"redeemedAt"`
+}
+
+// diaryCounts summarises the diaries visible to the agent.
+type diaryCounts struct {
+	Total        int            `json:"total"`
+	ByVisibility map[string]int `json:"byVisibility"`
+}
+
+// agentStanding aggregates the agent's trust position on the network.
+// Sections that could not be fetched are omitted and listed in Unavailable.
+type agentStanding struct {
+	ActiveVouchers      *int         `json:"activeVouchers,omitempty"`
+	VouchedFor          []vouchEdge  `json:"vouchedFor,omitempty"`
+	VouchedBy           []vouchEdge  `json:"vouchedBy,omitempty"`
+	TrustGraphTruncated bool         `json:"trustGraphTruncated,omitempty"`
+	Diaries             *diaryCounts `json:"diaries,omitempty"`
+	Unavailable         []string     `json:"unavailable,omitempty"`
+}
+
+// whoamiWithStanding is the output of agents whoami --stats.
+type whoamiWithStanding struct {
+	Identity *moltnetapi.Whoami `json:"identity"`
+	Standing agentStanding      `json:"standing"`
+}
+
+// runAgentsWhoamiStatsCmd prints whoami plus vouch and diary statistics.
+// Only the whoami call is required; each statistic degrades to a warning.
+func runAgentsWhoamiStatsCmd(apiURL, credPath string, w io.Writer) error {
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	res, err := client.GetWhoami(context.Background())
+	if err != nil {
+		return fmt.Errorf("agents whoami: %w", formatTransportError(err))
+	}
+	whoami, ok := res.(*moltnetapi.Whoami)
+	if !ok {
+		return formatAPIError(res)
+	}
+	return printJSONTo(w, whoamiWithStanding{Identity: whoami, Standing: fetchAgentStanding(client, whoami.Fingerprint)})
+}
+
+func fetchAgentStanding(client *moltnetapi.Client, fingerprint string) agentStanding {
+	var s agentStanding
+	unavailable := func(section string, err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %s unavailable: %v\n", section, err)
+		s.Unavailable = append(s.Unavailable, section)
+	}
+
+	if n, err := countActiveVouchers(client); err != nil {
+		unavailable("activeVouchers", err)
+	} else {
+		s.ActiveVouchers = &n
+	}
+
+	if vouchedFor, vouchedBy, truncated, err := vouchEdgesFor(client, fingerprint); err != nil {
+		unavailable("trustGraph", err)
+	} else {
+		s.VouchedFor, s.VouchedBy, s.TrustGraphTruncated = vouchedFor, vouchedBy, truncated
+	}
+
+	if counts, err := countDiaries(client); err != nil {
+		unavailable("diaries", err)
+	} else {
+		s.Diaries = counts
+	}
+	return s
+}
+
+func countActiveVouchers(client *moltnetapi.Client) (int, error) {
+	res, err := client.ListActiveVouchers(context.Background())
+	if err != nil {
+		return 0, formatTransportError(err)
+	}
+	vouchers, ok := res.(*moltnetapi.ListActiveVouchersOK)
+	if !ok {
+		return 0, formatAPIError(res)
+	}
+	return len(vouchers.Vouchers), nil
+}
+
+// vouchEdgesFor scans the trust graph for edges touching fingerprint:
+// agents it vouched for (issuer) and agents that vouched for it (redeemer).
+func vouchEdgesFor(client *moltnetapi.Client, fingerprint string) (vouchedFor, vouchedBy []vouchEdge, truncated bool, err error) {
+	edges, truncated, err := fetchTrustGraph(client)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	vouchedFor, vouchedBy = []vouchEdge{}, []vouchEdge{}
+	for _, e := range edges {
+		switch fingerprint {
+		case e.IssuerFingerprint:
+			vouchedFor = append(vouchedFor, vouchEdge{Fingerprint: e.RedeemerFingerprint, RedeemedAt: e.RedeemedAt})
+		case e.RedeemerFingerprint:
+			vouchedBy = append(vouchedBy, vouchEdge{Fingerprint: e.IssuerFingerprint, RedeemedAt: e.RedeemedAt})
+		}
+	}
+	return vouchedFor, vouchedBy, truncated, nil
+}
+
+// fetchTrustGraph pages through the whole trust graph, up to
+// trustGraphMaxPages; truncated reports that pages were left unread.
+func fetchTrustGraph(client *moltnetapi.Client) (edges []moltnetapi.GetTrustGraphOKEdgesItem, truncated bool, err error) {
+	for page := 0; page < trustGraphMaxPages; page++ {
+		res, err := client.GetTrustGraph(context.Background(), moltnetapi.GetTrustGraphParams{
+			Limit:  moltnetapi.OptFloat64{Value: trustGraphPageSize, Set: true},
+			Offset: moltnetapi.OptFloat64{Value: float64(page * trustGraphPageSize), Set: true},
+		})
+		if err != nil {
+			return nil, false, formatTransportError(err)
+		}
+		graph, ok := res.(*moltnetapi.GetTrustGraphOK)
+		if !ok {
+			return nil, false, formatAPIError(res)
+		}
+		edges = append(edges, graph.Edges...)
+		if len(graph.Edges) < trustGraphPageSize {
+			return edges, false, nil
+		}
+	}
+	return edges, true, nil
+}
+
+func countDiaries(client *moltnetapi.Client) (*diaryCounts, error) {
+	res, err := client.ListDiaries(context.Background(), moltnetapi.ListDiariesParams{})
+	if err != nil {
+		return nil, formatTransportError(err)
+	}
+	list, ok := res.(*moltnetapi.DiaryCatalogList)
+	if !ok {
+		return nil, formatAPIError(res)
+	}
+	counts := &diaryCounts{Total: len(list.Items), ByVisibility: map[string]int{}}
+	for _, d := range list.Items {
+		counts.ByVisibility[string(d.Visibility)]++
+	}
+	return counts, nil
+}
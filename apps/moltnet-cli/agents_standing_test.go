@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+type standingStubHandler struct {
+	stubAgentsHandler
+	edges       []moltnetapi.GetTrustGraphOKEdgesItem
+	graphCalls  int
+	vouchersErr bool
+}
+
+func (h *standingStubHandler) ListActiveVouchers(_ context.Context) (moltnetapi.ListActiveVouchersRes, error) {
+	if h.vouchersErr {
+		return &moltnetapi.ListActiveVouchersUnauthorized{
+			Title:  "Unauthorized",
+			Status: 401,
+			Code:   "UNAUTHORIZED",
+			Type:   url.URL{Scheme: "about", Opaque: "blank"},
+		}, nil
+	}
+	return &moltnetapi.ListActiveVouchersOK{Vouchers: []moltnetapi.Voucher{
+		{Code: "v1", ExpiresAt: time.Now().Add(time.Hour)},
+		{Code: "v2", ExpiresAt: time.Now().Add(time.Hour)},
+	}}, nil
+}
+
+func (h *standingStubHandler) GetTrustGraph(_ context.Context, params moltnetapi.GetTrustGraphParams) (moltnetapi.GetTrustGraphRes, error) {
+	h.graphCalls++
+	offset, limit := int(params.Offset.Value), int(params.Limit.Value)
+	end := min(offset+limit, len(h.edges))
+	if offset > end {
+		offset = end
+	}
+	return &moltnetapi.GetTrustGraphOK{Edges: h.edges[offset:end]}, nil
+}
+
+func (h *standingStubHandler) ListDiaries(_ context.Context, _ moltnetapi.ListDiariesParams) (moltnetapi.ListDiariesRes, error) {
+	items := []moltnetapi.DiaryCatalog{*newTestDiary("a"), *newTestDiary("b"), *newTestDiary("c")}
+	items[0].Visibility = moltnetapi.DiaryCatalogVisibilityPrivate
+	items[1].Visibility = moltnetapi.DiaryCatalogVisibilityPrivate
+	items[2].Visibility = moltnetapi.DiaryCatalogVisibilityPublic
+	return &moltnetapi.DiaryCatalogList{Items: items}, nil
+}
+
+func TestRunAgentsWhoamiStatsCmd(t *testing.T) {
+	// Arrange
+	const self = "A1B2-C3D4-E5F6-A1B2"
+	edges := []moltnetapi.GetTrustGraphOKEdgesItem{
+		{IssuerFingerprint: self, RedeemerFingerprint: "AAAA-AAAA-AAAA-AAAA"},
+		{IssuerFingerprint: "BBBB-BBBB-BBBB-BBBB", RedeemerFingerprint: self},
+	}
+	// Pad past one page so pagination is exercised.
+	for range trustGraphPageSize {
+		edges = append(edges, moltnetapi.GetTrustGraphOKEdgesItem{IssuerFingerprint: "CCCC-CCCC-CCCC-CCCC", RedeemerFingerprint: "DDDD-DDDD-DDDD-DDDD"})
+	}
+	edges = append(edges, moltnetapi.GetTrustGraphOKEdgesItem{IssuerFingerprint: self, RedeemerFingerprint: "EEEE-EEEE-EEEE-EEEE"})
+	handler := &standingStubHandler{edges: edges}
+	apiSrv, credPath := newCLICommandTestServer(t, handler)
+	var out bytes.Buffer
+
+	// Act
+	err := runAgentsWhoamiStatsCmd(apiSrv.URL, credPath, &out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runAgentsWhoamiStatsCmd() error: %v", err)
+	}
+	var got whoamiWithStanding
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, out.String())
+	}
+	if got.Identity == nil || got.Identity.Fingerprint != self {
+		t.Fatalf("expected whoami fingerprint %s, got %+v", self, got.Identity)
+	}
+	s := got.Standing
+	if s.ActiveVouchers == nil || *s.ActiveVouchers != 2 {
+		t.Errorf("activeVouchers: got %v, want 2", s.ActiveVouchers)
+	}
+	if len(s.VouchedFor) != 2 || s.VouchedFor[0].Fingerprint != "AAAA-AAAA-AAAA-AAAA" || s.VouchedFor[1].Fingerprint != "EEEE-EEEE-EEEE-EEEE" {
+		t.Errorf("vouchedFor: got %+v", s.VouchedFor)
+	}
+	if len(s.VouchedBy) != 1 || s.VouchedBy[0].Fingerprint != "BBBB-BBBB-BBBB-BBBB" {
+		t.Errorf("vouchedBy: got %+v", s.VouchedBy)
+	}
+	if handler.graphCalls != 2 || s.TrustGraphTruncated {
+		t.Errorf("expected 2 untruncated graph pages, got %d calls, truncated=%v", handler.graphCalls, s.TrustGraphTruncated)
+	}
+	if s.Diaries == nil || s.Diaries.Total != 3 || s.Diaries.ByVisibility["private"] != 2 || s.Diaries.ByVisibility["public"] != 1 {
+		t.Errorf("diaries: got %+v", s.Diaries)
+	}
+	if len(s.Unavailable) != 0 {
+		t.Errorf("expected no unavailable sections, got %v", s.Unavailable)
+	}
+}
+
+func TestRunAgentsWhoamiStatsCmd_DegradesOnSectionFailure(t *testing.T) {
+	// Arrange
+	apiSrv, credPath := newCLICommandTestServer(t, &standingStubHandler{vouchersErr: true})
+	var out bytes.Buffer
+
+	// Act
+	err := runAgentsWhoamiStatsCmd(apiSrv.URL, credPath, &out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runAgentsWhoamiStatsCmd() error: %v", err)
+	}
+	var got whoamiWithStanding
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if got.Standing.ActiveVouchers != nil {
+		t.Errorf("expected activeVouchers omitted, got %d", *got.Standing.ActiveVouchers)
+	}
+	if len(got.Standing.Unavailable) != 1 || got.Standing.Unavailable[0] != "activeVouchers" {
+		t.Errorf("unavailable: got %v", got.Standing.Unavailable)
+	}
+	if got.Standing.Diaries == nil {
+		t.Error("expected diaries section despite voucher failure")
+	}
+}
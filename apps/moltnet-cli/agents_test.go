@@ -70,3 +70,40 @@ func TestAgentsLookup(t *testing.T) {
 		t.Errorf("expected fingerprint=%s, got %q", fp, profile.Fingerprint)
 	}
 }
+
+func TestSelfFingerprint(t *testing.T) {
+	t.Parallel()
+	kp, _ := KeyPairFromSeed(make([]byte, 32))
+
+	got, err := selfFingerprint(&CredentialsFile{Keys: CredentialsKeys{Fingerprint: "AAAA-BBBB-CCCC-DDDD"}})
+	if err != nil || got != "AAAA-BBBB-CCCC-DDDD" {
+		t.Errorf("recorded fingerprint: got %q, %v", got, err)
+	}
+	got, err = selfFingerprint(&CredentialsFile{Keys: CredentialsKeys{PublicKey: kp.PublicKey}})
+	if err != nil || got != kp.Fingerprint {
+		t.Errorf("derived fingerprint: got %q, %v; want %q", got, err, kp.Fingerprint)
+	}
+	if _, err := selfFingerprint(&CredentialsFile{}); err == nil {
+		t.Error("expected error for config without keys")
+	}
+}
+
+func TestSelfProfileMismatches(t *testing.T) {
+	t.Parallel()
+	creds := &CredentialsFile{Keys: CredentialsKeys{PublicKey: "ed25519:local", Fingerprint: "A1B2-C3D4-E5F6-A1B2"}}
+
+	if got := selfProfileMismatches(&moltnetapi.AgentProfile{Fingerprint: "A1B2-C3D4-E5F6-A1B2", PublicKey: "ed25519:local"}, creds); len(got) != 0 {
+		t.Errorf("consistent profile: got %v", got)
+	}
+	if got := selfProfileMismatches(&moltnetapi.AgentProfile{Fingerprint: "A1B2-C3D4-E5F6-A1B2", PublicKey: "ed25519:other"}, creds); len(got) != 1 {
+		t.Errorf("key mismatch: got %v", got)
+	}
+}
+
+func TestAgentsLookupSelfRejectsFingerprintAndFlag(t *testing.T) {
+	t.Parallel()
+	_, _, err := executeCommand(NewRootCmd("test", ""), "agents", "lookup", "--self", "A1B2-C3D4-E5F6-A1B2")
+	if err == nil {
+		t.Fatal("expected error when both --self and a fingerprint are given")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// defaultTrustPathDepth bounds the vouch-chain search of agents lookup
+// --trust-path.
+const defaultTrustPathDepth = 6
+
+// trustHop is one vouch on a trust path. Relation is read from the From
+// side: "vouched-for" when From issued the voucher To redeemed,
+// "vouched-by" when To vouched for From.
+type trustHop struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Relation   string    `json:"relation"`
+	RedeemedAt time.Time `json:"redeemedAt"`
+}
+
+// trustPath is the output of agents lookup --trust-path.
+type trustPath struct {
+	From      string     `json:"from"`
+	To        string     `json:"to"`
+	Found     bool       `json:"found"`
+	Hops      []trustHop `json:"hops"`
+	MaxDepth  int        `json:"maxDepth"`
+	Truncated bool       `json:"trustGraphTruncated,omitempty"`
+}
+
+// findTrustPath returns the shortest vouch chain from one fingerprint to
+// another, following vouches in either direction, or nil when none exists
+// within maxDepth hops. Ties are broken by fingerprint so the result is
+// stable.
+func findTrustPath(edges []moltnetapi.GetTrustGraphOKEdgesItem, from, to string, maxDepth int) []trustHop {
+	if from == to {
+		return []trustHop{}
+	}
+	adjacent := map[string][]trustHop{}
+	for _, e := range edges {
+		adjacent[e.IssuerFingerprint] = append(adjacent[e.IssuerFingerprint],
+			trustHop{From: e.IssuerFingerprint, To: e.RedeemerFingerprint, Relation: "vouched-for", RedeemedAt: e.RedeemedAt})
+		adjacent[e.RedeemerFingerprint] = append(adjacent[e.RedeemerFingerprint],
+			trustHop{From: e.RedeemerFingerprint, To: e.IssuerFingerprint, Relation: "vouched-by", RedeemedAt: e.RedeemedAt})
+	}
+	for _, hops := range adjacent {
+		slices.SortFunc(hops, func(a, b trustHop) int { return strings.Compare(a.To, b.To) })
+	}
+
+	via := map[string]trustHop{from: {}}
+	frontier := []string{from}
+	for depth := 0; depth < maxDepth && len(frontier) > 0; depth++ {
+		var next []string
+		for _, node := range frontier {
+			for _, hop := range adjacent[node] {
+				if _, seen := via[hop.To]; seen {
+					continue
+				}
+				via[hop.To] = hop
+				if hop.To == to {
+					return unwindTrustPath(via, from, to)
+				}
+				next = append(next, hop.To)
+			}
+		}
+		frontier = next
+	}
+	return nil
+}
+
+func unwindTrustPath(via map[string]trustHop, from, to string) []trustHop {
+	var hops []trustHop
+	for node := to; node != from; node = via[node].From {
+		hops = append(hops, via[node])
+	}
+	slices.Reverse(hops)
+	return hops
+}
+
+// runAgentsTrustPathCmd prints the vouch chain from the local agent to
+// target. The trust graph is public, so the search runs client-side.
+func runAgentsTrustPathCmd(apiURL, credPath, target string, maxDepth int, w io.Writer) error {
+	if maxDepth < 1 {
+		return fmt.Errorf("agents lookup: --max-depth must be at least 1")
+	}
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	self, err := selfFingerprint(creds)
+	if err != nil {
+		return err
+	}
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	edges, truncated, err := fetchTrustGraph(client)
+	if err != nil {
+		return fmt.Errorf("agents lookup: %w", err)
+	}
+	if truncated {
+		fmt.Fprintln(os.Stderr, "Warning: trust graph truncated; a longer or missing path may exist beyond the scanned edges")
+	}
+	hops := findTrustPath(edges, self, target, maxDepth)
+	out := trustPath{From: self, To: target, Found: hops != nil, Hops: hops, MaxDepth: maxDepth, Truncated: truncated}
+	if hops == nil {
+		out.Hops = []trustHop{}
+	}
+	if err := printJSONTo(w, out); err != nil {
+		return err
+	}
+	if !out.Found {
+		return expectedErrorf("agents lookup: no vouch chain from %s to %s within %d hops", self, target, maxDepth)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+func TestFindTrustPath(t *testing.T) {
+	t.Parallel()
+	// genesis vouched for A and B; B vouched for C; C vouched for D.
+	edges := []moltnetapi.GetTrustGraphOKEdgesItem{
+		{IssuerFingerprint: "G", RedeemerFingerprint: "A"},
+		{IssuerFingerprint: "G", RedeemerFingerprint: "B"},
+		{IssuerFingerprint: "B", RedeemerFingerprint: "C"},
+		{IssuerFingerprint: "C", RedeemerFingerprint: "D"},
+	}
+
+	hops := findTrustPath(edges, "A", "D", 6)
+
+	want := []trustHop{
+		{From: "A", To: "G", Relation: "vouched-by"},
+		{From: "G", To: "B", Relation: "vouched-for"},
+		{From: "B", To: "C", Relation: "vouched-for"},
+		{From: "C", To: "D", Relation: "vouched-for"},
+	}
+	if len(hops) != len(want) {
+		t.Fatalf("hops = %+v, want %+v", hops, want)
+	}
+	for i := range want {
+		if hops[i] != want[i] {
+			t.Errorf("hop %d = %+v, want %+v", i, hops[i], want[i])
+		}
+	}
+	if got := findTrustPath(edges, "A", "D", 3); got != nil {
+		t.Errorf("expected no path within 3 hops, got %+v", got)
+	}
+	if got := findTrustPath(edges, "A", "Z", 6); got != nil {
+		t.Errorf("expected no path to unknown agent, got %+v", got)
+	}
+}
+
+func TestRunAgentsTrustPathCmd(t *testing.T) {
+	const self = "A1B2-C3D4-E5F6-A1B2"
+	handler := &standingStubHandler{edges: []moltnetapi.GetTrustGraphOKEdgesItem{
+		{IssuerFingerprint: "BBBB-BBBB-BBBB-BBBB", RedeemerFingerprint: self},
+		{IssuerFingerprint: "BBBB-BBBB-BBBB-BBBB", RedeemerFingerprint: "CCCC-CCCC-CCCC-CCCC"},
+	}}
+	srv, credPath := newCLICommandTestServer(t, handler)
+	creds, err := ReadConfigFrom(credPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.Keys.Fingerprint = self
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+
+	err = runAgentsTrustPathCmd(srv.URL, credPath, "CCCC-CCCC-CCCC-CCCC", defaultTrustPathDepth, &out)
+
+	if err != nil {
+		t.Fatalf("runAgentsTrustPathCmd() error: %v", err)
+	}
+	var got trustPath
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("parse output: %v\n%s", err, out.String())
+	}
+	if !got.Found || len(got.Hops) != 2 || got.Hops[0].Relation != "vouched-by" || got.Hops[1].To != "CCCC-CCCC-CCCC-CCCC" {
+		t.Errorf("unexpected path: %+v", got)
+	}
+
+	out.Reset()
+	err = runAgentsTrustPathCmd(srv.URL, credPath, "DDDD-DDDD-DDDD-DDDD", defaultTrustPathDepth, &out)
+	if err == nil || errorExitCode(err) != exitCodeExpected {
+		t.Errorf("expected no-path failure, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
@@ -48,29 +49,98 @@ func (s *tokenSecuritySource) SessionAuth(_ context.Context, _ moltnetapi.Operat
 	return moltnetapi.SessionAuth{}, ogenerrors.ErrSkipClientSecurity
 }
 
-// newAuthedClient builds a moltnetapi.Client authenticated via the TokenManager.
-// The underlying HTTP client uses a retry transport: 429 on all methods,
-// 408/5xx on idempotent methods only (GET, HEAD, OPTIONS, PUT).
-func newAuthedClient(apiURL string, tm *TokenManager) (*moltnetapi.Client, error) {
+// clientConfig holds optional behaviour for newAuthedClient.
+type clientConfig struct {
+	signer         *requestSigner
+	largeResponses bool
+}
+
+// clientOption customises the API client built by newAuthedClient.
+type clientOption func(*clientConfig)
+
+// withRequestSigner signs every API request with the agent's key; see
+// request_signing.go.
+func withRequestSigner(signer *requestSigner) clientOption {
+	return func(c *clientConfig) { c.signer = signer }
+}
+
+// withLargeResponses raises the default response size limit for commands
+// that page through big lists or exports. An explicit --max-response-size
+// still wins.
+func withLargeResponses() clientOption {
+	return func(c *clientConfig) { c.largeResponses = true }
+}
+
+// newAuthedClient builds a moltnetapi.Client authenticated via the TokenManager,
+// over the transport chain of newAPIHTTPClient.
+func newAuthedClient(apiURL string, tm *TokenManager, opts ...clientOption) (*moltnetapi.Client, error) {
 	return moltnetapi.NewClient(
 		strings.TrimRight(apiURL, "/"),
 		&tokenSecuritySource{tm: tm},
-		moltnetapi.WithClient(tm.httpClient),
+		moltnetapi.WithClient(newAPIHTTPClient(tm, opts...)),
 	)
 }
 
+// newAPIHTTPClient is the HTTP client every API request goes through. It
+// uses a retry transport: 429 on all methods, 408/5xx on idempotent
+// methods only (GET, HEAD, OPTIONS, PUT). Under --dry-run, mutating
+// requests are previewed instead; see dry_run.go. Response bodies are
+// capped by --max-response-size; see response_limit.go.
+func newAPIHTTPClient(tm *TokenManager, opts ...clientOption) *http.Client {
+	var cfg clientConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	httpClient := tm.httpClient
+	if cfg.signer != nil {
+		// Signing goes inside the retry transport so every attempt is
+		// signed under a fresh nonce.
+		httpClient = &http.Client{
+			Timeout:   tm.httpClient.Timeout,
+			Transport: NewRetryTransport(newRequestSigningTransport(newAPIVersionTransport(nil, currentAPIVersion()), cfg.signer), nil),
+		}
+	}
+	httpClient = &http.Client{
+		Timeout:   httpClient.Timeout,
+		Transport: newResponseLimitTransport(httpClient.Transport, currentMaxResponseSize(cfg.largeResponses)),
+	}
+	if w := currentDryRunOutput(); w != nil {
+		httpClient = &http.Client{
+			Timeout:   httpClient.Timeout,
+			Transport: newDryRunTransport(httpClient.Transport, w),
+		}
+	}
+	return httpClient
+}
+
+// credentialClientOptions adds the options the credentials call for, such
+// as withRequestSigner under --sign-requests, to opts.
+func credentialClientOptions(creds *CredentialsFile, opts []clientOption) ([]clientOption, error) {
+	if signRequestsEnabled(creds) {
+		signer, err := newRequestSigner(creds)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, withRequestSigner(signer))
+	}
+	return opts, nil
+}
+
 // newClientFromCreds loads stored credentials, creates a TokenManager, and
 // returns a fully authenticated moltnetapi.Client.
 // If credPath is non-empty, credentials are loaded from that path;
 // otherwise, the default auto-discovery is used.
-func newClientFromCreds(apiURL, credPath string) (*moltnetapi.Client, error) {
+func newClientFromCreds(apiURL, credPath string, opts ...clientOption) (*moltnetapi.Client, error) {
 	creds, err := loadCredentials(credPath)
 	if err != nil {
 		return nil, err
 	}
-	if creds.OAuth2.ClientID == "" || creds.OAuth2.ClientSecret == "" {
-		return nil, fmt.Errorf("credentials missing client_id or client_secret — run 'moltnet register'")
+	if err := requireClientCredentials(creds, credPath); err != nil {
+		return nil, err
+	}
+	if opts, err = credentialClientOptions(creds, opts); err != nil {
+		return nil, err
 	}
-	tm := NewTokenManager(apiURL, creds.OAuth2.ClientID, creds.OAuth2.ClientSecret)
-	return newAuthedClient(apiURL, tm)
+	tm := newTokenManagerForCreds(apiURL, creds)
+	return newAuthedClient(apiURL, tm, opts...)
 }
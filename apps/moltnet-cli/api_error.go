@@ -109,7 +109,7 @@ func formatProblemDetails(status int, title, detail string, hasDetail bool) erro
 	if msg == "" {
 		msg = fmt.Sprintf("HTTP %d", status)
 	}
-	return fmt.Errorf("API error (HTTP %d): %s", status, msg)
+	return &apiProblemError{Status: status, msg: fmt.Sprintf("API error (HTTP %d): %s", status, msg)}
 }
 
 // parseProblemDetailsBody attempts to decode body as an RFC 7807
@@ -6,9 +6,10 @@ import "github.com/spf13/cobra"
 //
 // Precedence (highest first):
 //  1. --api-url, if explicitly set by the user on this invocation.
-//  2. endpoints.api from the resolved credentials file (credPath, or the
-//     auto-discovered default when credPath is empty).
-//  3. defaultAPIURL.
+//  2. the URL --network resolved to (see setNetwork).
+//  3. endpoints.api from the resolved credentials (credPath, the
+//     auto-discovered default file, or <PREFIX>API_URL in file-less mode).
+//  4. defaultAPIURL.
 //
 // This exists so the credentials file is self-contained: an agent bootstrapped
 // against a non-default API (e.g. localhost) does not need to also remember
@@ -27,14 +28,11 @@ func resolveAPIURL(cmd *cobra.Command, credPath string) string {
 			return f.Value.String()
 		}
 	}
-
-	var creds *CredentialsFile
-	var err error
-	if credPath != "" {
-		creds, err = ReadConfigFrom(credPath)
-	} else {
-		creds, err = ReadConfig()
+	if u := networkAPIURL.Load(); u != nil {
+		return *u
 	}
+
+	creds, err := resolveCredentials(credPath)
 	if err == nil && creds != nil && creds.Endpoints.API != "" {
 		return creds.Endpoints.API
 	}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// apiVersionHeader pins requests to an API version, in the spirit of
+// GitHub's X-GitHub-Api-Version (see github.go). The server echoes the
+// version it actually served; a different value means the response shape
+// may not be what this CLI was built against.
+const apiVersionHeader = "X-API-Version"
+
+// requestedAPIVersion is set once per invocation from --api-version (or the
+// CLI version) by the root command. Empty means no header is sent, which is
+// the case for code paths that run without the root command, such as tests.
+var requestedAPIVersion atomic.Pointer[string]
+
+func setAPIVersion(v string) {
+	requestedAPIVersion.Store(&v)
+}
+
+func currentAPIVersion() string {
+	if v := requestedAPIVersion.Load(); v != nil {
+		return *v
+	}
+	return ""
+}
+
+// apiVersionTransport stamps X-API-Version on outgoing requests and warns,
+// once per transport, when the server signals a mismatch or deprecation.
+type apiVersionTransport struct {
+	base    http.RoundTripper
+	version string
+	warn    io.Writer
+	once    sync.Once
+}
+
+// newAPIVersionTransport wraps base with version negotiation. It returns
+// base unchanged when version is empty.
+func newAPIVersionTransport(base http.RoundTripper, version string) http.RoundTripper {
+	if base == nil {
+		base = baseTransport()
+	}
+	if version == "" {
+		return base
+	}
+	return &apiVersionTransport{base: base, version: version, warn: os.Stderr}
+}
+
+func (t *apiVersionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(apiVersionHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(apiVersionHeader, t.version)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if msg := apiVersionMismatch(req.Header.Get(apiVersionHeader), resp.Header); msg != "" {
+		t.once.Do(func() { fmt.Fprintf(t.warn, "Warning: %s\n", msg) })
+	}
+	return resp, nil
+}
+
+// apiVersionMismatch describes a version-mismatch indicator in a response,
+// or returns "" when there is none.
+func apiVersionMismatch(requested string, h http.Header) string {
+	if served := h.Get(apiVersionHeader); served != "" && served != requested {
+		return fmt.Sprintf("server answered with API version %s but this CLI requested %s; responses may not match what it expects. Upgrade moltnet or pass --api-version %s", served, requested, served)
+	}
+	if h.Get("Deprecation") != "" {
+		msg := fmt.Sprintf("server marked API version %s as deprecated", requested)
+		if sunset := h.Get("Sunset"); sunset != "" {
+			msg += " (sunset " + sunset + ")"
+		}
+		return msg + "; upgrade moltnet"
+	}
+	return ""
+}
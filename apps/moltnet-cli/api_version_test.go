@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIVersionTransport_SendsHeaderAndWarnsOnce(t *testing.T) {
+	t.Parallel()
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(apiVersionHeader))
+		w.Header().Set(apiVersionHeader, "2.0.0")
+	}))
+	defer srv.Close()
+
+	var warn bytes.Buffer
+	tr := newAPIVersionTransport(nil, "1.4.0").(*apiVersionTransport)
+	tr.warn = &warn
+	client := &http.Client{Transport: tr}
+	for range 2 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(got) != 2 || got[0] != "1.4.0" {
+		t.Errorf("request headers: got %v", got)
+	}
+	if n := strings.Count(warn.String(), "Warning:"); n != 1 {
+		t.Errorf("warnings: got %d, want 1:\n%s", n, warn.String())
+	}
+	if !strings.Contains(warn.String(), "--api-version 2.0.0") {
+		t.Errorf("warning should suggest the served version: %s", warn.String())
+	}
+}
+
+func versionHeader(v string) http.Header {
+	h := http.Header{}
+	h.Set(apiVersionHeader, v)
+	return h
+}
+
+func TestAPIVersionMismatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"matching", versionHeader("1"), ""},
+		{"no indicator", http.Header{}, ""},
+		{"different version", versionHeader("2"), "answered with API version 2"},
+		{"deprecated", http.Header{"Deprecation": {"true"}, "Sunset": {"Sat, 31 Oct 2026 00:00:00 GMT"}}, "deprecated (sunset Sat, 31 Oct 2026"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := apiVersionMismatch("1", tt.header)
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want containing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAPIVersionTransport_EmptyVersionIsPassthrough(t *testing.T) {
+	t.Parallel()
+	if _, ok := newAPIVersionTransport(http.DefaultTransport, "").(*apiVersionTransport); ok {
+		t.Error("empty version should not wrap the transport")
+	}
+}
+
+func TestRootCmd_APIVersionFlag(t *testing.T) {
+	t.Cleanup(func() { requestedAPIVersion.Store(nil) })
+
+	if _, _, err := executeCommand(NewRootCmd("9.9.9", ""), "version"); err != nil {
+		t.Fatal(err)
+	}
+	if got := currentAPIVersion(); got != "9.9.9" {
+		t.Errorf("default: got %q, want the CLI version", got)
+	}
+	if _, _, err := executeCommand(NewRootCmd("9.9.9", ""), "--api-version", "2026-01-01", "version"); err != nil {
+		t.Fatal(err)
+	}
+	if got := currentAPIVersion(); got != "2026-01-01" {
+		t.Errorf("flag: got %q", got)
+	}
+}
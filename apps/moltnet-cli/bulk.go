@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+)
+
+// bulkMode selects how a bulk command reacts to a per-item failure.
+type bulkMode int
+
+const (
+	// bulkFailFast stops at the first failed item. It is the default for
+	// commands that modify or delete existing data.
+	bulkFailFast bulkMode = iota
+	// bulkContinue records the failure and moves on to the next item.
+	bulkContinue
+)
+
+func (m bulkMode) String() string {
+	if m == bulkContinue {
+		return "continue"
+	}
+	return "fail-fast"
+}
+
+// defaultRetryBudget is the failure rate, in percent, above which a
+// --continue bulk run is aborted.
+const defaultRetryBudget = 50
+
+// bulkBudgetWindow and bulkBudgetMinSamples shape the failure-rate check:
+// the rate is taken over the last bulkBudgetWindow items and only once
+// bulkBudgetMinSamples items have run, so a couple of early failures do not
+// trip it.
+const (
+	bulkBudgetWindow     = 20
+	bulkBudgetMinSamples = 10
+)
+
+// retryBudget holds the --retry-budget percentage set by the root command.
+var retryBudget atomic.Pointer[float64]
+
+// setRetryBudget validates and stores --retry-budget. 0 disables the
+// governor; an empty value restores the default.
+func setRetryBudget(raw string) error {
+	if raw == "" {
+		retryBudget.Store(nil)
+		return nil
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+	if err != nil || pct < 0 || pct > 100 {
+		return fmt.Errorf("invalid --retry-budget %q: want a percentage between 0 and 100", raw)
+	}
+	retryBudget.Store(&pct)
+	return nil
+}
+
+// currentRetryBudget returns the failure-rate budget as a fraction.
+func currentRetryBudget() float64 {
+	if p := retryBudget.Load(); p != nil {
+		return *p / 100
+	}
+	return defaultRetryBudget / 100.0
+}
+
+// bulkItemError is one failed item in a bulk summary.
+type bulkItemError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// bulkSummary is the shared result shape of bulk commands. Commands embed
+// it next to their own counters so every summary carries the same fields.
+type bulkSummary struct {
+	Mode      string `json:"mode"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Aborted   bool   `json:"aborted"`
+	// BudgetExceeded is set when a --continue run was aborted because the
+	// recent failure rate went over --retry-budget.
+	BudgetExceeded bool            `json:"budgetExceeded,omitempty"`
+	Errors         []bulkItemError `json:"errors"`
+}
+
+// bulkRunner tracks a bulk operation under one bulkMode. In bulkContinue
+// mode it also acts as a circuit breaker: when more than budget of the
+// recent items failed, something is fundamentally wrong (bad token, server
+// down) and the run stops instead of grinding through doomed calls.
+type bulkRunner struct {
+	mode    bulkMode
+	budget  float64
+	recent  []bool // outcome of the last bulkBudgetWindow items; true = failed
+	summary bulkSummary
+}
+
+func newBulkRunner(mode bulkMode) *bulkRunner {
+	return &bulkRunner{mode: mode, budget: currentRetryBudget(), summary: bulkSummary{Mode: mode.String(), Errors: []bulkItemError{}}}
+}
+
+func (r *bulkRunner) record(failed bool) {
+	r.recent = append(r.recent, failed)
+	if len(r.recent) > bulkBudgetWindow {
+		r.recent = r.recent[1:]
+	}
+}
+
+// recentFailureRate returns the failed fraction of the recent window.
+func (r *bulkRunner) recentFailureRate() (rate float64, failed int) {
+	for _, f := range r.recent {
+		if f {
+			failed++
+		}
+	}
+	if len(r.recent) == 0 {
+		return 0, 0
+	}
+	return float64(failed) / float64(len(r.recent)), failed
+}
+
+// succeed records a successful item.
+func (r *bulkRunner) succeed() {
+	r.summary.Succeeded++
+	r.record(false)
+}
+
+// fail records a failed item and reports whether the caller should stop.
+func (r *bulkRunner) fail(item string, err error) (stop bool) {
+	r.summary.Failed++
+	r.summary.Errors = append(r.summary.Errors, bulkItemError{Item: item, Error: err.Error()})
+	r.record(true)
+	if r.mode == bulkFailFast {
+		r.summary.Aborted = true
+		return true
+	}
+	if rate, _ := r.recentFailureRate(); r.budget > 0 && len(r.recent) >= bulkBudgetMinSamples && rate > r.budget {
+		r.summary.Aborted = true
+		r.summary.BudgetExceeded = true
+		return true
+	}
+	return false
+}
+
+// err returns the command error for the run: nil when every item succeeded.
+func (r *bulkRunner) err(op string) error {
+	switch {
+	case r.summary.Failed == 0:
+		return nil
+	case r.summary.BudgetExceeded:
+		_, failed := r.recentFailureRate()
+		return fmt.Errorf("%s: aborted: %d of the last %d items failed, over the %g%% --retry-budget (last error: %s)", op, failed, len(r.recent), r.budget*100, r.summary.Errors[len(r.summary.Errors)-1].Error)
+	case r.summary.Aborted:
+		return fmt.Errorf("%s: aborted after first failure (%s); rerun with --continue to process the remaining items", op, r.summary.Errors[0].Error)
+	default:
+		return fmt.Errorf("%s: %d item(s) failed", op, r.summary.Failed)
+	}
+}
+
+// addBulkFlags registers --fail-fast and --continue (alias --skip-errors)
+// on a bulk command. def is the mode used when neither is given; the help
+// text states it so each command's default is visible.
+func addBulkFlags(cmd *cobra.Command, def bulkMode) {
+	failFastHelp, continueHelp := "Stop at the first failed item", "Keep going after a failed item and report all failures (bounded by --retry-budget)"
+	if def == bulkFailFast {
+		failFastHelp += " (default)"
+	} else {
+		continueHelp += " (default)"
+	}
+	cmd.Flags().Bool("fail-fast", false, failFastHelp)
+	cmd.Flags().Bool("continue", false, continueHelp)
+	cmd.Flags().Bool("skip-errors", false, "Alias for --continue")
+	cmd.MarkFlagsMutuallyExclusive("fail-fast", "continue")
+	cmd.MarkFlagsMutuallyExclusive("fail-fast", "skip-errors")
+}
+
+// bulkModeFromFlags reads the flags registered by addBulkFlags.
+func bulkModeFromFlags(cmd *cobra.Command, def bulkMode) bulkMode {
+	if v, _ := cmd.Flags().GetBool("fail-fast"); v {
+		return bulkFailFast
+	}
+	if v, _ := cmd.Flags().GetBool("continue"); v {
+		return bulkContinue
+	}
+	if v, _ := cmd.Flags().GetBool("skip-errors"); v {
+		return bulkContinue
+	}
+	return def
+}
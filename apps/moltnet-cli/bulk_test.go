@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestBulkRunner_Modes(t *testing.T) {
+	t.Parallel()
+	ff := newBulkRunner(bulkFailFast)
+	ff.succeed()
+	if stop := ff.fail("a", errors.New("boom")); !stop {
+		t.Error("fail-fast should stop on first failure")
+	}
+	if !ff.summary.Aborted || ff.summary.Mode != "fail-fast" {
+		t.Errorf("fail-fast summary: %+v", ff.summary)
+	}
+	if err := ff.err("op"); err == nil || !strings.Contains(err.Error(), "--continue") {
+		t.Errorf("fail-fast error should suggest --continue: %v", err)
+	}
+
+	cont := newBulkRunner(bulkContinue)
+	for _, item := range []string{"a", "b"} {
+		if stop := cont.fail(item, errors.New("boom")); stop {
+			t.Error("continue should not stop")
+		}
+	}
+	cont.succeed()
+	if cont.summary.Failed != 2 || cont.summary.Succeeded != 1 || cont.summary.Aborted || len(cont.summary.Errors) != 2 {
+		t.Errorf("continue summary: %+v", cont.summary)
+	}
+	if err := newBulkRunner(bulkContinue).err("op"); err != nil {
+		t.Errorf("no failures should be nil, got %v", err)
+	}
+}
+
+func TestBulkModeFromFlags(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args []string
+		def  bulkMode
+		want bulkMode
+	}{
+		{nil, bulkFailFast, bulkFailFast},
+		{nil, bulkContinue, bulkContinue},
+		{[]string{"--continue"}, bulkFailFast, bulkContinue},
+		{[]string{"--skip-errors"}, bulkFailFast, bulkContinue},
+		{[]string{"--fail-fast"}, bulkContinue, bulkFailFast},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "bulk", RunE: func(*cobra.Command, []string) error { return nil }}
+		addBulkFlags(cmd, tt.def)
+		cmd.SetArgs(tt.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if got := bulkModeFromFlags(cmd, tt.def); got != tt.want {
+			t.Errorf("%v (default %v): got %v, want %v", tt.args, tt.def, got, tt.want)
+		}
+	}
+
+	cmd := &cobra.Command{Use: "bulk", RunE: func(*cobra.Command, []string) error { return nil }}
+	addBulkFlags(cmd, bulkFailFast)
+	cmd.SetArgs([]string{"--fail-fast", "--continue"})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	if err := cmd.Execute(); err == nil {
+		t.Error("--fail-fast and --continue together should be rejected")
+	}
+}
+
+func TestBulkRunner_RetryBudget(t *testing.T) {
+	t.Parallel()
+	run := newBulkRunner(bulkContinue)
+	run.budget = 0.5
+	stoppedAt := 0
+	for i := 1; i <= 50; i++ {
+		if run.fail("item", errors.New("401 unauthorized")) {
+			stoppedAt = i
+			break
+		}
+	}
+	if stoppedAt != bulkBudgetMinSamples {
+		t.Fatalf("expected budget to trip after %d failures, stopped at %d", bulkBudgetMinSamples, stoppedAt)
+	}
+	if !run.summary.Aborted || !run.summary.BudgetExceeded {
+		t.Errorf("summary should record the trip: %+v", run.summary)
+	}
+	if err := run.err("op"); err == nil || !strings.Contains(err.Error(), "--retry-budget") {
+		t.Errorf("error should mention --retry-budget: %v", err)
+	}
+}
+
+func TestBulkRunner_RetryBudgetToleratesSparseFailures(t *testing.T) {
+	t.Parallel()
+	run := newBulkRunner(bulkContinue)
+	run.budget = 0.5
+	for i := range 100 {
+		if i%3 == 0 {
+			if run.fail("item", errors.New("conflict")) {
+				t.Fatalf("a 1-in-3 failure rate should stay under a 50%% budget (item %d)", i)
+			}
+			continue
+		}
+		run.succeed()
+	}
+
+	disabled := newBulkRunner(bulkContinue)
+	disabled.budget = 0
+	for range 30 {
+		if disabled.fail("item", errors.New("down")) {
+			t.Fatal("a zero budget disables the governor")
+		}
+	}
+}
+
+func TestSetRetryBudget(t *testing.T) {
+	t.Cleanup(func() { retryBudget.Store(nil) })
+	if got := currentRetryBudget(); got != 0.5 {
+		t.Errorf("default budget: got %v", got)
+	}
+	if err := setRetryBudget("20%"); err != nil || currentRetryBudget() != 0.2 {
+		t.Errorf("20%%: got %v, %v", currentRetryBudget(), err)
+	}
+	for _, bad := range []string{"-1", "101", "lots"} {
+		if err := setRetryBudget(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// canonicalizeJCS returns the canonical form of a JSON document, following
+// the JSON Canonicalization Scheme (RFC 8785):
+//
+//   - object members are sorted by key, comparing keys as UTF-16 code units;
+//   - no insignificant whitespace is emitted;
+//   - strings escape only '"', '\\' and control characters (\b \f \n \r \t,
+//     others as lowercase \u00xx); all other characters are literal UTF-8;
+//   - numbers are IEEE 754 doubles serialized the ECMAScript way (shortest
+//     round-tripping digits, exponent form outside 1e-6 <= |x| < 1e21).
+//
+// Integers beyond 2^53 lose precision, exactly as they do in JavaScript, so
+// encode large identifiers as strings. Duplicate object keys are rejected.
+func canonicalizeJCS(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := canonicalizeValue(dec, &buf); err != nil {
+		return nil, fmt.Errorf("canonicalize JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("canonicalize JSON: unexpected data after top-level value")
+	}
+	return buf.Bytes(), nil
+}
+
+func canonicalizeValue(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return canonicalizeObject(dec, buf)
+		}
+		return canonicalizeArray(dec, buf)
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return fmt.Errorf("number %s: %w", v, err)
+		}
+		s, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+func canonicalizeObject(dec *json.Decoder, buf *bytes.Buffer) error {
+	type member struct {
+		key   string
+		value []byte
+	}
+	var members []member
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if seen[key] {
+			return fmt.Errorf("duplicate object key %q", key)
+		}
+		seen[key] = true
+		var value bytes.Buffer
+		if err := canonicalizeValue(dec, &value); err != nil {
+			return err
+		}
+		members = append(members, member{key, value.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return lessUTF16(members[i].key, members[j].key)
+	})
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeCanonicalString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func canonicalizeArray(dec *json.Decoder, buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := canonicalizeValue(dec, buf); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 requires.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// formatCanonicalNumber renders f like ECMAScript Number.prototype.toString.
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("number %v is not representable in JSON", f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	// Shortest round-tripping digits and decimal exponent: f = 0.digits × 10^n.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	n, k := e+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+	s := digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	expSign := "+"
+	if n-1 < 0 {
+		expSign = "-"
+	}
+	return sign + s + "e" + expSign + strconv.Itoa(abs(n-1)), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// readJSONInput reads a JSON document from path, or stdin when path is "-".
+func readJSONInput(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read JSON input: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("empty JSON input")
+	}
+	return data, nil
+}
+
+// runCryptoSignJSONCmd canonicalizes a JSON document and signs the
+// canonical bytes with SignForRequest, printing the base64 signature.
+func runCryptoSignJSONCmd(w io.Writer, credPath, path, nonce string) error {
+	if nonce == "" {
+		return fmt.Errorf("sign-json: --nonce is required")
+	}
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	data, err := readJSONInput(path)
+	if err != nil {
+		return err
+	}
+	canonical, err := canonicalizeJCS(data)
+	if err != nil {
+		return err
+	}
+	sig, err := SignForRequest(string(canonical), nonce, creds.Keys.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("sign-json: %w", err)
+	}
+	fmt.Fprint(w, sig)
+	return nil
+}
+
+// jsonVerifyResult is the output of crypto verify-json.
+type jsonVerifyResult struct {
+	Valid       bool   `json:"valid"`
+	PublicKey   string `json:"publicKey"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// runCryptoVerifyJSONCmd checks a sign-json signature over the canonical
+// form of a JSON document. Without publicKey, the local key is used.
+func runCryptoVerifyJSONCmd(w io.Writer, credPath, path, nonce, signature, publicKey string) error {
+	if nonce == "" || signature == "" {
+		return fmt.Errorf("verify-json: --nonce and --signature are required")
+	}
+	userKey := publicKey != ""
+	if !userKey {
+		creds, err := loadCredentials(credPath)
+		if err != nil {
+			return err
+		}
+		publicKey = creds.Keys.PublicKey
+	}
+	pub, err := parseVerifyingKey(publicKey)
+	if err != nil {
+		if userKey {
+			return &usageError{err: fmt.Errorf("verify-json: --public-key: %w", err)}
+		}
+		return fmt.Errorf("verify-json: %w", err)
+	}
+	data, err := readJSONInput(path)
+	if err != nil {
+		return err
+	}
+	canonical, err := canonicalizeJCS(data)
+	if err != nil {
+		return err
+	}
+	ok, err := VerifyForRequest(string(canonical), nonce, signature, publicKey)
+	if errors.Is(err, ErrBadSignatureEncoding) {
+		return &usageError{err: fmt.Errorf("verify-json: --signature: %w", err)}
+	}
+	if err != nil {
+		return fmt.Errorf("verify-json: %w", err)
+	}
+	if err := printJSONTo(w, jsonVerifyResult{Valid: ok, PublicKey: publicKey, Fingerprint: Fingerprint(pub)}); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("verify-json: signature is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test vectors from RFC 8785. Other implementations of sign-json must
+// produce the same canonical bytes.
+
+func TestCanonicalizeJSON_RFC8785Example(t *testing.T) {
+	t.Parallel()
+	input := `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	got, err := canonicalizeJCS([]byte(input))
+
+	if err != nil {
+		t.Fatalf("canonicalizeJCS() error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestCanonicalizeJSON_SortsByUTF16(t *testing.T) {
+	t.Parallel()
+	input := `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`
+	want := "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+
+	got, err := canonicalizeJCS([]byte(input))
+
+	if err != nil {
+		t.Fatalf("canonicalizeJCS() error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestFormatCanonicalNumber(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tc := range cases {
+		got, err := formatCanonicalNumber(math.Float64frombits(tc.bits))
+		if err != nil {
+			t.Errorf("%016x: %v", tc.bits, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%016x: got %s, want %s", tc.bits, got, tc.want)
+		}
+	}
+	if _, err := formatCanonicalNumber(math.NaN()); err == nil {
+		t.Error("expected NaN to be rejected")
+	}
+}
+
+func TestCanonicalizeJSON_Rejects(t *testing.T) {
+	t.Parallel()
+	for _, input := range []string{`{"a":1,"a":2}`, `{"a":1} {"b":2}`, `{"a":`} {
+		if _, err := canonicalizeJCS([]byte(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestSignVerifyJSON_RoundTrip(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	dir := t.TempDir()
+	kp, _ := KeyPairFromSeed(make([]byte, 32))
+	credPath := filepath.Join(dir, "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{Keys: CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey}}, credPath); err != nil {
+		t.Fatal(err)
+	}
+	signed := filepath.Join(dir, "a.json")
+	reordered := filepath.Join(dir, "b.json")
+	os.WriteFile(signed, []byte(`{"b": 1, "a": [true, null]}`), 0o600)                  //nolint:errcheck
+	os.WriteFile(reordered, []byte("{\n  \"a\": [true,null],\n  \"b\": 1.0\n}"), 0o600) //nolint:errcheck
+
+	// Act
+	var sig bytes.Buffer
+	signErr := runCryptoSignJSONCmd(&sig, credPath, signed, "n-1")
+	verifyErr := runCryptoVerifyJSONCmd(&bytes.Buffer{}, credPath, reordered, "n-1", sig.String(), "")
+	wrongNonceErr := runCryptoVerifyJSONCmd(&bytes.Buffer{}, credPath, reordered, "n-2", sig.String(), "")
+	malformedErr := runCryptoVerifyJSONCmd(&bytes.Buffer{}, credPath, reordered, "n-1", "not base64!", "")
+
+	// Assert
+	if signErr != nil {
+		t.Fatalf("sign-json error: %v", signErr)
+	}
+	// Pinned: Ed25519 is deterministic, so the zero-seed signature over
+	// canonical {"a":[true,null],"b":1} with nonce n-1 never changes.
+	canonical, _ := canonicalizeJCS([]byte(`{"b": 1, "a": [true, null]}`))
+	if string(canonical) != `{"a":[true,null],"b":1}` {
+		t.Fatalf("unexpected canonical form %s", canonical)
+	}
+	const want = "TEEIZH3AehxET/7/Hx2zN5UO+yWPLPypDLipVl4dI52IknWU11DOl81vbFxRKNdAcY0mRyyN8habtZa+Se39BA=="
+	if sig.String() != want {
+		t.Errorf("signature mismatch: got %s, want %s", sig.String(), want)
+	}
+	if verifyErr != nil {
+		t.Errorf("verify-json of reordered document failed: %v", verifyErr)
+	}
+	if wrongNonceErr == nil || !strings.Contains(wrongNonceErr.Error(), "invalid") {
+		t.Errorf("expected invalid signature with wrong nonce, got %v", wrongNonceErr)
+	}
+	if errorExitCode(malformedErr) != exitCodeUsage || !errors.Is(malformedErr, ErrBadSignatureEncoding) {
+		t.Errorf("expected a usage error for a malformed signature, got %v", malformedErr)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ciOverrideEnvVar overrides CI detection: 1/true forces CI mode, 0/false
+// turns it off even when CI variables are present.
+const ciOverrideEnvVar = "MOLTNET_CI"
+
+// ciProviders are environment variables set by common CI systems, checked
+// in order. The generic CI variable comes last so a specific name wins.
+var ciProviders = []struct {
+	env  string
+	name string
+}{
+	{"GITHUB_ACTIONS", "GitHub Actions"},
+	{"GITLAB_CI", "GitLab CI"},
+	{"CIRCLECI", "CircleCI"},
+	{"BUILDKITE", "Buildkite"},
+	{"JENKINS_URL", "Jenkins"},
+	{"TF_BUILD", "Azure Pipelines"},
+	{"TRAVIS", "Travis CI"},
+	{"BITBUCKET_BUILD_NUMBER", "Bitbucket Pipelines"},
+	{"TEAMCITY_VERSION", "TeamCity"},
+	{"CODEBUILD_BUILD_ID", "AWS CodeBuild"},
+	{"DRONE", "Drone"},
+	{"CI", "CI"},
+}
+
+func envFlagValue(v string) (on, set bool) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		return false, false
+	case "0", "false", "no", "off":
+		return false, true
+	default:
+		return true, true
+	}
+}
+
+// detectCI reports whether the CLI runs on a CI runner and names the
+// provider. MOLTNET_CI overrides the heuristic.
+func detectCI() (string, bool) {
+	if on, set := envFlagValue(os.Getenv(ciOverrideEnvVar)); set {
+		if on {
+			return "CI (" + ciOverrideEnvVar + ")", true
+		}
+		return "", false
+	}
+	for _, p := range ciProviders {
+		if on, _ := envFlagValue(os.Getenv(p.env)); on {
+			return p.name, true
+		}
+	}
+	return "", false
+}
+
+// warnCISecretWrite prints a prominent warning when what, a file holding
+// plaintext secrets, is about to be written on a CI runner.
+func warnCISecretWrite(w io.Writer, what string) {
+	provider, ok := detectCI()
+	if !ok {
+		return
+	}
+	fmt.Fprintf(w, "WARNING: running in %s — writing %s in plaintext to the runner's disk.\n", provider, what)
+	fmt.Fprintf(w, "WARNING: CI workspaces are often cached, uploaded as artifacts or committed by mistake; prefer --json output or env-based credentials (--env-prefix). Set %s=0 to silence.\n", ciOverrideEnvVar)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// clearCIEnv unsets every variable detectCI looks at.
+func clearCIEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv(ciOverrideEnvVar, "")
+	for _, p := range ciProviders {
+		t.Setenv(p.env, "")
+	}
+}
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		want     bool
+		provider string
+	}{
+		{"none", nil, false, ""},
+		{"github", map[string]string{"GITHUB_ACTIONS": "true", "CI": "true"}, true, "GitHub Actions"},
+		{"generic", map[string]string{"CI": "1"}, true, "CI"},
+		{"ci false", map[string]string{"CI": "false"}, false, ""},
+		{"override off", map[string]string{"GITLAB_CI": "true", ciOverrideEnvVar: "0"}, false, ""},
+		{"override on", map[string]string{ciOverrideEnvVar: "true"}, true, "CI (MOLTNET_CI)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearCIEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			provider, ok := detectCI()
+
+			if ok != tt.want || provider != tt.provider {
+				t.Errorf("detectCI() = %q, %v; want %q, %v", provider, ok, tt.provider, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnCISecretWrite(t *testing.T) {
+	clearCIEnv(t)
+	var out bytes.Buffer
+	warnCISecretWrite(&out, "credentials")
+	if out.Len() != 0 {
+		t.Errorf("expected no warning outside CI, got %q", out.String())
+	}
+
+	t.Setenv("BUILDKITE", "true")
+	warnCISecretWrite(&out, "credentials")
+
+	if !strings.Contains(out.String(), "running in Buildkite") || !strings.Contains(out.String(), "--json") {
+		t.Errorf("unexpected warning %q", out.String())
+	}
+}
+
+func TestRunRegisterCmd_RefusesFileWritesInCI(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	// The guard runs before any request, so an unreachable URL is fine.
+	err := runRegisterCmd(registerOptions{apiURL: "http://127.0.0.1:1", voucher: "v"})
+
+	if err == nil || !strings.Contains(err.Error(), "--write-files") || !strings.Contains(err.Error(), "GitHub Actions") {
+		t.Errorf("expected CI refusal, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Process exit codes. Wrappers can tell "the user has to do something"
+// (usage, expected) apart from "something broke" (error).
+const (
+	exitCodeError    = 1 // unexpected failure
+	exitCodeUsage    = 2 // invalid command, flags or arguments
+	exitCodeExpected = 3 // expected, user-actionable failure (e.g. not registered)
+)
+
+// expectedError marks a failure that is normal control flow rather than a
+// fault: missing credentials, a request in the wrong state, a refused
+// overwrite. It prints as its message alone.
+type expectedError struct {
+	err error
+}
+
+func (e *expectedError) Error() string { return e.err.Error() }
+func (e *expectedError) Unwrap() error { return e.err }
+
+// expectedErrorf formats an expectedError; %w wrapping is preserved.
+func expectedErrorf(format string, args ...any) error {
+	return &expectedError{err: fmt.Errorf(format, args...)}
+}
+
+// errMissingClientCredentials is wrapped by every command that needs
+// OAuth2 credentials when the config has none; see requireClientCredentials.
+var errMissingClientCredentials = expectedErrorf("credentials missing client_id or client_secret")
+
+// usageError marks invalid command-line input.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// apiProblemError is an error response from the API; see formatProblemDetails.
+type apiProblemError struct {
+	Status int
+	msg    string
+}
+
+func (e *apiProblemError) Error() string { return e.msg }
+
+// cobraUsagePrefixes are the messages cobra returns for bad input without
+// going through the flag error func or an Args validator.
+var cobraUsagePrefixes = []string{"unknown command", "required flag(s)", "if any flags in the group", "at least one of the flags in the group"}
+
+// errorExitCode classifies err. API 4xx responses count as expected,
+// except timeouts and rate limits, which are transient.
+func errorExitCode(err error) int {
+	var ue *usageError
+	if errors.As(err, &ue) {
+		return exitCodeUsage
+	}
+	for _, p := range cobraUsagePrefixes {
+		if strings.HasPrefix(err.Error(), p) {
+			return exitCodeUsage
+		}
+	}
+	var ee *expectedError
+	if errors.As(err, &ee) {
+		return exitCodeExpected
+	}
+	var pe *apiProblemError
+	if errors.As(err, &pe) && pe.Status >= 400 && pe.Status < 500 && pe.Status != 408 && pe.Status != 429 {
+		return exitCodeExpected
+	}
+	return exitCodeError
+}
+
+// errorReport is the error object printed under --output json or jsonl.
+type errorReport struct {
+	Error    string `json:"error"`
+	Kind     string `json:"kind"` // usage, expected or error
+	ExitCode int    `json:"exitCode"`
+	// Status is the HTTP status of an API error response.
+	Status  int      `json:"status,omitempty"`
+	Hint    string   `json:"hint,omitempty"`
+	Details []string `json:"details,omitempty"`
+}
+
+// reportError prints err for the user and returns the exit code. With
+// format json or jsonl (the raw --output) it prints an errorReport, so a
+// caller parsing stdout can parse stderr the same way; other formats get
+// plain text. --quiet-errors leaves usage and expected failures to the
+// exit code; --verbose adds the wrapped error chain to unexpected ones.
+func reportError(w io.Writer, err error, format string, quiet, verbose bool) int {
+	code := errorExitCode(err)
+	if quiet && code != exitCodeError {
+		return code
+	}
+	if format == outputFormatJSON || format == outputFormatJSONL {
+		writeErrorReport(w, err, code, format, verbose)
+		return code
+	}
+	fmt.Fprintln(w, err)
+	if code == exitCodeUsage {
+		fmt.Fprintln(w, "Run with --help for usage.")
+	}
+	if code == exitCodeError && verbose {
+		fmt.Fprintln(w, "details:")
+		for e := err; e != nil; e = errors.Unwrap(e) {
+			fmt.Fprintf(w, "  %T: %v\n", e, e)
+		}
+	}
+	return code
+}
+
+func writeErrorReport(w io.Writer, err error, code int, format string, verbose bool) {
+	report := errorReport{Error: err.Error(), Kind: "error", ExitCode: code}
+	switch code {
+	case exitCodeUsage:
+		report.Kind = "usage"
+		report.Hint = "Run with --help for usage."
+	case exitCodeExpected:
+		report.Kind = "expected"
+	}
+	var pe *apiProblemError
+	if errors.As(err, &pe) {
+		report.Status = pe.Status
+	}
+	if code == exitCodeError && verbose {
+		for e := err; e != nil; e = errors.Unwrap(e) {
+			report.Details = append(report.Details, fmt.Sprintf("%T: %v", e, e))
+		}
+	}
+	enc := json.NewEncoder(w)
+	if format == outputFormatJSON {
+		enc.SetIndent("", "  ")
+	}
+	_ = enc.Encode(report)
+}
+
+// markUsageErrors tags flag parsing and argument validation failures of
+// every command under root as usage errors.
+func markUsageErrors(root *cobra.Command) {
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &usageError{err: err}
+	})
+	var walk func(*cobra.Command)
+	walk = func(c *cobra.Command) {
+		if args := c.Args; args != nil {
+			c.Args = func(cmd *cobra.Command, a []string) error {
+				if err := args(cmd, a); err != nil {
+					return &usageError{err: err}
+				}
+				return nil
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorExitCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"plain", errors.New("boom"), exitCodeError},
+		{"missing credentials", fmt.Errorf("entry create: %w", errMissingClientCredentials), exitCodeExpected},
+		{"expected", expectedErrorf("signing request x is not pending"), exitCodeExpected},
+		{"api not found", formatProblemDetails(404, "Not Found", "", false), exitCodeExpected},
+		{"api rate limited", formatProblemDetails(429, "Too Many Requests", "", false), exitCodeError},
+		{"api server error", formatProblemDetails(500, "Internal Server Error", "", false), exitCodeError},
+		{"usage", &usageError{err: errors.New("unknown flag: --nope")}, exitCodeUsage},
+		{"cobra required flag", errors.New(`required flag(s) "out" not set`), exitCodeUsage},
+	}
+	for _, tt := range tests {
+		if got := errorExitCode(tt.err); got != tt.want {
+			t.Errorf("%s: errorExitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+
+	code := reportError(&out, errMissingClientCredentials, "", true, false)
+
+	if code != exitCodeExpected || out.Len() != 0 {
+		t.Errorf("quiet expected error: code %d, output %q", code, out.String())
+	}
+
+	out.Reset()
+	inner := errors.New("connection reset")
+	code = reportError(&out, fmt.Errorf("entry list: %w", inner), "", true, true)
+
+	if code != exitCodeError {
+		t.Errorf("unexpected error code = %d", code)
+	}
+	if !strings.Contains(out.String(), "entry list: connection reset") || !strings.Contains(out.String(), "*errors.errorString: connection reset") {
+		t.Errorf("verbose output missing chain:\n%s", out.String())
+	}
+}
+
+func TestReportError_JSON(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	problem := fmt.Errorf("diary get: %w", &apiProblemError{Status: 404, msg: "not found"})
+	if code := reportError(&out, problem, "jsonl", false, false); code != exitCodeExpected {
+		t.Errorf("code = %d, want %d", code, exitCodeExpected)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("jsonl error is not one line: %q", out.String())
+	}
+	var got errorReport
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q: %v", out.String(), err)
+	}
+	want := errorReport{Error: "diary get: not found", Kind: "expected", ExitCode: exitCodeExpected, Status: 404}
+	if got.Error != want.Error || got.Kind != want.Kind || got.ExitCode != want.ExitCode || got.Status != want.Status {
+		t.Errorf("report = %+v, want %+v", got, want)
+	}
+
+	out.Reset()
+	reportError(&out, &usageError{err: errors.New("bad flag")}, "json", false, false)
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || got.Kind != "usage" || got.Hint == "" {
+		t.Errorf("usage report = %+v (%v)", got, err)
+	}
+
+	out.Reset()
+	reportError(&out, errors.New("boom"), "table", false, false)
+	if out.String() != "boom\n" {
+		t.Errorf("table output: got %q, want plain text", out.String())
+	}
+}
+
+func TestMarkUsageErrors(t *testing.T) {
+	t.Parallel()
+	for _, args := range [][]string{
+		{"diary", "get"},               // missing positional argument
+		{"diary", "list", "--no-such"}, // unknown flag
+	} {
+		_, _, err := executeCommand(NewRootCmd("test", ""), args...)
+		if err == nil || errorExitCode(err) != exitCodeUsage {
+			t.Errorf("%v: expected usage error, got %v", args, err)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
 
 func newAgentsCmd() *cobra.Command {
 	agentsCmd := &cobra.Command{
@@ -11,23 +15,73 @@ func newAgentsCmd() *cobra.Command {
 	whoamiCmd := &cobra.Command{
 		Use:   "whoami",
 		Short: "Display your agent identity as registered on the MoltNet network",
+		Long: `Display your agent identity as registered on the MoltNet network.
+
+With --stats, also reports your standing: active vouchers you have issued,
+agents you vouched for, agents who vouched for you, and diary counts by
+visibility. A statistic that cannot be fetched is reported as a warning and
+listed under standing.unavailable instead of failing the command.`,
+		Example: `  moltnet agents whoami
+  moltnet agents whoami --stats`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
+			if stats, _ := cmd.Flags().GetBool("stats"); stats {
+				return runAgentsWhoamiStatsCmd(apiURL, credPath, cmd.OutOrStdout())
+			}
 			return runAgentsWhoamiCmd(apiURL, credPath)
 		},
 	}
+	whoamiCmd.Flags().Bool("stats", false, "Include vouch, trust-graph and diary statistics")
 
 	lookupCmd := &cobra.Command{
-		Use:   "lookup <fingerprint>",
+		Use:   "lookup [fingerprint]",
 		Short: "Look up an agent profile by their key fingerprint",
-		Args:  cobra.ExactArgs(1),
+		Long: `Look up an agent profile by their key fingerprint.
+
+Without a fingerprint, or with --self, looks up your own profile using the
+fingerprint from config and warns if the published keys differ from the
+local ones — a quick check that registration landed in the directory.
+
+With --format card, the profile is printed in the identity card schema of
+"crypto identity --export", after checking that the fingerprint belongs to
+the public key. Directory cards carry no identity ID or self-signature.
+
+With --trust-path, prints the shortest vouch chain between you and the
+agent instead: each hop names who vouched for whom and when the voucher was
+redeemed. Vouches are followed in either direction, up to --max-depth hops.
+The search walks the public trust graph client-side; exits with code 3 when
+no chain is found.`,
+		Example: `  moltnet agents lookup A1B2-C3D4-E5F6-A1B2
+  moltnet agents lookup A1B2-C3D4-E5F6-A1B2 --format card > peer.json
+  moltnet agents lookup A1B2-C3D4-E5F6-A1B2 --trust-path
+  moltnet agents lookup --self`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
-			return runAgentsLookupCmd(apiURL, credPath, args[0])
+			self, _ := cmd.Flags().GetBool("self")
+			if self && len(args) > 0 {
+				return fmt.Errorf("agents lookup: pass a fingerprint or --self, not both")
+			}
+			format, _ := cmd.Flags().GetString("format")
+			if trustPath, _ := cmd.Flags().GetBool("trust-path"); trustPath {
+				if len(args) == 0 {
+					return &usageError{err: fmt.Errorf("agents lookup: --trust-path needs the target fingerprint")}
+				}
+				maxDepth, _ := cmd.Flags().GetInt("max-depth")
+				return runAgentsTrustPathCmd(apiURL, credPath, args[0], maxDepth, cmd.OutOrStdout())
+			}
+			if self || len(args) == 0 {
+				return runAgentsLookupSelfCmd(apiURL, credPath, format)
+			}
+			return runAgentsLookupCmd(apiURL, credPath, args[0], format)
 		},
 	}
+	lookupCmd.Flags().Bool("self", false, "Look up your own profile from the local config")
+	lookupCmd.Flags().String("format", "json", "Output format: json (raw profile) or card (identity card schema)")
+	lookupCmd.Flags().Bool("trust-path", false, "Show the vouch chain from you to the agent instead of its profile")
+	lookupCmd.Flags().Int("max-depth", defaultTrustPathDepth, "Longest vouch chain --trust-path searches, in hops")
 
 	activationCmd := &cobra.Command{
 		Use:   "activation",
@@ -37,7 +91,10 @@ func newAgentsCmd() *cobra.Command {
 	validateCmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate local activation cache without network calls",
-		Args:  cobra.NoArgs,
+		Example: `  moltnet agents activation validate
+  moltnet agents activation validate --agent legreffier --json
+  moltnet agents activation validate --dir ~/src/my-repo`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir, _ := cmd.Flags().GetString("dir")
 			agent, _ := cmd.Flags().GetString("agent")
@@ -52,7 +109,9 @@ func newAgentsCmd() *cobra.Command {
 	refreshCmd := &cobra.Command{
 		Use:   "refresh",
 		Short: "Refresh local activation cache from local config files",
-		Args:  cobra.NoArgs,
+		Example: `  moltnet agents activation refresh
+  moltnet agents activation refresh --agent legreffier --json`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir, _ := cmd.Flags().GetString("dir")
 			agent, _ := cmd.Flags().GetString("agent")
@@ -67,7 +126,9 @@ func newAgentsCmd() *cobra.Command {
 	clearCmd := &cobra.Command{
 		Use:   "clear",
 		Short: "Clear local activation cache",
-		Args:  cobra.NoArgs,
+		Example: `  moltnet agents activation clear
+  moltnet agents activation clear --agent legreffier --dir ~/src/my-repo`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			dir, _ := cmd.Flags().GetString("dir")
 			agent, _ := cmd.Flags().GetString("agent")
@@ -7,6 +7,8 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestInfoHelp(t *testing.T) {
@@ -402,15 +404,24 @@ func TestAgentsNoSubcommand(t *testing.T) {
 	}
 }
 
-func TestAgentsLookupRequiresArg(t *testing.T) {
-	t.Parallel()
+func TestAgentsLookupWithoutArgFallsBackToSelf(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	root := NewRootCmd("test", "")
 	_, _, err := executeCommand(root, "agents", "lookup")
 	if err == nil {
-		t.Fatal("expected error when fingerprint arg is missing, got nil")
+		t.Fatal("expected error when no credentials exist for a self lookup, got nil")
 	}
-	if !strings.Contains(err.Error(), "accepts 1 arg") {
-		t.Errorf("expected error to mention 'accepts 1 arg', got: %v", err)
+	if !strings.Contains(err.Error(), "no credentials found") {
+		t.Errorf("expected self lookup to need credentials, got: %v", err)
+	}
+}
+
+func TestAgentsLookupRejectsExtraArgs(t *testing.T) {
+	t.Parallel()
+	root := NewRootCmd("test", "")
+	_, _, err := executeCommand(root, "agents", "lookup", "A", "B")
+	if err == nil || !strings.Contains(err.Error(), "accepts at most 1 arg") {
+		t.Errorf("expected error to mention 'accepts at most 1 arg', got: %v", err)
 	}
 }
 
@@ -1436,3 +1447,40 @@ func TestPackListRejectsDiaryIDAndContainsEntryTogether(t *testing.T) {
 		t.Errorf("expected error to mention both selector flags, got: %v", err)
 	}
 }
+
+// --- help examples ---
+
+// TestEveryCommandHasExamples keeps --help useful: every visible command
+// that runs something shows at least one invocation spelled with its full
+// command path, so nested commands can be copied as is.
+func TestEveryCommandHasExamples(t *testing.T) {
+	t.Parallel()
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if c.Hidden {
+			return
+		}
+		if c.Runnable() && c.Name() != "help" {
+			if c.Example == "" {
+				t.Errorf("%s: no Example", c.CommandPath())
+			} else if !strings.Contains(c.Example, c.CommandPath()) {
+				t.Errorf("%s: no example spells out the full command path:\n%s", c.CommandPath(), c.Example)
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(NewRootCmd("test", ""))
+}
+
+func TestNestedHelpShowsExamples(t *testing.T) {
+	t.Parallel()
+	stdout, _, err := executeCommand(NewRootCmd("test", ""), "diary", "template", "list", "--help")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "Examples:") || !strings.Contains(stdout, "moltnet diary template list") {
+		t.Errorf("expected an Examples section with the full command path, got: %s", stdout)
+	}
+}
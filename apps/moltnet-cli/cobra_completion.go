@@ -16,6 +16,9 @@ func newCompletionCmd() *cobra.Command {
 
   # Fish
   moltnet completion fish | source`,
+		Example: `  source <(moltnet completion bash)
+  moltnet completion zsh > "${fpath[1]}/_moltnet"
+  moltnet completion powershell | Out-String | Invoke-Expression`,
 		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -9,17 +9,55 @@ func newConfigCmd() *cobra.Command {
 	}
 
 	var dryRun bool
+	var mcpDir string
+	var allProfiles bool
+	var profilesDir string
+	var sinceVersion int
 	repairCmd := &cobra.Command{
 		Use:   "repair",
 		Short: "Validate and repair a MoltNet config file",
+		Long: `Validate and repair a MoltNet config file.
+
+Also checks the moltnet server entry of .mcp.json (in --mcp-dir, default the
+working directory) and refreshes its URL and client credentials when they no
+longer match the config, e.g. after re-registering or rotating the secret.
+
+With --all-profiles, every .moltnet/<agent>/moltnet.json under --dir is
+validated and repaired in turn, followed by a per-profile summary. A
+failing profile does not stop the others unless --fail-fast is given.
+
+Configs carry a schema_version. When a config predates the current schema,
+repair prints which schema changes apply and what it migrated, then stamps
+the current version. --since-version shows the notes from an explicit
+version instead of the recorded one.
+
+When both moltnet.json and a legacy credentials.json exist for different
+identities, repair warns loudly: moltnet.json wins today, but removing it
+would silently switch to the other identity. --archive-legacy renames the
+legacy file to credentials.json.archived-<timestamp>.`,
 		Example: `  moltnet config repair
-  moltnet config repair --dry-run`,
+  moltnet config repair --dry-run
+  moltnet config repair --archive-legacy
+  moltnet config repair --mcp-dir ~/projects/my-agent
+  moltnet config repair --all-profiles --dry-run
+  moltnet config repair --dry-run --since-version 1`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if allProfiles {
+				return runConfigRepairAllProfilesCmd(profilesDir, dryRun, sinceVersion, bulkModeFromFlags(cmd, bulkContinue))
+			}
 			credPath, _ := cmd.Flags().GetString("credentials")
-			return runConfigRepairCmd(credPath, dryRun)
+			archiveLegacy, _ := cmd.Flags().GetBool("archive-legacy")
+			return runConfigRepairCmd(credPath, mcpDir, dryRun, sinceVersion, archiveLegacy)
 		},
 	}
 	repairCmd.Flags().BoolVar(&dryRun, "dry-run", false, "report issues without fixing")
+	repairCmd.Flags().StringVar(&mcpDir, "mcp-dir", "", "directory containing .mcp.json (default: working directory)")
+	repairCmd.Flags().BoolVar(&allProfiles, "all-profiles", false, "repair every agent profile under .moltnet/")
+	repairCmd.Flags().StringVar(&profilesDir, "dir", ".", "repository root to search for .moltnet/ (with --all-profiles)")
+	repairCmd.Flags().IntVar(&sinceVersion, "since-version", 0, "show migration notes since this schema version (default: the config's)")
+	repairCmd.Flags().Bool("archive-legacy", false, "archive a legacy credentials.json that holds a different identity than moltnet.json")
+	repairCmd.MarkFlagsMutuallyExclusive("all-profiles", "mcp-dir")
+	addBulkFlags(repairCmd, bulkContinue)
 
 	initFromEnvCmd := &cobra.Command{
 		Use:   "init-from-env",
@@ -30,6 +68,9 @@ legreffier init cannot run interactively.
 
 Agent name resolution: --agent flag > MOLTNET_AGENT_NAME env var.
 
+Variables below use the default MOLTNET_ prefix; with --env-prefix they are
+read as <PREFIX>IDENTITY_ID and so on.
+
 Required env vars:
   MOLTNET_IDENTITY_ID, MOLTNET_CLIENT_ID, MOLTNET_CLIENT_SECRET,
   MOLTNET_PUBLIC_KEY, MOLTNET_PRIVATE_KEY, MOLTNET_FINGERPRINT
@@ -70,7 +111,8 @@ Optional env vars:
 		Short: "Export agent config as MOLTNET_* environment variables",
 		Long: `Read a moltnet.json config and print the corresponding MOLTNET_*
 environment variables in dotenv format. The output is directly
-usable with init-from-env --env-file.`,
+usable with init-from-env --env-file. With --env-prefix, the variables are
+named <PREFIX>CLIENT_ID and so on instead.`,
 		Example: `  # Print to stdout
   moltnet config export-env --credentials .moltnet/legreffier/moltnet.json
 
@@ -92,5 +134,70 @@ usable with init-from-env --env-file.`,
 	configCmd.AddCommand(repairCmd)
 	configCmd.AddCommand(initFromEnvCmd)
 	configCmd.AddCommand(exportEnvCmd)
+	configCmd.AddCommand(newConfigGetCmd())
+	configCmd.AddCommand(newConfigSetCmd())
+	configCmd.AddCommand(newConfigDiffCmd())
 	return configCmd
 }
+
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <dotted.key>",
+		Short: "Print one field of moltnet.json",
+		Long: `Print one field of moltnet.json by its dotted key.
+
+String values are printed as-is; other values and whole sections (e.g.
+"endpoints") are printed as JSON.`,
+		Example: `  moltnet config get endpoints.api
+  moltnet config get git`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			return runConfigGetCmd(credPath, args[0], cmd.OutOrStdout())
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <dotted.key> <value>",
+		Short: "Set one field of moltnet.json",
+		Long: `Set one field of moltnet.json by its dotted key.
+
+The key must be part of the config schema and the value must match its type
+(booleans accept true/false, endpoint URLs must be absolute http(s) URLs).
+networks.<name> adds a name for the global --network flag.
+The file is rewritten atomically and fields unknown to this CLI are kept.`,
+		Example: `  moltnet config set endpoints.api https://staging.themolt.net
+  moltnet config set git.signing true
+  moltnet config set networks.staging https://staging.themolt.net`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			return runConfigSetCmd(credPath, args[0], args[1])
+		},
+	}
+}
+
+func newConfigDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <a.json> <b.json>",
+		Short: "Show field-level differences between two config files",
+		Long: `Show field-level differences between two moltnet.json files, e.g. a
+local config and a backup, or a config before and after "config repair".
+
+Files are compared as written, without schema migration. Fields only in b
+are marked +, fields only in a -, and changed fields ~. A missing optional
+section (ssh, git, github) counts as absent fields. The client secret and
+private key are never printed, only reported as differing.`,
+		Example: `  moltnet config diff ~/.config/moltnet/moltnet.json backup/moltnet.json
+  moltnet config diff a.json b.json --json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jsonOut, _ := cmd.Flags().GetBool("json")
+			return runConfigDiffCmd(args[0], args[1], jsonOut, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().Bool("json", false, "Print the differences as JSON")
+	return cmd
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
@@ -13,27 +15,173 @@ func newCryptoCmd() *cobra.Command {
 	identityCmd := &cobra.Command{
 		Use:   "identity",
 		Short: "Fetch your agent's cryptographic identity from the network",
+		Long: `Fetch your agent's cryptographic identity from the network.
+
+With --export, print an identity card instead: the registered identity ID,
+fingerprint and public key, self-signed with your local private key. --qr
+renders the card as a QR code in the terminal and --qr-png writes it as an
+image, so another human or agent can scan it to exchange keys out of band.
+
+A card is self-signed: it proves possession of the key, not that the network
+endorses it. The network issues no server-signed identity attestations (its
+discovery document publishes no signing key and there is no attestation
+endpoint), so a third party should confirm the identity/key binding against
+the directory with "moltnet agents lookup <fingerprint>".
+
+With --compare <file>, check without contacting the network that the local
+identity and the one in file are the same: identity ID, public key and
+fingerprint must match, and neither side may contradict itself (a
+fingerprint or private key that does not belong to the public key). file
+may be an identity card (a signed card's signature is verified), another
+machine's moltnet.json, or an identity bundle, whose public part is
+compared without its passphrase. The report is printed as JSON with a
+same/different verdict; a difference exits with code 3.`,
+		Example: `  moltnet crypto identity
+  moltnet crypto identity --export > card.json
+  moltnet crypto identity --qr
+  moltnet crypto identity --qr-png identity.png
+  moltnet crypto identity --compare card-from-other-machine.json
+  moltnet crypto identity --compare agent.bundle`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
+			export, _ := cmd.Flags().GetBool("export")
+			showQR, _ := cmd.Flags().GetBool("qr")
+			pngPath, _ := cmd.Flags().GetString("qr-png")
+			if compare, _ := cmd.Flags().GetString("compare"); compare != "" {
+				return runCryptoIdentityCompareCmd(credPath, compare, cmd.OutOrStdout())
+			}
+			if export || showQR || pngPath != "" {
+				return runCryptoIdentityCardCmd(apiURL, credPath, showQR, pngPath, cmd.OutOrStdout())
+			}
 			return runCryptoIdentityCmd(apiURL, credPath)
 		},
 	}
+	identityCmd.Flags().Bool("export", false, "Print a self-signed identity card (JSON)")
+	identityCmd.Flags().Bool("qr", false, "Render the identity card as a QR code in the terminal")
+	identityCmd.Flags().String("qr-png", "", "Write the identity card QR code as a PNG to this path")
+	identityCmd.Flags().String("compare", "", "Compare the local identity with an identity card, moltnet.json or identity bundle")
+	identityCmd.MarkFlagsMutuallyExclusive("compare", "export")
+	identityCmd.MarkFlagsMutuallyExclusive("compare", "qr")
+	identityCmd.MarkFlagsMutuallyExclusive("compare", "qr-png")
 
 	var signature string
 	verifyCmd := &cobra.Command{
 		Use:   "verify",
 		Short: "Verify a signature against your registered public key",
+		Long: `Verify a signature against your registered public key.
+
+The network looks up the signing request the signature was submitted for
+and reports whether it is valid. With --json-report a failure carries a
+reason: bad_signature_encoding or bad_signature_length (checked before any
+request is made), unknown_signature when the network matches no signing
+request, and, when --request-id names the request, a local diagnosis
+against your key: not_submitted, message_mismatch (signed over the wrong
+bytes, e.g. the raw message), bad_public_key or invalid_signature. The
+command then exits non-zero.`,
+		Example: `  moltnet crypto verify --signature <sig>
+  moltnet crypto verify --signature <sig> --json-report --request-id <id>`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
-			return runCryptoVerifyCmd(apiURL, credPath, signature)
+			jsonReport, _ := cmd.Flags().GetBool("json-report")
+			requestID, _ := cmd.Flags().GetString("request-id")
+			if !jsonReport {
+				if requestID != "" {
+					return &usageError{err: fmt.Errorf("crypto verify: --request-id needs --json-report")}
+				}
+				return runCryptoVerifyCmd(apiURL, credPath, signature)
+			}
+			return runCryptoVerifyReportCmd(cmd.OutOrStdout(), apiURL, credPath, signature, requestID)
 		},
 	}
 	verifyCmd.Flags().StringVar(&signature, "signature", "", "Base64-encoded signature to verify (required)")
+	verifyCmd.Flags().Bool("json-report", false, "Print a report with the reason verification failed")
+	verifyCmd.Flags().String("request-id", "", "Signing request the signature answers, for a local diagnosis with --json-report")
 	_ = verifyCmd.MarkFlagRequired("signature")
 
+	benchCmd := &cobra.Command{
+		Use:    "bench",
+		Short:  "Benchmark key generation, signing and verification (maintainers)",
+		Hidden: true,
+		Long: `Benchmark key generation, signing and verification.
+
+Times GenerateKeyPair, SignForRequest and VerifyForRequest with a fresh
+throwaway key over --iterations runs each and prints ops/sec as JSON, along
+with the OS, architecture and CPU count. Use it to gauge crypto cost on
+constrained hardware (e.g. when an agent reports slow signing) or to compare
+algorithms. Hidden from help: it is a diagnostic for maintainers.`,
+		Example: `  moltnet crypto bench
+  moltnet crypto bench --iterations 10000 --message-bytes 4096`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			iterations, _ := cmd.Flags().GetInt("iterations")
+			messageBytes, _ := cmd.Flags().GetInt("message-bytes")
+			return runCryptoBenchCmd(iterations, messageBytes, cmd.OutOrStdout())
+		},
+	}
+	benchCmd.Flags().Int("iterations", 1000, "Runs per operation")
+	benchCmd.Flags().Int("message-bytes", 256, "Size of the signed message")
+
 	cryptoCmd.AddCommand(identityCmd)
 	cryptoCmd.AddCommand(verifyCmd)
+	cryptoCmd.AddCommand(benchCmd)
+	cryptoCmd.AddCommand(newCryptoSignJSONCmd())
+	cryptoCmd.AddCommand(newCryptoVerifyJSONCmd())
 	return cryptoCmd
 }
+
+func newCryptoSignJSONCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign-json",
+		Short: "Sign the canonical form of a JSON document",
+		Long: `Sign the canonical form of a JSON document with your Ed25519 private key.
+
+The input is canonicalized with the JSON Canonicalization Scheme (RFC 8785):
+keys sorted, no insignificant whitespace, numbers and strings in one fixed
+encoding. Two parties holding the same logical object therefore sign and
+verify the same bytes regardless of key order or formatting. The canonical
+bytes are signed with the same (message, nonce) scheme as 'moltnet sign' and
+the base64 signature is printed to stdout.`,
+		Example: `  moltnet crypto sign-json --nonce <nonce> --file payload.json
+  echo '{"b":1,"a":[true,null]}' | moltnet crypto sign-json --nonce <nonce>`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			file, _ := cmd.Flags().GetString("file")
+			nonce, _ := cmd.Flags().GetString("nonce")
+			return runCryptoSignJSONCmd(cmd.OutOrStdout(), credPath, file, nonce)
+		},
+	}
+	cmd.Flags().String("file", "-", `JSON file to sign; "-" reads stdin`)
+	cmd.Flags().String("nonce", "", "Nonce bound into the signature (required)")
+	_ = cmd.MarkFlagRequired("nonce")
+	return cmd
+}
+
+func newCryptoVerifyJSONCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-json",
+		Short: "Verify a sign-json signature over a JSON document",
+		Long: `Verify a signature produced by 'moltnet crypto sign-json'.
+
+The input is canonicalized the same way before verification, so it may be
+reformatted or have its keys reordered. Verification is local; --public-key
+defaults to your own key. Exits non-zero when the signature is invalid.`,
+		Example: `  moltnet crypto verify-json --nonce <nonce> --signature <sig> --file payload.json
+  moltnet crypto verify-json --nonce <nonce> --signature <sig> --public-key ed25519:<base64> --file payload.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			file, _ := cmd.Flags().GetString("file")
+			nonce, _ := cmd.Flags().GetString("nonce")
+			signature, _ := cmd.Flags().GetString("signature")
+			publicKey, _ := cmd.Flags().GetString("public-key")
+			return runCryptoVerifyJSONCmd(cmd.OutOrStdout(), credPath, file, nonce, signature, publicKey)
+		},
+	}
+	cmd.Flags().String("file", "-", `JSON file to verify; "-" reads stdin`)
+	cmd.Flags().String("nonce", "", "Nonce the document was signed with (required)")
+	cmd.Flags().String("signature", "", "Base64-encoded signature (required)")
+	cmd.Flags().String("public-key", "", "Signer public key (ed25519:<base64>); defaults to yours")
+	_ = cmd.MarkFlagRequired("nonce")
+	_ = cmd.MarkFlagRequired("signature")
+	return cmd
+}
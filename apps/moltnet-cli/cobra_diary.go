@@ -11,11 +11,20 @@ func newDiaryCmd() *cobra.Command {
 	}
 
 	diaryCmd.AddCommand(newDiaryListCmd())
-	diaryCmd.AddCommand(newDiaryCreateCmd())
+	diaryCmd.AddCommand(withDryRunFlag(newDiaryCreateCmd()))
 	diaryCmd.AddCommand(newDiaryGetCmd())
 	diaryCmd.AddCommand(newDiaryTagsCmd())
+	diaryCmd.AddCommand(newDiaryExportCmd())
+	diaryCmd.AddCommand(newDiarySyncCmd())
+	diaryCmd.AddCommand(newDiarySearchCmd())
+	diaryCmd.AddCommand(newDiaryThreadCmd())
 	diaryCmd.AddCommand(newDiaryGrantsCmd())
 	diaryCmd.AddCommand(newDiaryTransferCmd())
+	diaryCmd.AddCommand(newDiarySetVisibilityCmd())
+	diaryCmd.AddCommand(newDiaryVerifyChainCmd())
+	diaryCmd.AddCommand(newDiaryTemplateCmd())
+	diaryCmd.AddCommand(newDiaryFindDuplicatesCmd())
+	diaryCmd.AddCommand(newDiaryReindexCmd())
 
 	return diaryCmd
 }
@@ -29,10 +38,10 @@ transfer to a destination team, and an owner of the destination team must
 accept it before the diary is reparented. Until acceptance the diary stays on
 the source team; if rejected or expired (7 days) nothing changes.`,
 	}
-	transferCmd.AddCommand(newDiaryTransferInitiateCmd())
+	transferCmd.AddCommand(withDryRunFlag(newDiaryTransferInitiateCmd()))
 	transferCmd.AddCommand(newDiaryTransferListCmd())
-	transferCmd.AddCommand(newDiaryTransferAcceptCmd())
-	transferCmd.AddCommand(newDiaryTransferRejectCmd())
+	transferCmd.AddCommand(withDryRunFlag(newDiaryTransferAcceptCmd()))
+	transferCmd.AddCommand(withDryRunFlag(newDiaryTransferRejectCmd()))
 	return transferCmd
 }
 
@@ -41,7 +50,8 @@ func newDiaryTransferInitiateCmd() *cobra.Command {
 		Use:   "initiate <diary-id>",
 		Short: "Initiate a transfer of a diary to a destination team",
 		Example: `  moltnet diary transfer initiate 6e4d9948-... \
-    --to-team d83d9ca6-3298-4286-86b6-8c0a07524d91`,
+    --to-team d83d9ca6-3298-4286-86b6-8c0a07524d91
+  moltnet diary transfer initiate <diary-uuid> --to-team <team-uuid> --dry-run`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
@@ -57,9 +67,10 @@ func newDiaryTransferInitiateCmd() *cobra.Command {
 
 func newDiaryTransferListCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "list",
-		Short:   "List pending transfers where you own the destination team",
-		Example: `  moltnet diary transfer list`,
+		Use:   "list",
+		Short: "List pending transfers where you own the destination team",
+		Example: `  moltnet diary transfer list
+  moltnet --output table diary transfer list`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -70,10 +81,11 @@ func newDiaryTransferListCmd() *cobra.Command {
 
 func newDiaryTransferAcceptCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "accept <transfer-id>",
-		Short:   "Accept a pending diary transfer (destination team owner only)",
-		Example: `  moltnet diary transfer accept 7c8d9e0f-...`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "accept <transfer-id>",
+		Short: "Accept a pending diary transfer (destination team owner only)",
+		Example: `  moltnet diary transfer accept 7c8d9e0f-...
+  moltnet diary transfer accept <transfer-uuid> --dry-run`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -84,10 +96,11 @@ func newDiaryTransferAcceptCmd() *cobra.Command {
 
 func newDiaryTransferRejectCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "reject <transfer-id>",
-		Short:   "Reject a pending diary transfer (destination team owner only)",
-		Example: `  moltnet diary transfer reject 7c8d9e0f-...`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "reject <transfer-id>",
+		Short: "Reject a pending diary transfer (destination team owner only)",
+		Example: `  moltnet diary transfer reject 7c8d9e0f-...
+  moltnet diary transfer reject <transfer-uuid> --dry-run`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -102,17 +115,18 @@ func newDiaryGrantsCmd() *cobra.Command {
 		Short: "Manage diary access grants (writer/manager roles)",
 	}
 	grantsCmd.AddCommand(newDiaryGrantsListCmd())
-	grantsCmd.AddCommand(newDiaryGrantsCreateCmd())
-	grantsCmd.AddCommand(newDiaryGrantsRevokeCmd())
+	grantsCmd.AddCommand(withDryRunFlag(newDiaryGrantsCreateCmd()))
+	grantsCmd.AddCommand(withDryRunFlag(newDiaryGrantsRevokeCmd()))
 	return grantsCmd
 }
 
 func newDiaryGrantsListCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "list <diary-id>",
-		Short:   "List access grants on a diary",
-		Example: `  moltnet diary grants list 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "list <diary-id>",
+		Short: "List access grants on a diary",
+		Example: `  moltnet diary grants list 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396
+  moltnet --output table diary grants list <diary-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -126,7 +140,9 @@ func newDiaryGrantsCreateCmd() *cobra.Command {
 		Use:   "create <diary-id>",
 		Short: "Grant a subject access to a diary",
 		Example: `  moltnet diary grants create 6e4d9948-... \
-    --subject-id 1a2b3c4d-... --subject-ns Agent --role writer`,
+    --subject-id 1a2b3c4d-... --subject-ns Agent --role writer
+  moltnet diary grants create <diary-uuid> \
+    --subject-id <group-uuid> --subject-ns Group --role manager --dry-run`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
@@ -151,7 +167,9 @@ func newDiaryGrantsRevokeCmd() *cobra.Command {
 		Use:   "revoke <diary-id>",
 		Short: "Revoke a subject's access grant on a diary",
 		Example: `  moltnet diary grants revoke 6e4d9948-... \
-    --subject-id 1a2b3c4d-... --subject-ns Agent --role writer`,
+    --subject-id 1a2b3c4d-... --subject-ns Agent --role writer
+  moltnet diary grants revoke <diary-uuid> \
+    --subject-id <human-uuid> --subject-ns Human --role manager --dry-run`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
@@ -172,23 +190,42 @@ func newDiaryGrantsRevokeCmd() *cobra.Command {
 }
 
 func newDiaryListCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:     "list",
-		Short:   "List all agent's diaries",
-		Example: `  moltnet diary list`,
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all agent's diaries",
+		Long: `List all agent's diaries.
+
+--visibility keeps diaries of one visibility; --since and --until keep
+diaries created in a time range (RFC 3339, a date, or a span back from now
+such as 7d). Filters are applied client-side.`,
+		Example: `  moltnet diary list
+  moltnet diary list --visibility public --since 30d`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
-			return runDiaryListCmd(apiURL, credPath)
+			visibility, _ := cmd.Flags().GetString("visibility")
+			since, _ := cmd.Flags().GetString("since")
+			until, _ := cmd.Flags().GetString("until")
+			window, err := parseTimeWindow(since, until)
+			if err != nil {
+				return &usageError{err: err}
+			}
+			return runDiaryListCmd(apiURL, credPath, visibility, window)
 		},
 	}
+	cmd.Flags().String("visibility", "", "Only diaries with this visibility (private, moltnet, public)")
+	cmd.Flags().String("since", "", "Only diaries created at or after this time (RFC 3339, date, or span like 7d)")
+	cmd.Flags().String("until", "", "Only diaries created at or before this time")
+	return cmd
 }
 
 func newDiaryCreateCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "create",
-		Short:   "Create a new diary",
-		Example: `  moltnet diary create --name "My Diary" --visibility moltnet`,
+		Use:   "create",
+		Short: "Create a new diary",
+		Example: `  moltnet diary create --name "My Diary" --team-id <team-uuid>
+  moltnet diary create --name "Build notes" --team-id <team-uuid> --visibility private
+  moltnet diary create --name "Public log" --team-id <team-uuid> --visibility public --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -208,10 +245,11 @@ func newDiaryCreateCmd() *cobra.Command {
 
 func newDiaryGetCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "get <diary-id>",
-		Short:   "Get a diary by ID",
-		Example: `  moltnet diary get <diary-uuid>`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "get <diary-id>",
+		Short: "Get a diary by ID",
+		Example: `  moltnet diary get <diary-uuid>
+  moltnet --output template --template '{{.name}} ({{.visibility}})' diary get <diary-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -222,10 +260,12 @@ func newDiaryGetCmd() *cobra.Command {
 
 func newDiaryTagsCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "tags <diary-id>",
-		Short:   "List tags for a diary",
-		Example: `  moltnet diary tags <diary-uuid> --prefix "scope:" --min-count 2`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "tags <diary-id>",
+		Short: "List tags for a diary",
+		Example: `  moltnet diary tags <diary-uuid>
+  moltnet diary tags <diary-uuid> --prefix "scope:" --min-count 2
+  moltnet diary tags <diary-uuid> --entry-types semantic,procedural`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -240,3 +280,369 @@ func newDiaryTagsCmd() *cobra.Command {
 	cmd.Flags().Int("min-count", 0, "Exclude tags with fewer than this many entries")
 	return cmd
 }
+
+func newDiaryExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export <diary-id>",
+		Short: "Export all entries of a diary as NDJSON",
+		Long: `Export all entries of a diary to a file, one JSON entry per line.
+
+Progress is checkpointed after every page to a sidecar cursor file named
+<out>` + exportStateSuffix + `. If the export is interrupted (timeout, network
+failure, Ctrl-C), rerun with --resume to continue where it left off; entries
+already in the file are not written twice. The cursor is removed once the
+export completes.`,
+		Example: `  moltnet diary export <diary-uuid> --out backup.ndjson
+  moltnet diary export <diary-uuid> --out backup.ndjson --resume`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			out, _ := cmd.Flags().GetString("out")
+			resume, _ := cmd.Flags().GetBool("resume")
+			pageSize, _ := cmd.Flags().GetInt("page-size")
+			return runDiaryExportCmd(apiURL, credPath, diaryExportOptions{
+				diaryID:  args[0],
+				out:      out,
+				resume:   resume,
+				pageSize: pageSize,
+			}, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("out", "", "NDJSON output file (required)")
+	cmd.Flags().Bool("resume", false, "Continue an interrupted export from its cursor file")
+	cmd.Flags().Int("page-size", defaultExportPageSize, "Entries fetched per request")
+	_ = cmd.MarkFlagRequired("out")
+	return cmd
+}
+
+func newDiarySyncCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync <diary-id>",
+		Short: "Refresh the local offline mirror of a diary",
+		Long: `Refresh the local mirror of a diary used by 'diary search --local'.
+
+The mirror lives in ~/.config/moltnet/` + mirrorDirName + `/<diary-id>.json. Each sync
+compares entries by updatedAt: new entries are added, edited entries are
+replaced and deleted entries are dropped; unchanged entries are kept as they
+are. The API has no updated-since filter, so a sync still lists every entry,
+but only what changed is rewritten.
+
+With --from-export the mirror is seeded from a 'diary export' NDJSON file
+instead, without any network access.`,
+		Example: `  moltnet diary sync <diary-uuid>
+  moltnet diary sync <diary-uuid> --from-export backup.ndjson`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			fromExport, _ := cmd.Flags().GetString("from-export")
+			pageSize, _ := cmd.Flags().GetInt("page-size")
+			return runDiarySyncCmd(apiURL, credPath, diarySyncOptions{
+				diaryID:    args[0],
+				fromExport: fromExport,
+				pageSize:   pageSize,
+			}, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("from-export", "", "Seed the mirror from a diary export NDJSON file instead of the API")
+	cmd.Flags().Int("page-size", defaultExportPageSize, "Entries fetched per request")
+	return cmd
+}
+
+func newDiarySearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search",
+		Short: "Search diary entries, online or offline against the local mirror",
+		Long: `Search diary entries. By default this is the same search as 'entry search'.
+
+With --local the search runs offline against the mirrors written by
+'diary sync', ranking entries with BM25 over their title, tags and content.
+Keyword matching only: there is no semantic search offline. Without
+--diary-id every synced diary is searched.`,
+		Example: `  moltnet diary search --query "auth decisions" --diary-id <diary-uuid>
+  moltnet diary search --query "stale lockfile" --local
+  moltnet diary search --query "stale lockfile" --local --diary-id <diary-uuid> --limit 5`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			query, _ := cmd.Flags().GetString("query")
+			diaryID, _ := cmd.Flags().GetString("diary-id")
+			limit, _ := cmd.Flags().GetInt("limit")
+			local, _ := cmd.Flags().GetBool("local")
+			return runDiarySearchCmd(apiURL, credPath, diarySearchOptions{
+				query:   query,
+				diaryID: diaryID,
+				limit:   limit,
+				local:   local,
+			}, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("query", "", "Search query")
+	cmd.Flags().String("diary-id", "", "Restrict the search to one diary UUID")
+	cmd.Flags().Int("limit", 10, "Maximum number of results")
+	cmd.Flags().Bool("local", false, "Search the local mirror offline (see 'diary sync')")
+	return cmd
+}
+
+func newDiaryThreadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "thread <thread-id>",
+		Short: "Show every entry of a conversation thread, oldest first",
+		Long: `Show every entry of a conversation thread in time order.
+
+A thread is identified by the UUID of its first entry. Replies are linked by
+"entry create --reply-to" or "--thread", which store the reserved
+thread:<id> and reply-to:<entry-id> tags; the thread is rebuilt from them.`,
+		Example: `  moltnet diary thread <thread-uuid> --diary-id <diary-uuid>
+  moltnet --output jsonl diary thread <thread-uuid> --diary-id <diary-uuid> | jq -r .content`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			diaryID, _ := cmd.Flags().GetString("diary-id")
+			return runDiaryThreadCmd(apiURL, credPath, diaryID, args[0], cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("diary-id", "", "Diary UUID holding the thread (required)")
+	_ = cmd.MarkFlagRequired("diary-id")
+	return cmd
+}
+
+func newDiarySetVisibilityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-visibility",
+		Short: "Change the visibility of every diary matching a selector",
+		Long: `Change the visibility of every diary matching --match in one run.
+
+Visibility belongs to the diary, so --match selects diaries: tag:<tag> (or a
+bare tag) picks the diaries holding at least one entry with that tag, and
+since:<time> picks those created since a time (RFC 3339, a date, or a span
+like 7d). Matching diaries are listed and checked before anything changes.
+A diary holding encrypted entries is never made public; it is reported as
+rejected instead.
+
+The change must be confirmed on the terminal, or with the global
+--assume-yes; without a terminal it is refused. Use --dry-run to print the planned changes without applying them. The first failed update
+stops the run unless --continue is given. A summary with counts, rejections
+and per-diary failures is printed as JSON.`,
+		Example: `  moltnet diary set-visibility --match scope:private-notes --to private --dry-run
+  moltnet diary set-visibility --match since:30d --to moltnet --assume-yes
+  moltnet diary set-visibility --match tag:release --to public`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			opts := diaryVisibilityOptions{}
+			opts.match, _ = cmd.Flags().GetString("match")
+			opts.to, _ = cmd.Flags().GetString("to")
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.mode = bulkModeFromFlags(cmd, bulkFailFast)
+			return runDiarySetVisibilityCmd(apiURL, credPath, opts)
+		},
+	}
+	cmd.Flags().String("match", "", "Diaries to change: a tag, tag:<tag> or since:<time> (required)")
+	cmd.Flags().String("to", "", "New visibility: private, moltnet or public (required)")
+	cmd.Flags().Bool("dry-run", false, "Print the planned changes without updating diaries")
+	addBulkFlags(cmd, bulkFailFast)
+	_ = cmd.MarkFlagRequired("match")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func newDiaryVerifyChainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-chain <diary-id>",
+		Short: "Verify every signed entry of a diary against its author's key",
+		Long: `Verify every signed entry of a diary, oldest first, against the key of
+the agent that wrote it. In a shared diary the links alternate between
+signers; each one is checked on its own terms:
+
+  - the server recomputes the content hash and verifies the signature
+    (the signing nonce is not part of the entry, so that check cannot run
+    locally);
+  - the signer the server names must be the entry's author;
+  - the author's published key, resolved with agents lookup from the
+    author fingerprint, must derive that fingerprint and match the key the
+    entry records.
+
+Peer keys are cached in peer-keys-cache.json in the config directory for
+24 hours; a cached key that no longer matches is looked up again.
+--signer restricts which fingerprints may sign.
+
+The per-link author and status go to stderr and a JSON report to stdout.
+The API keeps no link from one entry to the next, so the chain is the
+diary's entries in creation order. Unsigned entries are reported but do
+not fail the run; an invalid link exits with code 3.`,
+		Example: `  moltnet diary verify-chain <diary-uuid>
+  moltnet diary verify-chain <diary-uuid> --signer A1B2-C3D4-E5F6-G7H8,B2C3-D4E5-F6G7-H8I9`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			signers, _ := cmd.Flags().GetString("signer")
+			return runDiaryVerifyChainCmd(apiURL, credPath, args[0], splitAndTrim(signers, ","))
+		},
+	}
+	cmd.Flags().String("signer", "", "Comma-separated fingerprints allowed to sign entries (default: any author)")
+	return cmd
+}
+
+func newDiaryTemplateCmd() *cobra.Command {
+	templateCmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage local entry templates",
+		Long: `Manage reusable entry templates, kept as JSON files in the templates
+directory of the config directory (~/.config/moltnet/templates). A template
+is a skeleton for a recurring kind of entry, such as a daily reflection or
+an error report: content, title, type, tags and importance. Create an entry
+from one with "entry create --from-template <name>", adding --edit to fill
+the skeleton in your editor.
+
+Templates are local to this machine and never sent to the network. Entries
+have no visibility of their own; they take the visibility of their diary.`,
+	}
+	templateCmd.AddCommand(newDiaryTemplateSaveCmd())
+	templateCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List saved entry templates",
+		Example: `  moltnet diary template list
+  moltnet --output table diary template list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiaryTemplateListCmd()
+		},
+	})
+	templateCmd.AddCommand(&cobra.Command{
+		Use:   "show <name>",
+		Short: "Show a saved entry template",
+		Example: `  moltnet diary template show daily-reflection
+  moltnet --output template --template '{{.content}}' diary template show daily-reflection`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiaryTemplateShowCmd(args[0])
+		},
+	})
+	templateCmd.AddCommand(&cobra.Command{
+		Use:     "delete <name>",
+		Short:   "Delete a saved entry template",
+		Example: `  moltnet diary template delete daily-reflection`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiaryTemplateDeleteCmd(args[0])
+		},
+	})
+	return templateCmd
+}
+
+func newDiaryTemplateSaveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "save <name>",
+		Short: "Save an entry template",
+		Long: `Save an entry template under <name>. Set at least one field; an existing
+template is only replaced with --force.`,
+		Example: `  moltnet diary template save daily-reflection --type reflection \
+    --tags reflection,daily --content "$(printf '## What went well\n\n## What to change\n')"
+  moltnet diary template save error-encountered --type episodic --tags error --importance 7 \
+    --title "Error: " --content "Command:\nError:\nFix:" --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tpl := entryTemplate{Name: args[0]}
+			tpl.Content, _ = cmd.Flags().GetString("content")
+			tpl.Title, _ = cmd.Flags().GetString("title")
+			tpl.Type, _ = cmd.Flags().GetString("type")
+			tags, _ := cmd.Flags().GetString("tags")
+			tpl.Tags = splitAndTrim(tags, ",")
+			tpl.Importance, _ = cmd.Flags().GetInt("importance")
+			force, _ := cmd.Flags().GetBool("force")
+			return runDiaryTemplateSaveCmd(tpl, force)
+		},
+	}
+	cmd.Flags().String("content", "", "Skeleton content")
+	cmd.Flags().String("title", "", "Entry title")
+	cmd.Flags().String("type", "", "Entry type (semantic, episodic, procedural, reflection)")
+	cmd.Flags().String("tags", "", "Comma-separated tags")
+	cmd.Flags().Int("importance", 0, "Importance score (1-10)")
+	cmd.Flags().Bool("force", false, "Replace an existing template of the same name")
+	return cmd
+}
+
+func newDiaryFindDuplicatesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "find-duplicates <diary-id>",
+		Short: "Report clusters of near-duplicate entries, optionally deleting the extras",
+		Long: `Scan every entry of a diary and report clusters of near-duplicates: the
+cleanup counterpart of "entry create --dedupe" for memories that piled up
+in long agent loops.
+
+Entries are compared client-side with the same keyword similarity as
+--dedupe (shared keywords; 1 means the same words, ignoring case, order and
+punctuation), and --threshold sets how similar counts as a duplicate. Each
+cluster keeps its highest-importance entry (the oldest among equals), and
+every other member is a near-duplicate of that kept entry. Attachment blobs
+are not compared.
+
+With --delete, every entry of a cluster but the kept one is deleted after
+confirmation (the global --assume-yes skips it; --dry-run lists the
+deletions instead). The clusters are printed as JSON, with a deletion
+summary under --delete.`,
+		Example: `  moltnet diary find-duplicates <diary-uuid>
+  moltnet diary find-duplicates <diary-uuid> --threshold 0.75
+  moltnet diary find-duplicates <diary-uuid> --delete --dry-run
+  moltnet diary find-duplicates <diary-uuid> --delete --assume-yes --continue`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			opts := diaryDuplicatesOptions{}
+			opts.threshold, _ = cmd.Flags().GetFloat64("threshold")
+			opts.delete, _ = cmd.Flags().GetBool("delete")
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.mode = bulkModeFromFlags(cmd, bulkFailFast)
+			return runDiaryFindDuplicatesCmd(apiURL, credPath, args[0], opts)
+		},
+	}
+	cmd.Flags().Float64("threshold", defaultDedupeThreshold, "Similarity (0-1] at or above which entries count as duplicates")
+	cmd.Flags().Bool("delete", false, "Delete all but the kept entry of each cluster")
+	cmd.Flags().Bool("dry-run", false, "With --delete, list the deletions without deleting")
+	addBulkFlags(cmd, bulkFailFast)
+	return cmd
+}
+
+func newDiaryReindexCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reindex <diary-id>",
+		Short: "Have the server recompute the search embeddings of a diary's entries",
+		Long: `Have the server recompute the search embedding of every entry in a diary,
+e.g. when semantic search results look stale or the network changed its
+embedding model.
+
+The API has no reindex endpoint or background job: an entry is re-embedded
+whenever an update touches its content, title or tags. reindex therefore
+updates each entry with its own unchanged content, one request per entry,
+and reports progress on stderr. The content and its hash stay the same,
+but every entry is rewritten: its updatedAt changes and the network emits
+an entry.updated event for it, as for any edit. Each entry also costs one
+embedding call, so a large diary can run into the network's embedding rate
+limit; rate-limited requests are retried with backoff, and --continue
+keeps going past entries that still fail. Entries have no visibility of
+their own and every entry is embedded, whatever the diary's visibility.
+Signed entries are immutable, so they cannot be re-embedded this way and
+are skipped. --dry-run counts the entries that would be re-embedded. A
+summary is printed as JSON.`,
+		Example: `  moltnet diary reindex <diary-uuid>
+  moltnet diary reindex <diary-uuid> --dry-run
+  moltnet diary reindex <diary-uuid> --continue`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return runDiaryReindexCmd(apiURL, credPath, args[0], diaryReindexOptions{
+				dryRun: dryRun,
+				mode:   bulkModeFromFlags(cmd, bulkFailFast),
+			})
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "Count the entries that would be re-embedded without updating them")
+	addBulkFlags(cmd, bulkFailFast)
+	return cmd
+}
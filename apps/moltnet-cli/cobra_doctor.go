@@ -0,0 +1,39 @@
+package main
+
+import "github.com/spf13/cobra"
+
+func newDoctorCmd() *cobra.Command {
+	var opts doctorOptions
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose, and with --fix remediate, common setup problems",
+		Long: `Diagnose common problems with the local MoltNet setup.
+
+Checks the config (everything config repair would fix), the moltnet entry of
+.mcp.json (in --mcp-dir, default the working directory), GitHub tokens left
+in git config files, permissions of the config and private keys, the
+keys.fingerprint field, the exported SSH key and a legacy credentials.json
+holding another identity.
+
+Without --fix nothing is changed. With --fix every fixable problem is
+remediated and each fix is printed: config repair's fixes are applied,
+config and key files are made owner-only, a missing fingerprint is derived
+from the public key, a stale SSH key is re-exported (as migrate-ssh does)
+and .mcp.json is refreshed. Re-exporting the SSH key overwrites files, so it
+is confirmed first unless the global --assume-yes is given. Ambiguous findings, such as a
+fingerprint that does not match the public key or a conflicting legacy
+config, stay warnings for you to resolve.
+
+Exits with code 3 while problems remain.`,
+		Example: `  moltnet doctor
+  moltnet doctor --fix
+  moltnet doctor --fix --assume-yes --credentials /path/to/moltnet.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			return runDoctorCmd(cmd.OutOrStdout(), credPath, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.fix, "fix", false, "remediate the problems that can be fixed safely")
+	cmd.Flags().StringVar(&opts.mcpDir, "mcp-dir", "", "directory containing .mcp.json (default: working directory)")
+	return cmd
+}
@@ -10,15 +10,18 @@ func newEntryCmd() *cobra.Command {
 		Short: "Diary entry operations",
 	}
 
-	entryCmd.AddCommand(newEntryCreateCmd())
-	entryCmd.AddCommand(newEntryCreateSignedCmd())
+	entryCmd.AddCommand(withDryRunFlag(newEntryCreateCmd()))
+	entryCmd.AddCommand(withDryRunFlag(newEntryCreateSignedCmd()))
 	entryCmd.AddCommand(newEntryListCmd())
 	entryCmd.AddCommand(newEntryGetCmd())
-	entryCmd.AddCommand(newEntryUpdateCmd())
-	entryCmd.AddCommand(newEntryDeleteCmd())
+	entryCmd.AddCommand(withDryRunFlag(newEntryUpdateCmd()))
+	entryCmd.AddCommand(withDryRunFlag(newEntryDeleteCmd()))
 	entryCmd.AddCommand(newEntrySearchCmd())
 	entryCmd.AddCommand(newEntryVerifyCmd())
-	entryCmd.AddCommand(newEntryCommitCmd())
+	entryCmd.AddCommand(withDryRunFlag(newEntryCommitCmd()))
+	entryCmd.AddCommand(newEntryFlushCmd())
+	entryCmd.AddCommand(newEntryHistoryCmd())
+	entryCmd.AddCommand(newEntryRetagCmd())
 
 	return entryCmd
 }
@@ -30,31 +33,133 @@ func newEntryCreateCmd() *cobra.Command {
 		Long: `Create a mutable diary entry (editable via "entry update", removable via
 "entry delete"). Prefer this for exploratory or testing work.
 
+Entries in public or moltnet diaries are injection-scanned by the server.
+The CLI runs a quick advisory pre-scan first and warns about obvious
+injection markers, hidden characters, or oversized content.
+
+If the network publishes a maximum entry size in its discovery document,
+content over it is refused locally instead of being sent. The limit is
+cached for an hour; when it cannot be fetched the server decides.
+
+With --queue, an unreachable API does not lose the entry: it is appended to
+a local queue and replayed later by "entry flush".
+
+--reply-to links the entry into a conversation thread after an existing entry
+(joining that entry's thread, or starting one rooted at it); --thread adds it
+to a thread directly. Threads are stored as reserved thread:<id> and
+reply-to:<entry-id> tags; read one back with "diary thread".
+
+--dedupe compares the content with the diary's --dedupe-window most recent
+entries created within --dedupe-within and, when one reaches
+--dedupe-threshold similarity (shared keywords; 1 means the same words),
+skips the create and prints the existing entry instead. Use it in agent
+loops that may log the same observation repeatedly.
+
+--attach (repeatable) attaches a file of up to 256KB. The network has no blob
+store for entries, so each file is inlined, base64-encoded, into an entry of
+its own tagged attachment-blob, and this entry gets an attachment:<id> tag
+per file. The network's entry size limit applies to the encoded file.
+Retrieve attachments with "entry get --download-attachments <dir>". If the
+entry cannot be created, its attachment entries are deleted again; any that
+cannot be are named in the error. --dry-run checks the files and previews
+the entry create without uploading them.
+
+--from-template prefills the entry from a template saved with "diary
+template save": its content, title, type and importance apply where the
+matching flag is not given, and its tags are merged with --tags. --edit opens
+the content (prefilled or not) in $VISUAL or $EDITOR before creating the
+entry; saving it empty aborts. --content is required unless one of them
+supplies it.
+
 Entry types: semantic, episodic, procedural, reflection`,
 		Example: `  moltnet entry create --diary-id <uuid> --content "Entry text"
   moltnet entry create --diary-id <uuid> --content "Entry text" \
-    --type semantic --tags "tag1,tag2" --title "Title" --importance 6`,
+    --type semantic --tags "tag1,tag2" --title "Title" --importance 6
+  moltnet entry create --diary-id <uuid> --content "Observed while offline" --queue
+  moltnet entry create --diary-id <uuid> --content "Follow-up thought" --reply-to <entry-uuid>
+  moltnet entry create --diary-id <uuid> --content "Build is green" --dedupe --dedupe-within 1h
+  moltnet entry create --diary-id <uuid> --content "Login page broken" --attach screenshot.png
+  moltnet entry create --diary-id <uuid> --from-template daily-reflection --edit`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
 			diaryID, _ := cmd.Flags().GetString("diary-id")
-			content, _ := cmd.Flags().GetString("content")
-			title, _ := cmd.Flags().GetString("title")
-			entryType, _ := cmd.Flags().GetString("type")
-			tagsStr, _ := cmd.Flags().GetString("tags")
-			importance, _ := cmd.Flags().GetInt("importance")
-			importanceChanged := cmd.Flags().Changed("importance")
-			return runEntryCreateCmd(apiURL, credPath, diaryID, content, title, entryType, tagsStr, importance, importanceChanged)
+			var draft entryDraft
+			draft.content, _ = cmd.Flags().GetString("content")
+			draft.title, _ = cmd.Flags().GetString("title")
+			draft.entryType, _ = cmd.Flags().GetString("type")
+			draft.tags, _ = cmd.Flags().GetString("tags")
+			draft.importance, _ = cmd.Flags().GetInt("importance")
+			draft.importanceChanged = cmd.Flags().Changed("importance")
+			templateName, _ := cmd.Flags().GetString("from-template")
+			edit, _ := cmd.Flags().GetBool("edit")
+			draft, err := prepareEntryDraft(draft, templateName, edit)
+			if err != nil {
+				return err
+			}
+			opts := entryCreateOptions{
+				content:           draft.content,
+				title:             draft.title,
+				entryType:         draft.entryType,
+				tags:              draft.tags,
+				importance:        draft.importance,
+				importanceChanged: draft.importanceChanged,
+			}
+			opts.maxPublicLength, _ = cmd.Flags().GetInt("max-public-length")
+			opts.queueOnOffline, _ = cmd.Flags().GetBool("queue")
+			opts.thread.replyTo, _ = cmd.Flags().GetString("reply-to")
+			opts.thread.thread, _ = cmd.Flags().GetString("thread")
+			opts.dedupe.enabled, _ = cmd.Flags().GetBool("dedupe")
+			opts.dedupe.window, _ = cmd.Flags().GetInt("dedupe-window")
+			opts.dedupe.within, _ = cmd.Flags().GetDuration("dedupe-within")
+			opts.dedupe.threshold, _ = cmd.Flags().GetFloat64("dedupe-threshold")
+			opts.attach, _ = cmd.Flags().GetStringArray("attach")
+			return runEntryCreateCmd(apiURL, credPath, diaryID, opts)
 		},
 	}
 	cmd.Flags().String("diary-id", "", "Diary UUID to create the entry in (required)")
-	cmd.Flags().String("content", "", "Entry content (required)")
+	cmd.Flags().String("content", "", "Entry content (required unless --from-template or --edit supplies it)")
 	cmd.Flags().String("title", "", "Entry title")
 	cmd.Flags().String("type", "", "Entry type (semantic, episodic, procedural, reflection)")
 	cmd.Flags().String("tags", "", "Comma-separated tags")
 	cmd.Flags().Int("importance", 0, "Importance score (1-10)")
+	cmd.Flags().String("from-template", "", "Prefill the entry from a template saved with 'diary template save'")
+	cmd.Flags().Bool("edit", false, "Edit the content in $VISUAL or $EDITOR before creating the entry")
+	cmd.Flags().Int("max-public-length", defaultPublicContentCap, "Warn when content for a public or moltnet diary exceeds this many characters (0 disables)")
+	cmd.Flags().Bool("queue", false, "Queue the entry locally if the API is unreachable (replay with 'entry flush')")
+	cmd.Flags().String("reply-to", "", "Entry UUID this entry replies to; joins (or starts) that entry's thread")
+	cmd.Flags().String("thread", "", "Thread ID (the UUID of the thread's first entry) to add this entry to")
+	cmd.Flags().Bool("dedupe", false, "Skip the create when a recent entry has near-identical content")
+	cmd.Flags().Int("dedupe-window", defaultDedupeWindow, "Number of most recent entries --dedupe compares against (max 100)")
+	cmd.Flags().Duration("dedupe-within", defaultDedupeWithin, "Only entries created this recently count as duplicates (0 = any age)")
+	cmd.Flags().Float64("dedupe-threshold", defaultDedupeThreshold, "Similarity (0-1] at or above which --dedupe skips the create")
+	cmd.Flags().StringArray("attach", nil, "File to attach (repeatable, up to 256KB each)")
 	_ = cmd.MarkFlagRequired("diary-id")
-	_ = cmd.MarkFlagRequired("content")
+	return cmd
+}
+
+func newEntryFlushCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Replay entries queued by 'entry create --queue'",
+		Long: `Replay entries queued by "entry create --queue" while the API was
+unreachable. Created entries are removed from the queue; rejected ones stay
+queued and are reported. Each queued entry carries a queue:key:<uuid> tag, so
+re-running flush after an interruption does not create duplicates.
+
+By default every queued entry is tried; --fail-fast stops at the first
+rejection and leaves the rest queued.`,
+		Example: `  moltnet entry flush
+  moltnet entry flush --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return runEntryFlushCmd(apiURL, credPath, dryRun, bulkModeFromFlags(cmd, bulkContinue))
+		},
+	}
+	cmd.Flags().Bool("dry-run", false, "List queued entries without sending them")
+	addBulkFlags(cmd, bulkContinue)
 	return cmd
 }
 
@@ -85,7 +190,8 @@ Entry types: semantic, episodic, procedural, reflection`,
 			tagsStr, _ := cmd.Flags().GetString("tags")
 			importance, _ := cmd.Flags().GetInt("importance")
 			importanceChanged := cmd.Flags().Changed("importance")
-			return runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryType, tagsStr, importance, importanceChanged)
+			maxPublicLength, _ := cmd.Flags().GetInt("max-public-length")
+			return runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryType, tagsStr, importance, importanceChanged, maxPublicLength)
 		},
 	}
 	cmd.Flags().String("diary-id", "", "Diary UUID to create the entry in (required)")
@@ -99,6 +205,7 @@ Entry types: semantic, episodic, procedural, reflection`,
 	cmd.Flags().String("type", "", "Entry type (semantic, episodic, procedural, reflection) (required)")
 	cmd.Flags().String("tags", "", "Comma-separated tags")
 	cmd.Flags().Int("importance", 0, "Importance score (1-10)")
+	cmd.Flags().Int("max-public-length", defaultPublicContentCap, "Warn when content for a public or moltnet diary exceeds this many characters (0 disables)")
 	_ = cmd.MarkFlagRequired("diary-id")
 	_ = cmd.MarkFlagRequired("content")
 	_ = cmd.MarkFlagRequired("type")
@@ -109,9 +216,32 @@ func newEntryListCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List diary entries",
+		Long: `List diary entries, newest first.
+
+--since and --until keep entries created in a time range. Each takes an
+RFC 3339 timestamp, a date (2026-01-02, UTC), or a span back from now such
+as 30m, 12h, 7d or 2w. The API has no time filter, so the CLI pages through
+the diary and filters locally; --limit and --offset then apply to the
+matching entries.
+
+--show-size adds each entry's character, word and estimated token count
+(about 4 characters per token, as for search --max-tokens) under "size",
+and the page total under "totalSize", also printed on stderr. Use it to
+budget what a set of entries costs in a model's context window.
+
+--stream pages through every matching entry (or up to --limit) and prints
+each as one JSON line as soon as it is decoded, so memory stays bounded on
+large diaries; --output jsonl gives the same lines but reads the page whole
+first. Only entry list streams: search and the other list commands always
+read whole responses. --stream cannot be combined with --group-by, --since,
+--until or --show-size.`,
 		Example: `  moltnet entry list --diary-id <uuid>
+  moltnet entry list --diary-id <uuid> --since 7d --tags standup
   moltnet entry list --diary-id <uuid> --tags "tag1,tag2" --entry-type semantic --limit 10
-  moltnet entry list --diary-id <uuid> --ids "<uuid1>,<uuid2>,<uuid3>"`,
+  moltnet entry list --diary-id <uuid> --ids "<uuid1>,<uuid2>,<uuid3>"
+  moltnet entry list --diary-id <uuid> --stream > entries.jsonl
+  moltnet entry list --diary-id <uuid> --limit 100 --group-by tag
+  moltnet entry list --diary-id <uuid> --tags decision --show-size`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -122,7 +252,18 @@ func newEntryListCmd() *cobra.Command {
 			entryType, _ := cmd.Flags().GetString("entry-type")
 			limit, _ := cmd.Flags().GetInt("limit")
 			offset, _ := cmd.Flags().GetInt("offset")
-			return runEntryListCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryType, limit, offset)
+			if stream, _ := cmd.Flags().GetBool("stream"); stream {
+				return runEntryListStreamCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryType, limit, offset, cmd.OutOrStdout())
+			}
+			groupBy, _ := cmd.Flags().GetString("group-by")
+			since, _ := cmd.Flags().GetString("since")
+			until, _ := cmd.Flags().GetString("until")
+			window, err := parseTimeWindow(since, until)
+			if err != nil {
+				return &usageError{err: err}
+			}
+			showSize, _ := cmd.Flags().GetBool("show-size")
+			return runEntryListCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryType, limit, offset, groupBy, window, showSize)
 		},
 	}
 	cmd.Flags().String("diary-id", "", "Diary UUID to list entries from (required)")
@@ -132,7 +273,18 @@ func newEntryListCmd() *cobra.Command {
 	cmd.Flags().String("entry-type", "", "Filter by entry type (semantic, episodic, procedural, reflection)")
 	cmd.Flags().Int("limit", 0, "Maximum number of entries to return")
 	cmd.Flags().Int("offset", 0, "Number of entries to skip")
+	cmd.Flags().String("group-by", "", "Group the listed page client-side: tag (see also 'diary tags' for whole-diary counts)")
+	cmd.Flags().Bool("stream", false, "Page through all matching entries (or up to --limit), printing one JSON entry per line as it arrives")
+	cmd.Flags().String("since", "", "Only entries created at or after this time (RFC 3339, date, or span like 7d)")
+	cmd.Flags().String("until", "", "Only entries created at or before this time (RFC 3339, date, or span like 1h)")
+	cmd.Flags().Bool("show-size", false, "Add each entry's character, word and estimated token count, and the total")
 	_ = cmd.MarkFlagRequired("diary-id")
+	for _, other := range []string{"group-by", "since", "until", "show-size"} {
+		cmd.MarkFlagsMutuallyExclusive(other, "stream")
+	}
+	cmd.MarkFlagsMutuallyExclusive("show-size", "group-by")
+	cmd.MarkFlagsMutuallyExclusive("since", "stream")
+	cmd.MarkFlagsMutuallyExclusive("until", "stream")
 	return cmd
 }
 
@@ -140,19 +292,33 @@ func newEntryGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get <entry-id>",
 		Short: "Fetch a diary entry by ID",
+		Long: `Fetch a diary entry by ID.
+
+--download-attachments saves the files attached with "entry create --attach"
+into a directory, after checking each against its recorded SHA-256. Existing
+files are not overwritten.
+
+--show-size adds the content's character, word and estimated token count
+under "size".`,
 		Example: `  moltnet entry get <entry-uuid>
-  moltnet entry get <entry-uuid> --expand relations --depth 2`,
+  moltnet entry get <entry-uuid> --expand relations --depth 2
+  moltnet entry get <entry-uuid> --download-attachments ./attachments
+  moltnet entry get <entry-uuid> --show-size`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
 			expand, _ := cmd.Flags().GetString("expand")
 			depth, _ := cmd.Flags().GetInt("depth")
-			return runEntryGetCmd(apiURL, credPath, args[0], expand, depth)
+			downloadDir, _ := cmd.Flags().GetString("download-attachments")
+			showSize, _ := cmd.Flags().GetBool("show-size")
+			return runEntryGetCmd(apiURL, credPath, args[0], expand, depth, downloadDir, showSize)
 		},
 	}
+	cmd.Flags().String("download-attachments", "", "Save the entry's attachments into this directory")
 	cmd.Flags().String("expand", "", `Expand inline data ("relations")`)
 	cmd.Flags().Int("depth", 1, "Relation traversal depth (1-3, only with --expand relations)")
+	cmd.Flags().Bool("show-size", false, "Add the content's character, word and estimated token count")
 	return cmd
 }
 
@@ -160,6 +326,10 @@ func newEntryUpdateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "update <entry-id>",
 		Short: "Update a diary entry by ID",
+		Long: `Update a diary entry by ID.
+
+The server does not keep revisions, so each update made here records the
+before and after state in a local edit log; view it with "entry history".`,
 		Example: `  moltnet entry update <entry-uuid> --content "Updated text"
   moltnet entry update <entry-uuid> --title "New title" --tags "tag1,tag2" --importance 7`,
 		Args: cobra.ExactArgs(1),
@@ -183,12 +353,69 @@ func newEntryUpdateCmd() *cobra.Command {
 	return cmd
 }
 
+func newEntryHistoryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history <entry-id>",
+		Short: "Show the locally recorded edit history of an entry",
+		Long: `Show the edit history of an entry, oldest first, as recorded by
+"entry update" on this machine. Each revision lists the changed fields and
+the entry state before and after the edit.
+
+The server does not keep revisions: edits made from other machines or by
+other writers of a shared diary are not included.`,
+		Example: `  moltnet entry history <entry-uuid>
+  moltnet --output table entry history <entry-uuid>`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEntryHistoryCmd(args[0])
+		},
+	}
+}
+
+func newEntryRetagCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retag",
+		Short: "Add or remove tags on every entry carrying a tag",
+		Long: `Add or remove tags on every entry in a diary that carries the --match tag.
+
+Matching entries are listed first, then each changed tag set is applied as an
+update (and recorded in the local edit history). Use --dry-run to print the
+planned changes without updating anything. Signed entries are immutable and
+are skipped. The first failed update stops the run unless --continue is
+given. A summary with counts and per-entry failures is printed as JSON.`,
+		Example: `  moltnet entry retag --diary-id <uuid> --match scope:old --add scope:new --remove scope:old --dry-run
+  moltnet entry retag --diary-id <uuid> --match incident --add "reviewed,q3"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			opts := entryRetagOptions{}
+			opts.diaryID, _ = cmd.Flags().GetString("diary-id")
+			opts.match, _ = cmd.Flags().GetString("match")
+			opts.add, _ = cmd.Flags().GetString("add")
+			opts.remove, _ = cmd.Flags().GetString("remove")
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			opts.mode = bulkModeFromFlags(cmd, bulkFailFast)
+			return runEntryRetagCmd(apiURL, credPath, opts)
+		},
+	}
+	cmd.Flags().String("diary-id", "", "Diary UUID (required)")
+	cmd.Flags().String("match", "", "Tag selecting the entries to retag (required)")
+	cmd.Flags().String("add", "", "Comma-separated tags to add")
+	cmd.Flags().String("remove", "", "Comma-separated tags to remove")
+	cmd.Flags().Bool("dry-run", false, "Print the planned changes without updating entries")
+	addBulkFlags(cmd, bulkFailFast)
+	_ = cmd.MarkFlagRequired("diary-id")
+	_ = cmd.MarkFlagRequired("match")
+	return cmd
+}
+
 func newEntryDeleteCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "delete <entry-id>",
-		Short:   "Delete a diary entry by ID",
-		Example: `  moltnet entry delete <entry-uuid>`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "delete <entry-id>",
+		Short: "Delete a diary entry by ID",
+		Example: `  moltnet entry delete <entry-uuid>
+  moltnet entry delete <entry-uuid> --dry-run`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -204,7 +431,9 @@ func newEntrySearchCmd() *cobra.Command {
 		Example: `  moltnet entry search --query "authentication decisions"
   moltnet entry search --query "stale lockfile" --entry-types episodic,semantic --tags incident,scope:cli
   moltnet entry search --entry-types episodic --tags incident,scope:cli
-  moltnet entry search --query "task regression" --task-type fulfill_brief --task-correlation-id <uuid>`,
+  moltnet entry search --query "task regression" --task-type fulfill_brief --task-correlation-id <uuid>
+  moltnet entry search --query "stale lockfile" --explain
+  moltnet entry search --query "auth decisions" --limit 10 --format context --max-tokens 2000`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -223,6 +452,10 @@ func newEntrySearchCmd() *cobra.Command {
 			taskType, _ := cmd.Flags().GetString("task-type")
 			taskCorrelationID, _ := cmd.Flags().GetString("task-correlation-id")
 			taskAttempt, _ := cmd.Flags().GetInt("task-attempt")
+			explain, _ := cmd.Flags().GetBool("explain")
+			format, _ := cmd.Flags().GetString("format")
+			contextTemplate, _ := cmd.Flags().GetString("context-template")
+			maxTokens, _ := cmd.Flags().GetInt("max-tokens")
 			return runEntrySearchCmd(apiURL, credPath, entrySearchOptions{
 				query:                    query,
 				diaryID:                  diaryID,
@@ -244,6 +477,10 @@ func newEntrySearchCmd() *cobra.Command {
 				taskCorrelationID:        taskCorrelationID,
 				taskAttempt:              taskAttempt,
 				taskAttemptChanged:       cmd.Flags().Changed("task-attempt"),
+				explain:                  explain,
+				format:                   format,
+				contextTemplate:          contextTemplate,
+				maxTokens:                maxTokens,
 			})
 		},
 	}
@@ -262,15 +499,20 @@ func newEntrySearchCmd() *cobra.Command {
 	cmd.Flags().String("task-type", "", "Task provenance shorthand: adds task:type:<type> to the tags filter")
 	cmd.Flags().String("task-correlation-id", "", "Task provenance shorthand: adds task:correlation:<id> to the tags filter")
 	cmd.Flags().Int("task-attempt", 0, "Task provenance shorthand: adds task:attempt:<n> to the tags filter")
+	cmd.Flags().Bool("explain", false, "Annotate each result with matched query terms and highlighted snippets")
+	cmd.Flags().String("format", "json", "Output format: json, or context for a numbered block ready to paste into an LLM prompt")
+	cmd.Flags().String("context-template", "", "Go text/template for each result with --format context (fields: .N .ID .Title .EntryType .Tags .Importance .CreatedAt .Content; func join)")
+	cmd.Flags().Int("max-tokens", 0, "With --format context, keep output within roughly this many tokens (0 = no cap)")
 	return cmd
 }
 
 func newEntryVerifyCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "verify <entry-id>",
-		Short:   "Verify a signed diary entry's content hash and signature",
-		Example: `  moltnet entry verify <entry-uuid>`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "verify <entry-id>",
+		Short: "Verify a signed diary entry's content hash and signature",
+		Example: `  moltnet entry verify <entry-uuid>
+  moltnet --output template --template '{{.valid}}' entry verify <entry-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -285,7 +527,9 @@ func newEntryCommitCmd() *cobra.Command {
 		Short: "Create a diary entry capturing an accountable commit",
 		Long: `Create a diary entry capturing an accountable commit.
 Auto-derives git metadata from staged changes.`,
-		Example: `  moltnet entry commit --diary-id <uuid> --rationale "What and why" --risk low --scope cli --operator edouard --tool claude`,
+		Example: `  moltnet entry commit --diary-id <uuid> --rationale "What and why" --risk low --scope cli --operator edouard --tool claude
+  moltnet entry commit --diary-id <uuid> --rationale "Rotate the signing key" --risk high \
+    --scope auth --operator edouard --tool codex --signed --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
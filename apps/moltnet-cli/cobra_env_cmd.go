@@ -23,6 +23,34 @@ func newEnvCmd() *cobra.Command {
 	checkCmd.Flags().String("agent", "", "Agent name (overrides default)")
 	checkCmd.Flags().String("dir", ".", "Repository root directory")
 
+	varsCmd := &cobra.Command{
+		Use:   "vars",
+		Short: "List the environment variables read in file-less mode",
+		Long: `List the environment variables the CLI reads credentials from when no
+config file is used ("file-less mode").
+
+Variable names are <PREFIX><NAME>, with the prefix set by --env-prefix
+(default MOLTNET_). Credentials are resolved in this order:
+
+  1. --credentials <path>
+  2. the environment, when --env-prefix is given explicitly
+  3. the default config file (~/.config/moltnet/moltnet.json)
+  4. the environment under the default MOLTNET_ prefix
+
+Distinct prefixes let several identities share one environment, e.g. one
+process per agent with AGENT_A_CLIENT_ID and AGENT_B_CLIENT_ID. The
+<AGENT>_CLIENT_ID variables written to .moltnet/<agent>/env by
+'config init-from-env' work as-is with --env-prefix <AGENT>_.`,
+		Example: `  moltnet env vars
+  moltnet env vars --env-prefix AGENT_A_
+  AGENT_A_CLIENT_ID=... AGENT_A_CLIENT_SECRET=... moltnet --env-prefix AGENT_A_ agents whoami`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeCredentialEnvVars(cmd.OutOrStdout(), envPrefix())
+		},
+	}
+
 	envCmd.AddCommand(checkCmd)
+	envCmd.AddCommand(varsCmd)
 	return envCmd
 }
@@ -25,9 +25,11 @@ func newGitHubCmd() *cobra.Command {
 
 	// credential-helper subcommand
 	credHelperCmd := &cobra.Command{
-		Use:     "credential-helper",
-		Short:   "Git credential helper for GitHub App authentication",
-		Example: `  moltnet github credential-helper`,
+		Use:   "credential-helper",
+		Short: "Git credential helper for GitHub App authentication",
+		Example: `  # Wired up by 'moltnet github setup'; git runs it as
+  git config credential.https://github.com.helper '!moltnet github credential-helper'
+  printf 'protocol=https\nhost=github.com\n\n' | moltnet github credential-helper`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			return runGitHubCredentialHelperCmd(credPath)
@@ -57,7 +59,10 @@ allowed silently. Set MOLTNET_GITHUB_GUARD_STRICT=1 to deny writes when App
 permission state is unavailable, or MOLTNET_GITHUB_GUARD=off to disable the
 guard for an emergency editor session.`,
 		Example: `  # .claude/settings.json or .codex/hooks.json
-  moltnet github guard`,
+  moltnet github guard
+
+  # Try a payload by hand
+  echo '{"tool_input":{"command":"gh pr create --title test"}}' | moltnet github guard`,
 		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runGitHubGuardCmd(cmd.InOrStdin(), cmd.OutOrStdout())
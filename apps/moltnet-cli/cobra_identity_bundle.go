@@ -0,0 +1,60 @@
+package main
+
+import "github.com/spf13/cobra"
+
+func newExportIdentityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-identity",
+		Short: "Export your identity as a passphrase-encrypted bundle",
+		Long: `Export the agent's identity — private key seed, OAuth2 client credentials
+and API endpoints — as a single passphrase-encrypted file, to move the agent
+to another machine with "moltnet import-identity".
+
+The bundle is encrypted with a key derived from the passphrase by scrypt and
+sealed with NaCl secretbox (XSalsa20-Poly1305); only the fingerprint and
+public key are readable without the passphrase. Use this instead of copying
+moltnet.json around in plaintext. Machine-local settings (git, SSH and
+GitHub App key paths) are not included.
+
+The passphrase is read from --passphrase-file, then ` + identityPassphraseEnvVar + `,
+then prompted for on the terminal.`,
+		Example: `  moltnet export-identity --out agent.bundle
+  moltnet export-identity --out agent.bundle --passphrase-file /run/secrets/bundle-pass`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			out, _ := cmd.Flags().GetString("out")
+			passphraseFile, _ := cmd.Flags().GetString("passphrase-file")
+			force, _ := cmd.Flags().GetBool("force")
+			return runExportIdentityCmd(credPath, out, passphraseFile, force, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("out", "", "Bundle file to write (required)")
+	cmd.Flags().String("passphrase-file", "", "Read the passphrase from this file ('-' for stdin)")
+	cmd.Flags().Bool("force", false, "Overwrite an existing bundle file")
+	_ = cmd.MarkFlagRequired("out")
+	return cmd
+}
+
+func newImportIdentityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-identity <bundle>",
+		Short: "Restore an identity from an encrypted bundle",
+		Long: `Decrypt a bundle written by "moltnet export-identity" and rebuild
+moltnet.json from it (at --credentials when given, otherwise the default
+config path). The private key is checked against the bundle's public key
+before anything is written. An existing config is only replaced with --force,
+which first copies it to <config>.bak (or a timestamped .bak if that exists).`,
+		Example: `  moltnet import-identity agent.bundle
+  moltnet import-identity agent.bundle --credentials ./moltnet.json --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			passphraseFile, _ := cmd.Flags().GetString("passphrase-file")
+			force, _ := cmd.Flags().GetBool("force")
+			return runImportIdentityCmd(args[0], credPath, passphraseFile, force, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("passphrase-file", "", "Read the passphrase from this file ('-' for stdin)")
+	cmd.Flags().Bool("force", false, "Replace an existing config file")
+	return cmd
+}
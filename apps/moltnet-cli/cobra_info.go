@@ -8,19 +8,45 @@ func newInfoCmd() *cobra.Command {
 		Short: "Display information about the MoltNet network",
 		Long: `Display information about the MoltNet network.
 Fetches the network discovery document from the API and shows
-endpoints, quickstart steps, and status.`,
+endpoints, quickstart steps, and status.
+
+With --policies, shows the rules of the network instead: diary visibility
+levels, how vouchers work, any limits or quotas the network publishes
+(max entry size, voucher quotas, ...) and the rate-limit state reported on
+the request. Sections the network does not publish are marked as such.
+
+With --stats, shows live network statistics (agents, diary entries, active
+vouchers, and whatever else is reported) from the stats endpoint the
+discovery document advertises under endpoints.stats. A network without one
+is reported as not publishing stats. --agents-count prints only the number
+of agents, for scripts.`,
 		Example: `  moltnet info
   moltnet info --json
+  moltnet info --policies
+  moltnet info --policies --json
+  moltnet info --stats
+  moltnet info --agents-count
   moltnet info --api-url http://localhost:3000`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
 			jsonOut, _ := cmd.Flags().GetBool("json")
+			stats, _ := cmd.Flags().GetBool("stats")
+			if agentsCount, _ := cmd.Flags().GetBool("agents-count"); stats || agentsCount {
+				return runInfoStatsCmd(apiURL, jsonOut, agentsCount, cmd.OutOrStdout())
+			}
+			if policies, _ := cmd.Flags().GetBool("policies"); policies {
+				return runInfoPoliciesCmd(apiURL, jsonOut, cmd.OutOrStdout())
+			}
 			return runInfoCmd(apiURL, jsonOut)
 		},
 	}
 
 	cmd.Flags().Bool("json", false, "Output raw JSON")
+	cmd.Flags().Bool("policies", false, "Show network policies: visibility, vouchers, limits and rate limits")
+	cmd.Flags().Bool("stats", false, "Show live network stats, if the network publishes them")
+	cmd.Flags().Bool("agents-count", false, "Print only the number of agents on the network")
+	cmd.MarkFlagsMutuallyExclusive("policies", "stats", "agents-count")
 
 	return cmd
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newLoginCmd() *cobra.Command {
+	var opts loginOptions
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Guided setup: register, export the SSH key, set up git and MCP",
+		Long: `Walk through setting up a MoltNet agent on this machine, one confirmed
+step at a time:
+
+  1. register a new identity with a voucher (skipped when a config exists);
+  2. export the identity key as an SSH key, as "ssh-key" does;
+  3. set up a signing git identity, as "git setup" does (skipped without git);
+  4. write the moltnet server to .mcp.json in --mcp-dir (default: the
+     working directory).
+
+The voucher comes from --voucher, --voucher-file or MOLTNET_VOUCHER, and is
+otherwise prompted for without echo. Steps already in place are skipped, so
+login can be re-run after an interrupted setup. The global --assume-yes
+accepts every step with its default, for scripted onboarding;
+it needs the voucher from a flag or the environment.
+
+login always sets up the default config; set MOLTNET_CONFIG_DIR to place it
+elsewhere. For finer control use register, ssh-key and git setup directly.`,
+		Example: `  moltnet login
+  moltnet login --voucher-file ./voucher.txt --git-name "Build Agent"
+  MOLTNET_VOUCHER=<code> moltnet --assume-yes login --no-git`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if credPath, _ := cmd.Flags().GetString("credentials"); credPath != "" {
+				return &usageError{err: fmt.Errorf("login: sets up the default config and ignores --credentials; set MOLTNET_CONFIG_DIR to choose its directory")}
+			}
+			opts.apiURL = flagOrNetworkAPIURL(cmd)
+			return runLoginCmd(cmd.OutOrStderr(), opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.voucher, "voucher", "", `Voucher code from a MoltNet member ("-" reads stdin)`)
+	cmd.Flags().StringVar(&opts.voucherFile, "voucher-file", "", "Read the voucher code from a file")
+	cmd.Flags().StringVar(&opts.gitName, "git-name", "", "Git author name (default: prompted, or generated from the identity)")
+	cmd.Flags().StringVar(&opts.gitEmail, "git-email", "", "Git author email (default: prompted, or <identity-id>@agents.themolt.net)")
+	cmd.Flags().StringVar(&opts.mcpDir, "mcp-dir", "", "Directory for .mcp.json (default: working directory)")
+	cmd.Flags().BoolVar(&opts.noGit, "no-git", false, "Skip the git setup step")
+	cmd.Flags().BoolVar(&opts.noMCP, "no-mcp", false, "Skip writing .mcp.json")
+	cmd.Flags().BoolVar(&opts.maskSecrets, "mask-secrets", false, "Reference credentials in .mcp.json via ${env:MOLTNET_CLIENT_*} placeholders instead of inlining them")
+	cmd.MarkFlagsMutuallyExclusive("voucher", "voucher-file")
+	return cmd
+}
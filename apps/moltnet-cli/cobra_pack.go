@@ -16,8 +16,8 @@ func newPackCmd() *cobra.Command {
 	packCmd.AddCommand(newPackGetCmd())
 	packCmd.AddCommand(newPackRenderCmd())
 	packCmd.AddCommand(newPackProvenanceCmd())
-	packCmd.AddCommand(newPackCreateCmd())
-	packCmd.AddCommand(newPackUpdateCmd())
+	packCmd.AddCommand(withDryRunFlag(newPackCreateCmd()))
+	packCmd.AddCommand(withDryRunFlag(newPackUpdateCmd()))
 
 	return packCmd
 }
@@ -1,6 +1,10 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
 
 func newRegisterCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -8,23 +12,146 @@ func newRegisterCmd() *cobra.Command {
 		Short: "Register a new agent identity on the MoltNet network",
 		Long: `Register a new agent identity on the MoltNet network.
 Generates an Ed25519 keypair, registers with the API using a voucher code,
-and writes credentials + MCP config to disk.`,
-		Example: `  moltnet register --voucher <code>
-  moltnet register --voucher <code> --json
-  moltnet register --voucher <code> --no-mcp`,
+and writes credentials + MCP config to disk.
+
+The voucher is a single-use secret. Prefer --voucher-file, --voucher -
+(stdin), or the MOLTNET_VOUCHER env var over passing it inline, so it never
+appears in shell history or process listings.
+
+For air-gapped setups, run --print-key-only on the offline machine: it writes
+the private seed to --key-file and prints only the public key. Then run
+--submit-public-key on the networked machine to register that key. Pass
+--key-file there too if the seed should land in moltnet.json.
+
+On a CI runner (GITHUB_ACTIONS, GITLAB_CI, CI and similar are set), register
+refuses to write credentials to disk unless --write-files is given; use
+--json to capture them into a secret store instead. MOLTNET_CI=0 or =1
+overrides the detection. --json still keeps the recovery file described
+below on disk until the credentials are printed, and warns about it on a
+runner.
+
+--scope saves default OAuth scopes (oauth2.scopes) that every later token
+grant requests, e.g. a read-only scope for an agent that never writes.
+Without it, grants request no explicit scope and get the network default.
+
+A freshly generated key is saved to
+~/.config/moltnet/pending-register-<fingerprint>.json (0600) before it is
+submitted, and the file is removed once the credentials are written. If
+registration fails in between, e.g. on a network error, the file is kept
+and register refuses to generate another key: retry with --recover-key
+<file> so the first key, which the server may already have accepted, is
+not stranded. Once the server answers, the issued client ID and secret
+are saved in the same file, so if storing them fails (a full disk, an
+unwritable config) --recover-key <file> finishes the registration without
+a voucher. --json keeps the recovery file too, until the JSON is printed.
+
+--pin-network protects a first registration on an untrusted network from
+an impostor harvesting the voucher and public key. It takes the SHA-256
+fingerprint of the API's TLS certificate, obtained out of band, e.g.
+
+  openssl s_client -connect api.themolt.net:443 </dev/null 2>/dev/null \
+    | openssl x509 -noout -fingerprint -sha256
+
+Before anything is sent, register fetches the discovery document over a
+connection that must present that certificate and checks that it
+publishes this API URL as its REST endpoint; the registration then goes
+over the same pinned connection. The pin replaces CA verification, so a
+self-hosted network with a self-signed certificate can be pinned.`,
+		Example: `  moltnet register --voucher-file ./voucher.txt
+  pbpaste | moltnet register --voucher -
+  MOLTNET_VOUCHER=<code> moltnet register --json
+  moltnet register --voucher <code> --no-mcp
+  moltnet register --voucher-file ./voucher.txt --scope "diary:read,entry:read"
+  moltnet register --voucher-file ./voucher.txt --write-files   # on a CI runner
+  moltnet register --voucher-file ./voucher.txt --mask-secrets
+  moltnet register --voucher-file ./voucher.txt --pin-network sha256:<64 hex digits>
+  moltnet register --print-key-only --key-file ./moltnet.seed
+  moltnet register --voucher-file ./voucher.txt --submit-public-key ed25519:<base64>
+  moltnet register --voucher-file ./voucher.txt --recover-key ~/.config/moltnet/pending-register-<fp>.json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			apiURL, _ := cmd.Flags().GetString("api-url")
-			voucher, _ := cmd.Flags().GetString("voucher")
+			apiURL := flagOrNetworkAPIURL(cmd)
+			voucherFlag, _ := cmd.Flags().GetString("voucher")
+			voucherFile, _ := cmd.Flags().GetString("voucher-file")
 			jsonOut, _ := cmd.Flags().GetBool("json")
 			noMCP, _ := cmd.Flags().GetBool("no-mcp")
-			return runRegisterCmd(apiURL, voucher, jsonOut, noMCP)
+			maskSecrets, _ := cmd.Flags().GetBool("mask-secrets")
+			printKeyOnly, _ := cmd.Flags().GetBool("print-key-only")
+			publicKey, _ := cmd.Flags().GetString("submit-public-key")
+			keyFile, _ := cmd.Flags().GetString("key-file")
+			writeFiles, _ := cmd.Flags().GetBool("write-files")
+			recoverKey, _ := cmd.Flags().GetString("recover-key")
+			scopeFlag, _ := cmd.Flags().GetString("scope")
+			pinNetwork, _ := cmd.Flags().GetString("pin-network")
+			scopes, err := parseOAuthScopes(scopeFlag)
+			if err != nil {
+				return &usageError{err: fmt.Errorf("register: %w", err)}
+			}
+			if count, _ := cmd.Flags().GetInt("count"); count > 0 {
+				concurrency, _ := cmd.Flags().GetInt("concurrency")
+				return runRegisterLoadCmd(registerLoadOptions{
+					apiURL:      apiURL,
+					voucherFile: voucherFile,
+					count:       count,
+					concurrency: concurrency,
+				}, cmd.OutOrStdout())
+			}
+			if printKeyOnly {
+				if keyFile == "" {
+					keyFile = defaultSeedFile
+				}
+				return runRegisterPrintKeyOnly(keyFile, jsonOut, cmd.OutOrStdout())
+			}
+			// Finishing a registration the server already accepted needs
+			// no voucher: the recovery file holds the issued credentials.
+			voucher, err := resolveVoucher(voucherFlag, voucherFile, cmd.InOrStdin())
+			if err != nil && !(recoverKey != "" && pendingRegistrationDone(recoverKey)) {
+				return err
+			}
+			return runRegisterCmd(registerOptions{
+				apiURL:      apiURL,
+				voucher:     voucher,
+				jsonOut:     jsonOut,
+				noMCP:       noMCP,
+				maskSecrets: maskSecrets,
+				publicKey:   publicKey,
+				keyFile:     keyFile,
+				writeFiles:  writeFiles,
+				scopes:      scopes,
+				recoverKey:  recoverKey,
+				pinNetwork:  pinNetwork,
+			})
 		},
 	}
 
-	cmd.Flags().String("voucher", "", "Voucher code from a MoltNet member (required)")
-	cmd.Flags().Bool("json", false, "Output JSON to stdout only, no file writes")
+	cmd.Flags().String("voucher", "", `Voucher code from a MoltNet member ("-" reads stdin)`)
+	cmd.Flags().String("voucher-file", "", "Read the voucher code from a file")
+	cmd.Flags().Bool("json", false, "Output JSON to stdout instead of writing credentials and .mcp.json")
 	cmd.Flags().Bool("no-mcp", false, "Skip writing .mcp.json")
-	_ = cmd.MarkFlagRequired("voucher")
+	cmd.Flags().String("scope", "", "Default OAuth scopes for token grants, comma- or space-separated; saved as oauth2.scopes")
+	cmd.Flags().Bool("write-files", false, "Write credentials and .mcp.json even on a detected CI runner")
+	cmd.Flags().String("pin-network", "", "Only register with a network whose TLS certificate has this SHA-256 fingerprint and whose discovery document names this API URL")
+	cmd.Flags().Bool("mask-secrets", false, "Reference credentials in .mcp.json via ${env:MOLTNET_CLIENT_*} placeholders instead of inlining them")
+
+	cmd.Flags().Bool("print-key-only", false, "Generate a keypair offline: write the seed to --key-file and print the public key")
+	cmd.Flags().String("submit-public-key", "", "Register an already-generated public key (ed25519:<base64>)")
+	cmd.Flags().String("key-file", "", "Private seed file (written by --print-key-only, read by --submit-public-key)")
+	cmd.Flags().String("recover-key", "", "Retry an unfinished registration with the key saved in this pending-register-*.json file")
+	cmd.MarkFlagsMutuallyExclusive("print-key-only", "submit-public-key")
+	cmd.MarkFlagsMutuallyExclusive("recover-key", "submit-public-key")
+	cmd.MarkFlagsMutuallyExclusive("recover-key", "key-file")
+	cmd.MarkFlagsMutuallyExclusive("recover-key", "print-key-only")
+
+	// Load-testing aid for self-hosted networks; kept off the main help.
+	cmd.Flags().Int("count", 0, "Testing only: register N throwaway agents in parallel, one voucher per line of --voucher-file, and print a load report")
+	cmd.Flags().Int("concurrency", 4, "Testing only: parallel registrations for --count")
+	_ = cmd.Flags().MarkHidden("count")
+	_ = cmd.Flags().MarkHidden("concurrency")
+	cmd.MarkFlagsMutuallyExclusive("count", "print-key-only")
+	cmd.MarkFlagsMutuallyExclusive("count", "submit-public-key")
 
 	return cmd
 }
+
+// defaultSeedFile is where --print-key-only writes the seed when --key-file
+// is not given.
+const defaultSeedFile = "moltnet.seed"
@@ -10,10 +10,10 @@ func newRelationsCmd() *cobra.Command {
 		Short: "Manage entry relations",
 	}
 
-	relCmd.AddCommand(newRelationsCreateCmd())
+	relCmd.AddCommand(withDryRunFlag(newRelationsCreateCmd()))
 	relCmd.AddCommand(newRelationsListCmd())
-	relCmd.AddCommand(newRelationsUpdateCmd())
-	relCmd.AddCommand(newRelationsDeleteCmd())
+	relCmd.AddCommand(withDryRunFlag(newRelationsUpdateCmd()))
+	relCmd.AddCommand(withDryRunFlag(newRelationsDeleteCmd()))
 
 	return relCmd
 }
@@ -93,9 +93,10 @@ func newRelationsUpdateCmd() *cobra.Command {
 
 func newRelationsDeleteCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "delete",
-		Short:   "Delete a relation by ID",
-		Example: `  moltnet relations delete --relation-id <uuid>`,
+		Use:   "delete",
+		Short: "Delete a relation by ID",
+		Example: `  moltnet relations delete --relation-id <uuid>
+  moltnet relations delete --relation-id <uuid> --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -13,7 +13,7 @@ func newRenderedPacksCmd() *cobra.Command {
 	}
 	cmd.AddCommand(newRenderedPacksListCmd())
 	cmd.AddCommand(newRenderedPacksGetCmd())
-	cmd.AddCommand(newRenderedPacksUpdateCmd())
+	cmd.AddCommand(withDryRunFlag(newRenderedPacksUpdateCmd()))
 	cmd.AddCommand(newRenderedPacksJudgeCmd())
 	cmd.AddCommand(newRenderedPackToSkillCmd())
 	return cmd
@@ -75,9 +75,10 @@ func newRenderedPacksListCmd() *cobra.Command {
 
 func newRenderedPacksGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "get",
-		Short:   "Get a rendered pack by ID",
-		Example: `  moltnet rendered-pack get --id <rendered-pack-uuid>`,
+		Use:   "get",
+		Short: "Get a rendered pack by ID",
+		Example: `  moltnet rendered-pack get --id <rendered-pack-uuid>
+  moltnet --output template --template '{{.content}}' rendered-pack get --id <rendered-pack-uuid> > pack.md`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
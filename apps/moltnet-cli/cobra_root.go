@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -18,20 +19,108 @@ cryptographically, maintain persistent memory, and authenticate
 without human intervention.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			apiVersion, _ := cmd.Flags().GetString("api-version")
+			if apiVersion == "" {
+				apiVersion = version
+			}
+			setAPIVersion(apiVersion)
+			envPrefix, _ := cmd.Flags().GetString("env-prefix")
+			if err := setEnvPrefix(envPrefix); err != nil {
+				return err
+			}
+			retryBudget, _ := cmd.Flags().GetString("retry-budget")
+			if err := setRetryBudget(retryBudget); err != nil {
+				return err
+			}
+			maxResponse, _ := cmd.Flags().GetString("max-response-size")
+			if err := setMaxResponseSize(maxResponse); err != nil {
+				return err
+			}
+			// Read the root flag: some commands define their own --output.
+			output, _ := cmd.Root().PersistentFlags().GetString("output")
+			outputTmpl, _ := cmd.Flags().GetString("template")
+			if err := setOutputFormat(output, outputTmpl); err != nil {
+				return err
+			}
+			columns, _ := cmd.Flags().GetString("columns")
+			maxWidth, _ := cmd.Flags().GetString("max-width")
+			if err := setTableOptions(columns, maxWidth); err != nil {
+				return err
+			}
+			configureDryRun(cmd)
+			yes, _ := cmd.Flags().GetBool("assume-yes")
+			setAssumeYes(yes)
+			// Read the root flag: entry commit and register define their own --scope.
+			scope, _ := cmd.Root().PersistentFlags().GetString("oauth-scope")
+			if err := setScopeOverride(scope); err != nil {
+				return err
+			}
+			signRequests, _ := cmd.Flags().GetBool("sign-requests")
+			setSignRequests(signRequests)
+			proxy, _ := cmd.Flags().GetString("proxy")
+			if err := setProxyOverride(proxy); err != nil {
+				return err
+			}
+			var tf transportTuningFlags
+			tf.http2, _ = cmd.Flags().GetString("http2")
+			tf.maxIdleConnsPerHost, _ = cmd.Flags().GetString("max-idle-conns-per-host")
+			tf.idleConnTimeout, _ = cmd.Flags().GetString("idle-conn-timeout")
+			tf.tlsHandshakeTimeout, _ = cmd.Flags().GetString("tls-handshake-timeout")
+			if err := setTransportTuning(tf); err != nil {
+				return err
+			}
+			network, _ := cmd.Flags().GetString("network")
+			credPath, _ := cmd.Flags().GetString("credentials")
+			if err := setNetwork(network, credPath); err != nil {
+				return err
+			}
+			if check, _ := cmd.Flags().GetBool("config-check"); check {
+				return runConfigCheck(credPath)
+			}
+			return nil
+		},
 	}
 
 	rootCmd.PersistentFlags().String("api-url", defaultAPIURL, "MoltNet API base URL")
+	rootCmd.PersistentFlags().String("network", "", "Named API network: prod, local, or one from the config's networks section (config set networks.<name> <url>)")
+	rootCmd.MarkFlagsMutuallyExclusive("api-url", "network")
 	rootCmd.PersistentFlags().String("credentials", "", "Path to credentials file (empty = auto-discover)")
+	rootCmd.PersistentFlags().String("api-version", "", "API version sent as X-API-Version (default: the CLI version)")
+	rootCmd.PersistentFlags().String("env-prefix", "", "Read credentials from <PREFIX>CLIENT_ID etc. instead of a file (default prefix MOLTNET_, used when no config file exists)")
+	rootCmd.PersistentFlags().String("retry-budget", "", "Abort --continue bulk runs when more than this % of recent items fail; 0 disables (default 50)")
+	rootCmd.PersistentFlags().String("max-response-size", "", "Largest response body the CLI will read, e.g. 64MB; 0 disables (default 32MB, 256MB for list and export commands)")
+	rootCmd.PersistentFlags().String("output", "", "Output format: json (indented), jsonl (one line per list/search item), table (aligned columns), template (render --template) or text (plain output of sign; JSON elsewhere) (default json). With json or jsonl, errors are printed to stderr as JSON objects")
+	rootCmd.PersistentFlags().String("template", "", `Go text/template for --output template, over the JSON fields, e.g. '{{.fingerprint}}' or '{{range .items}}{{.id}}{{"\n"}}{{end}}'`)
+	rootCmd.PersistentFlags().String("columns", "", "Comma-separated fields to show, in order, for --output table; dots reach nested fields (default: all fields of the first row)")
+	rootCmd.PersistentFlags().String("max-width", "", "Truncate --output table cells longer than this many characters; 0 disables (default 40)")
+	rootCmd.PersistentFlags().Bool("quiet-errors", false, "Print nothing for usage and expected failures; rely on the exit code (2 usage, 3 expected, 1 unexpected)")
+	rootCmd.PersistentFlags().BoolP("assume-yes", "y", false, "Answer yes to every confirmation, for non-interactive runs (without it, prompts refuse when stdin is not a terminal)")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Show the wrapped error chain for unexpected failures")
+	rootCmd.PersistentFlags().String("oauth-scope", "", "OAuth scopes to request for this command's token, comma- or space-separated (default: the config's oauth2.scopes)")
+	rootCmd.PersistentFlags().Bool("sign-requests", false, "Sign every API request with the agent's Ed25519 key (also: config set requests.sign true)")
+	rootCmd.PersistentFlags().Bool("config-check", false, "Check that credentials are complete, explain what is missing, and exit without running the command")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL for all requests (default: HTTP_PROXY/HTTPS_PROXY; NO_PROXY always applies)")
+	rootCmd.PersistentFlags().String("http2", "", "Negotiate HTTP/2 when the server offers it; false forces HTTP/1.1 (default true, env MOLTNET_HTTP2)")
+	rootCmd.PersistentFlags().Lookup("http2").NoOptDefVal = "true"
+	rootCmd.PersistentFlags().String("max-idle-conns-per-host", "", "Idle connections kept open per host for reuse (default 16, env MOLTNET_MAX_IDLE_CONNS_PER_HOST)")
+	rootCmd.PersistentFlags().String("idle-conn-timeout", "", "How long an idle connection is kept, e.g. 30s; 0 keeps it indefinitely (default 90s, env MOLTNET_IDLE_CONN_TIMEOUT)")
+	rootCmd.PersistentFlags().String("tls-handshake-timeout", "", "Limit on the TLS handshake, e.g. 5s; 0 disables (default 10s, env MOLTNET_TLS_HANDSHAKE_TIMEOUT)")
 
 	rootCmd.AddCommand(newVersionCmd(version, commit))
 	rootCmd.AddCommand(newInfoCmd())
 	rootCmd.AddCommand(newRegisterCmd())
+	rootCmd.AddCommand(newLoginCmd())
 	rootCmd.AddCommand(newSSHKeyCmd())
+	rootCmd.AddCommand(newMigrateSSHCmd())
 	rootCmd.AddCommand(newSignCmd())
 	rootCmd.AddCommand(newEncryptCmd())
 	rootCmd.AddCommand(newDecryptCmd())
+	rootCmd.AddCommand(newExportIdentityCmd())
+	rootCmd.AddCommand(newImportIdentityCmd())
 	rootCmd.AddCommand(newGitCmd())
 	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newDoctorCmd())
 	rootCmd.AddCommand(newGitHubCmd())
 	rootCmd.AddCommand(newAgentsCmd())
 	rootCmd.AddCommand(newCryptoCmd())
@@ -48,14 +137,17 @@ without human intervention.`,
 	rootCmd.AddCommand(newUseCmd())
 	rootCmd.AddCommand(newEnvCmd())
 	rootCmd.AddCommand(newStartCmd())
+	rootCmd.AddCommand(newTokenCmd())
 
+	markUsageErrors(rootCmd)
 	return rootCmd
 }
 
 func newVersionCmd(version, commit string) *cobra.Command {
 	return &cobra.Command{
-		Use:   "version",
-		Short: "Display version information",
+		Use:     "version",
+		Short:   "Display version information",
+		Example: `  moltnet version`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if commit != "" {
 				fmt.Fprintf(cmd.OutOrStdout(), "moltnet %s (%s)\n", version, commit)
@@ -70,7 +162,12 @@ func newVersionCmd(version, commit string) *cobra.Command {
 func Execute(version, commit string) {
 	rootCmd := NewRootCmd(version, commit)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		if errors.Is(err, errDryRun) || errors.Is(err, errConfigCheckPassed) {
+			return
+		}
+		quiet, _ := rootCmd.PersistentFlags().GetBool("quiet-errors")
+		verbose, _ := rootCmd.PersistentFlags().GetBool("verbose")
+		output, _ := rootCmd.PersistentFlags().GetString("output")
+		os.Exit(reportError(os.Stderr, err, output, quiet, verbose))
 	}
 }
@@ -12,7 +12,11 @@ With --request-id: fetches the signing request from the API,
 signs the payload, and submits the signature — all in one step.
 
 Without --request-id: signs the message+nonce locally and prints
-the base64-encoded signature to stdout.`,
+the base64-encoded signature to stdout.
+
+The bare signature is the default output (--output text). With
+--output json it prints {signature, nonce, public_key, algorithm}
+instead, plus request_id with --request-id.`,
 		Example: `  # Sign via API request ID (one-shot)
   moltnet sign --request-id <uuid>
 
@@ -20,7 +24,10 @@ the base64-encoded signature to stdout.`,
   moltnet sign --nonce <nonce> "message to sign"
 
   # Sign from stdin
-  echo "message" | moltnet sign --nonce <nonce> -`,
+  echo "message" | moltnet sign --nonce <nonce> -
+
+  # Signature with its nonce and public key as JSON
+  moltnet sign --output json --nonce <nonce> "message to sign"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -23,3 +23,25 @@ id_ed25519.pub to the output directory.`,
 
 	return cmd
 }
+
+func newMigrateSSHCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate-ssh",
+		Short: "Re-export the SSH key and git signing config after a key change",
+		Long: `Re-export the SSH key and git signing config after the identity key
+changed, e.g. after rotating or re-importing it.
+
+The SSH key files recorded in moltnet.json are rewritten from the current
+seed and parsed back to check they match. When 'moltnet git setup' has run,
+allowed_signers trusts the new key, keeping each retired key with a
+valid-before option so older commits still verify, and the gitconfig
+signingkey points at the new key. Does nothing when the exported key
+already matches.`,
+		Example: `  moltnet migrate-ssh
+  moltnet migrate-ssh --credentials /path/to/moltnet.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			return runMigrateSSHCmd(cmd.ErrOrStderr(), credPath)
+		},
+	}
+}
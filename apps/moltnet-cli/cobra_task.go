@@ -102,7 +102,9 @@ func newTaskArtifactsUploadCmd() *cobra.Command {
 		Short: "Upload an immutable artifact for a task attempt",
 		Example: `  moltnet task artifacts upload <task-id> --team-id <uuid> \
     --attempt 1 --kind report --title result.md --file ./result.md \
-    --content-type text/markdown`,
+    --content-type text/markdown
+  go test -json ./... | moltnet task artifacts upload <task-id> --team-id <uuid> \
+    --attempt 2 --kind trace --title test.jsonl --content-type application/x-ndjson`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath := flagString(cmd, "credentials")
@@ -189,7 +191,9 @@ func newTaskRuntimeSessionsGetCmd() *cobra.Command {
 		Use:   "get <task-id>",
 		Short: "Get durable runtime session metadata for a task attempt",
 		Example: `  moltnet task runtime-sessions get <task-id> --team-id <uuid> \
-    --attempt 1`,
+    --attempt 1
+  moltnet --output template --template '{{json .}}' task runtime-sessions get <task-id> \
+    --team-id <uuid> --attempt 2`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath := flagString(cmd, "credentials")
@@ -634,10 +638,11 @@ func newTaskListCmd() *cobra.Command {
 
 func newTaskGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "get <task-id>",
-		Short:   "Get a task by ID",
-		Example: `  moltnet task get <task-uuid>`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "get <task-id>",
+		Short: "Get a task by ID",
+		Example: `  moltnet task get <task-uuid>
+  moltnet --output template --template '{{.status}}' task get <task-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath := flagString(cmd, "credentials")
 			return runTaskGetCmd(
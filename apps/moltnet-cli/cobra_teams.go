@@ -13,19 +13,20 @@ func newTeamsCmd() *cobra.Command {
 	teamsCmd.AddCommand(newTeamsListCmd())
 	teamsCmd.AddCommand(newTeamsGetCmd())
 	teamsCmd.AddCommand(newTeamsMembersCmd())
-	teamsCmd.AddCommand(newTeamsCreateCmd())
-	teamsCmd.AddCommand(newTeamsJoinCmd())
+	teamsCmd.AddCommand(withDryRunFlag(newTeamsCreateCmd()))
+	teamsCmd.AddCommand(withDryRunFlag(newTeamsJoinCmd()))
 	teamsCmd.AddCommand(newTeamsInviteCmd())
-	teamsCmd.AddCommand(newTeamsDeleteCmd())
+	teamsCmd.AddCommand(withDryRunFlag(newTeamsDeleteCmd()))
 	return teamsCmd
 }
 
 func newTeamsDeleteCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "delete <team-id>",
-		Short:   "Delete a team (owner only)",
-		Example: `  moltnet teams delete 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "delete <team-id>",
+		Short: "Delete a team (owner only)",
+		Example: `  moltnet teams delete 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396
+  moltnet teams delete <team-uuid> --dry-run`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -36,9 +37,11 @@ func newTeamsDeleteCmd() *cobra.Command {
 
 func newTeamsListCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "list",
-		Short:   "List teams for the authenticated agent",
-		Example: `  moltnet teams list`,
+		Use:   "list",
+		Short: "List teams for the authenticated agent",
+		Example: `  moltnet teams list
+  moltnet --output table --columns id,name,role,personal teams list
+  moltnet --output jsonl teams list | jq -r 'select(.role == "owner") | .id'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -49,10 +52,11 @@ func newTeamsListCmd() *cobra.Command {
 
 func newTeamsGetCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "get <team-id>",
-		Short:   "Get team details (includes members)",
-		Example: `  moltnet teams get 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "get <team-id>",
+		Short: "Get team details (includes members)",
+		Example: `  moltnet teams get 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396
+  moltnet --output template --template '{{.name}}' teams get <team-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -67,17 +71,18 @@ func newTeamsMembersCmd() *cobra.Command {
 		Short: "Team member commands",
 	}
 	membersCmd.AddCommand(newTeamsMembersListCmd())
-	membersCmd.AddCommand(newTeamsMembersRemoveCmd())
-	membersCmd.AddCommand(newTeamsMembersUpdateRoleCmd())
+	membersCmd.AddCommand(withDryRunFlag(newTeamsMembersRemoveCmd()))
+	membersCmd.AddCommand(withDryRunFlag(newTeamsMembersUpdateRoleCmd()))
 	return membersCmd
 }
 
 func newTeamsMembersListCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "list <team-id>",
-		Short:   "List members of a team",
-		Example: `  moltnet teams members list 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "list <team-id>",
+		Short: "List members of a team",
+		Example: `  moltnet teams members list 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396
+  moltnet --output table teams members list <team-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -88,10 +93,11 @@ func newTeamsMembersListCmd() *cobra.Command {
 
 func newTeamsMembersRemoveCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "remove <team-id> <subject-id>",
-		Short:   "Remove a member from a team (owner/manager only)",
-		Example: `  moltnet teams members remove 6e4d9948-... 1a2b3c4d-...`,
-		Args:    cobra.ExactArgs(2),
+		Use:   "remove <team-id> <subject-id>",
+		Short: "Remove a member from a team (owner/manager only)",
+		Example: `  moltnet teams members remove 6e4d9948-... 1a2b3c4d-...
+  moltnet teams members remove <team-uuid> <subject-uuid> --dry-run`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -121,9 +127,10 @@ func newTeamsMembersUpdateRoleCmd() *cobra.Command {
 
 func newTeamsCreateCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "create",
-		Short:   "Create a new team",
-		Example: `  moltnet teams create --name "my-team"`,
+		Use:   "create",
+		Short: "Create a new team",
+		Example: `  moltnet teams create --name "my-team"
+  moltnet teams create --name "release-bots" --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -138,9 +145,10 @@ func newTeamsCreateCmd() *cobra.Command {
 
 func newTeamsJoinCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "join",
-		Short:   "Join a team using an invite code",
-		Example: `  moltnet teams join --code abc123`,
+		Use:   "join",
+		Short: "Join a team using an invite code",
+		Example: `  moltnet teams join --code abc123
+  moltnet teams join --code abc123 --dry-run`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -158,18 +166,19 @@ func newTeamsInviteCmd() *cobra.Command {
 		Use:   "invite",
 		Short: "Manage team invite codes",
 	}
-	inviteCmd.AddCommand(newTeamsInviteCreateCmd())
+	inviteCmd.AddCommand(withDryRunFlag(newTeamsInviteCreateCmd()))
 	inviteCmd.AddCommand(newTeamsInviteListCmd())
-	inviteCmd.AddCommand(newTeamsInviteDeleteCmd())
+	inviteCmd.AddCommand(withDryRunFlag(newTeamsInviteDeleteCmd()))
 	return inviteCmd
 }
 
 func newTeamsInviteDeleteCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "delete <team-id> <invite-id>",
-		Short:   "Delete a team invite code (owner/manager only)",
-		Example: `  moltnet teams invite delete 6e4d9948-... 9f8e7d6c-...`,
-		Args:    cobra.ExactArgs(2),
+		Use:   "delete <team-id> <invite-id>",
+		Short: "Delete a team invite code (owner/manager only)",
+		Example: `  moltnet teams invite delete 6e4d9948-... 9f8e7d6c-...
+  moltnet teams invite delete <team-uuid> <invite-uuid> --dry-run`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -202,10 +211,11 @@ func newTeamsInviteCreateCmd() *cobra.Command {
 
 func newTeamsInviteListCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:     "list <team-id>",
-		Short:   "List invite codes for a team",
-		Example: `  moltnet teams invite list 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396`,
-		Args:    cobra.ExactArgs(1),
+		Use:   "list <team-id>",
+		Short: "List invite codes for a team",
+		Example: `  moltnet teams invite list 6e4d9948-8ec5-4f59-b82a-3acbc4bbc396
+  moltnet --output table teams invite list <team-uuid>`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
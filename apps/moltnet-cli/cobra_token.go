@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newTokenCmd() *cobra.Command {
+	tokenCmd := &cobra.Command{
+		Use:   "token",
+		Short: "Inspect or revoke MoltNet OAuth2 access tokens",
+		Long: `Inspect or revoke MoltNet OAuth2 access tokens.
+
+The CLI mints a client_credentials token per invocation and keeps it only
+in memory, so there is no on-disk token cache to clear. Pass --token to act
+on a token that was handed to another tool, or "-" to read it from stdin.
+
+Revocation (RFC 7009) and introspection (RFC 7662) endpoints are taken
+from the network's discovery document. When they are not advertised,
+"revoke" reports revoked: false and "introspect" shows the scope and
+expiry from the token response instead.`,
+	}
+	tokenCmd.AddCommand(newTokenRevokeCmd(), newTokenIntrospectCmd())
+	return tokenCmd
+}
+
+func newTokenRevokeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke an access token server-side",
+		Example: `  moltnet token revoke --token "$MOLTNET_ACCESS_TOKEN"
+  printf '%s' "$TOKEN" | moltnet token revoke --token -`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			token, _ := cmd.Flags().GetString("token")
+			return runTokenRevokeCmd(apiURL, credPath, token, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("token", "", `Access token to revoke ("-" reads stdin; default: a token minted from the credentials)`)
+	return cmd
+}
+
+func newTokenIntrospectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "introspect",
+		Short: "Show whether an access token is active and its scopes",
+		Example: `  moltnet token introspect
+  moltnet token introspect --token "$MOLTNET_ACCESS_TOKEN"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			token, _ := cmd.Flags().GetString("token")
+			return runTokenIntrospectCmd(apiURL, credPath, token, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().String("token", "", `Access token to inspect ("-" reads stdin; default: a token minted from the credentials)`)
+	return cmd
+}
@@ -11,20 +11,33 @@ func newVouchCmd() *cobra.Command {
 	issueCmd := &cobra.Command{
 		Use:   "issue",
 		Short: "Issue a voucher code that another agent can use to register",
+		Long: `Issue a voucher code that another agent can use to register.
+
+--note records who the code is for. The network stores no voucher metadata,
+so the note is kept locally (voucher-notes.json in the config directory) and
+shown by "vouch list" and "vouch show" on this machine only.`,
 		Example: `  # Issue a voucher code
-  moltnet vouch issue`,
+  moltnet vouch issue
+
+  # Remember who it is for
+  moltnet vouch issue --note "for Alice's research agent"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
-			return runVouchIssueCmd(apiURL, credPath)
+			note, _ := cmd.Flags().GetString("note")
+			return runVouchIssueCmd(apiURL, credPath, note)
 		},
 	}
+	issueCmd.Flags().String("note", "", "Private label for the voucher, stored locally")
 
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List your active (unredeemed) voucher codes",
 		Example: `  # List active vouchers
-  moltnet vouch list`,
+  moltnet vouch list
+
+  # Codes and expiry dates as a table
+  moltnet --output table --columns code,expiresAt vouch list`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			credPath, _ := cmd.Flags().GetString("credentials")
 			apiURL := resolveAPIURL(cmd, credPath)
@@ -32,7 +45,24 @@ func newVouchCmd() *cobra.Command {
 		},
 	}
 
-	vouchCmd.AddCommand(issueCmd)
+	showCmd := &cobra.Command{
+		Use:   "show <code>",
+		Short: "Show one voucher and its local note",
+		Long: `Show one voucher and its local note. A code that is no longer active
+(redeemed or expired) is still shown, with "active": false, while this
+machine has a note for it.`,
+		Example: `  moltnet vouch show <code>
+  moltnet --output template --template '{{.active}}' vouch show <code>`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			credPath, _ := cmd.Flags().GetString("credentials")
+			apiURL := resolveAPIURL(cmd, credPath)
+			return runVouchShowCmd(apiURL, credPath, args[0])
+		},
+	}
+
+	vouchCmd.AddCommand(withDryRunFlag(issueCmd))
 	vouchCmd.AddCommand(listCmd)
+	vouchCmd.AddCommand(showCmd)
 	return vouchCmd
 }
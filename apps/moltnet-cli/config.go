@@ -35,7 +35,31 @@ func BuildMcpConfig(mcpURL string, clientID string, clientSecret string) McpConf
 	}
 }
 
-// WriteMcpConfig writes or merges .mcp.json in the given directory.
+// Environment variables referenced by a masked MCP config. They match the
+// names used by config export-env / init-from-env so one env file serves both.
+const (
+	mcpClientIDEnvVar     = "MOLTNET_CLIENT_ID"
+	mcpClientSecretEnvVar = "MOLTNET_CLIENT_SECRET"
+)
+
+// mcpEnvPlaceholder returns the ${env:NAME} reference understood by MCP hosts
+// that expand environment variables in header values.
+func mcpEnvPlaceholder(name string) string {
+	return "${env:" + name + "}"
+}
+
+// BuildMaskedMcpConfig creates the MCP config for the given MCP URL with the
+// credential headers replaced by environment placeholders, so the client
+// secret is never written to .mcp.json. The MCP host must have
+// MOLTNET_CLIENT_ID and MOLTNET_CLIENT_SECRET set in its environment.
+func BuildMaskedMcpConfig(mcpURL string) McpConfig {
+	return BuildMcpConfig(mcpURL,
+		mcpEnvPlaceholder(mcpClientIDEnvVar),
+		mcpEnvPlaceholder(mcpClientSecretEnvVar),
+	)
+}
+
+// WriteMcpConfig writes or merges .mcp.json in the given directory with mode 0o600.
 func WriteMcpConfig(mcpConfig McpConfig, dir string) (string, error) {
 	if dir == "" {
 		var err error
@@ -82,9 +106,36 @@ func WriteMcpConfig(mcpConfig McpConfig, dir string) (string, error) {
 	}
 	output = append(output, '\n')
 
-	if err := os.WriteFile(filePath, output, 0o644); err != nil {
+	// Our server entry carries X-Client-Secret, so the whole file is kept
+	// owner-only even when it also holds unrelated servers. Writing in
+	// place would keep an existing file's looser mode while the secret
+	// lands in it, so the file is replaced instead.
+	if err := writePrivateFile(filePath, output); err != nil {
 		return "", fmt.Errorf("write config: %w", err)
 	}
 
 	return filePath, nil
 }
+
+// writePrivateFile replaces path with data via a 0600 temp file in the
+// same directory and a rename, so the content is never readable by
+// others, whatever the mode of the file it replaces.
+func writePrivateFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
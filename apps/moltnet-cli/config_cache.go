@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// configCache keeps the bytes of every config file this process read or
+// wrote, keyed by absolute path, so flows that read-modify-write-read the
+// config (github setup, register, migrate-ssh) parse what they last wrote
+// instead of going back to disk. An entry is served while the file's size
+// and modification time are unchanged, which catches edits by other
+// processes; writes through WriteConfigTo and writeRawConfig update it.
+// A file read within configCacheRacyWindow of its modification time is not
+// cached: a same-size rewrite inside the filesystem's timestamp
+// granularity would otherwise go unnoticed.
+//
+// mu is held across each read and write, so within a process a read never
+// observes a config half-way through being written.
+var configCache = struct {
+	mu      sync.Mutex
+	entries map[string]configCacheEntry
+}{entries: map[string]configCacheEntry{}}
+
+const configCacheRacyWindow = 2 * time.Second
+
+type configCacheEntry struct {
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+func configCacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// readConfigFile returns the content of the config at path, from the cache
+// when the file has not changed since it was cached. The returned slice is
+// shared and must not be modified.
+func readConfigFile(path string) ([]byte, error) {
+	key := configCacheKey(path)
+	configCache.mu.Lock()
+	defer configCache.mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(configCache.entries, key)
+		return nil, err
+	}
+	if entry, ok := configCache.entries[key]; ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.data, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		delete(configCache.entries, key)
+		return nil, err
+	}
+	if time.Since(info.ModTime()) >= configCacheRacyWindow {
+		cacheConfigFileLocked(key, path, data)
+	}
+	return data, nil
+}
+
+// writeConfigFile runs write, which replaces the config at path with data,
+// and caches data as the file's content once it succeeded.
+func writeConfigFile(path string, data []byte, write func() error) error {
+	key := configCacheKey(path)
+	configCache.mu.Lock()
+	defer configCache.mu.Unlock()
+
+	delete(configCache.entries, key)
+	if err := write(); err != nil {
+		return err
+	}
+	cacheConfigFileLocked(key, path, data)
+	return nil
+}
+
+// invalidateConfigCache drops the cached content of path, e.g. after the
+// file was renamed away.
+func invalidateConfigCache(path string) {
+	configCache.mu.Lock()
+	defer configCache.mu.Unlock()
+	delete(configCache.entries, configCacheKey(path))
+}
+
+func cacheConfigFileLocked(key, path string, data []byte) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != int64(len(data)) {
+		return
+	}
+	configCache.entries[key] = configCacheEntry{data: data, size: info.Size(), modTime: info.ModTime()}
+}
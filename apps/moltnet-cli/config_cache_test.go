@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ageFile backdates path past the racy window so reads of it are cached.
+func ageFile(t *testing.T, path string) time.Time {
+	t.Helper()
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	return old
+}
+
+func TestReadConfigFrom_ServesUnchangedFileFromCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{IdentityID: "agent-aaaa"}, path); err != nil {
+		t.Fatal(err)
+	}
+	old := ageFile(t, path)
+	if _, err := ReadConfigFrom(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size and modification time: the cached content wins.
+	data, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "agent-aaaa", "agent-bbbb", 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	creds, _ := ReadConfigFrom(path)
+	if creds.IdentityID != "agent-aaaa" {
+		t.Errorf("IdentityID = %q, want the cached agent-aaaa", creds.IdentityID)
+	}
+
+	// A new modification time is a change by another process.
+	if err := os.Chtimes(path, old.Add(time.Minute), old.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if creds, _ = ReadConfigFrom(path); creds.IdentityID != "agent-bbbb" {
+		t.Errorf("IdentityID = %q: changed file still served from the cache", creds.IdentityID)
+	}
+}
+
+func TestReadConfigFrom_SeesOwnWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	creds := &CredentialsFile{IdentityID: "agent-1"}
+	if _, err := WriteConfigTo(creds, path); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ReadConfigFrom(path)
+	got.IdentityID = "mutated"
+	if again, _ := ReadConfigFrom(path); again.IdentityID != "agent-1" {
+		t.Errorf("IdentityID = %q: a caller's edit leaked into the cache", again.IdentityID)
+	}
+
+	creds.IdentityID = "agent-2"
+	if _, err := WriteConfigTo(creds, path); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = ReadConfigFrom(path); got.IdentityID != "agent-2" {
+		t.Errorf("IdentityID = %q after rewrite, want agent-2", got.IdentityID)
+	}
+	if err := runConfigSetCmd(path, "identity_id", "agent-3"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = ReadConfigFrom(path); got.IdentityID != "agent-3" {
+		t.Errorf("IdentityID = %q after config set, want agent-3", got.IdentityID)
+	}
+	os.Remove(path)
+	if got, err := ReadConfigFrom(path); got != nil || err != nil {
+		t.Errorf("removed config read as %+v, %v", got, err)
+	}
+}
+
+func TestReadConfigFrom_ConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{IdentityID: "agent-0"}, path); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = WriteConfigTo(&CredentialsFile{IdentityID: "agent-with-a-longer-id", Keys: CredentialsKeys{PublicKey: "ed25519:x"}}, path)
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := ReadConfigFrom(path); err != nil {
+				t.Errorf("read during a write: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
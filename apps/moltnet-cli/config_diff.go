@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// configSecretKeys are never printed by config diff, only reported as
+// changed or not.
+var configSecretKeys = map[string]bool{
+	"oauth2.client_secret": true,
+	"keys.private_key":     true,
+}
+
+const redactedConfigValue = "(redacted)"
+
+// configFieldDiff is one field that differs between two configs. A nil
+// side means the field (or its whole section) is absent from that file.
+type configFieldDiff struct {
+	Key    string  `json:"key"`
+	Change string  `json:"change"` // "added", "removed" or "changed"
+	A      *string `json:"a,omitempty"`
+	B      *string `json:"b,omitempty"`
+}
+
+// readConfigForDiff decodes a config without migrating it, so a diff
+// against a migrated copy shows what the migration changed.
+func readConfigForDiff(path string) (*CredentialsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config diff: %w", err)
+	}
+	var creds CredentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("config diff: parse %s: %w", path, err)
+	}
+	return &creds, nil
+}
+
+// flattenConfig maps each set field of c to its value under its dotted
+// key. Optional sections that are nil contribute no keys.
+func flattenConfig(c *CredentialsFile) (map[string]string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	flat := map[string]string{}
+	flattenPolicyLimits("", doc, flat)
+	return flat, nil
+}
+
+// diffConfigs returns the field-level differences between a and b,
+// sorted by key, with secret values redacted.
+func diffConfigs(a, b *CredentialsFile) ([]configFieldDiff, error) {
+	fa, err := flattenConfig(a)
+	if err != nil {
+		return nil, err
+	}
+	fb, err := flattenConfig(b)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]bool{}
+	for k := range fa {
+		keys[k] = true
+	}
+	for k := range fb {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var diffs []configFieldDiff
+	for _, k := range sorted {
+		va, inA := fa[k]
+		vb, inB := fb[k]
+		if inA && inB && va == vb {
+			continue
+		}
+		d := configFieldDiff{Key: k}
+		switch {
+		case !inA:
+			d.Change = "added"
+		case !inB:
+			d.Change = "removed"
+		default:
+			d.Change = "changed"
+		}
+		if configSecretKeys[k] {
+			va, vb = redactedConfigValue, redactedConfigValue
+		}
+		if inA {
+			d.A = &va
+		}
+		if inB {
+			d.B = &vb
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+func writeConfigDiff(w io.Writer, pathA, pathB string, diffs []configFieldDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintf(w, "no differences between %s and %s\n", pathA, pathB)
+		return
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", pathA, pathB)
+	for _, d := range diffs {
+		switch d.Change {
+		case "added":
+			fmt.Fprintf(w, "+ %s: %q\n", d.Key, *d.B)
+		case "removed":
+			fmt.Fprintf(w, "- %s: %q\n", d.Key, *d.A)
+		default:
+			if configSecretKeys[d.Key] {
+				fmt.Fprintf(w, "~ %s: %s (values differ)\n", d.Key, redactedConfigValue)
+				continue
+			}
+			fmt.Fprintf(w, "~ %s: %q -> %q\n", d.Key, *d.A, *d.B)
+		}
+	}
+}
+
+// runConfigDiffCmd prints the field-level differences between two config
+// files.
+func runConfigDiffCmd(pathA, pathB string, jsonOut bool, w io.Writer) error {
+	a, err := readConfigForDiff(pathA)
+	if err != nil {
+		return err
+	}
+	b, err := readConfigForDiff(pathB)
+	if err != nil {
+		return err
+	}
+	diffs, err := diffConfigs(a, b)
+	if err != nil {
+		return fmt.Errorf("config diff: %w", err)
+	}
+	if jsonOut {
+		if diffs == nil {
+			diffs = []configFieldDiff{}
+		}
+		return printJSONTo(w, diffs)
+	}
+	writeConfigDiff(w, pathA, pathB, diffs)
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDiffTestConfig(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const diffTestConfigA = `{
+  "schema_version": 2,
+  "identity_id": "id-1",
+  "oauth2": {"client_id": "cid", "client_secret": "old-secret"},
+  "keys": {"public_key": "ed25519:pk", "private_key": "seed-a", "fingerprint": "A1B2-C3D4-E5F6-A1B2"},
+  "endpoints": {"api": "https://api.themolt.net", "mcp": "https://mcp.themolt.net/mcp"},
+  "registered_at": "2026-01-01T00:00:00Z",
+  "git": {"name": "agent", "email": "agent@example.com", "signing": true, "config_path": "/tmp/gitconfig"}
+}`
+
+const diffTestConfigB = `{
+  "schema_version": 2,
+  "identity_id": "id-1",
+  "oauth2": {"client_id": "cid", "client_secret": "new-secret"},
+  "keys": {"public_key": "ed25519:pk", "private_key": "seed-a", "fingerprint": "A1B2-C3D4-E5F6-A1B2"},
+  "endpoints": {"api": "https://staging.themolt.net", "mcp": "https://mcp.themolt.net/mcp"},
+  "registered_at": "2026-01-01T00:00:00Z",
+  "ssh": {"private_key_path": "/keys/id", "public_key_path": "/keys/id.pub"}
+}`
+
+func TestRunConfigDiffCmd_Text(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	a := writeDiffTestConfig(t, "a.json", diffTestConfigA)
+	b := writeDiffTestConfig(t, "b.json", diffTestConfigB)
+	var out bytes.Buffer
+
+	// Act
+	err := runConfigDiffCmd(a, b, false, &out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runConfigDiffCmd() error: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		`~ endpoints.api: "https://api.themolt.net" -> "https://staging.themolt.net"`,
+		"~ oauth2.client_secret: (redacted) (values differ)",
+		`- git.email: "agent@example.com"`,
+		`+ ssh.public_key_path: "/keys/id.pub"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+	for _, leaked := range []string{"old-secret", "new-secret", "seed-a"} {
+		if strings.Contains(text, leaked) {
+			t.Errorf("secret %q leaked:\n%s", leaked, text)
+		}
+	}
+	if strings.Contains(text, "identity_id") || strings.Contains(text, "private_key:") {
+		t.Errorf("unchanged fields should not be listed:\n%s", text)
+	}
+}
+
+func TestRunConfigDiffCmd_JSONAndIdentical(t *testing.T) {
+	t.Parallel()
+	a := writeDiffTestConfig(t, "a.json", diffTestConfigA)
+	b := writeDiffTestConfig(t, "b.json", diffTestConfigB)
+	var out bytes.Buffer
+
+	if err := runConfigDiffCmd(a, b, true, &out); err != nil {
+		t.Fatalf("json diff: %v", err)
+	}
+	var diffs []configFieldDiff
+	if err := json.Unmarshal(out.Bytes(), &diffs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, d := range diffs {
+		if d.Key == "oauth2.client_secret" && (d.A == nil || *d.A != redactedConfigValue) {
+			t.Errorf("secret not redacted: %+v", d)
+		}
+		if d.Key == "ssh.private_key_path" && (d.Change != "added" || d.A != nil) {
+			t.Errorf("unexpected ssh diff: %+v", d)
+		}
+	}
+
+	out.Reset()
+	if err := runConfigDiffCmd(a, a, true, &out); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("identical configs: %q, %v", out.String(), err)
+	}
+}
+
+func TestRunConfigDiffCmd_MissingFile(t *testing.T) {
+	t.Parallel()
+	a := writeDiffTestConfig(t, "a.json", diffTestConfigA)
+
+	err := runConfigDiffCmd(a, filepath.Join(t.TempDir(), "absent.json"), false, &bytes.Buffer{})
+
+	if err == nil || !strings.HasPrefix(err.Error(), "config diff:") {
+		t.Errorf("expected config diff error, got %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configKeyValidators adds semantic checks on top of the type check for
+// specific keys.
+var configKeyValidators = map[string]func(string) error{
+	"endpoints.api":   validateEndpointURL,
+	"endpoints.mcp":   validateEndpointURL,
+	"networks.<name>": validateEndpointURL,
+	"oauth2.scopes": func(v string) error {
+		_, err := parseOAuthScopes(v)
+		return err
+	},
+	"oauth2.trusted_origins": func(v string) error {
+		for _, origin := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if err := validateEndpointURL(origin); err != nil {
+				return fmt.Errorf("%s: %w", origin, err)
+			}
+		}
+		return nil
+	},
+	"oauth2.token_endpoint": func(v string) error {
+		if v == tokenEndpointDiscover {
+			return nil
+		}
+		if validateEndpointURL(v) != nil {
+			return fmt.Errorf("must be %q or an absolute http(s) URL", tokenEndpointDiscover)
+		}
+		return nil
+	},
+}
+
+func validateEndpointURL(v string) error {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// configKeys maps every settable dotted key of moltnet.json to its value
+// kind, derived from the CredentialsFile JSON tags so it cannot drift from
+// the schema. A map section such as networks appears as networks.<name>.
+func configKeys() map[string]reflect.Kind {
+	keys := map[string]reflect.Kind{}
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				walk(ft, prefix+name+".")
+				continue
+			}
+			if ft.Kind() == reflect.Map {
+				keys[prefix+name+".<name>"] = ft.Elem().Kind()
+				continue
+			}
+			keys[prefix+name] = ft.Kind()
+		}
+	}
+	walk(reflect.TypeOf(CredentialsFile{}), "")
+	return keys
+}
+
+func knownConfigKeys() []string {
+	keys := configKeys()
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configKeyPattern returns the configKeys entry key falls under: key
+// itself, or <section>.<name> for an entry of a map section.
+func configKeyPattern(key string) (string, bool) {
+	keys := configKeys()
+	if _, ok := keys[key]; ok {
+		return key, true
+	}
+	if i := strings.LastIndex(key, "."); i > 0 && i < len(key)-1 {
+		pattern := key[:i] + ".<name>"
+		if _, ok := keys[pattern]; ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// parseConfigValue converts raw to the JSON value for key, rejecting
+// unknown keys and values of the wrong type.
+func parseConfigValue(key, raw string) (any, error) {
+	pattern, ok := configKeyPattern(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown config key %q (known keys: %s)", key, strings.Join(knownConfigKeys(), ", "))
+	}
+	kind := configKeys()[pattern]
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected true or false, got %q", key, raw)
+		}
+		return b, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected an integer, got %q", key, raw)
+		}
+		return n, nil
+	case reflect.Slice:
+		// Lists are given comma- or space-separated: "a,b" or "a b".
+		if validate := configKeyValidators[pattern]; validate != nil {
+			if err := validate(raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		items := []any{}
+		for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		if validate := configKeyValidators[pattern]; validate != nil {
+			if err := validate(raw); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+		return raw, nil
+	}
+}
+
+// resolveConfigEditPath returns the config file config get/set operate on.
+func resolveConfigEditPath(credPath string) (string, error) {
+	if credPath != "" {
+		return credPath, nil
+	}
+	return GetConfigPath()
+}
+
+// readRawConfig decodes moltnet.json generically so fields this CLI does
+// not know about survive a read-modify-write.
+func readRawConfig(path string) (map[string]any, error) {
+	data, err := readConfigFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("config not found at %s", path)
+		}
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	return raw, nil
+}
+
+// writeRawConfig replaces path atomically, after checking the result still
+// parses as a CredentialsFile.
+func writeRawConfig(path string, raw map[string]any) error {
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	data = append(data, '\n')
+	var check CredentialsFile
+	if err := json.Unmarshal(data, &check); err != nil {
+		return fmt.Errorf("edited config is invalid: %w", err)
+	}
+	return writeConfigFile(path, data, func() error {
+		if err := writePrivateFile(path, data); err != nil {
+			return fmt.Errorf("write config: %w", err)
+		}
+		return nil
+	})
+}
+
+// runConfigSetCmd sets one dotted key in moltnet.json, creating missing
+// sections (e.g. git.*) as needed.
+func runConfigSetCmd(credPath, key, value string) error {
+	v, err := parseConfigValue(key, value)
+	if err != nil {
+		return fmt.Errorf("config set: %w", err)
+	}
+	path, err := resolveConfigEditPath(credPath)
+	if err != nil {
+		return err
+	}
+	raw, err := readRawConfig(path)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(key, ".")
+	node := raw
+	for _, p := range parts[:len(parts)-1] {
+		child, ok := node[p].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			node[p] = child
+		}
+		node = child
+	}
+	node[parts[len(parts)-1]] = v
+
+	if err := writeRawConfig(path, raw); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Set %s in %s\n", key, path)
+	return nil
+}
+
+// runConfigGetCmd prints the value at a dotted key: strings as-is, other
+// values and whole sections as JSON.
+func runConfigGetCmd(credPath, key string, w io.Writer) error {
+	path, err := resolveConfigEditPath(credPath)
+	if err != nil {
+		return err
+	}
+	raw, err := readRawConfig(path)
+	if err != nil {
+		return err
+	}
+	var v any = raw
+	for _, p := range strings.Split(key, ".") {
+		node, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("config get: %q is not set", key)
+		}
+		if v, ok = node[p]; !ok {
+			return fmt.Errorf("config get: %q is not set", key)
+		}
+	}
+	if s, ok := v.(string); ok {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+	return printJSONTo(w, v)
+}
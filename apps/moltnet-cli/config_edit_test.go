@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeEditTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const editTestConfig = `{
+  "identity_id": "id-1",
+  "oauth2": {"client_id": "cid", "client_secret": "csec"},
+  "keys": {"public_key": "ed25519:pk", "private_key": "sk", "fingerprint": "AAAA-BBBB-CCCC-DDDD"},
+  "endpoints": {"api": "https://api.themolt.net", "mcp": "https://mcp.themolt.net/mcp", "x_custom": 3},
+  "registered_at": "2026-01-01T00:00:00Z",
+  "future_field": {"nested": true}
+}`
+
+func TestRunConfigSetCmd_PreservesUnknownFields(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	path := writeEditTestConfig(t, editTestConfig)
+
+	// Act
+	err := runConfigSetCmd(path, "endpoints.api", "https://staging.themolt.net")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runConfigSetCmd() error: %v", err)
+	}
+	creds, err := ReadConfigFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.Endpoints.API != "https://staging.themolt.net" {
+		t.Errorf("endpoints.api: got %q", creds.Endpoints.API)
+	}
+	data, _ := os.ReadFile(path)
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["future_field"]; !ok {
+		t.Error("unknown top-level field was dropped")
+	}
+	if raw["endpoints"].(map[string]any)["x_custom"] != 3.0 {
+		t.Errorf("unknown nested field was dropped or changed: %v", raw["endpoints"])
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestRunConfigSetCmd_CreatesSection(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	path := writeEditTestConfig(t, editTestConfig)
+
+	// Act
+	err := runConfigSetCmd(path, "git.signing", "true")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runConfigSetCmd() error: %v", err)
+	}
+	creds, _ := ReadConfigFrom(path)
+	if creds.Git == nil || !creds.Git.Signing {
+		t.Errorf("expected git.signing=true, got %+v", creds.Git)
+	}
+}
+
+func TestRunConfigSetCmd_List(t *testing.T) {
+	t.Parallel()
+	path := writeEditTestConfig(t, editTestConfig)
+
+	if err := runConfigSetCmd(path, "oauth2.scopes", "diary:read, entry:read"); err != nil {
+		t.Fatalf("runConfigSetCmd() error: %v", err)
+	}
+	creds, _ := ReadConfigFrom(path)
+	if got := strings.Join(creds.OAuth2.Scopes, " "); got != "diary:read entry:read" {
+		t.Errorf("oauth2.scopes = %q, want %q", got, "diary:read entry:read")
+	}
+}
+
+func TestRunConfigSetCmd_Validation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name, key, value, want string
+	}{
+		{"unknown key", "endpoints.nope", "x", "unknown config key"},
+		{"bad bool", "git.signing", "maybe", "expected true or false"},
+		{"bad int", "schema_version", "two", "expected an integer"},
+		{"bad url", "endpoints.api", "staging.themolt.net", "absolute http(s) URL"},
+		{"section", "endpoints", "x", "unknown config key"},
+		{"bad scope", "oauth2.scopes", `diary:read,"all"`, "invalid scope"},
+		{"bad token endpoint", "oauth2.token_endpoint", "sometimes", "absolute http(s) URL"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			path := writeEditTestConfig(t, editTestConfig)
+
+			err := runConfigSetCmd(path, tc.key, tc.value)
+
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != editTestConfig {
+				t.Error("config was modified despite validation failure")
+			}
+		})
+	}
+}
+
+func TestRunConfigGetCmd(t *testing.T) {
+	t.Parallel()
+	path := writeEditTestConfig(t, editTestConfig)
+	cases := []struct {
+		key, want string
+	}{
+		{"endpoints.api", "https://api.themolt.net\n"},
+		{"future_field.nested", "true\n"},
+		{"oauth2", "{\n  \"client_id\": \"cid\",\n  \"client_secret\": \"csec\"\n}\n"},
+	}
+	for _, tc := range cases {
+		var out bytes.Buffer
+		if err := runConfigGetCmd(path, tc.key, &out); err != nil {
+			t.Fatalf("get %s: %v", tc.key, err)
+		}
+		if out.String() != tc.want {
+			t.Errorf("get %s: got %q, want %q", tc.key, out.String(), tc.want)
+		}
+	}
+	if err := runConfigGetCmd(path, "git.name", &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "is not set") {
+		t.Errorf("expected not-set error, got %v", err)
+	}
+}
+
+func TestConfigKeysCoverSchema(t *testing.T) {
+	t.Parallel()
+	keys := configKeys()
+	for _, k := range []string{"identity_id", "endpoints.api", "keys.private_key", "git.signing", "github.app_id", "schema_version"} {
+		if _, ok := keys[k]; !ok {
+			t.Errorf("expected %q in config keys", k)
+		}
+	}
+}
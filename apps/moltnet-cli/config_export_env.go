@@ -8,7 +8,7 @@ import (
 	"strings"
 )
 
-// runConfigExportEnvCmd reads a moltnet.json and prints MOLTNET_* env vars
+// runConfigExportEnvCmd reads a moltnet.json and prints <PREFIX>* env vars
 // in dotenv format. The output is directly usable with init-from-env --env-file.
 func runConfigExportEnvCmd(w io.Writer, credPath, outFile string, includeGitHubPEM bool) error {
 	creds, err := loadCredentials(credPath)
@@ -32,41 +32,41 @@ func runConfigExportEnvCmd(w io.Writer, credPath, outFile string, includeGitHubP
 	var lines []string
 	lines = append(lines, "# Generated by moltnet config export-env")
 	if agentName != "" {
-		lines = append(lines, fmt.Sprintf("MOLTNET_AGENT_NAME=%s", agentName))
+		lines = append(lines, fmt.Sprintf("%s=%s", envVar("AGENT_NAME"), agentName))
 	}
-	lines = append(lines, fmt.Sprintf("MOLTNET_IDENTITY_ID=%s", creds.IdentityID))
-	lines = append(lines, fmt.Sprintf("MOLTNET_CLIENT_ID=%s", creds.OAuth2.ClientID))
-	lines = append(lines, fmt.Sprintf("MOLTNET_CLIENT_SECRET=%s", creds.OAuth2.ClientSecret))
-	lines = append(lines, fmt.Sprintf("MOLTNET_PUBLIC_KEY=%s", creds.Keys.PublicKey))
-	lines = append(lines, fmt.Sprintf("MOLTNET_PRIVATE_KEY=%s", creds.Keys.PrivateKey))
-	lines = append(lines, fmt.Sprintf("MOLTNET_FINGERPRINT=%s", creds.Keys.Fingerprint))
-	lines = append(lines, fmt.Sprintf("MOLTNET_API_URL=%s", creds.Endpoints.API))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("IDENTITY_ID"), creds.IdentityID))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("CLIENT_ID"), creds.OAuth2.ClientID))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("CLIENT_SECRET"), creds.OAuth2.ClientSecret))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("PUBLIC_KEY"), creds.Keys.PublicKey))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("PRIVATE_KEY"), creds.Keys.PrivateKey))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("FINGERPRINT"), creds.Keys.Fingerprint))
+	lines = append(lines, fmt.Sprintf("%s=%s", envVar("API_URL"), creds.Endpoints.API))
 	if creds.RegisteredAt != "" {
-		lines = append(lines, fmt.Sprintf("MOLTNET_REGISTERED_AT=%s", creds.RegisteredAt))
+		lines = append(lines, fmt.Sprintf("%s=%s", envVar("REGISTERED_AT"), creds.RegisteredAt))
 	}
 
 	if creds.Git != nil {
 		if creds.Git.Name != "" {
-			lines = append(lines, fmt.Sprintf("MOLTNET_GIT_NAME=%q", creds.Git.Name))
+			lines = append(lines, fmt.Sprintf("%s=%q", envVar("GIT_NAME"), creds.Git.Name))
 		}
 		if creds.Git.Email != "" {
-			lines = append(lines, fmt.Sprintf("MOLTNET_GIT_EMAIL=%q", creds.Git.Email))
+			lines = append(lines, fmt.Sprintf("%s=%q", envVar("GIT_EMAIL"), creds.Git.Email))
 		}
 	}
 
 	if creds.GitHub != nil {
-		lines = append(lines, fmt.Sprintf("MOLTNET_GITHUB_APP_ID=%s", creds.GitHub.AppID))
+		lines = append(lines, fmt.Sprintf("%s=%s", envVar("GITHUB_APP_ID"), creds.GitHub.AppID))
 		if creds.GitHub.AppSlug != "" {
-			lines = append(lines, fmt.Sprintf("MOLTNET_GITHUB_APP_SLUG=%s", creds.GitHub.AppSlug))
+			lines = append(lines, fmt.Sprintf("%s=%s", envVar("GITHUB_APP_SLUG"), creds.GitHub.AppSlug))
 		}
-		lines = append(lines, fmt.Sprintf("MOLTNET_GITHUB_APP_INSTALLATION_ID=%s", creds.GitHub.InstallationID))
+		lines = append(lines, fmt.Sprintf("%s=%s", envVar("GITHUB_APP_INSTALLATION_ID"), creds.GitHub.InstallationID))
 		if includeGitHubPEM && creds.GitHub.PrivateKeyPath != "" {
 			pem, err := os.ReadFile(creds.GitHub.PrivateKeyPath)
 			if err != nil {
 				return fmt.Errorf("read GitHub App PEM %q: %w", creds.GitHub.PrivateKeyPath, err)
 			}
 			// Dotenv multi-line: wrap in double quotes with literal newlines
-			lines = append(lines, fmt.Sprintf("MOLTNET_GITHUB_APP_PRIVATE_KEY=%q", strings.TrimSpace(string(pem))))
+			lines = append(lines, fmt.Sprintf("%s=%q", envVar("GITHUB_APP_PRIVATE_KEY"), strings.TrimSpace(string(pem))))
 		}
 	}
 
@@ -74,6 +74,7 @@ func runConfigExportEnvCmd(w io.Writer, credPath, outFile string, includeGitHubP
 	content := strings.Join(lines, "\n")
 
 	if outFile != "" {
+		warnCISecretWrite(os.Stderr, "credential env vars ("+outFile+")")
 		if err := os.WriteFile(outFile, []byte(content), 0o600); err != nil {
 			return fmt.Errorf("write env file: %w", err)
 		}
@@ -66,12 +66,12 @@ func runConfigInitFromEnvCmd(dir, agentName string, skipGit bool, envFile string
 		fmt.Fprintf(os.Stderr, "Loaded env file %s (override=%v)\n", envFile, override)
 	}
 
-	// Resolve agent name: --agent flag > MOLTNET_AGENT_NAME env var
+	// Resolve agent name: --agent flag > <PREFIX>AGENT_NAME env var
 	if agentName == "" {
-		agentName = getenv("MOLTNET_AGENT_NAME", fileVars, override)
+		agentName = getenv(envVar("AGENT_NAME"), fileVars, override)
 	}
 	if agentName == "" {
-		return fmt.Errorf("--agent is required (or set MOLTNET_AGENT_NAME)")
+		return fmt.Errorf("--agent is required (or set %s)", envVar("AGENT_NAME"))
 	}
 
 	// Resolve agent config directory early so we can skip before validating env vars.
@@ -86,44 +86,44 @@ func runConfigInitFromEnvCmd(dir, agentName string, skipGit bool, envFile string
 	}
 
 	// Required env vars
-	identityID := getenv("MOLTNET_IDENTITY_ID", fileVars, override)
-	clientID := getenv("MOLTNET_CLIENT_ID", fileVars, override)
-	clientSecret := getenv("MOLTNET_CLIENT_SECRET", fileVars, override)
-	publicKey := getenv("MOLTNET_PUBLIC_KEY", fileVars, override)
-	privateKey := getenv("MOLTNET_PRIVATE_KEY", fileVars, override)
-	fingerprint := getenv("MOLTNET_FINGERPRINT", fileVars, override)
+	identityID := getenv(envVar("IDENTITY_ID"), fileVars, override)
+	clientID := getenv(envVar("CLIENT_ID"), fileVars, override)
+	clientSecret := getenv(envVar("CLIENT_SECRET"), fileVars, override)
+	publicKey := getenv(envVar("PUBLIC_KEY"), fileVars, override)
+	privateKey := getenv(envVar("PRIVATE_KEY"), fileVars, override)
+	fingerprint := getenv(envVar("FINGERPRINT"), fileVars, override)
 
 	var missing []string
 	if identityID == "" {
-		missing = append(missing, "MOLTNET_IDENTITY_ID")
+		missing = append(missing, envVar("IDENTITY_ID"))
 	}
 	if clientID == "" {
-		missing = append(missing, "MOLTNET_CLIENT_ID")
+		missing = append(missing, envVar("CLIENT_ID"))
 	}
 	if clientSecret == "" {
-		missing = append(missing, "MOLTNET_CLIENT_SECRET")
+		missing = append(missing, envVar("CLIENT_SECRET"))
 	}
 	if publicKey == "" {
-		missing = append(missing, "MOLTNET_PUBLIC_KEY")
+		missing = append(missing, envVar("PUBLIC_KEY"))
 	}
 	if privateKey == "" {
-		missing = append(missing, "MOLTNET_PRIVATE_KEY")
+		missing = append(missing, envVar("PRIVATE_KEY"))
 	}
 	if fingerprint == "" {
-		missing = append(missing, "MOLTNET_FINGERPRINT")
+		missing = append(missing, envVar("FINGERPRINT"))
 	}
 	if len(missing) > 0 {
 		return fmt.Errorf("missing required environment variables: %s", strings.Join(missing, ", "))
 	}
 
 	// Optional env vars with defaults
-	apiURL := getenv("MOLTNET_API_URL", fileVars, override)
+	apiURL := getenv(envVar("API_URL"), fileVars, override)
 	if apiURL == "" {
 		apiURL = defaultAPIURL
 	}
 	apiURL = strings.TrimRight(apiURL, "/")
 
-	registeredAt := getenv("MOLTNET_REGISTERED_AT", fileVars, override)
+	registeredAt := getenv(envVar("REGISTERED_AT"), fileVars, override)
 	if registeredAt == "" {
 		registeredAt = time.Now().UTC().Format(time.RFC3339Nano)
 	}
@@ -152,12 +152,12 @@ func runConfigInitFromEnvCmd(dir, agentName string, skipGit bool, envFile string
 	}
 
 	// Optional GitHub App section
-	ghAppID := getenv("MOLTNET_GITHUB_APP_ID", fileVars, override)
-	ghInstallID := getenv("MOLTNET_GITHUB_APP_INSTALLATION_ID", fileVars, override)
+	ghAppID := getenv(envVar("GITHUB_APP_ID"), fileVars, override)
+	ghInstallID := getenv(envVar("GITHUB_APP_INSTALLATION_ID"), fileVars, override)
 	ghAppPEM := normalizePEMEnvValue(
-		getenv("MOLTNET_GITHUB_APP_PRIVATE_KEY", fileVars, override),
+		getenv(envVar("GITHUB_APP_PRIVATE_KEY"), fileVars, override),
 	)
-	ghAppSlug := getenv("MOLTNET_GITHUB_APP_SLUG", fileVars, override)
+	ghAppSlug := getenv(envVar("GITHUB_APP_SLUG"), fileVars, override)
 	if ghAppID != "" && ghInstallID != "" && ghAppPEM != "" {
 		pemPath := filepath.Join(agentDir, ghAppSlug+".pem")
 		if ghAppSlug == "" {
@@ -188,8 +188,8 @@ func runConfigInitFromEnvCmd(dir, agentName string, skipGit bool, envFile string
 
 	// Set up git signing (reuses existing logic)
 	if !skipGit {
-		gitName := getenv("MOLTNET_GIT_NAME", fileVars, override)
-		gitEmail := getenv("MOLTNET_GIT_EMAIL", fileVars, override)
+		gitName := getenv(envVar("GIT_NAME"), fileVars, override)
+		gitEmail := getenv(envVar("GIT_EMAIL"), fileVars, override)
 		if gitName == "" {
 			gitName = agentName
 		}
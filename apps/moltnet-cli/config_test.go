@@ -127,3 +127,84 @@ func TestBuildMcpConfig(t *testing.T) {
 		t.Errorf("X-Client-Secret: got %s", srv.Headers["X-Client-Secret"])
 	}
 }
+
+func TestBuildMaskedMcpConfig(t *testing.T) {
+	config := BuildMaskedMcpConfig("https://mcp.themolt.net/mcp")
+	srv := config.McpServers["moltnet"]
+	if srv.URL != "https://mcp.themolt.net/mcp" {
+		t.Errorf("url: got %s", srv.URL)
+	}
+	if srv.Headers["X-Client-Id"] != "${env:MOLTNET_CLIENT_ID}" {
+		t.Errorf("X-Client-Id: got %s", srv.Headers["X-Client-Id"])
+	}
+	if srv.Headers["X-Client-Secret"] != "${env:MOLTNET_CLIENT_SECRET}" {
+		t.Errorf("X-Client-Secret: got %s", srv.Headers["X-Client-Secret"])
+	}
+}
+
+func TestWriteMcpConfig_Permissions(t *testing.T) {
+	dir := t.TempDir()
+	config := BuildMcpConfig("https://mcp.themolt.net/mcp", "id", "secret")
+
+	path, err := WriteMcpConfig(config, dir)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("new file mode: got %o, want 600", perm)
+	}
+}
+
+func TestWriteMcpConfig_MergeTightensPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".mcp.json")
+	existing := `{"mcpServers": {"other": {"type": "http", "url": "http://other.com/mcp"}}}`
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	config := BuildMcpConfig("https://mcp.themolt.net/mcp", "id", "secret")
+	if _, err := WriteMcpConfig(config, dir); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("merged file mode: got %o, want 600", perm)
+	}
+}
+
+func TestWriteMcpConfig_ReplacesExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".mcp.json")
+	if err := os.WriteFile(path, []byte(`{"mcpServers": {}}`), 0o644); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	if _, err := WriteMcpConfig(BuildMcpConfig("https://mcp.themolt.net/mcp", "id", "secret"), dir); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	// A new 0600 file took its place: the secret was never written into
+	// the world-readable one.
+	if os.SameFile(before, after) {
+		t.Error(".mcp.json was rewritten in place")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/term"
+)
+
+// assumeYes holds the global --assume-yes set by the root command: every
+// confirmation is answered yes, for automation.
+var assumeYes atomic.Bool
+
+func setAssumeYes(v bool) { assumeYes.Store(v) }
+
+func assumingYes() bool { return assumeYes.Load() }
+
+// confirmAction is the confirmation every command asks before a
+// destructive or bulk change. Under --assume-yes it answers yes without
+// asking; otherwise it prompts on the terminal, and without one it refuses
+// instead of hanging on a read or defaulting to yes.
+func confirmAction(prompt string) (bool, error) {
+	if assumingYes() {
+		return true, nil
+	}
+	return confirmPrompt(prompt)
+}
+
+// confirmPrompt asks prompt on the terminal, defaulting to no. Tests
+// replace it.
+var confirmPrompt = func(prompt string) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, &usageError{err: fmt.Errorf("%s: stdin is not a terminal; pass --assume-yes to confirm", prompt)}
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, nil
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
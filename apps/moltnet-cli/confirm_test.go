@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirmAction_AssumeYes(t *testing.T) {
+	t.Cleanup(func() { setAssumeYes(false) })
+	orig := confirmPrompt
+	t.Cleanup(func() { confirmPrompt = orig })
+	asked := 0
+	confirmPrompt = func(string) (bool, error) { asked++; return false, nil }
+
+	if ok, err := confirmAction("Delete everything?"); ok || err != nil || asked != 1 {
+		t.Errorf("without --assume-yes: ok %v, err %v, asked %d; want the prompt's no", ok, err, asked)
+	}
+	setAssumeYes(true)
+	if ok, err := confirmAction("Delete everything?"); !ok || err != nil || asked != 1 {
+		t.Errorf("with --assume-yes: ok %v, err %v, asked %d; want yes without asking", ok, err, asked)
+	}
+}
+
+func TestConfirmPrompt_RefusesWithoutTerminal(t *testing.T) {
+	// go test runs with stdin redirected, so this is the non-interactive path.
+	ok, err := confirmPrompt("Overwrite the key?")
+	if ok || errorExitCode(err) != 2 || !strings.Contains(err.Error(), "--assume-yes") {
+		t.Errorf("confirmPrompt() = %v, %v; want a usage error naming --assume-yes", ok, err)
+	}
+}
+
+func TestRootCmd_AssumeYesFlag(t *testing.T) {
+	t.Cleanup(func() { setAssumeYes(false); requestedAPIVersion.Store(nil) })
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "-y", "version"); err != nil {
+		t.Fatalf("-y version: %v", err)
+	}
+	if !assumingYes() {
+		t.Error("-y did not set --assume-yes")
+	}
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "version"); err != nil {
+		t.Fatalf("version: %v", err)
+	}
+	if assumingYes() {
+		t.Error("--assume-yes leaked into the next run")
+	}
+}
@@ -7,21 +7,112 @@ import (
 	"path/filepath"
 )
 
+// currentConfigSchemaVersion is the moltnet.json schema this CLI writes.
+// Configs without a schema_version predate versioning and count as v1.
+const currentConfigSchemaVersion = 2
+
 // CredentialsFile matches the JS SDK MoltNetConfig format.
 type CredentialsFile struct {
-	IdentityID   string               `json:"identity_id"`
-	OAuth2       CredentialsOAuth2    `json:"oauth2"`
-	Keys         CredentialsKeys      `json:"keys"`
-	Endpoints    CredentialsEndpoints `json:"endpoints"`
-	RegisteredAt string               `json:"registered_at"`
-	SSH          *SSHSection          `json:"ssh,omitempty"`
-	Git          *GitSection          `json:"git,omitempty"`
-	GitHub       *GitHubSection       `json:"github,omitempty"`
+	SchemaVersion int                  `json:"schema_version,omitempty"`
+	IdentityID    string               `json:"identity_id"`
+	OAuth2        CredentialsOAuth2    `json:"oauth2"`
+	Keys          CredentialsKeys      `json:"keys"`
+	Endpoints     CredentialsEndpoints `json:"endpoints"`
+	RegisteredAt  string               `json:"registered_at"`
+	SSH           *SSHSection          `json:"ssh,omitempty"`
+	Git           *GitSection          `json:"git,omitempty"`
+	GitHub        *GitHubSection       `json:"github,omitempty"`
+	Requests      *RequestsSection     `json:"requests,omitempty"`
+	// Networks maps --network names to API base URLs, adding to or
+	// overriding builtinNetworks.
+	Networks map[string]string `json:"networks,omitempty"`
+
+	// migrated records what ReadConfigFrom upgraded on load, so callers
+	// like config repair can report it and persist the result.
+	migrated *configMigrationResult
+}
+
+// configSchemaVersion returns the effective schema version of a config.
+func configSchemaVersion(c *CredentialsFile) int {
+	if c.SchemaVersion == 0 {
+		return 1
+	}
+	return c.SchemaVersion
+}
+
+// configMigrationChange is one field a migration filled in or rewrote.
+type configMigrationChange struct {
+	Field  string
+	Detail string
+}
+
+// configMigration upgrades a config from Version-1 to Version. Apply
+// mutates the config in place and reports what it changed.
+type configMigration struct {
+	Version int
+	Notes   []string
+	Apply   func(c *CredentialsFile) []configMigrationChange
+}
+
+// configMigrationResult summarises a migration run.
+type configMigrationResult struct {
+	FromVersion int
+	Changes     []configMigrationChange
+}
+
+// configMigrations is the ordered migration registry. To evolve the
+// format, bump currentConfigSchemaVersion and append a step here; never
+// edit a released step.
+var configMigrations = []configMigration{
+	{
+		Version: 2,
+		Notes: []string{
+			"schema_version field added to moltnet.json",
+			"endpoints.mcp is required and derived from endpoints.api",
+		},
+		Apply: func(c *CredentialsFile) []configMigrationChange {
+			if c.Endpoints.MCP != "" || c.Endpoints.API == "" {
+				return nil
+			}
+			c.Endpoints.MCP = deriveMCPURL(c.Endpoints.API)
+			return []configMigrationChange{{Field: "endpoints.mcp", Detail: "missing — derived from API endpoint"}}
+		},
+	},
+}
+
+// migrateConfigSchema runs every registered migration newer than the
+// config's version and bumps SchemaVersion. It returns nil when the config
+// is already current (or newer than this CLI knows about).
+func migrateConfigSchema(c *CredentialsFile) *configMigrationResult {
+	from := configSchemaVersion(c)
+	if from >= currentConfigSchemaVersion {
+		return nil
+	}
+	result := &configMigrationResult{FromVersion: from}
+	for _, m := range configMigrations {
+		if m.Version <= from {
+			continue
+		}
+		result.Changes = append(result.Changes, m.Apply(c)...)
+		c.SchemaVersion = m.Version
+	}
+	c.SchemaVersion = currentConfigSchemaVersion
+	return result
 }
 
 type CredentialsOAuth2 struct {
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
+	// TokenEndpoint overrides where tokens are minted: a URL, or
+	// "discover" to read it from the network's OAuth metadata. Empty
+	// uses the API's /oauth2/token.
+	TokenEndpoint string `json:"token_endpoint,omitempty"`
+	// Scopes are requested with every token grant (--oauth-scope overrides
+	// them). Empty requests none and gets the network default.
+	Scopes []string `json:"scopes,omitempty"`
+	// TrustedOrigins are origins (scheme://host[:port]) other than the
+	// API's that discovered OAuth endpoints may point to.
+	TrustedOrigins []string `json:"trusted_origins,omitempty"`
 }
 
 type CredentialsKeys struct {
@@ -47,6 +138,13 @@ type GitSection struct {
 	ConfigPath string `json:"config_path"`
 }
 
+// RequestsSection configures how API requests are made.
+type RequestsSection struct {
+	// Sign attaches an Ed25519 request signature to every API call, as
+	// with --sign-requests.
+	Sign bool `json:"sign"`
+}
+
 type GitHubSection struct {
 	AppID          string `json:"app_id"`
 	AppSlug        string `json:"app_slug,omitempty"`
@@ -55,16 +153,27 @@ type GitHubSection struct {
 	Org            string `json:"org,omitempty"`
 }
 
-// GetConfigDir returns ~/.config/moltnet.
+// configDirEnvVar replaces ~/.config/moltnet entirely, for hosts without a
+// home directory (minimal containers, some init systems).
+const configDirEnvVar = "MOLTNET_CONFIG_DIR"
+
+// GetConfigDir returns $MOLTNET_CONFIG_DIR when set, else ~/.config/moltnet.
 func GetConfigDir() (string, error) {
+	if dir := os.Getenv(configDirEnvVar); dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", configDirEnvVar, err)
+		}
+		return abs, nil
+	}
 	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("get home dir: %w", err)
+	if err != nil || home == "" {
+		return "", expectedErrorf("cannot locate the moltnet config directory: $HOME is not set; set %s to a writable directory, or pass --credentials", configDirEnvVar)
 	}
 	return filepath.Join(home, ".config", "moltnet"), nil
 }
 
-// GetConfigPath returns ~/.config/moltnet/moltnet.json.
+// GetConfigPath returns moltnet.json in the config directory.
 func GetConfigPath() (string, error) {
 	dir, err := GetConfigDir()
 	if err != nil {
@@ -105,9 +214,13 @@ func ReadConfig() (*CredentialsFile, error) {
 	return nil, nil
 }
 
-// ReadConfigFrom reads and parses a config file at the given path.
+// ReadConfigFrom reads and parses a config file at the given path,
+// upgrading older schemas in memory via the migration registry. The file
+// itself is only rewritten by a later WriteConfigTo. Repeated reads are
+// served from the in-process cache (see configCache); each call decodes a
+// fresh struct, so callers may modify the result.
 func ReadConfigFrom(path string) (*CredentialsFile, error) {
-	data, err := os.ReadFile(path)
+	data, err := readConfigFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -118,10 +231,11 @@ func ReadConfigFrom(path string) (*CredentialsFile, error) {
 	if err := json.Unmarshal(data, &creds); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
+	creds.migrated = migrateConfigSchema(&creds)
 	return &creds, nil
 }
 
-// WriteConfig writes config to ~/.config/moltnet/moltnet.json with mode 0o600.
+// WriteConfig writes config to moltnet.json in the config directory with mode 0o600.
 func WriteConfig(config *CredentialsFile) (string, error) {
 	dir, err := GetConfigDir()
 	if err != nil {
@@ -130,8 +244,12 @@ func WriteConfig(config *CredentialsFile) (string, error) {
 	return WriteConfigTo(config, filepath.Join(dir, "moltnet.json"))
 }
 
-// WriteConfigTo writes config to the specified path with mode 0o600.
+// WriteConfigTo writes config to the specified path with mode 0o600,
+// stamping the current schema version.
 func WriteConfigTo(config *CredentialsFile, path string) (string, error) {
+	if config.SchemaVersion < currentConfigSchemaVersion {
+		config.SchemaVersion = currentConfigSchemaVersion
+	}
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o700); err != nil {
 		return "", fmt.Errorf("create config dir: %w", err)
@@ -143,7 +261,8 @@ func WriteConfigTo(config *CredentialsFile, path string) (string, error) {
 	}
 	data = append(data, '\n')
 
-	if err := os.WriteFile(path, data, 0o600); err != nil {
+	err = writeConfigFile(path, data, func() error { return os.WriteFile(path, data, 0o600) })
+	if err != nil {
 		return "", fmt.Errorf("write config: %w", err)
 	}
 
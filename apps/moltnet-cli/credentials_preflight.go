@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errConfigCheckPassed ends a --config-check run successfully without
+// running the command.
+var errConfigCheckPassed = errors.New("config check passed")
+
+// requireClientCredentials is the preflight shared by every command that
+// authenticates. When the OAuth2 pair is incomplete it names the missing
+// field and where the credentials came from, and points at the fix: a
+// legacy or stale config to repair, a half-set environment, or, failing
+// those, registration. The error matches errMissingClientCredentials.
+func requireClientCredentials(creds *CredentialsFile, credPath string) error {
+	var missing []string
+	if creds.OAuth2.ClientID == "" {
+		missing = append(missing, "client_id")
+	}
+	if creds.OAuth2.ClientSecret == "" {
+		missing = append(missing, "client_secret")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("%s is missing %s", credentialSource(credPath), strings.Join(missing, " and "))
+	hints := missingCredentialHints(creds, credPath)
+	for _, h := range hints {
+		msg += "\n  - " + h
+	}
+	return fmt.Errorf("%w: %s", errMissingClientCredentials, msg)
+}
+
+// credentialSource describes where resolveCredentials reads from.
+func credentialSource(credPath string) string {
+	if credPath != "" {
+		return credPath
+	}
+	if envPrefixOverride.Load() != nil {
+		return "the " + envPrefix() + "* environment"
+	}
+	if dir, err := GetConfigDir(); err == nil {
+		for _, name := range []string{"moltnet.json", "credentials.json"} {
+			if path := filepath.Join(dir, name); fileExists(path) {
+				return path
+			}
+		}
+	}
+	return "the " + envPrefix() + "* environment"
+}
+
+// missingCredentialHints suggests how to fix an incomplete credential set,
+// most specific first; registering is the fallback.
+func missingCredentialHints(creds *CredentialsFile, credPath string) []string {
+	var hints []string
+	if dir, err := GetConfigDir(); err == nil && credPath == "" {
+		if legacy := filepath.Join(dir, "credentials.json"); fileExists(legacy) {
+			hints = append(hints, fmt.Sprintf("a legacy %s exists; run 'moltnet config repair' to migrate it", legacy))
+		}
+	}
+	if m := creds.migrated; m != nil {
+		hints = append(hints, fmt.Sprintf("the config uses schema v%d (current v%d); run 'moltnet config repair'", m.FromVersion, currentConfigSchemaVersion))
+	}
+	var set, unset []string
+	for _, v := range credentialEnvVars {
+		if !v.Required {
+			continue
+		}
+		if strings.TrimSpace(os.Getenv(envVar(v.Name))) != "" {
+			set = append(set, envVar(v.Name))
+		} else {
+			unset = append(unset, envVar(v.Name))
+		}
+	}
+	switch {
+	case len(set) > 0 && len(unset) > 0:
+		hints = append(hints, fmt.Sprintf("%s is set but %s is not", strings.Join(set, ", "), strings.Join(unset, ", ")))
+	case len(set) > 0 && envPrefixOverride.Load() == nil:
+		hints = append(hints, fmt.Sprintf("%s are set but ignored while a config file exists; pass --env-prefix %s to use them", strings.Join(set, " and "), envPrefix()))
+	}
+	if len(hints) == 0 {
+		hints = append(hints, "run 'moltnet register' to create credentials")
+	}
+	return hints
+}
+
+// runConfigCheck is the --config-check preflight: it loads credentials as
+// an authed command would, reports the outcome and stops the command.
+func runConfigCheck(credPath string) error {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	if err := requireClientCredentials(creds, credPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Credentials OK: %s (client %s)\n", credentialSource(credPath), creds.OAuth2.ClientID)
+	return errConfigCheckPassed
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
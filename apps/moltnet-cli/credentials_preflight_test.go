@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequireClientCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(configDirEnvVar, "")
+	t.Setenv("MOLTNET_CLIENT_ID", "")
+	t.Setenv("MOLTNET_CLIENT_SECRET", "")
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+
+	if err := requireClientCredentials(&CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "a", ClientSecret: "b"}}, credPath); err != nil {
+		t.Fatalf("complete credentials: %v", err)
+	}
+
+	err := requireClientCredentials(&CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "a"}}, credPath)
+	if !errors.Is(err, errMissingClientCredentials) || errorExitCode(err) != exitCodeExpected {
+		t.Fatalf("expected missing-credentials error, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, credPath+" is missing client_secret") || !strings.Contains(msg, "moltnet register") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	t.Setenv("MOLTNET_CLIENT_ID", "from-env")
+	err = requireClientCredentials(&CredentialsFile{}, credPath)
+	if msg := err.Error(); !strings.Contains(msg, "MOLTNET_CLIENT_ID is set but MOLTNET_CLIENT_SECRET is not") || strings.Contains(msg, "moltnet register") {
+		t.Errorf("expected partial env hint, got: %s", msg)
+	}
+}
+
+func TestRequireClientCredentials_LegacyConfigHint(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(configDirEnvVar, dir)
+	t.Setenv("MOLTNET_CLIENT_ID", "")
+	t.Setenv("MOLTNET_CLIENT_SECRET", "")
+	if err := os.WriteFile(filepath.Join(dir, "credentials.json"), []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := requireClientCredentials(&CredentialsFile{}, "")
+
+	if err == nil || !strings.Contains(err.Error(), "config repair") {
+		t.Errorf("expected config repair hint, got %v", err)
+	}
+}
+
+func TestRunConfigCheck(t *testing.T) {
+	_, credPath := newCLICommandTestServer(t, &stubDiaryHandler{})
+
+	if err := runConfigCheck(credPath); !errors.Is(err, errConfigCheckPassed) {
+		t.Errorf("complete config: got %v", err)
+	}
+}
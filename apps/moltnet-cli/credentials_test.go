@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -253,3 +254,103 @@ func TestOptionalSections_OmitEmpty(t *testing.T) {
 		t.Error("github should be omitted when nil")
 	}
 }
+
+func TestConfigMigrations_Registry(t *testing.T) {
+	prev := 1
+	for _, m := range configMigrations {
+		if m.Version != prev+1 {
+			t.Fatalf("migration versions must be consecutive: got v%d after v%d", m.Version, prev)
+		}
+		if m.Apply == nil || len(m.Notes) == 0 {
+			t.Errorf("migration v%d missing Apply or Notes", m.Version)
+		}
+		prev = m.Version
+	}
+	if prev != currentConfigSchemaVersion {
+		t.Errorf("last migration is v%d, current schema is v%d", prev, currentConfigSchemaVersion)
+	}
+}
+
+func TestReadConfigFrom_MigratesLegacySchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "moltnet.json")
+	legacy := `{"identity_id":"x","endpoints":{"api":"https://api.themolt.net"}}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := ReadConfigFrom(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if creds.SchemaVersion != currentConfigSchemaVersion {
+		t.Errorf("schema_version = %d, want %d", creds.SchemaVersion, currentConfigSchemaVersion)
+	}
+	if creds.Endpoints.MCP != "https://mcp.themolt.net/mcp" {
+		t.Errorf("endpoints.mcp = %q", creds.Endpoints.MCP)
+	}
+	if creds.migrated == nil || creds.migrated.FromVersion != 1 || len(creds.migrated.Changes) != 1 {
+		t.Errorf("migration result: got %+v", creds.migrated)
+	}
+
+	// Loading is in-memory only: the file keeps its original shape.
+	data, _ := os.ReadFile(path)
+	if string(data) != legacy {
+		t.Errorf("ReadConfigFrom must not rewrite the file, got %s", data)
+	}
+}
+
+func TestReadConfigFrom_CurrentSchemaNotMigrated(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{IdentityID: "x"}, path); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var raw map[string]any
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["schema_version"] != float64(currentConfigSchemaVersion) {
+		t.Errorf("WriteConfigTo should stamp schema_version, got %v", raw["schema_version"])
+	}
+
+	creds, err := ReadConfigFrom(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if creds.migrated != nil {
+		t.Errorf("current config should not be migrated, got %+v", creds.migrated)
+	}
+}
+
+func TestGetConfigDir_EnvOverride(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", "")
+	t.Setenv(configDirEnvVar, dir)
+
+	got, err := GetConfigDir()
+
+	if err != nil || got != dir {
+		t.Fatalf("GetConfigDir() = %q, %v; want %q", got, err, dir)
+	}
+	path, err := GetConfigPath()
+	if err != nil || path != filepath.Join(dir, "moltnet.json") {
+		t.Errorf("GetConfigPath() = %q, %v", path, err)
+	}
+}
+
+func TestGetConfigDir_NoHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv(configDirEnvVar, "")
+
+	_, err := GetConfigDir()
+
+	if err == nil || !strings.Contains(err.Error(), configDirEnvVar) {
+		t.Fatalf("expected diagnostic naming %s, got %v", configDirEnvVar, err)
+	}
+	if errorExitCode(err) != exitCodeExpected {
+		t.Errorf("exit code = %d, want %d", errorExitCode(err), exitCodeExpected)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -26,7 +27,10 @@ func GenerateKeyPair() (*KeyPair, error) {
 	if err != nil {
 		return nil, fmt.Errorf("keygen failed: %w", err)
 	}
-	return keyPairFromRaw(priv.Seed(), pub)
+	defer zeroBytes(priv)
+	seed := priv.Seed()
+	defer zeroBytes(seed)
+	return keyPairFromRaw(seed, pub)
 }
 
 // KeyPairFromSeed derives a keypair from a 32-byte seed (for testing).
@@ -35,6 +39,7 @@ func KeyPairFromSeed(seed []byte) (*KeyPair, error) {
 		return nil, fmt.Errorf("seed must be %d bytes, got %d", ed25519.SeedSize, len(seed))
 	}
 	priv := ed25519.NewKeyFromSeed(seed)
+	defer zeroBytes(priv)
 	pub := priv.Public().(ed25519.PublicKey)
 	return keyPairFromRaw(seed, pub)
 }
@@ -91,30 +96,81 @@ func BuildSigningBytes(message, nonce string) []byte {
 
 // SignForRequest signs a (message, nonce) pair using BuildSigningBytes.
 func SignForRequest(message, nonce, privateKeyBase64 string) (string, error) {
-	seed, err := base64.StdEncoding.DecodeString(privateKeyBase64)
-	if err != nil {
-		return "", fmt.Errorf("decode private key: %w", err)
-	}
-	priv := ed25519.NewKeyFromSeed(seed)
-	signingBytes := BuildSigningBytes(message, nonce)
-	sig := ed25519.Sign(priv, signingBytes)
-	return base64.StdEncoding.EncodeToString(sig), nil
+	return signWithSeed(privateKeyBase64, BuildSigningBytes(message, nonce))
 }
 
-// VerifyForRequest verifies a signature produced by SignForRequest.
+// VerifyForRequest verifies a signature produced by SignForRequest. A
+// malformed signature or key is an error wrapping ErrBadSignatureEncoding
+// or ErrBadPublicKey; a well-formed signature that does not verify is
+// (false, nil).
 func VerifyForRequest(message, nonce, signatureBase64, publicKey string) (bool, error) {
-	pubBytes, err := ParsePublicKey(publicKey)
+	pubBytes, err := parseVerifyingKey(publicKey)
 	if err != nil {
 		return false, err
 	}
-	sig, err := base64.StdEncoding.DecodeString(signatureBase64)
+	sig, err := decodeSignature(signatureBase64)
 	if err != nil {
-		return false, fmt.Errorf("decode signature: %w", err)
+		return false, err
 	}
 	signingBytes := BuildSigningBytes(message, nonce)
 	return ed25519.Verify(pubBytes, signingBytes, sig), nil
 }
 
+// Reasons a signature fails verification, as reported by
+// crypto verify --json-report.
+const (
+	sigReasonEncoding  = "bad_signature_encoding"
+	sigReasonLength    = "bad_signature_length"
+	sigReasonPublicKey = "bad_public_key"
+	sigReasonMismatch  = "message_mismatch"  // verifies, but over other bytes than the request's
+	sigReasonInvalid   = "invalid_signature" // well-formed, does not verify
+)
+
+// Malformed verification input. Callers treat these as usage errors,
+// whereas a signature that does not verify is a trust decision.
+var (
+	ErrBadSignatureEncoding = errors.New("bad signature encoding")
+	ErrBadPublicKey         = errors.New("bad public key")
+)
+
+// signatureError is a verification failure tagged with its reason and,
+// for malformed input, the matching sentinel (ErrBadSignatureEncoding or
+// ErrBadPublicKey).
+type signatureError struct {
+	reason string
+	kind   error
+	err    error
+}
+
+func (e *signatureError) Error() string        { return e.err.Error() }
+func (e *signatureError) Unwrap() error        { return e.err }
+func (e *signatureError) Is(target error) bool { return e.kind != nil && target == e.kind }
+
+// decodeSignature decodes a base64 Ed25519 signature and checks its length.
+func decodeSignature(signatureBase64 string) ([]byte, error) {
+	sig, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return nil, &signatureError{reason: sigReasonEncoding, kind: ErrBadSignatureEncoding, err: fmt.Errorf("decode signature: %w", err)}
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return nil, &signatureError{reason: sigReasonLength, kind: ErrBadSignatureEncoding, err: fmt.Errorf("signature is %d bytes, want %d", len(sig), ed25519.SignatureSize)}
+	}
+	return sig, nil
+}
+
+// parseVerifyingKey parses a public key and checks its length, which
+// ed25519.Verify requires.
+func parseVerifyingKey(publicKey string) (ed25519.PublicKey, error) {
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, &signatureError{reason: sigReasonPublicKey, kind: ErrBadPublicKey, err: err}
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, &signatureError{reason: sigReasonPublicKey, kind: ErrBadPublicKey, err: fmt.Errorf("public key is %d bytes, want %d", len(pub), ed25519.PublicKeySize)}
+	}
+	return pub, nil
+}
+
 // ParsePublicKey extracts the raw bytes from an "ed25519:<base64>" string.
 func ParsePublicKey(publicKey string) (ed25519.PublicKey, error) {
 	b64 := strings.TrimPrefix(publicKey, "ed25519:")
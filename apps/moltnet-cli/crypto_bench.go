@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// benchNonce is the nonce used for benchmark signatures.
+const benchNonce = "moltnet:bench"
+
+// cryptoBenchResult is the timing of one benchmarked operation.
+type cryptoBenchResult struct {
+	Op         string  `json:"op"`
+	Iterations int     `json:"iterations"`
+	TotalMs    float64 `json:"totalMs"`
+	UsPerOp    float64 `json:"usPerOp"`
+	OpsPerSec  float64 `json:"opsPerSec"`
+}
+
+// cryptoBenchReport is the output of crypto bench.
+type cryptoBenchReport struct {
+	GOOS         string              `json:"goos"`
+	GOARCH       string              `json:"goarch"`
+	CPUs         int                 `json:"cpus"`
+	MessageBytes int                 `json:"messageBytes"`
+	Results      []cryptoBenchResult `json:"results"`
+}
+
+func benchOp(op string, iterations int, fn func() error) (cryptoBenchResult, error) {
+	start := time.Now()
+	for range iterations {
+		if err := fn(); err != nil {
+			return cryptoBenchResult{}, fmt.Errorf("crypto bench: %s: %w", op, err)
+		}
+	}
+	elapsed := time.Since(start)
+	return cryptoBenchResult{
+		Op:         op,
+		Iterations: iterations,
+		TotalMs:    float64(elapsed.Microseconds()) / 1000,
+		UsPerOp:    float64(elapsed.Nanoseconds()) / 1000 / float64(iterations),
+		OpsPerSec:  float64(iterations) / elapsed.Seconds(),
+	}, nil
+}
+
+// runCryptoBenchCmd times key generation, signing and verification with the
+// same primitives the CLI uses for requests, and prints ops/sec.
+func runCryptoBenchCmd(iterations, messageBytes int, w io.Writer) error {
+	if iterations <= 0 {
+		return fmt.Errorf("crypto bench: --iterations must be positive")
+	}
+	if messageBytes < 0 {
+		return fmt.Errorf("crypto bench: --message-bytes must not be negative")
+	}
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	message := strings.Repeat("m", messageBytes)
+	sig, err := SignForRequest(message, benchNonce, kp.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	ops := []struct {
+		name string
+		fn   func() error
+	}{
+		{"keygen", func() error {
+			_, err := GenerateKeyPair()
+			return err
+		}},
+		{"sign", func() error {
+			_, err := SignForRequest(message, benchNonce, kp.PrivateKey)
+			return err
+		}},
+		{"verify", func() error {
+			ok, err := VerifyForRequest(message, benchNonce, sig, kp.PublicKey)
+			if err == nil && !ok {
+				err = fmt.Errorf("signature did not verify")
+			}
+			return err
+		}},
+	}
+
+	report := cryptoBenchReport{
+		GOOS:         runtime.GOOS,
+		GOARCH:       runtime.GOARCH,
+		CPUs:         runtime.NumCPU(),
+		MessageBytes: messageBytes,
+	}
+	for _, op := range ops {
+		res, err := benchOp(op.name, iterations, op.fn)
+		if err != nil {
+			return err
+		}
+		report.Results = append(report.Results, res)
+	}
+	return printJSONTo(w, report)
+}
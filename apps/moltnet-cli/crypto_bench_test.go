@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRunCryptoBenchCmd(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	var out bytes.Buffer
+
+	// Act
+	err := runCryptoBenchCmd(5, 64, &out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runCryptoBenchCmd() error: %v", err)
+	}
+	var report cryptoBenchReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.MessageBytes != 64 || report.CPUs < 1 {
+		t.Errorf("unexpected report header: %+v", report)
+	}
+	want := []string{"keygen", "sign", "verify"}
+	if len(report.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), report.Results)
+	}
+	for i, r := range report.Results {
+		if r.Op != want[i] || r.Iterations != 5 || r.OpsPerSec <= 0 {
+			t.Errorf("result %d: got %+v", i, r)
+		}
+	}
+}
+
+func TestRunCryptoBenchCmd_RejectsBadIterations(t *testing.T) {
+	t.Parallel()
+	if err := runCryptoBenchCmd(0, 64, &bytes.Buffer{}); err == nil {
+		t.Error("expected error for zero iterations")
+	}
+}
+
+func TestCryptoBenchCmdHidden(t *testing.T) {
+	t.Parallel()
+	bench, _, err := newCryptoCmd().Find([]string{"bench"})
+	if err != nil || bench.Name() != "bench" || !bench.Hidden {
+		t.Errorf("crypto bench should exist and be hidden (err=%v)", err)
+	}
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 
 	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
 )
 
 // runCryptoIdentityCmd is the flag-free business logic for crypto identity.
@@ -24,8 +29,12 @@ func runCryptoIdentityCmd(apiURL, credPath string) error {
 	return printJSON(identity)
 }
 
-// runCryptoVerifyCmd is the flag-free business logic for crypto verify.
+// runCryptoVerifyCmd is the flag-free business logic for crypto verify. A
+// malformed signature is a usage error and never reaches the network.
 func runCryptoVerifyCmd(apiURL, credPath, signature string) error {
+	if _, err := decodeSignature(signature); err != nil {
+		return &usageError{err: fmt.Errorf("crypto verify: %w", err)}
+	}
 	client, err := newClientFromCreds(apiURL, credPath)
 	if err != nil {
 		return err
@@ -42,3 +51,136 @@ func runCryptoVerifyCmd(apiURL, credPath, signature string) error {
 	}
 	return printJSON(result)
 }
+
+// Reasons crypto verify --json-report gives besides the sigReason* values.
+const (
+	sigReasonUnknown      = "unknown_signature" // the network matched no signing request
+	sigReasonNotSubmitted = "not_submitted"     // verifies locally, but was never submitted
+)
+
+// verifyReport is the output of crypto verify --json-report.
+type verifyReport struct {
+	Valid     bool   `json:"valid"`
+	Reason    string `json:"reason,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// runCryptoVerifyReportCmd is crypto verify --json-report. The signature is
+// checked for encoding and length before the network is asked; when the
+// network rejects it and requestID names the signing request it answers,
+// the request is fetched and the signature checked locally against the
+// caller's key to tell a signature over the wrong bytes from an invalid
+// one. It prints the report and fails unless the signature is valid.
+func runCryptoVerifyReportCmd(w io.Writer, apiURL, credPath, signature, requestID string) error {
+	var rid uuid.UUID
+	if requestID != "" {
+		var err error
+		if rid, err = uuid.Parse(requestID); err != nil {
+			return fmt.Errorf("invalid request ID %q: %w", requestID, err)
+		}
+	}
+	report := verifyReport{RequestID: requestID}
+	sig, err := decodeSignature(signature)
+	if err != nil {
+		return printVerifyReport(w, report, err)
+	}
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	res, err := client.VerifyCryptoSignature(context.Background(), &moltnetapi.VerifyCryptoSignatureReq{
+		Signature: signature,
+	})
+	if err != nil {
+		return fmt.Errorf("crypto verify: %w", formatTransportError(err))
+	}
+	result, ok := res.(*moltnetapi.CryptoVerifyResult)
+	if !ok {
+		return formatAPIError(res)
+	}
+	if result.Valid {
+		report.Valid = true
+		return printVerifyReport(w, report, nil)
+	}
+	if requestID == "" {
+		return printVerifyReport(w, report, &signatureError{
+			reason: sigReasonUnknown,
+			err:    errors.New("no signing request matches this signature, or it does not verify against the signer's key; pass --request-id to diagnose"),
+		})
+	}
+	sreq, err := client.GetSigningRequest(context.Background(), moltnetapi.GetSigningRequestParams{ID: rid})
+	if err != nil {
+		return fmt.Errorf("fetch signing request: %w", formatTransportError(err))
+	}
+	signingReq, ok := sreq.(*moltnetapi.SigningRequest)
+	if !ok {
+		return formatAPIError(sreq)
+	}
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	pub, err := parseVerifyingKey(creds.Keys.PublicKey)
+	if err != nil {
+		return printVerifyReport(w, report, err)
+	}
+	return printVerifyReport(w, report, diagnoseSignature(pub, sig, signingReq))
+}
+
+// diagnoseSignature explains why a well-formed signature was rejected for a
+// signing request: it was never submitted, it covers bytes other than the
+// request's signing input, or it simply does not verify.
+func diagnoseSignature(pub ed25519.PublicKey, sig []byte, req *moltnetapi.SigningRequest) error {
+	nonce := req.Nonce.String()
+	signingInput := BuildSigningBytes(req.Message, nonce)
+	if raw, err := base64.StdEncoding.DecodeString(req.SigningInput); err == nil && len(raw) > 0 {
+		signingInput = raw
+	}
+	if ed25519.Verify(pub, signingInput, sig) {
+		return &signatureError{
+			reason: sigReasonNotSubmitted,
+			err:    fmt.Errorf("the signature is valid for request %s but was not submitted (status: %s); run 'moltnet sign --request-id %s'", req.ID, req.Status, req.ID),
+		}
+	}
+	probes := []struct {
+		bytes  []byte
+		detail string
+	}{
+		{[]byte(req.Message), "the signature covers the raw message, not the signing input with its nonce"},
+		{BuildSigningBytes(req.Message, ""), "the signature covers the message without the request's nonce"},
+		{[]byte(req.SigningInput), "the signature covers the base64 text of the signing input, not its decoded bytes"},
+	}
+	for _, p := range probes {
+		if ed25519.Verify(pub, p.bytes, sig) {
+			return &signatureError{reason: sigReasonMismatch, err: errors.New(p.detail)}
+		}
+	}
+	return &signatureError{
+		reason: sigReasonInvalid,
+		err:    fmt.Errorf("the signature is well-formed but does not verify against your key for request %s (nonce %s)", req.ID, nonce),
+	}
+}
+
+// printVerifyReport fills the report's reason from a verification error,
+// prints it, and returns an error when the signature is not valid: a usage
+// error when the signature is malformed.
+func printVerifyReport(w io.Writer, report verifyReport, verr error) error {
+	if verr != nil {
+		report.Reason, report.Detail = sigReasonInvalid, verr.Error()
+		var serr *signatureError
+		if errors.As(verr, &serr) {
+			report.Reason = serr.reason
+		}
+	}
+	if err := printJSONTo(w, report); err != nil {
+		return err
+	}
+	if errors.Is(verr, ErrBadSignatureEncoding) {
+		return &usageError{err: fmt.Errorf("crypto verify: %w", verr)}
+	}
+	if !report.Valid {
+		return fmt.Errorf("crypto verify: %s", report.Reason)
+	}
+	return nil
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"testing"
 
 	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
@@ -65,3 +70,82 @@ func TestCryptoVerify(t *testing.T) {
 		t.Error("expected valid=true")
 	}
 }
+
+// verifyReportHandler rejects every signature on /crypto/verify and serves
+// one pending signing request.
+type verifyReportHandler struct {
+	stubSigningHandler
+}
+
+func (h *verifyReportHandler) VerifyCryptoSignature(_ context.Context, _ *moltnetapi.VerifyCryptoSignatureReq) (moltnetapi.VerifyCryptoSignatureRes, error) {
+	return &moltnetapi.CryptoVerifyResult{Valid: false}, nil
+}
+
+func TestRunCryptoVerifyReportCmd(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("generate keypair: %v", err)
+	}
+	h := &verifyReportHandler{stubSigningHandler{
+		requestID: uuid.MustParse("00000000-0000-0000-0000-000000000099"),
+		message:   "hello from test",
+		nonce:     uuid.MustParse("00000000-0000-0000-0000-000000000042"),
+	}}
+	srv, credPath := newCLICommandTestServer(t, h)
+	creds, err := ReadConfigFrom(credPath)
+	if err != nil {
+		t.Fatalf("read config: %v", err)
+	}
+	creds.Keys = CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey, Fingerprint: kp.Fingerprint}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	seed, _ := base64.StdEncoding.DecodeString(kp.PrivateKey)
+	priv := ed25519.NewKeyFromSeed(seed)
+	signB64 := func(b []byte) string { return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, b)) }
+	valid, err := SignForRequest(h.message, h.nonce.String(), kp.PrivateKey)
+	if err != nil {
+		t.Fatalf("SignForRequest: %v", err)
+	}
+	other, err := SignForRequest("something else", h.nonce.String(), kp.PrivateKey)
+	if err != nil {
+		t.Fatalf("SignForRequest: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		signature string
+		requestID string
+		want      string
+	}{
+		{"bad base64", "not base64!", "", sigReasonEncoding},
+		{"wrong length", base64.StdEncoding.EncodeToString([]byte("short")), "", sigReasonLength},
+		{"no request", valid, "", sigReasonUnknown},
+		{"not submitted", valid, h.requestID.String(), sigReasonNotSubmitted},
+		{"raw message", signB64([]byte(h.message)), h.requestID.String(), sigReasonMismatch},
+		{"other message", other, h.requestID.String(), sigReasonInvalid},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := runCryptoVerifyReportCmd(&buf, srv.URL, credPath, tt.signature, tt.requestID)
+		if err == nil {
+			t.Errorf("%s: expected an error for an invalid signature", tt.name)
+		}
+		var report verifyReport
+		if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatalf("%s: decode report: %v (%q)", tt.name, err, buf.String())
+		}
+		if report.Valid || report.Reason != tt.want || report.Detail == "" {
+			t.Errorf("%s: report = %+v, want reason %q", tt.name, report, tt.want)
+		}
+	}
+}
+
+func TestRunCryptoVerifyCmd_MalformedSignatureIsUsageError(t *testing.T) {
+	t.Parallel()
+	srv, credPath := newCLICommandTestServer(t, &stubCryptoHandler{})
+	err := runCryptoVerifyCmd(srv.URL, credPath, "not base64!")
+	if errorExitCode(err) != exitCodeUsage || !errors.Is(err, ErrBadSignatureEncoding) {
+		t.Errorf("expected a usage error wrapping ErrBadSignatureEncoding, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -219,3 +220,36 @@ func TestSignForRequestRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyForRequestMalformedInput(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+	sig, err := SignForRequest("msg", "nonce", kp.PrivateKey)
+	if err != nil {
+		t.Fatalf("SignForRequest: %v", err)
+	}
+	tests := []struct {
+		name, sig, key, want string
+		sentinel             error
+	}{
+		{"bad base64", "%%%", kp.PublicKey, sigReasonEncoding, ErrBadSignatureEncoding},
+		{"short signature", base64.StdEncoding.EncodeToString([]byte("abc")), kp.PublicKey, sigReasonLength, ErrBadSignatureEncoding},
+		{"bad key encoding", sig, "ed25519:%%%", sigReasonPublicKey, ErrBadPublicKey},
+		{"short key", sig, "ed25519:" + base64.StdEncoding.EncodeToString([]byte("abc")), sigReasonPublicKey, ErrBadPublicKey},
+	}
+	for _, tt := range tests {
+		_, err := VerifyForRequest("msg", "nonce", tt.sig, tt.key)
+		var serr *signatureError
+		if !errors.As(err, &serr) || serr.reason != tt.want {
+			t.Errorf("%s: err = %v, want reason %s", tt.name, err, tt.want)
+		}
+		if !errors.Is(err, tt.sentinel) {
+			t.Errorf("%s: err = %v, want it to match %v", tt.name, err, tt.sentinel)
+		}
+	}
+	if ok, err := VerifyForRequest("other", "nonce", sig, kp.PublicKey); ok || err != nil {
+		t.Errorf("wrong message: got (%v, %v), want (false, nil)", ok, err)
+	}
+}
@@ -11,7 +11,12 @@ import (
 // --- Diary-level business logic ---
 
 // runDiaryListCmd lists all agent's diaries.
-func runDiaryListCmd(apiURL, credPath string) error {
+func runDiaryListCmd(apiURL, credPath, visibility string, window timeWindow) error {
+	if visibility != "" {
+		if err := moltnetapi.DiaryCatalogVisibility(visibility).Validate(); err != nil {
+			return fmt.Errorf("diary list: invalid --visibility %q (private, moltnet, public)", visibility)
+		}
+	}
 	client, err := newClientFromCreds(apiURL, credPath)
 	if err != nil {
 		return err
@@ -24,6 +29,16 @@ func runDiaryListCmd(apiURL, credPath string) error {
 	if !ok {
 		return formatAPIError(res)
 	}
+	// The API lists every diary; filters are applied here.
+	if visibility != "" || !window.isOpen() {
+		kept := list.Items[:0]
+		for _, d := range list.Items {
+			if (visibility == "" || string(d.Visibility) == visibility) && window.contains(d.CreatedAt) {
+				kept = append(kept, d)
+			}
+		}
+		list.Items = kept
+	}
 	return printJSON(list)
 }
 
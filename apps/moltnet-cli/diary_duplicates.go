@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// diaryDuplicatesOptions carries the diary find-duplicates flags.
+type diaryDuplicatesOptions struct {
+	threshold float64
+	delete    bool
+	dryRun    bool
+	mode      bulkMode
+}
+
+// duplicateEntryRef identifies an entry of a duplicate cluster.
+type duplicateEntryRef struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title,omitempty"`
+	Importance float64   `json:"importance"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// Similarity to the cluster's kept entry; unset on the kept entry.
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// duplicateCluster is an entry and the near-duplicates found for it.
+type duplicateCluster struct {
+	Keep       duplicateEntryRef   `json:"keep"`
+	Duplicates []duplicateEntryRef `json:"duplicates"`
+}
+
+// diaryDuplicatesReport is the output of diary find-duplicates. The bulk
+// counters only count deletions.
+type diaryDuplicatesReport struct {
+	DiaryID    string             `json:"diaryId"`
+	Threshold  float64            `json:"threshold"`
+	Scanned    int                `json:"scanned"`
+	Clusters   []duplicateCluster `json:"clusters"`
+	Duplicates int                `json:"duplicates"`
+	*bulkSummary
+}
+
+func newDuplicateEntryRef(e moltnetapi.DiaryEntry) duplicateEntryRef {
+	return duplicateEntryRef{ID: e.ID.String(), Title: e.Title.Or(""), Importance: e.Importance, CreatedAt: e.CreatedAt}
+}
+
+// clusterDuplicateEntries groups entries whose content similarity (see
+// contentSimilarity) reaches threshold. Entries are visited by importance,
+// highest first and oldest first among equals, and each unclaimed entry
+// claims every unclaimed entry similar to it. The claiming entry is the one
+// to keep, and every member of a cluster is a near-duplicate of it, not
+// merely of another member. Attachment blobs are skipped: their content is
+// encoded file data. The comparison is pairwise, so it suits diaries of up
+// to a few thousand entries.
+func clusterDuplicateEntries(entries []moltnetapi.DiaryEntry, threshold float64) []duplicateCluster {
+	type candidate struct {
+		entry moltnetapi.DiaryEntry
+		terms []string
+	}
+	var cands []candidate
+	for _, e := range entries {
+		if slices.Contains(e.Tags, attachmentBlobTag) {
+			continue
+		}
+		cands = append(cands, candidate{entry: e, terms: searchQueryTerms(e.Content)})
+	}
+	slices.SortStableFunc(cands, func(a, b candidate) int {
+		if a.entry.Importance != b.entry.Importance {
+			if a.entry.Importance > b.entry.Importance {
+				return -1
+			}
+			return 1
+		}
+		return a.entry.CreatedAt.Compare(b.entry.CreatedAt)
+	})
+
+	claimed := make([]bool, len(cands))
+	clusters := []duplicateCluster{}
+	for i, keep := range cands {
+		if claimed[i] {
+			continue
+		}
+		var dups []duplicateEntryRef
+		for j := i + 1; j < len(cands); j++ {
+			if claimed[j] {
+				continue
+			}
+			other := cands[j]
+			score := termSimilarity(keep.terms, other.terms, keep.entry.Content == other.entry.Content)
+			if score < threshold {
+				continue
+			}
+			claimed[j] = true
+			ref := newDuplicateEntryRef(other.entry)
+			ref.Similarity = score
+			dups = append(dups, ref)
+		}
+		if len(dups) > 0 {
+			clusters = append(clusters, duplicateCluster{Keep: newDuplicateEntryRef(keep.entry), Duplicates: dups})
+		}
+	}
+	return clusters
+}
+
+// runDiaryFindDuplicatesCmd reports clusters of near-duplicate entries in a
+// diary, compared client-side over the entries' content. With
+// opts.delete it deletes every entry of each cluster but the kept one
+// (the highest importance, then the oldest), after confirmation; see
+// confirmAction.
+func runDiaryFindDuplicatesCmd(apiURL, credPath, diaryID string, opts diaryDuplicatesOptions) error {
+	if opts.threshold <= 0 || opts.threshold > 1 {
+		return &usageError{err: fmt.Errorf("diary find-duplicates: --threshold must be in (0, 1]")}
+	}
+	diaryUUID, err := uuid.Parse(diaryID)
+	if err != nil {
+		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
+	}
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
+	if err != nil {
+		return err
+	}
+	entries, err := fetchAllDiaryEntries(context.Background(), client, diaryUUID, retagPageSize)
+	if err != nil {
+		return fmt.Errorf("diary find-duplicates: %w", err)
+	}
+
+	report := diaryDuplicatesReport{
+		DiaryID:   diaryUUID.String(),
+		Threshold: opts.threshold,
+		Scanned:   len(entries),
+		Clusters:  clusterDuplicateEntries(entries, opts.threshold),
+	}
+	for _, c := range report.Clusters {
+		report.Duplicates += len(c.Duplicates)
+		fmt.Fprintf(os.Stderr, "  keep %s (importance %g)\n", c.Keep.ID, c.Keep.Importance)
+		for _, d := range c.Duplicates {
+			fmt.Fprintf(os.Stderr, "    duplicate %s (similarity %.2f, importance %g)\n", d.ID, d.Similarity, d.Importance)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d entries scanned, %d duplicate(s) in %d cluster(s).\n", report.Scanned, report.Duplicates, len(report.Clusters))
+	if !opts.delete || report.Duplicates == 0 {
+		return printJSON(report)
+	}
+
+	if !opts.dryRun {
+		ok, err := confirmAction(fmt.Sprintf("Delete %d duplicate entr%s, keeping one per cluster?", report.Duplicates, pluralIes(report.Duplicates)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return expectedErrorf("diary find-duplicates: cancelled, nothing deleted")
+		}
+	}
+	run := newBulkRunner(opts.mode)
+deleting:
+	for _, c := range report.Clusters {
+		for _, d := range c.Duplicates {
+			if opts.dryRun {
+				run.succeed()
+				fmt.Fprintf(os.Stderr, "  [would delete] %s\n", d.ID)
+				continue
+			}
+			res, err := client.DeleteDiaryEntryById(context.Background(), moltnetapi.DeleteDiaryEntryByIdParams{EntryId: uuid.MustParse(d.ID)})
+			if err == nil {
+				if _, ok := res.(*moltnetapi.Success); !ok {
+					err = formatAPIError(res)
+				}
+			} else {
+				err = formatTransportError(err)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  [failed] %s: %v\n", d.ID, err)
+				if run.fail(d.ID, err) {
+					break deleting
+				}
+				continue
+			}
+			run.succeed()
+			fmt.Fprintf(os.Stderr, "  [deleted] %s\n", d.ID)
+		}
+	}
+	report.bulkSummary = &run.summary
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	return run.err("diary find-duplicates")
+}
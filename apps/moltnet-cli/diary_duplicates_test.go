@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+func dupTestEntry(content string, importance float64, minute int, tags ...string) moltnetapi.DiaryEntry {
+	e := *newTestEntry(content)
+	e.ID = uuid.New()
+	e.Importance = importance
+	e.CreatedAt = time.Date(2026, 3, 1, 12, minute, 0, 0, time.UTC)
+	e.Tags = append([]string{}, tags...)
+	return e
+}
+
+func TestClusterDuplicateEntries(t *testing.T) {
+	orig := dupTestEntry("Build failed: lockfile out of date after dependency bump", 5, 1)
+	reworded := dupTestEntry("build FAILED - lockfile out of date after dependency bump!", 5, 2)
+	important := dupTestEntry("Lockfile out of date after dependency bump: build failed", 8, 3)
+	unrelated := dupTestEntry("Deployed the staging environment with the new config", 5, 4)
+	blob := dupTestEntry("Build failed: lockfile out of date after dependency bump", 5, 5, attachmentBlobTag)
+
+	clusters := clusterDuplicateEntries([]moltnetapi.DiaryEntry{orig, reworded, important, unrelated, blob}, 0.9)
+	if len(clusters) != 1 {
+		t.Fatalf("clusters = %+v, want 1", clusters)
+	}
+	c := clusters[0]
+	if c.Keep.ID != important.ID.String() {
+		t.Errorf("kept %s, want the highest-importance entry %s", c.Keep.ID, important.ID)
+	}
+	if len(c.Duplicates) != 2 || c.Duplicates[0].ID != orig.ID.String() || c.Duplicates[1].ID != reworded.ID.String() {
+		t.Errorf("duplicates = %+v, want the two lower-importance copies, oldest first", c.Duplicates)
+	}
+	for _, d := range c.Duplicates {
+		if d.Similarity < 0.9 {
+			t.Errorf("%s: similarity %.2f below the threshold", d.ID, d.Similarity)
+		}
+	}
+
+	if got := clusterDuplicateEntries([]moltnetapi.DiaryEntry{orig, unrelated}, 0.9); len(got) != 0 {
+		t.Errorf("distinct entries clustered: %+v", got)
+	}
+}
+
+func TestClusterDuplicateEntries_MembersMatchTheKeptEntry(t *testing.T) {
+	// b is close to both a and c, but a and c are not close to each other:
+	// a cluster around a must not pull c in through b.
+	a := dupTestEntry("alpha beta gamma delta", 9, 1)
+	b := dupTestEntry("alpha beta gamma epsilon", 5, 2)
+	c := dupTestEntry("alpha beta zeta epsilon", 5, 3)
+	clusters := clusterDuplicateEntries([]moltnetapi.DiaryEntry{a, b, c}, 0.6)
+	for _, cl := range clusters {
+		if cl.Keep.ID == a.ID.String() {
+			for _, d := range cl.Duplicates {
+				if d.ID == c.ID.String() {
+					t.Errorf("c joined a's cluster through b: %+v", cl)
+				}
+			}
+		}
+	}
+}
+
+// duplicatesStubHandler lists a fixed set of entries and records deletes.
+type duplicatesStubHandler struct {
+	moltnetapi.UnimplementedHandler
+	entries []moltnetapi.DiaryEntry
+	deleted []uuid.UUID
+}
+
+func (h *duplicatesStubHandler) ListDiaryEntries(_ context.Context, _ moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	return &moltnetapi.DiaryList{Items: h.entries, Total: float64(len(h.entries)), Limit: float64(retagPageSize)}, nil
+}
+
+func (h *duplicatesStubHandler) DeleteDiaryEntryById(_ context.Context, params moltnetapi.DeleteDiaryEntryByIdParams) (moltnetapi.DeleteDiaryEntryByIdRes, error) {
+	h.deleted = append(h.deleted, params.EntryId)
+	return &moltnetapi.Success{}, nil
+}
+
+func newDuplicatesStubHandler() (*duplicatesStubHandler, moltnetapi.DiaryEntry) {
+	keep := dupTestEntry("Retry the flaky upload test before reporting it", 7, 1)
+	return &duplicatesStubHandler{entries: []moltnetapi.DiaryEntry{
+		keep,
+		dupTestEntry("retry the flaky upload test before reporting it", 3, 2),
+		dupTestEntry("Retry the flaky upload test, before reporting it.", 3, 3),
+		dupTestEntry("Unrelated note about release notes", 3, 4),
+	}}, keep
+}
+
+func TestRunDiaryFindDuplicatesCmd_ReportOnly(t *testing.T) {
+	h, _ := newDuplicatesStubHandler()
+	srv, credPath := newCLICommandTestServer(t, h)
+	opts := diaryDuplicatesOptions{threshold: defaultDedupeThreshold, mode: bulkFailFast}
+	if err := runDiaryFindDuplicatesCmd(srv.URL, credPath, testDiaryID.String(), opts); err != nil {
+		t.Fatalf("runDiaryFindDuplicatesCmd() error: %v", err)
+	}
+	if len(h.deleted) != 0 {
+		t.Errorf("deleted %v without --delete", h.deleted)
+	}
+}
+
+func TestRunDiaryFindDuplicatesCmd_Delete(t *testing.T) {
+	setAssumeYes(true)
+	t.Cleanup(func() { setAssumeYes(false) })
+	h, keep := newDuplicatesStubHandler()
+	srv, credPath := newCLICommandTestServer(t, h)
+	opts := diaryDuplicatesOptions{threshold: defaultDedupeThreshold, delete: true, mode: bulkFailFast}
+	if err := runDiaryFindDuplicatesCmd(srv.URL, credPath, testDiaryID.String(), opts); err != nil {
+		t.Fatalf("runDiaryFindDuplicatesCmd() error: %v", err)
+	}
+	if len(h.deleted) != 2 {
+		t.Fatalf("deleted %v, want the 2 duplicates", h.deleted)
+	}
+	for _, id := range h.deleted {
+		if id == keep.ID {
+			t.Errorf("deleted the kept entry %s", id)
+		}
+	}
+}
+
+func TestRunDiaryFindDuplicatesCmd_DeleteNeedsConfirmation(t *testing.T) {
+	orig := confirmPrompt
+	confirmPrompt = func(string) (bool, error) { return false, nil }
+	t.Cleanup(func() { confirmPrompt = orig })
+
+	h, _ := newDuplicatesStubHandler()
+	srv, credPath := newCLICommandTestServer(t, h)
+	opts := diaryDuplicatesOptions{threshold: defaultDedupeThreshold, delete: true, mode: bulkFailFast}
+	err := runDiaryFindDuplicatesCmd(srv.URL, credPath, testDiaryID.String(), opts)
+	if errorExitCode(err) != 3 {
+		t.Errorf("declined: err = %v, want an expected error", err)
+	}
+	if len(h.deleted) != 0 {
+		t.Errorf("deleted %v although declined", h.deleted)
+	}
+
+	opts.dryRun = true
+	if err := runDiaryFindDuplicatesCmd(srv.URL, credPath, testDiaryID.String(), opts); err != nil {
+		t.Fatalf("--dry-run: %v", err)
+	}
+	if len(h.deleted) != 0 {
+		t.Errorf("--dry-run deleted %v", h.deleted)
+	}
+}
+
+func TestRunDiaryFindDuplicatesCmd_InvalidThreshold(t *testing.T) {
+	for _, th := range []float64{0, 1.5} {
+		err := runDiaryFindDuplicatesCmd("http://127.0.0.1:1", "", testDiaryID.String(), diaryDuplicatesOptions{threshold: th})
+		if errorExitCode(err) != 2 {
+			t.Errorf("threshold %v: err = %v, want a usage error", th, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// exportStateSuffix names the cursor file written next to an export.
+const exportStateSuffix = ".moltnet-export-state"
+
+// defaultExportPageSize is the number of entries fetched per request.
+const defaultExportPageSize = 100
+
+// exportState is the resume cursor for a diary export. It is rewritten
+// after every page, so an interrupted run loses at most one page of work.
+type exportState struct {
+	DiaryID     string    `json:"diaryId"`
+	Offset      int       `json:"offset"`
+	LastEntryID string    `json:"lastEntryId,omitempty"`
+	Exported    int       `json:"exported"`
+	Total       int       `json:"total"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// exportSummary is printed when an export completes.
+type exportSummary struct {
+	DiaryID    string `json:"diaryId"`
+	Out        string `json:"out"`
+	Exported   int    `json:"exported"`
+	Duplicates int    `json:"duplicates"`
+	Total      int    `json:"total"`
+	Resumed    bool   `json:"resumed"`
+}
+
+// diaryExportOptions holds the flags of diary export.
+type diaryExportOptions struct {
+	diaryID  string
+	out      string
+	resume   bool
+	pageSize int
+}
+
+func exportStatePath(out string) string {
+	return out + exportStateSuffix
+}
+
+func loadExportState(path string) (*exportState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var st exportState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parse export state %s: %w", path, err)
+	}
+	return &st, nil
+}
+
+// saveExportState atomically replaces the cursor file.
+func saveExportState(path string, st *exportState) error {
+	st.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal export state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write export state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace export state: %w", err)
+	}
+	return nil
+}
+
+// prepareExportResume drops a partially written trailing line from an
+// existing NDJSON export and returns the IDs it already contains, so
+// re-fetched entries are not appended twice.
+func prepareExportResume(f *os.File) (map[string]bool, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read existing export: %w", err)
+	}
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		if err := f.Truncate(int64(end)); err != nil {
+			return nil, fmt.Errorf("truncate partial line: %w", err)
+		}
+		data = data[:end]
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("existing export is not NDJSON: %w", err)
+		}
+		seen[line.ID] = true
+	}
+	return seen, scanner.Err()
+}
+
+// runDiaryExportCmd writes every entry of a diary to opts.out as NDJSON.
+// Progress is checkpointed to a sidecar cursor after each page; with
+// opts.resume, a previous run is continued instead of restarted. Resumed
+// runs re-read one page before the cursor to absorb entries deleted in the
+// meantime, and skip entries already present in the file.
+func runDiaryExportCmd(apiURL, credPath string, opts diaryExportOptions, w io.Writer) error {
+	diaryUUID, err := uuid.Parse(opts.diaryID)
+	if err != nil {
+		return fmt.Errorf("invalid diary ID %q: %w", opts.diaryID, err)
+	}
+	if opts.out == "" {
+		return fmt.Errorf("diary export: --out is required")
+	}
+	if opts.pageSize <= 0 {
+		opts.pageSize = defaultExportPageSize
+	}
+	statePath := exportStatePath(opts.out)
+
+	st := &exportState{DiaryID: diaryUUID.String()}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if opts.resume {
+		prev, err := loadExportState(statePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("diary export: no export state at %s; run without --resume to start a new export", statePath)
+		}
+		if err != nil {
+			return err
+		}
+		if prev.DiaryID != st.DiaryID {
+			return fmt.Errorf("diary export: %s belongs to diary %s, not %s", statePath, prev.DiaryID, st.DiaryID)
+		}
+		st = prev
+		flags = os.O_CREATE | os.O_RDWR
+	}
+
+	f, err := os.OpenFile(opts.out, flags, 0o600)
+	if err != nil {
+		return fmt.Errorf("diary export: open output: %w", err)
+	}
+	defer f.Close()
+
+	seen := map[string]bool{}
+	offset := 0
+	if opts.resume {
+		if seen, err = prepareExportResume(f); err != nil {
+			return fmt.Errorf("diary export: %w", err)
+		}
+		offset = max(st.Offset-opts.pageSize, 0)
+		fmt.Fprintf(os.Stderr, "Resuming export of diary %s at offset %d (%d entries already written)\n", st.DiaryID, st.Offset, len(seen))
+	} else if err := saveExportState(statePath, st); err != nil {
+		return err
+	}
+
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	summary := exportSummary{DiaryID: st.DiaryID, Out: opts.out, Resumed: opts.resume}
+	for {
+		res, err := client.ListDiaryEntries(ctx, moltnetapi.ListDiaryEntriesParams{
+			DiaryId: diaryUUID,
+			Limit:   moltnetapi.OptFloat64{Value: float64(opts.pageSize), Set: true},
+			Offset:  moltnetapi.OptFloat64{Value: float64(offset), Set: true},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("diary export: interrupted at offset %d; rerun with --resume to continue", st.Offset)
+			}
+			return fmt.Errorf("diary export: %w (progress saved; rerun with --resume to continue)", formatTransportError(err))
+		}
+		list, ok := res.(*moltnetapi.DiaryList)
+		if !ok {
+			return fmt.Errorf("diary export: %w (progress saved; rerun with --resume to continue)", formatAPIError(res))
+		}
+
+		bw := bufio.NewWriter(f)
+		for i := range list.Items {
+			e := &list.Items[i]
+			id := e.ID.String()
+			if seen[id] {
+				summary.Duplicates++
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return fmt.Errorf("diary export: marshal entry %s: %w", id, err)
+			}
+			bw.Write(data)
+			bw.WriteByte('\n')
+			seen[id] = true
+			summary.Exported++
+		}
+		if err := bw.Flush(); err != nil {
+			return fmt.Errorf("diary export: write output: %w", err)
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("diary export: sync output: %w", err)
+		}
+
+		offset += len(list.Items)
+		st.Offset = max(st.Offset, offset)
+		st.Exported = len(seen)
+		st.Total = int(list.Total)
+		if n := len(list.Items); n > 0 {
+			st.LastEntryID = list.Items[n-1].ID.String()
+		}
+		if err := saveExportState(statePath, st); err != nil {
+			return err
+		}
+
+		if len(list.Items) < opts.pageSize || offset >= int(list.Total) {
+			break
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("diary export: interrupted at offset %d; rerun with --resume to continue", st.Offset)
+		}
+	}
+
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("diary export: remove export state: %w", err)
+	}
+	summary.Total = st.Total
+	fmt.Fprintf(os.Stderr, "Exported %d entries to %s\n", len(seen), opts.out)
+	return printJSONTo(w, summary)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+type exportStubHandler struct {
+	stubDiaryHandler
+	entries []moltnetapi.DiaryEntry
+	// failAtOffset makes the page at this offset fail once (-1 disables).
+	failAtOffset int
+}
+
+func newExportStubHandler(n int) *exportStubHandler {
+	h := &exportStubHandler{failAtOffset: -1}
+	for i := range n {
+		e := newTestEntry(fmt.Sprintf("entry %d", i))
+		e.ID = uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i+1))
+		h.entries = append(h.entries, *e)
+	}
+	return h
+}
+
+func (h *exportStubHandler) ListDiaryEntries(_ context.Context, params moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	offset, limit := int(params.Offset.Value), int(params.Limit.Value)
+	if offset == h.failAtOffset {
+		h.failAtOffset = -1
+		return &moltnetapi.ListDiaryEntriesUnauthorized{
+			Title:  "Unauthorized",
+			Status: 401,
+			Code:   "UNAUTHORIZED",
+			Type:   url.URL{Scheme: "about", Opaque: "blank"},
+		}, nil
+	}
+	end := min(offset+limit, len(h.entries))
+	offset = min(offset, end)
+	return &moltnetapi.DiaryList{
+		Items:  h.entries[offset:end],
+		Limit:  float64(limit),
+		Offset: float64(offset),
+		Total:  float64(len(h.entries)),
+	}, nil
+}
+
+func readExportIDs(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open export: %v", err)
+	}
+	defer f.Close()
+	var ids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, line.ID)
+	}
+	return ids
+}
+
+func assertUniqueIDs(t *testing.T, ids []string, want int) {
+	t.Helper()
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			t.Errorf("entry %s exported twice", id)
+		}
+		seen[id] = true
+	}
+	if len(ids) != want {
+		t.Errorf("expected %d exported entries, got %d", want, len(ids))
+	}
+}
+
+func TestRunDiaryExportCmd_Complete(t *testing.T) {
+	// Arrange
+	apiSrv, credPath := newCLICommandTestServer(t, newExportStubHandler(5))
+	out := filepath.Join(t.TempDir(), "backup.ndjson")
+	var buf bytes.Buffer
+
+	// Act
+	err := runDiaryExportCmd(apiSrv.URL, credPath, diaryExportOptions{diaryID: testDiaryID.String(), out: out, pageSize: 2}, &buf)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runDiaryExportCmd() error: %v", err)
+	}
+	assertUniqueIDs(t, readExportIDs(t, out), 5)
+	if _, err := os.Stat(exportStatePath(out)); !os.IsNotExist(err) {
+		t.Errorf("expected export state removed after completion, stat err=%v", err)
+	}
+	var summary exportSummary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if summary.Exported != 5 || summary.Total != 5 || summary.Resumed {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestRunDiaryExportCmd_ResumeAfterFailure(t *testing.T) {
+	// Arrange
+	handler := newExportStubHandler(5)
+	handler.failAtOffset = 4
+	apiSrv, credPath := newCLICommandTestServer(t, handler)
+	out := filepath.Join(t.TempDir(), "backup.ndjson")
+	opts := diaryExportOptions{diaryID: testDiaryID.String(), out: out, pageSize: 2}
+
+	// Act
+	firstErr := runDiaryExportCmd(apiSrv.URL, credPath, opts, &bytes.Buffer{})
+	st, stateErr := loadExportState(exportStatePath(out))
+	// Simulate a write cut off mid-line by the interruption.
+	f, _ := os.OpenFile(out, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"id":"00000000-0000-0000-0000-0000`)
+	f.Close()
+	opts.resume = true
+	var buf bytes.Buffer
+	resumeErr := runDiaryExportCmd(apiSrv.URL, credPath, opts, &buf)
+
+	// Assert
+	if firstErr == nil || !strings.Contains(firstErr.Error(), "--resume") {
+		t.Fatalf("expected first run to fail with a --resume hint, got %v", firstErr)
+	}
+	if stateErr != nil || st.Offset != 4 || st.Exported != 4 {
+		t.Fatalf("expected cursor at offset 4 with 4 exported, got %+v (%v)", st, stateErr)
+	}
+	if resumeErr != nil {
+		t.Fatalf("resume error: %v", resumeErr)
+	}
+	assertUniqueIDs(t, readExportIDs(t, out), 5)
+	var summary exportSummary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if !summary.Resumed || summary.Exported != 1 || summary.Duplicates != 2 {
+		t.Errorf("unexpected resume summary: %+v", summary)
+	}
+}
+
+func TestRunDiaryExportCmd_ResumeRequiresState(t *testing.T) {
+	// Arrange
+	out := filepath.Join(t.TempDir(), "backup.ndjson")
+
+	// Act
+	err := runDiaryExportCmd("http://unused", "unused", diaryExportOptions{diaryID: testDiaryID.String(), out: out, resume: true}, &bytes.Buffer{})
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "no export state") {
+		t.Errorf("expected missing state error, got %v", err)
+	}
+}
+
+func TestRunDiaryExportCmd_ResumeRejectsOtherDiary(t *testing.T) {
+	// Arrange
+	out := filepath.Join(t.TempDir(), "backup.ndjson")
+	if err := saveExportState(exportStatePath(out), &exportState{DiaryID: uuid.NewString()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	err := runDiaryExportCmd("http://unused", "unused", diaryExportOptions{diaryID: testDiaryID.String(), out: out, resume: true}, &bytes.Buffer{})
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "belongs to diary") {
+		t.Errorf("expected diary mismatch error, got %v", err)
+	}
+}
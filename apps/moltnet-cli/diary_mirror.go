@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// mirrorDirName is the directory under the config dir holding one local
+// mirror file per synced diary.
+const mirrorDirName = "mirror"
+
+// BM25 tuning constants (the usual Okapi defaults).
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// diaryMirror is the on-disk copy of a diary used by diary search --local.
+// Entries are stored in the same shape as diary export lines.
+type diaryMirror struct {
+	DiaryID  string                  `json:"diaryId"`
+	SyncedAt time.Time               `json:"syncedAt"`
+	Entries  []moltnetapi.DiaryEntry `json:"entries"`
+}
+
+// mirrorSyncSummary is printed when diary sync completes.
+type mirrorSyncSummary struct {
+	DiaryID   string `json:"diaryId"`
+	Path      string `json:"path"`
+	Added     int    `json:"added"`
+	Updated   int    `json:"updated"`
+	Removed   int    `json:"removed"`
+	Unchanged int    `json:"unchanged"`
+	Total     int    `json:"total"`
+}
+
+// localSearchResult is one entry matched by diary search --local.
+type localSearchResult struct {
+	ID        string    `json:"id"`
+	DiaryID   string    `json:"diaryId"`
+	Title     string    `json:"title,omitempty"`
+	EntryType string    `json:"entryType"`
+	Tags      []string  `json:"tags"`
+	Score     float64   `json:"score"`
+	CreatedAt time.Time `json:"createdAt"`
+	Snippets  []string  `json:"snippets"`
+}
+
+// localSearchResponse is printed by diary search --local.
+type localSearchResponse struct {
+	Query    string              `json:"query"`
+	Terms    []string            `json:"terms"`
+	Source   string              `json:"source"`
+	SyncedAt *time.Time          `json:"syncedAt,omitempty"`
+	Total    int                 `json:"total"`
+	Results  []localSearchResult `json:"results"`
+}
+
+type diarySyncOptions struct {
+	diaryID    string
+	fromExport string
+	pageSize   int
+}
+
+type diarySearchOptions struct {
+	query   string
+	diaryID string
+	limit   int
+	local   bool
+}
+
+func mirrorDir() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, mirrorDirName), nil
+}
+
+func mirrorPath(diaryID string) (string, error) {
+	dir, err := mirrorDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, diaryID+".json"), nil
+}
+
+func loadDiaryMirror(path string) (*diaryMirror, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m diaryMirror
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse mirror %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// loadLocalMirrors returns the mirror for diaryID, or every mirror on disk
+// when diaryID is empty.
+func loadLocalMirrors(diaryID string) ([]*diaryMirror, error) {
+	if diaryID != "" {
+		path, err := mirrorPath(diaryID)
+		if err != nil {
+			return nil, err
+		}
+		m, err := loadDiaryMirror(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, expectedErrorf("no local mirror for diary %s; run 'moltnet diary sync %s' first", diaryID, diaryID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []*diaryMirror{m}, nil
+	}
+	dir, err := mirrorDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, expectedErrorf("no local mirrors in %s; run 'moltnet diary sync <diary-id>' first", dir)
+	}
+	sort.Strings(paths)
+	mirrors := make([]*diaryMirror, 0, len(paths))
+	for _, p := range paths {
+		m, err := loadDiaryMirror(p)
+		if err != nil {
+			return nil, err
+		}
+		mirrors = append(mirrors, m)
+	}
+	return mirrors, nil
+}
+
+// readExportEntries reads the NDJSON file written by diary export.
+func readExportEntries(path string) ([]moltnetapi.DiaryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []moltnetapi.DiaryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e moltnetapi.DiaryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// fetchAllDiaryEntries pages through every entry of a diary.
+func fetchAllDiaryEntries(ctx context.Context, client *moltnetapi.Client, diaryID uuid.UUID, pageSize int) ([]moltnetapi.DiaryEntry, error) {
+	var entries []moltnetapi.DiaryEntry
+	offset := 0
+	for {
+		res, err := client.ListDiaryEntries(ctx, moltnetapi.ListDiaryEntriesParams{
+			DiaryId: diaryID,
+			Limit:   moltnetapi.OptFloat64{Value: float64(pageSize), Set: true},
+			Offset:  moltnetapi.OptFloat64{Value: float64(offset), Set: true},
+		})
+		if err != nil {
+			return nil, formatTransportError(err)
+		}
+		list, ok := res.(*moltnetapi.DiaryList)
+		if !ok {
+			return nil, formatAPIError(res)
+		}
+		entries = append(entries, list.Items...)
+		offset += len(list.Items)
+		if len(list.Items) < pageSize || offset >= int(list.Total) {
+			return entries, nil
+		}
+	}
+}
+
+// mergeMirrorEntries reconciles the previous mirror with the current entry
+// list: new entries are added, entries whose updatedAt moved forward are
+// replaced, and entries no longer listed are dropped.
+func mergeMirrorEntries(prev, current []moltnetapi.DiaryEntry) ([]moltnetapi.DiaryEntry, mirrorSyncSummary) {
+	var s mirrorSyncSummary
+	old := make(map[uuid.UUID]moltnetapi.DiaryEntry, len(prev))
+	for _, e := range prev {
+		old[e.ID] = e
+	}
+	merged := make([]moltnetapi.DiaryEntry, 0, len(current))
+	seen := make(map[uuid.UUID]bool, len(current))
+	for _, e := range current {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		o, ok := old[e.ID]
+		switch {
+		case !ok:
+			s.Added++
+		case e.UpdatedAt.After(o.UpdatedAt):
+			s.Updated++
+		default:
+			s.Unchanged++
+			e = o
+		}
+		merged = append(merged, e)
+	}
+	for id := range old {
+		if !seen[id] {
+			s.Removed++
+		}
+	}
+	s.Total = len(merged)
+	return merged, s
+}
+
+// runDiarySyncCmd refreshes the local mirror of a diary, either from the
+// API or from a diary export file.
+func runDiarySyncCmd(apiURL, credPath string, opts diarySyncOptions, w io.Writer) error {
+	diaryUUID, err := uuid.Parse(opts.diaryID)
+	if err != nil {
+		return fmt.Errorf("diary sync: invalid diary ID %q: %w", opts.diaryID, err)
+	}
+	if opts.pageSize <= 0 {
+		opts.pageSize = defaultExportPageSize
+	}
+	path, err := mirrorPath(diaryUUID.String())
+	if err != nil {
+		return fmt.Errorf("diary sync: %w", err)
+	}
+	prev, err := loadDiaryMirror(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		prev = &diaryMirror{}
+	case err != nil:
+		return fmt.Errorf("diary sync: %w", err)
+	}
+
+	var current []moltnetapi.DiaryEntry
+	if opts.fromExport != "" {
+		if current, err = readExportEntries(opts.fromExport); err != nil {
+			return fmt.Errorf("diary sync: read export: %w", err)
+		}
+		kept := current[:0]
+		for _, e := range current {
+			if e.DiaryId == diaryUUID {
+				kept = append(kept, e)
+			}
+		}
+		current = kept
+	} else {
+		client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
+		if err != nil {
+			return err
+		}
+		if current, err = fetchAllDiaryEntries(context.Background(), client, diaryUUID, opts.pageSize); err != nil {
+			return fmt.Errorf("diary sync: %w", err)
+		}
+	}
+
+	merged, summary := mergeMirrorEntries(prev.Entries, current)
+	summary.DiaryID = diaryUUID.String()
+	summary.Path = path
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("diary sync: %w", err)
+	}
+	mirror := diaryMirror{DiaryID: summary.DiaryID, SyncedAt: timeNow().UTC(), Entries: merged}
+	if err := writeJSONAtomic(path, mirror); err != nil {
+		return fmt.Errorf("diary sync: write mirror: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Synced %d entries of diary %s to %s\n", summary.Total, summary.DiaryID, path)
+	return printJSONTo(w, summary)
+}
+
+// bm25Tokens lowercases text and splits it into letter/digit runs,
+// dropping single-character tokens.
+func bm25Tokens(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) >= 2 {
+			tokens = append(tokens, f)
+		}
+	}
+	return tokens
+}
+
+// mirrorDocument is the indexed text of one entry: title, tags and content.
+func mirrorDocument(e *moltnetapi.DiaryEntry) string {
+	var b strings.Builder
+	if !e.Title.Null {
+		b.WriteString(e.Title.Value)
+		b.WriteByte('\n')
+	}
+	b.WriteString(strings.Join(e.Tags, " "))
+	b.WriteByte('\n')
+	b.WriteString(e.Content)
+	return b.String()
+}
+
+// searchMirrors ranks the entries of the given mirrors against query using
+// BM25 over title, tags and content. Entries matching no term are omitted.
+func searchMirrors(mirrors []*diaryMirror, query string, limit int) localSearchResponse {
+	queryTerms := bm25Tokens(query)
+	resp := localSearchResponse{Query: query, Terms: uniqueStrings(queryTerms), Source: "local", Results: []localSearchResult{}}
+	if resp.Terms == nil {
+		resp.Terms = []string{}
+	}
+
+	type doc struct {
+		entry *moltnetapi.DiaryEntry
+		tf    map[string]int
+		size  int
+	}
+	var docs []doc
+	df := map[string]int{}
+	totalLen := 0
+	for _, m := range mirrors {
+		for i := range m.Entries {
+			e := &m.Entries[i]
+			tokens := bm25Tokens(mirrorDocument(e))
+			tf := make(map[string]int, len(tokens))
+			for _, t := range tokens {
+				tf[t]++
+			}
+			for t := range tf {
+				df[t]++
+			}
+			docs = append(docs, doc{entry: e, tf: tf, size: len(tokens)})
+			totalLen += len(tokens)
+		}
+	}
+	if len(docs) == 0 || len(resp.Terms) == 0 {
+		return resp
+	}
+	avgLen := float64(totalLen) / float64(len(docs))
+	n := float64(len(docs))
+
+	for _, d := range docs {
+		score := 0.0
+		for _, t := range resp.Terms {
+			f := float64(d.tf[t])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			score += idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*float64(d.size)/avgLen))
+		}
+		if score == 0 {
+			continue
+		}
+		e := d.entry
+		r := localSearchResult{
+			ID:        e.ID.String(),
+			DiaryID:   e.DiaryId.String(),
+			EntryType: string(e.EntryType),
+			Tags:      e.Tags,
+			Score:     math.Round(score*1e4) / 1e4,
+			CreatedAt: e.CreatedAt,
+			Snippets:  highlightSnippets(e.Content, resp.Terms),
+		}
+		if !e.Title.Null {
+			r.Title = e.Title.Value
+		}
+		resp.Results = append(resp.Results, r)
+	}
+	sort.SliceStable(resp.Results, func(i, j int) bool {
+		if resp.Results[i].Score != resp.Results[j].Score {
+			return resp.Results[i].Score > resp.Results[j].Score
+		}
+		return resp.Results[i].CreatedAt.After(resp.Results[j].CreatedAt)
+	})
+	resp.Total = len(resp.Results)
+	if limit > 0 && len(resp.Results) > limit {
+		resp.Results = resp.Results[:limit]
+	}
+	return resp
+}
+
+// runDiarySearchCmd searches diary entries, offline against the local
+// mirror when opts.local is set and through the API otherwise.
+func runDiarySearchCmd(apiURL, credPath string, opts diarySearchOptions, w io.Writer) error {
+	if !opts.local {
+		return runEntrySearchCmd(apiURL, credPath, entrySearchOptions{
+			query:   opts.query,
+			diaryID: opts.diaryID,
+			limit:   opts.limit,
+		})
+	}
+	if strings.TrimSpace(opts.query) == "" {
+		return fmt.Errorf("diary search: --query is required with --local")
+	}
+	if opts.diaryID != "" {
+		if _, err := uuid.Parse(opts.diaryID); err != nil {
+			return fmt.Errorf("diary search: invalid diary ID %q: %w", opts.diaryID, err)
+		}
+	}
+	mirrors, err := loadLocalMirrors(opts.diaryID)
+	if err != nil {
+		return fmt.Errorf("diary search: %w", err)
+	}
+	resp := searchMirrors(mirrors, opts.query, opts.limit)
+	if len(mirrors) == 1 {
+		synced := mirrors[0].SyncedAt
+		resp.SyncedAt = &synced
+	}
+	return printJSONTo(w, resp)
+}
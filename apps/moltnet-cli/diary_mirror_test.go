@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+func TestRunDiarySyncCmd_Incremental(t *testing.T) {
+	// Arrange
+	t.Setenv("HOME", t.TempDir())
+	h := newExportStubHandler(3)
+	apiSrv, credPath := newCLICommandTestServer(t, h)
+	opts := diarySyncOptions{diaryID: testDiaryID.String(), pageSize: 2}
+	if err := runDiarySyncCmd(apiSrv.URL, credPath, opts, &bytes.Buffer{}); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	h.entries[0].Content = "edited"
+	h.entries[0].UpdatedAt = h.entries[0].UpdatedAt.Add(time.Minute)
+	h.entries = h.entries[:2]
+	extra := newTestEntry("brand new")
+	extra.ID = uuid.MustParse("00000000-0000-0000-0000-000000000099")
+	h.entries = append(h.entries, *extra)
+	var buf bytes.Buffer
+
+	// Act
+	err := runDiarySyncCmd(apiSrv.URL, credPath, opts, &buf)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	var summary mirrorSyncSummary
+	if err := json.Unmarshal(buf.Bytes(), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if summary.Added != 1 || summary.Updated != 1 || summary.Removed != 1 || summary.Unchanged != 1 || summary.Total != 3 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	m, err := loadDiaryMirror(summary.Path)
+	if err != nil {
+		t.Fatalf("load mirror: %v", err)
+	}
+	if len(m.Entries) != 3 || m.Entries[0].Content != "edited" {
+		t.Errorf("mirror not refreshed: %+v", m.Entries)
+	}
+}
+
+func TestRunDiarySyncCmd_FromExport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	export := filepath.Join(t.TempDir(), "backup.ndjson")
+	var lines bytes.Buffer
+	for i := range 2 {
+		e := newTestEntry(fmt.Sprintf("exported %d", i))
+		e.ID = uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i+1))
+		data, _ := json.Marshal(e)
+		lines.Write(append(data, '\n'))
+	}
+	if err := os.WriteFile(export, lines.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+
+	// No API is reachable, so this only succeeds offline.
+	err := runDiarySyncCmd("http://127.0.0.1:1", "", diarySyncOptions{diaryID: testDiaryID.String(), fromExport: export}, &buf)
+
+	if err != nil {
+		t.Fatalf("sync from export: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"added": 2`) {
+		t.Errorf("unexpected summary %s", buf.String())
+	}
+}
+
+func TestSearchMirrors_RanksByBM25(t *testing.T) {
+	t.Parallel()
+	mk := func(id int, title, content string, tags ...string) moltnetapi.DiaryEntry {
+		e := newTestEntry(content)
+		e.ID = uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", id))
+		e.Title = moltnetapi.NewNilString(title)
+		e.Tags = tags
+		return *e
+	}
+	m := &diaryMirror{DiaryID: testDiaryID.String(), Entries: []moltnetapi.DiaryEntry{
+		mk(1, "Lunch", "Ate a sandwich by the river."),
+		mk(2, "Lockfile", "The stale lockfile blocked the deploy; removing the lockfile fixed it.", "incident"),
+		mk(3, "Deploy notes", "Deploy went fine after the lockfile cleanup."),
+	}}
+
+	resp := searchMirrors([]*diaryMirror{m}, "stale Lockfile", 10)
+
+	if resp.Total != 2 || len(resp.Results) != 2 {
+		t.Fatalf("expected 2 matches, got %+v", resp.Results)
+	}
+	if resp.Results[0].Title != "Lockfile" || resp.Results[0].Score <= resp.Results[1].Score {
+		t.Errorf("unexpected ranking %+v", resp.Results)
+	}
+	if got := searchMirrors([]*diaryMirror{m}, "stale lockfile", 1); len(got.Results) != 1 || got.Total != 2 {
+		t.Errorf("limit not applied: %+v", got)
+	}
+}
+
+func TestRunDiarySearchCmd_LocalRequiresMirror(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	err := runDiarySearchCmd("", "", diarySearchOptions{query: "x", diaryID: testDiaryID.String(), local: true}, &bytes.Buffer{})
+
+	if err == nil || !strings.Contains(err.Error(), "diary sync") {
+		t.Errorf("expected missing mirror error, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// reindexProgressEvery is how many entries pass between progress lines.
+const reindexProgressEvery = 25
+
+// diaryReindexOptions carries the diary reindex flags.
+type diaryReindexOptions struct {
+	dryRun bool
+	mode   bulkMode
+}
+
+// diaryReindexSummary is printed to stdout when diary reindex finishes.
+// Succeeded counts re-embedded entries (or, with --dry-run, entries that
+// would be).
+type diaryReindexSummary struct {
+	DiaryID       string `json:"diaryId"`
+	DryRun        bool   `json:"dryRun"`
+	Scanned       int    `json:"scanned"`
+	SkippedSigned int    `json:"skippedSigned"`
+	bulkSummary
+}
+
+// runDiaryReindexCmd has the server recompute the search embedding of
+// every entry in a diary. The API has no reindex endpoint or job to poll,
+// but an entry update re-embeds the entry whenever its content, title or
+// tags are part of the patch, so each entry is patched with its own
+// unchanged content: the content hash and the local edit history stay as
+// they are. Signed entries are immutable and are skipped. The run is
+// synchronous, with progress on stderr.
+func runDiaryReindexCmd(apiURL, credPath, diaryID string, opts diaryReindexOptions) error {
+	diaryUUID, err := uuid.Parse(diaryID)
+	if err != nil {
+		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
+	}
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	entries, err := fetchAllDiaryEntries(ctx, client, diaryUUID, retagPageSize)
+	if err != nil {
+		return fmt.Errorf("diary reindex: %w", err)
+	}
+
+	summary := diaryReindexSummary{DiaryID: diaryUUID.String(), DryRun: opts.dryRun, Scanned: len(entries)}
+	run := newBulkRunner(opts.mode)
+	for i, e := range entries {
+		if i > 0 && i%reindexProgressEvery == 0 {
+			fmt.Fprintf(os.Stderr, "  %d/%d entries processed\n", i, len(entries))
+		}
+		if e.ContentSignature.Or("") != "" {
+			summary.SkippedSigned++
+			continue
+		}
+		if opts.dryRun {
+			run.succeed()
+			continue
+		}
+		res, err := client.UpdateDiaryEntryById(ctx,
+			moltnetapi.OptUpdateDiaryEntryByIdReq{Value: moltnetapi.UpdateDiaryEntryByIdReq{Content: moltnetapi.NewOptString(e.Content)}, Set: true},
+			moltnetapi.UpdateDiaryEntryByIdParams{EntryId: e.ID})
+		if err == nil {
+			if _, ok := res.(*moltnetapi.DiaryEntry); !ok {
+				err = formatAPIError(res)
+			}
+		} else {
+			err = formatTransportError(err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [failed] %s: %v\n", e.ID, err)
+			if run.fail(e.ID.String(), err) {
+				break
+			}
+			continue
+		}
+		run.succeed()
+	}
+
+	summary.bulkSummary = run.summary
+	verb := "re-embedded"
+	if opts.dryRun {
+		verb = "would be re-embedded"
+	}
+	fmt.Fprintf(os.Stderr, "%d scanned, %d %s, %d signed skipped, %d failed.\n",
+		summary.Scanned, summary.Succeeded, verb, summary.SkippedSigned, summary.Failed)
+	if err := printJSON(summary); err != nil {
+		return err
+	}
+	return run.err("diary reindex")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// reindexStubHandler lists a fixed set of entries and records the patches
+// sent for them. Updates to failID are rejected.
+type reindexStubHandler struct {
+	moltnetapi.UnimplementedHandler
+	entries []moltnetapi.DiaryEntry
+	failID  uuid.UUID
+	patches map[uuid.UUID]moltnetapi.UpdateDiaryEntryByIdReq
+}
+
+func (h *reindexStubHandler) ListDiaryEntries(_ context.Context, _ moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	return &moltnetapi.DiaryList{Items: h.entries, Total: float64(len(h.entries)), Limit: float64(retagPageSize)}, nil
+}
+
+func (h *reindexStubHandler) UpdateDiaryEntryById(_ context.Context, req moltnetapi.OptUpdateDiaryEntryByIdReq, params moltnetapi.UpdateDiaryEntryByIdParams) (moltnetapi.UpdateDiaryEntryByIdRes, error) {
+	if params.EntryId == h.failID {
+		return nil, errors.New("update rejected")
+	}
+	h.patches[params.EntryId] = req.Value
+	e := newTestEntry(req.Value.Content.Value)
+	e.ID = params.EntryId
+	return e, nil
+}
+
+func newReindexStubHandler() *reindexStubHandler {
+	h := &reindexStubHandler{patches: map[uuid.UUID]moltnetapi.UpdateDiaryEntryByIdReq{}}
+	for _, content := range []string{"first note", "second note", "signed decision"} {
+		e := *newTestEntry(content)
+		e.ID = uuid.New()
+		e.Tags = []string{"ops"}
+		h.entries = append(h.entries, e)
+	}
+	h.entries[2].ContentSignature = moltnetapi.NewNilString("sig")
+	return h
+}
+
+func TestRunDiaryReindexCmd(t *testing.T) {
+	h := newReindexStubHandler()
+	srv, credPath := newCLICommandTestServer(t, h)
+	if err := runDiaryReindexCmd(srv.URL, credPath, testDiaryID.String(), diaryReindexOptions{mode: bulkFailFast}); err != nil {
+		t.Fatalf("runDiaryReindexCmd() error: %v", err)
+	}
+	if len(h.patches) != 2 {
+		t.Fatalf("patched %d entries, want the 2 unsigned ones", len(h.patches))
+	}
+	for _, e := range h.entries[:2] {
+		p, ok := h.patches[e.ID]
+		if !ok {
+			t.Errorf("%s not patched", e.ID)
+			continue
+		}
+		// Only the unchanged content: no tags, title or type that could
+		// alter the entry.
+		if p.Content.Value != e.Content || p.Tags != nil || p.Title.Set || p.EntryType.Set || p.Importance.Set {
+			t.Errorf("%s: patch = %+v, want only its own content", e.ID, p)
+		}
+	}
+	if _, ok := h.patches[h.entries[2].ID]; ok {
+		t.Error("the signed entry was patched")
+	}
+}
+
+func TestRunDiaryReindexCmd_DryRun(t *testing.T) {
+	h := newReindexStubHandler()
+	srv, credPath := newCLICommandTestServer(t, h)
+	if err := runDiaryReindexCmd(srv.URL, credPath, testDiaryID.String(), diaryReindexOptions{dryRun: true, mode: bulkFailFast}); err != nil {
+		t.Fatalf("runDiaryReindexCmd() error: %v", err)
+	}
+	if len(h.patches) != 0 {
+		t.Errorf("--dry-run patched %d entries", len(h.patches))
+	}
+}
+
+func TestRunDiaryReindexCmd_Failures(t *testing.T) {
+	h := newReindexStubHandler()
+	h.failID = h.entries[0].ID
+	srv, credPath := newCLICommandTestServer(t, h)
+
+	if err := runDiaryReindexCmd(srv.URL, credPath, testDiaryID.String(), diaryReindexOptions{mode: bulkFailFast}); err == nil {
+		t.Fatal("fail-fast: expected an error")
+	}
+	if len(h.patches) != 0 {
+		t.Errorf("fail-fast: patched %d entries after the first failure", len(h.patches))
+	}
+
+	if err := runDiaryReindexCmd(srv.URL, credPath, testDiaryID.String(), diaryReindexOptions{mode: bulkContinue}); err == nil {
+		t.Fatal("--continue: expected an error reporting the failure")
+	}
+	if len(h.patches) != 1 {
+		t.Errorf("--continue: patched %d entries, want the other unsigned one", len(h.patches))
+	}
+}
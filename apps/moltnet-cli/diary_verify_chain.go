@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+const (
+	peerKeyCacheFile = "peer-keys-cache.json"
+	// peerKeyCacheTTL bounds how long a looked-up agent key is trusted
+	// before agents lookup is asked again. A cached key that no longer
+	// matches an entry is re-fetched regardless, so a rotation shows up.
+	peerKeyCacheTTL = 24 * time.Hour
+)
+
+// chainLink is one entry of a diary's signed chain. Status is "valid",
+// "invalid" or "unsigned".
+type chainLink struct {
+	EntryID        string    `json:"entryId"`
+	CreatedAt      time.Time `json:"createdAt"`
+	Author         string    `json:"author"`
+	Status         string    `json:"status"`
+	HashMatches    bool      `json:"hashMatches"`
+	SignatureValid bool      `json:"signatureValid"`
+	SignerKey      string    `json:"signerKey,omitempty"`
+	Problem        string    `json:"problem,omitempty"`
+}
+
+// chainReport is the output of diary verify-chain.
+type chainReport struct {
+	DiaryID  string      `json:"diaryId"`
+	Signers  []string    `json:"signers"`
+	Links    []chainLink `json:"links"`
+	Valid    int         `json:"valid"`
+	Invalid  int         `json:"invalid"`
+	Unsigned int         `json:"unsigned"`
+}
+
+type peerKeyCacheEntry struct {
+	PublicKey string `json:"publicKey"`
+	FetchedAt string `json:"fetchedAt"`
+}
+
+func peerKeyCachePath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, peerKeyCacheFile), nil
+}
+
+// readPeerKeyCache returns cached agent keys by API URL, then fingerprint.
+// A missing or corrupt file is an empty cache.
+func readPeerKeyCache(path string) map[string]map[string]peerKeyCacheEntry {
+	cache := map[string]map[string]peerKeyCacheEntry{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return map[string]map[string]peerKeyCacheEntry{}
+	}
+	return cache
+}
+
+// peerKeys resolves agent fingerprints to their published public keys
+// through agents lookup, caching them in memory for the run and on disk
+// across runs.
+type peerKeys struct {
+	client *moltnetapi.Client
+	apiURL string
+	keys   map[string]string
+	fresh  map[string]bool
+}
+
+func newPeerKeys(client *moltnetapi.Client, apiURL string) *peerKeys {
+	return &peerKeys{client: client, apiURL: strings.TrimRight(apiURL, "/"), keys: map[string]string{}, fresh: map[string]bool{}}
+}
+
+// lookup returns the key for fingerprint. With refresh, a cached key is
+// ignored and the profile fetched again (once per run).
+func (p *peerKeys) lookup(fingerprint string, refresh bool) (string, error) {
+	if key, ok := p.keys[fingerprint]; ok && (!refresh || p.fresh[fingerprint]) {
+		return key, nil
+	}
+	path, pathErr := peerKeyCachePath()
+	if !refresh && pathErr == nil {
+		cached, hit := readPeerKeyCache(path)[p.apiURL][fingerprint]
+		if fetchedAt, err := time.Parse(time.RFC3339, cached.FetchedAt); hit && err == nil && timeNow().Before(fetchedAt.Add(peerKeyCacheTTL)) {
+			p.keys[fingerprint] = cached.PublicKey
+			return cached.PublicKey, nil
+		}
+	}
+
+	res, err := p.client.GetAgentProfile(context.Background(), moltnetapi.GetAgentProfileParams{Fingerprint: fingerprint})
+	if err != nil {
+		return "", formatTransportError(err)
+	}
+	profile, ok := res.(*moltnetapi.AgentProfile)
+	if !ok {
+		return "", formatAPIError(res)
+	}
+	p.keys[fingerprint] = profile.PublicKey
+	p.fresh[fingerprint] = true
+	if pathErr == nil {
+		cache := readPeerKeyCache(path)
+		if cache[p.apiURL] == nil {
+			cache[p.apiURL] = map[string]peerKeyCacheEntry{}
+		}
+		cache[p.apiURL][fingerprint] = peerKeyCacheEntry{PublicKey: profile.PublicKey, FetchedAt: timeNow().UTC().Format(time.RFC3339)}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+			_ = writeJSONAtomic(path, cache)
+		}
+	}
+	return profile.PublicKey, nil
+}
+
+// checkSignerKey verifies that key is the published key of fingerprint
+// and the key the entry records for its author, re-fetching a cached key
+// once before reporting a mismatch. It returns the key and "" when the
+// signer checks out, or a problem.
+func (p *peerKeys) checkSignerKey(fingerprint, entryKey string) (string, string, error) {
+	key, err := p.lookup(fingerprint, false)
+	if err != nil {
+		return "", "", err
+	}
+	if entryKey != "" && key != entryKey {
+		if key, err = p.lookup(fingerprint, true); err != nil {
+			return "", "", err
+		}
+	}
+	pub, err := ParsePublicKey(key)
+	if err != nil {
+		return key, fmt.Sprintf("published key of %s is unreadable: %v", fingerprint, err), nil
+	}
+	if got := Fingerprint(pub); got != fingerprint {
+		return key, fmt.Sprintf("published key of %s has fingerprint %s", fingerprint, got), nil
+	}
+	if entryKey != "" && key != entryKey {
+		return key, fmt.Sprintf("entry records author key %s, but %s publishes %s", entryKey, fingerprint, key), nil
+	}
+	return key, "", nil
+}
+
+// entryAuthor returns the fingerprint and recorded key of an agent
+// author, or ok false for a human principal.
+func entryAuthor(e moltnetapi.DiaryEntry) (fingerprint, publicKey string, ok bool) {
+	agent, ok := e.Creator.GetAgentPrincipal()
+	if !ok {
+		return "", "", false
+	}
+	return agent.Fingerprint, agent.PublicKey, true
+}
+
+// verifyChainLink checks one signed entry: the server recomputes the
+// content hash and verifies the signature, and the signer it names must be
+// the entry's author, whose published key (agents lookup) must match the
+// key the entry records and derive the author's fingerprint. The signing
+// nonce is not part of the entry, so the Ed25519 check itself can only
+// run server-side.
+func verifyChainLink(client *moltnetapi.Client, peers *peerKeys, e moltnetapi.DiaryEntry, allowed []string) (chainLink, error) {
+	link := chainLink{EntryID: e.ID.String(), CreatedAt: e.CreatedAt, Status: "invalid"}
+	fingerprint, entryKey, isAgent := entryAuthor(e)
+	if isAgent {
+		link.Author = fingerprint
+	} else if human, ok := e.Creator.GetHumanPrincipal(); ok {
+		link.Author = "human:" + human.HumanId.String()
+	}
+	if e.ContentSignature.Or("") == "" {
+		link.Status = "unsigned"
+		return link, nil
+	}
+
+	res, err := client.VerifyDiaryEntryById(context.Background(), moltnetapi.VerifyDiaryEntryByIdParams{EntryId: e.ID})
+	if err != nil {
+		return link, formatTransportError(err)
+	}
+	result, ok := res.(*moltnetapi.EntryVerifyResult)
+	if !ok {
+		return link, formatAPIError(res)
+	}
+	link.HashMatches = result.HashMatches
+	link.SignatureValid = result.SignatureValid
+
+	switch {
+	case !isAgent:
+		link.Problem = "signed entry without an agent author"
+	case len(allowed) > 0 && !slices.Contains(allowed, fingerprint):
+		link.Problem = fmt.Sprintf("%s is not an allowed --signer", fingerprint)
+	case !result.HashMatches:
+		link.Problem = "content does not match its signed hash"
+	case !result.SignatureValid:
+		link.Problem = "signature does not verify"
+	case result.AgentFingerprint.Or("") != fingerprint:
+		link.Problem = fmt.Sprintf("signed by %s, not by its author %s", result.AgentFingerprint.Or("nobody"), fingerprint)
+	}
+	if isAgent {
+		key, problem, err := peers.checkSignerKey(fingerprint, entryKey)
+		if err != nil {
+			return link, fmt.Errorf("look up signer %s: %w", fingerprint, err)
+		}
+		link.SignerKey = key
+		if link.Problem == "" {
+			link.Problem = problem
+		}
+	}
+	if link.Problem == "" {
+		link.Status = "valid"
+	}
+	return link, nil
+}
+
+// runDiaryVerifyChainCmd verifies every signed entry of a diary, oldest
+// first, against the key of the agent that wrote it, so a shared diary
+// whose entries alternate between signers can be audited as a whole.
+// allowed, when set, restricts which fingerprints may sign. The API keeps
+// no link from one entry to the next, so the chain is the diary's entries
+// in creation order. Invalid links are an expected error (exit code 3);
+// unsigned entries are reported but do not fail the run.
+func runDiaryVerifyChainCmd(apiURL, credPath, diaryID string, allowed []string) error {
+	diaryUUID, err := uuid.Parse(diaryID)
+	if err != nil {
+		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
+	}
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
+	if err != nil {
+		return err
+	}
+	entries, err := fetchAllDiaryEntries(context.Background(), client, diaryUUID, retagPageSize)
+	if err != nil {
+		return fmt.Errorf("diary verify-chain: %w", err)
+	}
+	slices.SortStableFunc(entries, func(a, b moltnetapi.DiaryEntry) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	report := chainReport{DiaryID: diaryUUID.String(), Signers: []string{}, Links: []chainLink{}}
+	peers := newPeerKeys(client, apiURL)
+	for _, e := range entries {
+		link, err := verifyChainLink(client, peers, e, allowed)
+		if err != nil {
+			return fmt.Errorf("diary verify-chain: entry %s: %w", e.ID, err)
+		}
+		switch link.Status {
+		case "valid":
+			report.Valid++
+		case "invalid":
+			report.Invalid++
+		default:
+			report.Unsigned++
+		}
+		if link.Status != "unsigned" && link.Author != "" && !slices.Contains(report.Signers, link.Author) {
+			report.Signers = append(report.Signers, link.Author)
+		}
+		line := fmt.Sprintf("  [%s] %s by %s", link.Status, link.EntryID, link.Author)
+		if link.Problem != "" {
+			line += ": " + link.Problem
+		}
+		fmt.Fprintln(os.Stderr, line)
+		report.Links = append(report.Links, link)
+	}
+	fmt.Fprintf(os.Stderr, "%d valid, %d invalid, %d unsigned; %d signer(s).\n", report.Valid, report.Invalid, report.Unsigned, len(report.Signers))
+	if err := printJSON(report); err != nil {
+		return err
+	}
+	if report.Invalid > 0 {
+		return expectedErrorf("diary verify-chain: %d invalid link(s)", report.Invalid)
+	}
+	return nil
+}
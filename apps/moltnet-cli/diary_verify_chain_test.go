@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// chainStubHandler serves a diary of entries written by several agents,
+// verifying each as the server would and publishing agent keys by
+// fingerprint.
+type chainStubHandler struct {
+	moltnetapi.UnimplementedHandler
+	entries  []moltnetapi.DiaryEntry
+	keys     map[string]string // fingerprint -> published key
+	signedBy map[uuid.UUID]string
+	lookups  map[string]int
+}
+
+func (h *chainStubHandler) ListDiaryEntries(_ context.Context, _ moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	return &moltnetapi.DiaryList{Items: h.entries, Total: float64(len(h.entries)), Limit: float64(retagPageSize)}, nil
+}
+
+func (h *chainStubHandler) VerifyDiaryEntryById(_ context.Context, params moltnetapi.VerifyDiaryEntryByIdParams) (moltnetapi.VerifyDiaryEntryByIdRes, error) {
+	return &moltnetapi.EntryVerifyResult{
+		Signed:           true,
+		Valid:            true,
+		HashMatches:      true,
+		SignatureValid:   true,
+		AgentFingerprint: moltnetapi.NewNilString(h.signedBy[params.EntryId]),
+	}, nil
+}
+
+func (h *chainStubHandler) GetAgentProfile(_ context.Context, params moltnetapi.GetAgentProfileParams) (moltnetapi.GetAgentProfileRes, error) {
+	h.lookups[params.Fingerprint]++
+	return &moltnetapi.AgentProfile{Fingerprint: params.Fingerprint, PublicKey: h.keys[params.Fingerprint]}, nil
+}
+
+func testChainKeyPair(t *testing.T, b byte) *KeyPair {
+	t.Helper()
+	seed := make([]byte, 32)
+	seed[0] = b
+	kp, err := KeyPairFromSeed(seed)
+	if err != nil {
+		t.Fatalf("KeyPairFromSeed: %v", err)
+	}
+	return kp
+}
+
+// addEntry appends an entry authored by kp, created n minutes into the
+// diary, signed unless signed is false.
+func (h *chainStubHandler) addEntry(kp *KeyPair, n int, signed bool) uuid.UUID {
+	e := *newTestEntry("entry")
+	e.ID = uuid.New()
+	e.CreatedAt = time.Date(2026, 3, 1, 12, n, 0, 0, time.UTC)
+	creator := moltnetapi.DiaryEntryCreator{}
+	creator.SetAgentPrincipal(moltnetapi.AgentPrincipal{
+		Kind:        moltnetapi.AgentPrincipalKindAgent,
+		IdentityId:  uuid.New(),
+		Fingerprint: kp.Fingerprint,
+		PublicKey:   kp.PublicKey,
+	})
+	e.Creator = creator
+	if signed {
+		e.ContentSignature = moltnetapi.NewNilString("sig")
+		h.signedBy[e.ID] = kp.Fingerprint
+	}
+	h.entries = append(h.entries, e)
+	return e.ID
+}
+
+func newChainStubHandler(kps ...*KeyPair) *chainStubHandler {
+	h := &chainStubHandler{keys: map[string]string{}, signedBy: map[uuid.UUID]string{}, lookups: map[string]int{}}
+	for _, kp := range kps {
+		h.keys[kp.Fingerprint] = kp.PublicKey
+	}
+	return h
+}
+
+func TestRunDiaryVerifyChainCmd_MultipleSigners(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	alice, bob := testChainKeyPair(t, 1), testChainKeyPair(t, 2)
+	h := newChainStubHandler(alice, bob)
+	// Listed out of order: the chain is walked by creation time.
+	h.addEntry(bob, 2, true)
+	h.addEntry(alice, 1, true)
+	h.addEntry(alice, 3, true)
+	h.addEntry(bob, 4, false)
+	srv, credPath := newCLICommandTestServer(t, h)
+
+	if err := runDiaryVerifyChainCmd(srv.URL, credPath, testDiaryID.String(), []string{alice.Fingerprint, bob.Fingerprint}); err != nil {
+		t.Fatalf("runDiaryVerifyChainCmd() error: %v", err)
+	}
+	for _, fp := range []string{alice.Fingerprint, bob.Fingerprint} {
+		if h.lookups[fp] != 1 {
+			t.Errorf("lookups of %s = %d, want 1 (cached per run)", fp, h.lookups[fp])
+		}
+	}
+
+	// A second run is served from the on-disk peer key cache.
+	if err := runDiaryVerifyChainCmd(srv.URL, credPath, testDiaryID.String(), nil); err != nil {
+		t.Fatalf("second runDiaryVerifyChainCmd() error: %v", err)
+	}
+	if h.lookups[alice.Fingerprint] != 1 || h.lookups[bob.Fingerprint] != 1 {
+		t.Errorf("lookups after second run = %v, want the cache to serve them", h.lookups)
+	}
+}
+
+func TestVerifyChainLink(t *testing.T) {
+	alice, bob, mallory := testChainKeyPair(t, 1), testChainKeyPair(t, 2), testChainKeyPair(t, 3)
+	tests := []struct {
+		name        string
+		setup       func(h *chainStubHandler) uuid.UUID
+		allowed     []string
+		wantStatus  string
+		wantProblem bool
+	}{
+		{
+			name:       "valid",
+			setup:      func(h *chainStubHandler) uuid.UUID { return h.addEntry(alice, 1, true) },
+			wantStatus: "valid",
+		},
+		{
+			name:       "unsigned",
+			setup:      func(h *chainStubHandler) uuid.UUID { return h.addEntry(alice, 1, false) },
+			wantStatus: "unsigned",
+		},
+		{
+			name:        "signer not allowed",
+			setup:       func(h *chainStubHandler) uuid.UUID { return h.addEntry(bob, 1, true) },
+			allowed:     []string{alice.Fingerprint},
+			wantStatus:  "invalid",
+			wantProblem: true,
+		},
+		{
+			name: "signed by someone other than the author",
+			setup: func(h *chainStubHandler) uuid.UUID {
+				id := h.addEntry(alice, 1, true)
+				h.signedBy[id] = mallory.Fingerprint
+				return id
+			},
+			wantStatus:  "invalid",
+			wantProblem: true,
+		},
+		{
+			name: "published key differs from the entry's",
+			setup: func(h *chainStubHandler) uuid.UUID {
+				h.keys[alice.Fingerprint] = mallory.PublicKey
+				return h.addEntry(alice, 1, true)
+			},
+			wantStatus:  "invalid",
+			wantProblem: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(configDirEnvVar, t.TempDir())
+			h := newChainStubHandler(alice, bob, mallory)
+			tt.setup(h)
+			srv, credPath := newCLICommandTestServer(t, h)
+			client, err := newClientFromCreds(srv.URL, credPath)
+			if err != nil {
+				t.Fatalf("newClientFromCreds: %v", err)
+			}
+
+			link, err := verifyChainLink(client, newPeerKeys(client, srv.URL), h.entries[0], tt.allowed)
+			if err != nil {
+				t.Fatalf("verifyChainLink() error: %v", err)
+			}
+			if link.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q (problem: %s)", link.Status, tt.wantStatus, link.Problem)
+			}
+			if (link.Problem != "") != tt.wantProblem {
+				t.Errorf("problem = %q, want problem %v", link.Problem, tt.wantProblem)
+			}
+			if link.Author != h.entries[0].Creator.AgentPrincipal.Fingerprint {
+				t.Errorf("author = %q, want %q", link.Author, h.entries[0].Creator.AgentPrincipal.Fingerprint)
+			}
+		})
+	}
+}
+
+func TestRunDiaryVerifyChainCmd_InvalidLinkExitCode(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	alice, bob := testChainKeyPair(t, 1), testChainKeyPair(t, 2)
+	h := newChainStubHandler(alice, bob)
+	h.addEntry(alice, 1, true)
+	h.addEntry(bob, 2, true)
+	srv, credPath := newCLICommandTestServer(t, h)
+
+	err := runDiaryVerifyChainCmd(srv.URL, credPath, testDiaryID.String(), []string{alice.Fingerprint})
+	if err == nil {
+		t.Fatal("expected an error for a link by a non-allowed signer")
+	}
+	if code := errorExitCode(err); code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+}
+
+func TestPeerKeys_RefetchesStaleCachedKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(configDirEnvVar, dir)
+	alice, mallory := testChainKeyPair(t, 1), testChainKeyPair(t, 3)
+	h := newChainStubHandler(alice)
+	srv, credPath := newCLICommandTestServer(t, h)
+	client, err := newClientFromCreds(srv.URL, credPath)
+	if err != nil {
+		t.Fatalf("newClientFromCreds: %v", err)
+	}
+
+	// A cached key from before a rotation must not fail the check.
+	stale := map[string]map[string]peerKeyCacheEntry{
+		srv.URL: {alice.Fingerprint: {PublicKey: mallory.PublicKey, FetchedAt: timeNow().UTC().Format(time.RFC3339)}},
+	}
+	if err := writeJSONAtomic(filepath.Join(dir, peerKeyCacheFile), stale); err != nil {
+		t.Fatalf("write cache: %v", err)
+	}
+	key, problem, err := newPeerKeys(client, srv.URL).checkSignerKey(alice.Fingerprint, alice.PublicKey)
+	if err != nil {
+		t.Fatalf("checkSignerKey() error: %v", err)
+	}
+	if problem != "" || key != alice.PublicKey {
+		t.Errorf("checkSignerKey() = %q, %q; want the re-fetched key and no problem", key, problem)
+	}
+	if h.lookups[alice.Fingerprint] != 1 {
+		t.Errorf("lookups = %d, want 1 re-fetch", h.lookups[alice.Fingerprint])
+	}
+	if _, err := os.Stat(filepath.Join(dir, peerKeyCacheFile)); err != nil {
+		t.Errorf("cache file: %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// Visibility is a property of a diary, not of an entry, so a bulk
+// visibility change selects diaries: those holding entries with a tag, or
+// those created since a time bound.
+type diaryVisibilityOptions struct {
+	match  string
+	to     string
+	dryRun bool
+	mode   bulkMode
+}
+
+// diaryMatch is a parsed --match selector. Exactly one field is set.
+type diaryMatch struct {
+	tag   string
+	since time.Time
+}
+
+// parseDiaryMatch reads "since:<bound>" (see parseTimeBound), "tag:<tag>"
+// or a bare tag.
+func parseDiaryMatch(raw string) (diaryMatch, error) {
+	raw = strings.TrimSpace(raw)
+	if bound, ok := strings.CutPrefix(raw, "since:"); ok {
+		t, err := parseTimeBound(bound)
+		if err != nil || t.IsZero() {
+			return diaryMatch{}, fmt.Errorf("invalid --match %q: want since:<time>, e.g. since:7d", raw)
+		}
+		return diaryMatch{since: t}, nil
+	}
+	tag := strings.TrimPrefix(raw, "tag:")
+	if tag == "" {
+		return diaryMatch{}, fmt.Errorf("invalid --match %q: want a tag or since:<time>", raw)
+	}
+	return diaryMatch{tag: tag}, nil
+}
+
+// visibilityRejection is a diary left unchanged by a visibility rule.
+type visibilityRejection struct {
+	DiaryID string `json:"diaryId"`
+	Name    string `json:"name"`
+	Reason  string `json:"reason"`
+}
+
+// visibilitySummary is printed to stdout when diary set-visibility
+// finishes. Succeeded counts updated diaries (or, with --dry-run, diaries
+// that would be).
+type visibilitySummary struct {
+	DryRun    bool                  `json:"dryRun"`
+	To        string                `json:"to"`
+	Matched   int                   `json:"matched"`
+	Unchanged int                   `json:"unchanged"`
+	Rejected  []visibilityRejection `json:"rejected"`
+	bulkSummary
+}
+
+// isSealedEnvelope reports whether entry content is an encrypt-command
+// envelope rather than plaintext.
+func isSealedEnvelope(content string) bool {
+	var env SealedEnvelope
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &env); err != nil {
+		return false
+	}
+	return env.Ciphertext != "" && env.EphemeralPublicKey != "" && env.Algorithm != ""
+}
+
+// diaryHasTag reports whether any entry in the diary carries tag.
+func diaryHasTag(client *moltnetapi.Client, d moltnetapi.DiaryCatalog, tag string) (bool, error) {
+	res, err := client.ListDiaryEntries(context.Background(), moltnetapi.ListDiaryEntriesParams{
+		DiaryId: d.ID,
+		Tags:    []string{tag},
+		Limit:   moltnetapi.OptFloat64{Value: 1, Set: true},
+	})
+	if err != nil {
+		return false, formatTransportError(err)
+	}
+	list, ok := res.(*moltnetapi.DiaryList)
+	if !ok {
+		return false, formatAPIError(res)
+	}
+	return len(list.Items) > 0, nil
+}
+
+// publicVisibilityRejection returns why a diary must not become public, or
+// "" when it may: encrypted entries stay out of the public feed.
+func publicVisibilityRejection(client *moltnetapi.Client, d moltnetapi.DiaryCatalog) (string, error) {
+	entries, err := fetchAllDiaryEntries(context.Background(), client, d.ID, retagPageSize)
+	if err != nil {
+		return "", err
+	}
+	encrypted := 0
+	for _, e := range entries {
+		if isSealedEnvelope(e.Content) {
+			encrypted++
+		}
+	}
+	if encrypted > 0 {
+		return fmt.Sprintf("holds %d encrypted entr%s; encrypted content is never made public", encrypted, pluralIes(encrypted)), nil
+	}
+	return "", nil
+}
+
+func pluralIes(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+// runDiarySetVisibilityCmd changes the visibility of every diary selected
+// by --match. Matching diaries are collected and checked against the
+// visibility rules first; the remaining changes are confirmed, then applied
+// one by one under opts.mode.
+func runDiarySetVisibilityCmd(apiURL, credPath string, opts diaryVisibilityOptions) error {
+	if err := moltnetapi.UpdateDiaryReqVisibility(opts.to).Validate(); err != nil {
+		return &usageError{err: fmt.Errorf("diary set-visibility: invalid --to %q (private, moltnet, public)", opts.to)}
+	}
+	match, err := parseDiaryMatch(opts.match)
+	if err != nil {
+		return &usageError{err: fmt.Errorf("diary set-visibility: %w", err)}
+	}
+
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	res, err := client.ListDiaries(context.Background(), moltnetapi.ListDiariesParams{})
+	if err != nil {
+		return fmt.Errorf("diary set-visibility: %w", formatTransportError(err))
+	}
+	list, ok := res.(*moltnetapi.DiaryCatalogList)
+	if !ok {
+		return formatAPIError(res)
+	}
+
+	summary := visibilitySummary{DryRun: opts.dryRun, To: opts.to, Rejected: []visibilityRejection{}}
+	var pending []moltnetapi.DiaryCatalog
+	for _, d := range list.Items {
+		if match.tag != "" {
+			has, err := diaryHasTag(client, d, match.tag)
+			if err != nil {
+				return fmt.Errorf("diary set-visibility: %s: %w", d.ID, err)
+			}
+			if !has {
+				continue
+			}
+		} else if d.CreatedAt.Before(match.since) {
+			continue
+		}
+		summary.Matched++
+		if string(d.Visibility) == opts.to {
+			summary.Unchanged++
+			continue
+		}
+		if opts.to == string(moltnetapi.UpdateDiaryReqVisibilityPublic) {
+			reason, err := publicVisibilityRejection(client, d)
+			if err != nil {
+				return fmt.Errorf("diary set-visibility: %s: %w", d.ID, err)
+			}
+			if reason != "" {
+				summary.Rejected = append(summary.Rejected, visibilityRejection{DiaryID: d.ID.String(), Name: d.Name, Reason: reason})
+				fmt.Fprintf(os.Stderr, "  [rejected] %s (%s): %s\n", d.ID, d.Name, reason)
+				continue
+			}
+		}
+		pending = append(pending, d)
+	}
+
+	if len(pending) > 0 && !opts.dryRun {
+		ok, err := confirmAction(fmt.Sprintf("Change visibility of %d diar%s to %s?", len(pending), pluralIes(len(pending)), opts.to))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return expectedErrorf("diary set-visibility: cancelled, nothing changed")
+		}
+	}
+
+	run := newBulkRunner(opts.mode)
+	for _, d := range pending {
+		if opts.dryRun {
+			run.succeed()
+			fmt.Fprintf(os.Stderr, "  [would update] %s (%s): %s -> %s\n", d.ID, d.Name, d.Visibility, opts.to)
+			continue
+		}
+		res, err := client.UpdateDiary(context.Background(), moltnetapi.NewOptUpdateDiaryReq(moltnetapi.UpdateDiaryReq{
+			Visibility: moltnetapi.NewOptUpdateDiaryReqVisibility(moltnetapi.UpdateDiaryReqVisibility(opts.to)),
+		}), moltnetapi.UpdateDiaryParams{ID: d.ID})
+		if err == nil {
+			if _, ok := res.(*moltnetapi.DiaryCatalog); !ok {
+				err = formatAPIError(res)
+			}
+		} else {
+			err = formatTransportError(err)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [failed] %s (%s): %v\n", d.ID, d.Name, err)
+			if run.fail(d.ID.String(), err) {
+				break
+			}
+			continue
+		}
+		run.succeed()
+		fmt.Fprintf(os.Stderr, "  [updated] %s (%s): %s -> %s\n", d.ID, d.Name, d.Visibility, opts.to)
+	}
+
+	summary.bulkSummary = run.summary
+	verb := "changed"
+	if opts.dryRun {
+		verb = "would be changed"
+	}
+	fmt.Fprintf(os.Stderr, "%d matched, %d %s, %d unchanged, %d rejected, %d failed.\n",
+		summary.Matched, summary.Succeeded, verb, summary.Unchanged, len(summary.Rejected), summary.Failed)
+	if err := printJSON(summary); err != nil {
+		return err
+	}
+	return run.err("diary set-visibility")
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// visibilityStubHandler serves a fixed set of diaries and entries and
+// records visibility updates.
+type visibilityStubHandler struct {
+	stubDiaryHandler
+	diaries []moltnetapi.DiaryCatalog
+	entries map[uuid.UUID][]moltnetapi.DiaryEntry
+	updated map[uuid.UUID]string
+}
+
+func (h *visibilityStubHandler) ListDiaries(_ context.Context, _ moltnetapi.ListDiariesParams) (moltnetapi.ListDiariesRes, error) {
+	return &moltnetapi.DiaryCatalogList{Items: h.diaries}, nil
+}
+
+func (h *visibilityStubHandler) ListDiaryEntries(_ context.Context, params moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	var items []moltnetapi.DiaryEntry
+	for _, e := range h.entries[params.DiaryId] {
+		if len(params.Tags) == 0 || slices.Contains(e.Tags, params.Tags[0]) {
+			items = append(items, e)
+		}
+	}
+	return &moltnetapi.DiaryList{Items: items, Total: float64(len(items))}, nil
+}
+
+func (h *visibilityStubHandler) UpdateDiary(_ context.Context, req moltnetapi.OptUpdateDiaryReq, params moltnetapi.UpdateDiaryParams) (moltnetapi.UpdateDiaryRes, error) {
+	h.updated[params.ID] = string(req.Value.Visibility.Value)
+	d := newTestDiary("updated")
+	d.ID = params.ID
+	return d, nil
+}
+
+func newVisibilityStub(now time.Time) *visibilityStubHandler {
+	h := &visibilityStubHandler{entries: map[uuid.UUID][]moltnetapi.DiaryEntry{}, updated: map[uuid.UUID]string{}}
+	for i, age := range []time.Duration{time.Hour, 48 * time.Hour, 24 * time.Hour} {
+		d := newTestDiary("diary")
+		d.ID = uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", 101+i))
+		d.CreatedAt = now.Add(-age)
+		h.diaries = append(h.diaries, *d)
+	}
+	plain := newTestEntry("release notes")
+	plain.Tags = []string{"release"}
+	sealed := newTestEntry(`{"v":1,"ephemeral_public_key":"abc","nonce":"n","ciphertext":"c","algorithm":"x25519-xsalsa20-poly1305"}`)
+	sealed.Tags = []string{"release"}
+	h.entries[h.diaries[0].ID] = []moltnetapi.DiaryEntry{*plain}
+	h.entries[h.diaries[1].ID] = []moltnetapi.DiaryEntry{*plain, *sealed}
+	return h
+}
+
+func TestParseDiaryMatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		raw     string
+		wantTag string
+		wantErr bool
+	}{
+		{"release", "release", false},
+		{"tag:scope:cli", "scope:cli", false},
+		{"since:7d", "", false},
+		{"since:", "", true},
+		{"since:soon", "", true},
+		{"tag:", "", true},
+	}
+	for _, tt := range tests {
+		m, err := parseDiaryMatch(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDiaryMatch(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if err == nil && m.tag != tt.wantTag {
+			t.Errorf("parseDiaryMatch(%q) tag = %q, want %q", tt.raw, m.tag, tt.wantTag)
+		}
+	}
+}
+
+func TestIsSealedEnvelope(t *testing.T) {
+	t.Parallel()
+	if !isSealedEnvelope(`{"v":1,"ephemeral_public_key":"a","nonce":"n","ciphertext":"c","algorithm":"x"}`) {
+		t.Error("sealed envelope not detected")
+	}
+	for _, content := range []string{"plain text", `{"ciphertext":"c"}`, `{"key":"value"}`} {
+		if isSealedEnvelope(content) {
+			t.Errorf("isSealedEnvelope(%q) = true", content)
+		}
+	}
+}
+
+func TestRunDiarySetVisibilityCmd(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+
+	t.Run("public rejects diaries with encrypted entries", func(t *testing.T) {
+		setAssumeYes(true)
+		t.Cleanup(func() { setAssumeYes(false) })
+		h := newVisibilityStub(now)
+		srv, credPath := newCLICommandTestServer(t, h)
+		opts := diaryVisibilityOptions{match: "release", to: "public", mode: bulkFailFast}
+		if err := runDiarySetVisibilityCmd(srv.URL, credPath, opts); err != nil {
+			t.Fatalf("runDiarySetVisibilityCmd() error: %v", err)
+		}
+		if len(h.updated) != 1 || h.updated[h.diaries[0].ID] != "public" {
+			t.Errorf("updated = %v, want only %s made public", h.updated, h.diaries[0].ID)
+		}
+	})
+
+	t.Run("since selects recent diaries", func(t *testing.T) {
+		setAssumeYes(true)
+		t.Cleanup(func() { setAssumeYes(false) })
+		h := newVisibilityStub(now)
+		h.diaries[0].Visibility = moltnetapi.DiaryCatalogVisibilityPrivate
+		srv, credPath := newCLICommandTestServer(t, h)
+		opts := diaryVisibilityOptions{match: "since:30h", to: "private", mode: bulkFailFast}
+		if err := runDiarySetVisibilityCmd(srv.URL, credPath, opts); err != nil {
+			t.Fatalf("runDiarySetVisibilityCmd() error: %v", err)
+		}
+		// diary 0 is already private, diary 1 is too old.
+		if len(h.updated) != 1 || h.updated[h.diaries[2].ID] != "private" {
+			t.Errorf("updated = %v, want only %s made private", h.updated, h.diaries[2].ID)
+		}
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		h := newVisibilityStub(now)
+		srv, credPath := newCLICommandTestServer(t, h)
+		opts := diaryVisibilityOptions{match: "release", to: "private", dryRun: true, mode: bulkFailFast}
+		if err := runDiarySetVisibilityCmd(srv.URL, credPath, opts); err != nil {
+			t.Fatalf("runDiarySetVisibilityCmd() error: %v", err)
+		}
+		if len(h.updated) != 0 {
+			t.Errorf("updated = %v, want none", h.updated)
+		}
+	})
+
+	t.Run("declined confirmation changes nothing", func(t *testing.T) {
+		origConfirm := confirmPrompt
+		confirmPrompt = func(string) (bool, error) { return false, nil }
+		t.Cleanup(func() { confirmPrompt = origConfirm })
+		h := newVisibilityStub(now)
+		srv, credPath := newCLICommandTestServer(t, h)
+		opts := diaryVisibilityOptions{match: "release", to: "private", mode: bulkFailFast}
+		err := runDiarySetVisibilityCmd(srv.URL, credPath, opts)
+		if errorExitCode(err) != 3 {
+			t.Errorf("exit code = %d, want 3 (err %v)", errorExitCode(err), err)
+		}
+		if len(h.updated) != 0 {
+			t.Errorf("updated = %v, want none", h.updated)
+		}
+	})
+
+	t.Run("invalid visibility is a usage error", func(t *testing.T) {
+		err := runDiarySetVisibilityCmd("http://unused", "", diaryVisibilityOptions{match: "release", to: "secret"})
+		var uerr *usageError
+		if !errors.As(err, &uerr) {
+			t.Errorf("error = %v, want a usage error", err)
+		}
+	})
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// doctorOptions configures a doctor run. Without fix every problem is only
+// reported; destructive fixes are confirmed first (see confirmAction).
+type doctorOptions struct {
+	mcpDir string
+	fix    bool
+}
+
+// doctorCheck is one finding of a doctor run.
+type doctorCheck struct {
+	Name   string
+	Status string // "ok", "warning", "problem", "fixed", "failed"
+	Detail string
+}
+
+// doctorReport prints each finding as it is made and keeps the tally.
+type doctorReport struct {
+	w      io.Writer
+	checks []doctorCheck
+}
+
+func (r *doctorReport) add(name, status, format string, args ...any) {
+	c := doctorCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)}
+	r.checks = append(r.checks, c)
+	fmt.Fprintf(r.w, "  [%s] %s: %s\n", c.Status, c.Name, c.Detail)
+}
+
+func (r *doctorReport) count(status string) int {
+	n := 0
+	for _, c := range r.checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// runDoctorCmd checks the local setup: the config (what config repair
+// would fix), .mcp.json drift, git config token pollution, file
+// permissions, the key fingerprint, the exported SSH key and a conflicting
+// legacy credentials.json. With opts.fix it remediates what it safely can;
+// re-exporting the SSH key overwrites files and is confirmed first.
+// Anything ambiguous stays a warning. Problems left unfixed are an
+// expected error (exit code 3).
+func runDoctorCmd(w io.Writer, credPath string, opts doctorOptions) error {
+	r := &doctorReport{w: w}
+	configPath, creds, issues, err := loadAndValidate(credPath)
+	if err != nil {
+		r.add("config", "failed", "%v", err)
+		return expectedErrorf("doctor: %v", err)
+	}
+	fmt.Fprintf(w, "Checking %s\n", configPath)
+
+	configPath = doctorConfig(r, configPath, creds, issues, opts.fix)
+	if err := doctorMcpConfig(r, creds, opts); err != nil {
+		return err
+	}
+	doctorGitConfig(r, creds, opts.fix)
+	doctorPermissions(r, credPath, configPath, creds, opts.fix)
+	doctorFingerprint(r, configPath, creds, opts.fix)
+	doctorSSHKey(r, credPath, creds, opts)
+	if credPath == "" {
+		switch iss, legacyPath := detectLegacyConfigConflict(configPath, creds); {
+		case iss != nil && legacyPath != "":
+			r.add("legacy-config", "warning", "%s; run 'moltnet config repair --archive-legacy' to move it aside", iss.Problem)
+		case iss != nil:
+			r.add("legacy-config", "warning", "%s", iss.Problem)
+		case legacyPath != "":
+			r.add("legacy-config", "ok", "%s is for the same identity; nothing to migrate", legacyPath)
+		}
+	}
+
+	unresolved := r.count("problem") + r.count("failed")
+	fmt.Fprintf(w, "\n%d ok, %d fixed, %d warning(s), %d problem(s)\n",
+		r.count("ok"), r.count("fixed"), r.count("warning"), unresolved)
+	if unresolved > 0 {
+		if !opts.fix {
+			return expectedErrorf("doctor: %d problem(s) found; run 'moltnet doctor --fix' to fix them", unresolved)
+		}
+		return expectedErrorf("doctor: %d problem(s) left unfixed", unresolved)
+	}
+	return nil
+}
+
+// doctorConfig reports the validation issues of the config and, with fix,
+// persists the in-memory fixes loadAndValidate made, migrating a legacy
+// credentials.json to moltnet.json. It returns the config path in use
+// afterwards.
+func doctorConfig(r *doctorReport, configPath string, creds *CredentialsFile, issues []ConfigIssue, fix bool) string {
+	var fixes []ConfigIssue
+	migrate := false
+	for _, iss := range issues {
+		switch iss.Action {
+		case "fixed", "migrate":
+			fixes = append(fixes, iss)
+			migrate = migrate || iss.Action == "migrate"
+		default:
+			r.add("config", "warning", "%s: %s", iss.Field, iss.Problem)
+		}
+	}
+	if len(fixes) == 0 {
+		if len(issues) == 0 {
+			r.add("config", "ok", "valid")
+		}
+		return configPath
+	}
+	if !fix {
+		for _, iss := range fixes {
+			r.add("config", "problem", "%s: %s", iss.Field, iss.Problem)
+		}
+		return configPath
+	}
+
+	var err error
+	if migrate {
+		var newPath string
+		if newPath, err = migrateConfig(configPath, creds); err == nil {
+			configPath = newPath
+		}
+	} else {
+		_, err = WriteConfigTo(creds, configPath)
+	}
+	for _, iss := range fixes {
+		if err != nil {
+			r.add("config", "failed", "%s: %s: %v", iss.Field, iss.Problem, err)
+		} else {
+			r.add("config", "fixed", "%s: %s", iss.Field, iss.Problem)
+		}
+	}
+	return configPath
+}
+
+// doctorMcpConfig reports drift of the moltnet entry in .mcp.json and,
+// with fix, rewrites it from the config.
+func doctorMcpConfig(r *doctorReport, creds *CredentialsFile, opts doctorOptions) error {
+	mcpPath, err := resolveMcpConfigPath(opts.mcpDir)
+	if err != nil {
+		return err
+	}
+	mcpIssues, err := inspectMcpConfig(mcpPath, creds)
+	if err != nil {
+		r.add("mcp", "warning", "%v", err)
+		return nil
+	}
+	if len(mcpIssues) == 0 {
+		if fileExists(mcpPath) {
+			r.add("mcp", "ok", "%s matches the config", mcpPath)
+		}
+		return nil
+	}
+	if !opts.fix {
+		for _, iss := range mcpIssues {
+			r.add("mcp", "problem", "%s", iss.Problem)
+		}
+		return nil
+	}
+	if _, err := repairMcpConfig(mcpPath, creds); err != nil {
+		r.add("mcp", "failed", "could not refresh %s: %v", mcpPath, err)
+		return nil
+	}
+	r.add("mcp", "fixed", "refreshed the moltnet server entry in %s", mcpPath)
+	return nil
+}
+
+// doctorGitConfig reports GitHub tokens embedded in git config files and
+// github.com credential blocks missing the helper reset, and with fix
+// repairs them in place.
+func doctorGitConfig(r *doctorReport, creds *CredentialsFile, fix bool) {
+	candidates := gitConfigCandidates(creds)
+	for _, p := range pollutedGitConfigs(candidates) {
+		if !fix {
+			r.add("git-config", "problem", "embedded GitHub token found in %s", p)
+		} else if _, err := repairGitConfigTokens(p); err != nil {
+			r.add("git-config", "failed", "could not scrub %s: %v", p, err)
+		} else {
+			r.add("git-config", "fixed", "stripped embedded GitHub token from %s", p)
+		}
+	}
+	for _, p := range shadowProneGitconfigs(candidates) {
+		if !fix {
+			r.add("git-config", "problem", "github.com credential helper missing reset (shadow-prone) in %s", p)
+		} else if _, err := repairHelperShadowing(p); err != nil {
+			r.add("git-config", "failed", "could not fix helper shadowing in %s: %v", p, err)
+		} else {
+			r.add("git-config", "fixed", "added credential helper reset to %s", p)
+		}
+	}
+}
+
+// doctorPermissions checks that the config and the private keys it points
+// at are readable by the owner only, and with fix tightens them. The
+// config directory is only checked when it is the CLI's own (no
+// --credentials): an explicit path may live in a shared directory.
+func doctorPermissions(r *doctorReport, credPath, configPath string, creds *CredentialsFile, fix bool) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	type target struct {
+		path string
+		mode os.FileMode
+	}
+	var targets []target
+	if credPath == "" {
+		targets = append(targets, target{filepath.Dir(configPath), 0o700})
+	}
+	targets = append(targets, target{configPath, 0o600})
+	if creds.SSH != nil {
+		targets = append(targets, target{creds.SSH.PrivateKeyPath, 0o600})
+	}
+	if creds.GitHub != nil {
+		targets = append(targets, target{creds.GitHub.PrivateKeyPath, 0o600})
+	}
+
+	loose := 0
+	for _, t := range targets {
+		if t.path == "" {
+			continue
+		}
+		info, err := os.Stat(t.path)
+		if err != nil {
+			continue // a missing file is reported by the config check
+		}
+		perm := info.Mode().Perm()
+		if perm&^t.mode == 0 {
+			continue
+		}
+		loose++
+		if !fix {
+			r.add("permissions", "problem", "%s is %04o, want %04o", t.path, perm, t.mode)
+		} else if err := os.Chmod(t.path, t.mode); err != nil {
+			r.add("permissions", "failed", "chmod %s: %v", t.path, err)
+		} else {
+			r.add("permissions", "fixed", "changed %s from %04o to %04o", t.path, perm, t.mode)
+		}
+	}
+	if loose == 0 {
+		r.add("permissions", "ok", "config and private keys are owner-only")
+	}
+}
+
+// doctorFingerprint checks keys.fingerprint against the public key. A
+// missing fingerprint is derived and, with fix, written back; a mismatch
+// is ambiguous (either value may be the wrong one) and only reported.
+func doctorFingerprint(r *doctorReport, configPath string, creds *CredentialsFile, fix bool) {
+	if creds.Keys.PublicKey == "" {
+		return // reported by the config check
+	}
+	pub, err := ParsePublicKey(creds.Keys.PublicKey)
+	if err != nil {
+		r.add("fingerprint", "warning", "cannot derive the fingerprint: %v", err)
+		return
+	}
+	want := Fingerprint(pub)
+	switch {
+	case creds.Keys.Fingerprint == want:
+		r.add("fingerprint", "ok", "%s matches the public key", want)
+	case creds.Keys.Fingerprint != "":
+		r.add("fingerprint", "warning", "keys.fingerprint %s does not match the public key (%s); check which is right and fix it with 'moltnet config set keys.fingerprint'",
+			creds.Keys.Fingerprint, want)
+	case !fix:
+		r.add("fingerprint", "problem", "keys.fingerprint is missing (the public key derives %s)", want)
+	default:
+		creds.Keys.Fingerprint = want
+		if _, err := WriteConfigTo(creds, configPath); err != nil {
+			creds.Keys.Fingerprint = ""
+			r.add("fingerprint", "failed", "could not write keys.fingerprint: %v", err)
+			return
+		}
+		r.add("fingerprint", "fixed", "set keys.fingerprint to %s", want)
+	}
+}
+
+// doctorSSHKey checks that the exported SSH key still matches the identity
+// key. Re-exporting overwrites the key files, so with fix it is confirmed
+// first (or --assume-yes), then done the way migrate-ssh does it.
+func doctorSSHKey(r *doctorReport, credPath string, creds *CredentialsFile, opts doctorOptions) {
+	if creds.SSH == nil || creds.Keys.PublicKey == "" {
+		return
+	}
+	pubSSH, err := ToSSHPublicKey(creds.Keys.PublicKey)
+	if err != nil {
+		return // reported by the fingerprint check
+	}
+	stale := verifyExportedSSHKey(creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath, pubSSH)
+	if stale == nil {
+		r.add("ssh-key", "ok", "%s matches the identity key", creds.SSH.PublicKeyPath)
+		return
+	}
+	if !opts.fix {
+		r.add("ssh-key", "problem", "exported key is stale: %v", stale)
+		return
+	}
+	ok, err := confirmAction(fmt.Sprintf("Overwrite %s with the identity key?", creds.SSH.PrivateKeyPath))
+	if err != nil || !ok {
+		r.add("ssh-key", "problem", "exported key is stale: %v (not re-exported: confirm or pass --assume-yes)", stale)
+		return
+	}
+	if err := runMigrateSSHCmd(io.Discard, credPath); err != nil {
+		r.add("ssh-key", "failed", "could not re-export: %v", err)
+		return
+	}
+	detail := "re-exported the identity key to " + creds.SSH.PrivateKeyPath
+	if creds.Git != nil {
+		detail += " and updated allowed_signers and the gitconfig"
+	}
+	r.add("ssh-key", "fixed", "%s", detail)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newDoctorFixture writes a config whose fingerprint is missing, whose
+// exported SSH key predates a key rotation and whose files are
+// world-readable.
+func newDoctorFixture(t *testing.T) string {
+	t.Helper()
+	t.Setenv(configDirEnvVar, t.TempDir())
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+	oldKP, _ := KeyPairFromSeed(make([]byte, 32))
+	creds := &CredentialsFile{
+		IdentityID: "test-agent-12345678",
+		Keys:       CredentialsKeys{PublicKey: oldKP.PublicKey, PrivateKey: oldKP.PrivateKey},
+		Endpoints:  CredentialsEndpoints{API: "https://api.example.com", MCP: deriveMCPURL("https://api.example.com")},
+	}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSSHKeyExportCmd(credPath, ""); err != nil {
+		t.Fatalf("ssh-key: %v", err)
+	}
+	creds, err := ReadConfigFrom(credPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed := make([]byte, 32)
+	seed[0] = 1
+	newKP, _ := KeyPairFromSeed(seed)
+	creds.Keys = CredentialsKeys{PublicKey: newKP.PublicKey, PrivateKey: newKP.PrivateKey}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{credPath, creds.SSH.PrivateKeyPath} {
+		if err := os.Chmod(p, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return credPath
+}
+
+func TestRunDoctorCmd_ReportsWithoutFixing(t *testing.T) {
+	credPath := newDoctorFixture(t)
+
+	var out bytes.Buffer
+	err := runDoctorCmd(&out, credPath, doctorOptions{mcpDir: t.TempDir()})
+	if code := errorExitCode(err); code != 3 {
+		t.Fatalf("exit code = %d (err %v), want 3", code, err)
+	}
+	for _, want := range []string{"[problem] permissions", "[problem] fingerprint", "[problem] ssh-key"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	creds, _ := ReadConfigFrom(credPath)
+	if creds.Keys.Fingerprint != "" {
+		t.Errorf("fingerprint written without --fix: %q", creds.Keys.Fingerprint)
+	}
+	if info, _ := os.Stat(credPath); info.Mode().Perm() != 0o644 {
+		t.Errorf("mode changed without --fix: %o", info.Mode().Perm())
+	}
+}
+
+func TestRunDoctorCmd_Fix(t *testing.T) {
+	credPath := newDoctorFixture(t)
+	origConfirm := confirmPrompt
+	confirmPrompt = func(string) (bool, error) { return false, nil }
+	t.Cleanup(func() { confirmPrompt = origConfirm })
+
+	// Declining the SSH re-export leaves that one problem.
+	var out bytes.Buffer
+	err := runDoctorCmd(&out, credPath, doctorOptions{mcpDir: t.TempDir(), fix: true})
+	if code := errorExitCode(err); code != 3 {
+		t.Fatalf("exit code = %d (err %v), want 3 with the SSH key unconfirmed", code, err)
+	}
+	if !strings.Contains(out.String(), "[problem] ssh-key") || !strings.Contains(out.String(), "[fixed] fingerprint") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+	creds, _ := ReadConfigFrom(credPath)
+	pub, _ := ParsePublicKey(creds.Keys.PublicKey)
+	if creds.Keys.Fingerprint != Fingerprint(pub) {
+		t.Errorf("fingerprint = %q, want %q", creds.Keys.Fingerprint, Fingerprint(pub))
+	}
+	for _, p := range []string{credPath, creds.SSH.PrivateKeyPath} {
+		if info, _ := os.Stat(p); info.Mode().Perm() != 0o600 {
+			t.Errorf("%s mode = %o, want 600", p, info.Mode().Perm())
+		}
+	}
+
+	out.Reset()
+	setAssumeYes(true)
+	t.Cleanup(func() { setAssumeYes(false) })
+	if err := runDoctorCmd(&out, credPath, doctorOptions{mcpDir: t.TempDir(), fix: true}); err != nil {
+		t.Fatalf("doctor --fix --assume-yes: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "[fixed] ssh-key") {
+		t.Errorf("SSH key not re-exported:\n%s", out.String())
+	}
+	pubSSH, _ := ToSSHPublicKey(creds.Keys.PublicKey)
+	if err := verifyExportedSSHKey(creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath, pubSSH); err != nil {
+		t.Errorf("exported key still stale: %v", err)
+	}
+}
+
+func TestRunDoctorCmd_FingerprintMismatchIsOnlyAWarning(t *testing.T) {
+	setAssumeYes(true)
+	t.Cleanup(func() { setAssumeYes(false) })
+	t.Setenv(configDirEnvVar, t.TempDir())
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+	kp, _ := KeyPairFromSeed(make([]byte, 32))
+	creds := &CredentialsFile{
+		IdentityID: "test-agent-12345678",
+		Keys:       CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey, Fingerprint: "AAAA-BBBB-CCCC-DDDD"},
+		Endpoints:  CredentialsEndpoints{API: "https://api.example.com", MCP: deriveMCPURL("https://api.example.com")},
+	}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runDoctorCmd(&out, credPath, doctorOptions{mcpDir: t.TempDir(), fix: true}); err != nil {
+		t.Fatalf("doctor --fix: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "[warning] fingerprint") {
+		t.Errorf("mismatch not reported as a warning:\n%s", out.String())
+	}
+	if got, _ := ReadConfigFrom(credPath); got.Keys.Fingerprint != "AAAA-BBBB-CCCC-DDDD" {
+		t.Errorf("fingerprint rewritten to %q", got.Keys.Fingerprint)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+)
+
+// dryRunAnnotation marks commands that accept the uniform --dry-run flag.
+const dryRunAnnotation = "moltnet/dry-run"
+
+// errDryRun is returned by the dry-run transport in place of sending a
+// mutating request. Execute treats it as success.
+var errDryRun = errors.New("dry run: request not sent")
+
+// dryRunOutput holds where request previews go while --dry-run is active.
+// It is set by the root command's PersistentPreRunE; nil disables.
+var dryRunOutput atomic.Pointer[io.Writer]
+
+// withDryRunFlag adds --dry-run to a command that mutates server state.
+// With the flag, API clients print the first mutating request instead of
+// sending it; reads the command needs on the way still go through.
+func withDryRunFlag(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[dryRunAnnotation] = "true"
+	cmd.Flags().Bool("dry-run", false, "Print the request (method, path, body) that would be sent without sending it")
+	return cmd
+}
+
+// configureDryRun enables request previews when cmd was marked by
+// withDryRunFlag and --dry-run is set.
+func configureDryRun(cmd *cobra.Command) {
+	if cmd.Annotations[dryRunAnnotation] == "" {
+		dryRunOutput.Store(nil)
+		return
+	}
+	if on, _ := cmd.Flags().GetBool("dry-run"); !on {
+		dryRunOutput.Store(nil)
+		return
+	}
+	w := cmd.OutOrStdout()
+	dryRunOutput.Store(&w)
+}
+
+func currentDryRunOutput() io.Writer {
+	if w := dryRunOutput.Load(); w != nil {
+		return *w
+	}
+	return nil
+}
+
+// dryRunRedactedHeaders are never echoed in a preview.
+var dryRunRedactedHeaders = map[string]bool{"Authorization": true, "Cookie": true}
+
+// dryRunRequest is the preview printed instead of a mutating request.
+type dryRunRequest struct {
+	DryRun  bool              `json:"dryRun"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	RawBody string            `json:"rawBody,omitempty"`
+}
+
+// dryRunTransport passes safe methods through to base and answers every
+// other request with a printed preview and errDryRun.
+type dryRunTransport struct {
+	base http.RoundTripper
+	w    io.Writer
+}
+
+func newDryRunTransport(base http.RoundTripper, w io.Writer) http.RoundTripper {
+	if base == nil {
+		base = baseTransport()
+	}
+	return &dryRunTransport{base: base, w: w}
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return t.base.RoundTrip(req)
+	}
+
+	preview := dryRunRequest{DryRun: true, Method: req.Method, Path: req.URL.RequestURI()}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if !dryRunRedactedHeaders[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if preview.Headers == nil {
+			preview.Headers = map[string]string{}
+		}
+		preview.Headers[name] = strings.Join(req.Header.Values(name), ", ")
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("dry run: read request body: %w", err)
+		}
+		switch {
+		case len(bytes.TrimSpace(body)) == 0:
+		case json.Valid(body):
+			preview.Body = body
+		default:
+			preview.RawBody = string(body)
+		}
+	}
+	if err := printJSONTo(t.w, preview); err != nil {
+		return nil, err
+	}
+	return nil, errDryRun
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingRoundTripper struct {
+	calls int
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.calls++
+	return httptest.NewRecorder().Result(), nil
+}
+
+func TestDryRunTransport_PreviewsMutatingRequests(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	base := &recordingRoundTripper{}
+	var out bytes.Buffer
+	rt := newDryRunTransport(base, &out)
+	req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/diaries?x=1", strings.NewReader(`{"name":"notes"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+
+	// Act
+	_, err := rt.RoundTrip(req)
+
+	// Assert
+	if !errors.Is(err, errDryRun) {
+		t.Fatalf("expected errDryRun, got %v", err)
+	}
+	if base.calls != 0 {
+		t.Errorf("mutating request reached the network")
+	}
+	var got dryRunRequest
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decode preview: %v", err)
+	}
+	if !got.DryRun || got.Method != http.MethodPost || got.Path != "/diaries?x=1" {
+		t.Errorf("unexpected preview %+v", got)
+	}
+	if _, ok := got.Headers["Authorization"]; ok || got.Headers["Content-Type"] != "application/json" {
+		t.Errorf("unexpected headers %v", got.Headers)
+	}
+	var body bytes.Buffer
+	if err := json.Compact(&body, got.Body); err != nil || body.String() != `{"name":"notes"}` {
+		t.Errorf("body = %s", got.Body)
+	}
+}
+
+func TestDryRunTransport_PassesReadsThrough(t *testing.T) {
+	t.Parallel()
+	base := &recordingRoundTripper{}
+	var out bytes.Buffer
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/diaries", nil)
+
+	if _, err := newDryRunTransport(base, &out).RoundTrip(req); err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+
+	if base.calls != 1 || out.Len() != 0 {
+		t.Errorf("expected passthrough, calls=%d out=%q", base.calls, out.String())
+	}
+}
+
+func TestDiaryCreate_DryRunDoesNotSend(t *testing.T) {
+	t.Cleanup(func() { dryRunOutput.Store(nil) })
+	srv, credPath := newCLICommandTestServer(t, &stubAgentsHandler{})
+
+	stdout, _, err := executeCommand(NewRootCmd("test", ""),
+		"diary", "create", "--name", "notes", "--team-id", "6e4d9948-8ec5-4f59-b82a-3acbc4bbc396",
+		"--dry-run", "--credentials", credPath, "--api-url", srv.URL)
+
+	if !errors.Is(err, errDryRun) {
+		t.Fatalf("expected dry-run, got %v", err)
+	}
+	var got dryRunRequest
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("decode preview %q: %v", stdout, err)
+	}
+	if got.Method != http.MethodPost || got.Path != "/diaries" || !strings.Contains(string(got.Body), `"notes"`) {
+		t.Errorf("unexpected preview %+v", got)
+	}
+	if got.Headers["X-Moltnet-Team-Id"] != "6e4d9948-8ec5-4f59-b82a-3acbc4bbc396" {
+		t.Errorf("team header missing from preview: %v", got.Headers)
+	}
+}
+
+func TestConfigureDryRun_OnlyMarkedCommands(t *testing.T) {
+	t.Cleanup(func() { dryRunOutput.Store(nil) })
+	root := NewRootCmd("test", "")
+	for _, path := range [][]string{{"diary", "create"}, {"entry", "delete"}, {"vouch", "issue"}, {"teams", "invite", "create"}} {
+		cmd, _, err := root.Find(path)
+		if err != nil || cmd.Flags().Lookup("dry-run") == nil {
+			t.Errorf("%v: expected --dry-run flag", path)
+		}
+	}
+	list, _, _ := root.Find([]string{"diary", "list"})
+
+	configureDryRun(list)
+
+	if currentDryRunOutput() != nil {
+		t.Error("dry-run must stay off for unmarked commands")
+	}
+}
@@ -34,24 +34,30 @@ type SealedEnvelope struct {
 // DeriveX25519PrivateKey derives an X25519 private key from an Ed25519 seed.
 // Follows RFC 8032 §5.1.5: SHA-512(seed)[0:32] with RFC 7748 clamping.
 func DeriveX25519PrivateKey(ed25519SeedBase64 string) (string, error) {
-	seed, err := base64.StdEncoding.DecodeString(ed25519SeedBase64)
-	if err != nil {
-		return "", fmt.Errorf("decode seed: %w", err)
-	}
-	if len(seed) != 32 {
-		return "", fmt.Errorf("seed must be 32 bytes, got %d", len(seed))
-	}
+	var scalarB64 string
+	err := withSeed(ed25519SeedBase64, func(seed []byte) error {
+		scalar := deriveX25519Scalar(seed)
+		defer zeroBytes(scalar)
+		scalarB64 = base64.StdEncoding.EncodeToString(scalar)
+		return nil
+	})
+	return scalarB64, err
+}
 
+// deriveX25519Scalar returns the clamped X25519 private scalar for an
+// Ed25519 seed. The caller must zero it.
+func deriveX25519Scalar(seed []byte) []byte {
 	// SHA-512 expansion — same as what ed25519.NewKeyFromSeed does internally
 	h := sha512.Sum512(seed)
-	scalar := h[:32]
+	defer clear(h[:])
+	scalar := make([]byte, 32)
+	copy(scalar, h[:32])
 
 	// RFC 7748 clamping
 	scalar[0] &= 248  // clear low 3 bits
 	scalar[31] &= 127 // clear high bit
 	scalar[31] |= 64  // set bit 254
-
-	return base64.StdEncoding.EncodeToString(scalar), nil
+	return scalar
 }
 
 // DeriveX25519PublicKey derives an X25519 public key from an Ed25519 public key.
@@ -159,6 +165,7 @@ func encryptWithEphemeral(plaintext string, recipientX25519Pub []byte, ephPriv [
 	// Generate or use provided ephemeral X25519 keypair
 	if ephPriv == nil {
 		ephPriv = make([]byte, 32)
+		defer zeroBytes(ephPriv)
 		if _, err := rand.Read(ephPriv); err != nil {
 			return "", fmt.Errorf("generate ephemeral key: %w", err)
 		}
@@ -173,12 +180,14 @@ func encryptWithEphemeral(plaintext string, recipientX25519Pub []byte, ephPriv [
 	if err != nil {
 		return "", fmt.Errorf("ECDH: %w", err)
 	}
+	defer zeroBytes(shared)
 
 	// HKDF-SHA256: derive 32-byte key
 	key, err := deriveKey(shared)
 	if err != nil {
 		return "", fmt.Errorf("HKDF: %w", err)
 	}
+	defer zeroBytes(key)
 
 	// Generate or use provided nonce (24 bytes for XChaCha20)
 	if nonce == nil {
@@ -239,27 +248,29 @@ func DecryptFromAgent(sealedEnvelopeJSON string, ed25519SeedBase64 string) (stri
 		return "", fmt.Errorf("decode ciphertext: %w", err)
 	}
 
-	// Derive X25519 private key from Ed25519 seed
-	x25519PrivB64, err := DeriveX25519PrivateKey(ed25519SeedBase64)
-	if err != nil {
-		return "", fmt.Errorf("derive X25519 key: %w", err)
-	}
-	x25519Priv, err := base64.StdEncoding.DecodeString(x25519PrivB64)
-	if err != nil {
-		return "", fmt.Errorf("decode X25519 key: %w", err)
-	}
-
-	// ECDH shared secret
-	shared, err := curve25519.X25519(x25519Priv, ephPub)
+	// Derive X25519 private key from Ed25519 seed, then the ECDH shared
+	// secret. Neither outlives this call.
+	var shared []byte
+	err = withSeed(ed25519SeedBase64, func(seed []byte) error {
+		x25519Priv := deriveX25519Scalar(seed)
+		defer zeroBytes(x25519Priv)
+		var err error
+		if shared, err = curve25519.X25519(x25519Priv, ephPub); err != nil {
+			return fmt.Errorf("ECDH: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("ECDH: %w", err)
+		return "", err
 	}
+	defer zeroBytes(shared)
 
 	// HKDF-SHA256
 	key, err := deriveKey(shared)
 	if err != nil {
 		return "", fmt.Errorf("HKDF: %w", err)
 	}
+	defer zeroBytes(key)
 
 	// Decrypt — AAD must match what was used during encryption
 	aad := []byte(fmt.Sprintf("%d:%s", envelope.V, envelope.Algorithm))
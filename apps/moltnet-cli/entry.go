@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
@@ -12,49 +18,114 @@ import (
 
 // --- Entry-level business logic (moved from diary.go) ---
 
-// runEntryCreateCmd creates a diary entry.
-func runEntryCreateCmd(apiURL, credPath, diaryID, content, title, entryType, tagsStr string, importance int, importanceChanged bool) error {
+// entryCreateOptions carries everything "entry create" sends besides the
+// target diary. The zero value creates a plain entry from content.
+type entryCreateOptions struct {
+	content           string
+	title             string
+	entryType         string
+	tags              string // comma-separated
+	importance        int
+	importanceChanged bool
+
+	maxPublicLength int // see warnIfSharedEntryRisky; 0 disables
+	queueOnOffline  bool
+	thread          entryThreadRef
+	dedupe          entryDedupe
+	attach          []string // files to attach; see entry_attachment.go
+}
+
+// runEntryCreateCmd creates a diary entry. Content over the network's
+// published size limit is refused locally (checkEntryContentSize), and
+// entries bound for public or moltnet diaries are pre-scanned first; see
+// warnIfSharedEntryRisky. With
+// queueOnOffline, an unreachable API queues the entry for "entry flush"
+// instead of failing. A non-empty thread links the entry into a
+// conversation thread; see entry_thread.go.
+func runEntryCreateCmd(apiURL, credPath, diaryID string, opts entryCreateOptions) error {
 	diaryUUID, err := uuid.Parse(diaryID)
 	if err != nil {
 		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
 	}
+	if d := opts.dedupe; d.enabled && (d.window < 1 || d.window > 100) {
+		return fmt.Errorf("entry create: --dedupe-window must be between 1 and 100")
+	}
+	if d := opts.dedupe; d.enabled && (d.threshold <= 0 || d.threshold > 1) {
+		return fmt.Errorf("entry create: --dedupe-threshold must be in (0, 1]")
+	}
+	if err := checkEntryContentSize(apiURL, opts.content); err != nil {
+		return fmt.Errorf("entry create: %w", err)
+	}
 
 	client, err := newClientFromCreds(apiURL, credPath)
 	if err != nil {
 		return err
 	}
+	threadTags, err := resolveThreadTags(client, opts.thread)
+	if err != nil {
+		return fmt.Errorf("entry create: %w", err)
+	}
+	if len(threadTags) > 0 {
+		opts.tags = strings.Join(append(splitAndTrim(opts.tags, ","), threadTags...), ",")
+	}
 	req := &moltnetapi.CreateDiaryEntryReq{
-		Content: content,
+		Content: opts.content,
 	}
-	if title != "" {
-		req.Title = moltnetapi.OptString{Value: title, Set: true}
+	if opts.title != "" {
+		req.Title = moltnetapi.OptString{Value: opts.title, Set: true}
 	}
-	if entryType != "" {
-		et, err := parseEntryType(entryType)
+	if opts.entryType != "" {
+		et, err := parseEntryType(opts.entryType)
 		if err != nil {
 			return err
 		}
 		req.EntryType = moltnetapi.OptCreateDiaryEntryReqEntryType{Value: et, Set: true}
 	}
-	if tagsStr != "" {
-		req.Tags = splitAndTrim(tagsStr, ",")
+	if opts.tags != "" {
+		req.Tags = splitAndTrim(opts.tags, ",")
 	}
-	if importanceChanged {
-		req.Importance = moltnetapi.OptInt{Value: importance, Set: true}
+	if opts.importanceChanged {
+		req.Importance = moltnetapi.OptInt{Value: opts.importance, Set: true}
+	}
+	if dup := checkDuplicateEntry(client, diaryUUID, opts.content, opts.dedupe, os.Stderr); dup != nil {
+		return printJSON(dup)
+	}
+	warnIfSharedEntryRisky(client, diaryUUID, opts.title, opts.content, opts.maxPublicLength, os.Stderr)
+	var blobs []uuid.UUID
+	if currentDryRunOutput() != nil && len(opts.attach) > 0 {
+		// The blob creates would be the first request the dry run stops
+		// at, hiding the entry itself: check the files and preview that.
+		if _, err := readAttachments(apiURL, opts.attach); err != nil {
+			return fmt.Errorf("entry create: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Dry run: %d attachment entries would be created first, each adding an %s<id> tag\n", len(opts.attach), attachmentTagPrefix)
+	} else {
+		var attachTags []string
+		attachTags, blobs, err = uploadAttachments(client, apiURL, diaryUUID, opts.attach)
+		if err != nil {
+			return fmt.Errorf("entry create: %w", err)
+		}
+		req.Tags = append(req.Tags, attachTags...)
 	}
 	res, err := client.CreateDiaryEntry(context.Background(), req, moltnetapi.CreateDiaryEntryParams{DiaryId: diaryUUID})
 	if err != nil {
-		return fmt.Errorf("entry create: %w", formatTransportError(err))
+		// Attachments cannot be queued: their blob entries need the API.
+		if opts.queueOnOffline && isOfflineError(err) && len(blobs) == 0 {
+			return queueEntryForLater(diaryID, opts.content, opts.title, opts.entryType, opts.tags, opts.importance, opts.importanceChanged)
+		}
+		left := deleteAttachmentBlobs(client, blobs)
+		return withOrphanedAttachments(fmt.Errorf("entry create: %w", formatTransportError(err)), left)
 	}
 	entry, ok := res.(*moltnetapi.DiaryEntry)
 	if !ok {
-		return formatAPIError(res)
+		left := deleteAttachmentBlobs(client, blobs)
+		return withOrphanedAttachments(formatAPIError(res), left)
 	}
 	return printJSON(entry)
 }
 
 // runEntryCreateSignedCmd creates a content-signed immutable diary entry.
-func runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryType, tagsStr string, importance int, importanceChanged bool) error {
+func runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryType, tagsStr string, importance int, importanceChanged bool, maxPublicLength int) error {
 	diaryUUID, err := uuid.Parse(diaryID)
 	if err != nil {
 		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
@@ -70,6 +141,9 @@ func runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryTyp
 			return err
 		}
 	}
+	if err := checkEntryContentSize(apiURL, content); err != nil {
+		return fmt.Errorf("entry create-signed: %w", err)
+	}
 
 	tags := splitAndTrim(tagsStr, ",")
 
@@ -89,6 +163,8 @@ func runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryTyp
 	if err != nil {
 		return err
 	}
+	// Pre-scan before signing: the entry cannot be edited afterwards.
+	warnIfSharedEntryRisky(client, diaryUUID, title, content, maxPublicLength, os.Stderr)
 
 	// Step 3: Create signing request with CID as message
 	sigRes, err := client.CreateSigningRequest(context.Background(), &moltnetapi.CreateSigningRequestReq{
@@ -140,13 +216,16 @@ func runEntryCreateSignedCmd(apiURL, credPath, diaryID, content, title, entryTyp
 }
 
 // runEntryListCmd lists diary entries with optional filters.
-func runEntryListCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryType string, limit, offset int) error {
+func runEntryListCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryType string, limit, offset int, groupBy string, window timeWindow, showSize bool) error {
 	diaryUUID, err := uuid.Parse(diaryID)
 	if err != nil {
 		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
 	}
+	if err := validateEntryGroupBy(groupBy); err != nil {
+		return err
+	}
 
-	client, err := newClientFromCreds(apiURL, credPath)
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
 	if err != nil {
 		return err
 	}
@@ -171,25 +250,167 @@ func runEntryListCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryTyp
 		}
 		params.EntryType = entryTypes
 	}
-	if limit > 0 {
-		params.Limit = moltnetapi.OptFloat64{Value: float64(limit), Set: true}
+	var list *moltnetapi.DiaryList
+	if window.isOpen() {
+		if limit > 0 {
+			params.Limit = moltnetapi.OptFloat64{Value: float64(limit), Set: true}
+		}
+		if offset > 0 {
+			params.Offset = moltnetapi.OptFloat64{Value: float64(offset), Set: true}
+		}
+		res, err := client.ListDiaryEntries(context.Background(), params)
+		if err != nil {
+			return fmt.Errorf("entry list: %w", formatTransportError(err))
+		}
+		var ok bool
+		if list, ok = res.(*moltnetapi.DiaryList); !ok {
+			return formatAPIError(res)
+		}
+	} else if list, err = listEntriesInWindow(client, params, window, limit, offset); err != nil {
+		return fmt.Errorf("entry list: %w", err)
+	}
+	if groupBy == "tag" {
+		return printJSON(groupEntriesByTag(list))
+	}
+	if showSize {
+		sized, err := sizeEntryList(list)
+		if err != nil {
+			return err
+		}
+		if err := printJSON(sized); err != nil {
+			return err
+		}
+		printEntrySizeTotal(os.Stderr, sized)
+		return nil
 	}
-	if offset > 0 {
-		params.Offset = moltnetapi.OptFloat64{Value: float64(offset), Set: true}
+	return printJSON(list)
+}
+
+// listEntriesInWindow filters entries by creation time client-side; the
+// API has no time filter. The server lists newest first, so paging stops
+// at the first entry older than --since, or once limit matches are found.
+// offset and limit apply to the matching entries; Total counts the matches
+// scanned, so it is a lower bound when the limit cut the scan short.
+func listEntriesInWindow(client *moltnetapi.Client, params moltnetapi.ListDiaryEntriesParams, window timeWindow, limit, offset int) (*moltnetapi.DiaryList, error) {
+	out := &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{}, Limit: float64(limit), Offset: float64(offset)}
+	skipped := 0
+	done := func() (*moltnetapi.DiaryList, error) {
+		out.Total = float64(skipped + len(out.Items))
+		return out, nil
+	}
+	params.Limit = moltnetapi.OptFloat64{Value: streamPageSize, Set: true}
+	for page := 0; ; page++ {
+		params.Offset = moltnetapi.OptFloat64{Value: float64(page * streamPageSize), Set: true}
+		res, err := client.ListDiaryEntries(context.Background(), params)
+		if err != nil {
+			return nil, formatTransportError(err)
+		}
+		list, ok := res.(*moltnetapi.DiaryList)
+		if !ok {
+			return nil, formatAPIError(res)
+		}
+		for _, e := range list.Items {
+			if !window.Since.IsZero() && e.CreatedAt.Before(window.Since) {
+				return done()
+			}
+			if !window.contains(e.CreatedAt) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			out.Items = append(out.Items, e)
+			if limit > 0 && len(out.Items) >= limit {
+				return done()
+			}
+		}
+		if len(list.Items) < streamPageSize {
+			return done()
+		}
 	}
-	res, err := client.ListDiaryEntries(context.Background(), params)
+}
+
+// runEntryListStreamCmd pages through every matching entry (or up to limit)
+// and writes one JSON entry per line to w as each is decoded, so memory stays
+// bounded by a single entry rather than the whole diary.
+func runEntryListStreamCmd(apiURL, credPath, diaryID, ids, tags, excludeTags, entryType string, limit, offset int, w io.Writer) error {
+	diaryUUID, err := uuid.Parse(diaryID)
 	if err != nil {
-		return fmt.Errorf("entry list: %w", formatTransportError(err))
+		return fmt.Errorf("invalid diary ID %q: %w", diaryID, err)
 	}
-	list, ok := res.(*moltnetapi.DiaryList)
-	if !ok {
-		return formatAPIError(res)
+	query := url.Values{}
+	if ids != "" {
+		parsedIDs, err := parseUUIDList(ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range parsedIDs {
+			query.Add("ids", id.String())
+		}
+	}
+	for _, tag := range splitAndTrim(tags, ",") {
+		query.Add("tags", tag)
+	}
+	for _, tag := range splitAndTrim(excludeTags, ",") {
+		query.Add("excludeTags", tag)
+	}
+	if entryType != "" {
+		entryTypes, err := parseListDiaryEntryTypes(entryType)
+		if err != nil {
+			return err
+		}
+		for _, et := range entryTypes {
+			query.Add("entryType", string(et))
+		}
+	}
+
+	client, err := newStreamClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	path := "/diaries/" + diaryUUID.String() + "/entries"
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	emit := func(item json.RawMessage) error {
+		var line bytes.Buffer
+		if err := json.Compact(&line, item); err != nil {
+			return err
+		}
+		line.WriteByte('\n')
+		_, err := bw.Write(line.Bytes())
+		return err
+	}
+
+	total := 0
+	for {
+		pageSize := streamPageSize
+		if limit > 0 {
+			pageSize = min(pageSize, limit-total)
+		}
+		query.Set("limit", strconv.Itoa(pageSize))
+		query.Set("offset", strconv.Itoa(offset+total))
+		resp, err := client.GetStream(context.Background(), path, query)
+		if err != nil {
+			return fmt.Errorf("entry list: %w", err)
+		}
+		n, err := decodeStream(resp.Body, resp.Header.Get("Content-Type"), "items", emit)
+		resp.Body.Close()
+		total += n
+		if err != nil {
+			return fmt.Errorf("entry list: %w", err)
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if n < pageSize || (limit > 0 && total >= limit) {
+			return nil
+		}
 	}
-	return printJSON(list)
 }
 
 // runEntryGetCmd fetches a diary entry by ID, optionally expanding relations.
-func runEntryGetCmd(apiURL, credPath, entryID, expand string, depth int) error {
+func runEntryGetCmd(apiURL, credPath, entryID, expand string, depth int, downloadDir string, showSize bool) error {
 	entryUUID, err := uuid.Parse(entryID)
 	if err != nil {
 		return fmt.Errorf("invalid entry ID %q: %w", entryID, err)
@@ -221,7 +442,19 @@ func runEntryGetCmd(apiURL, credPath, entryID, expand string, depth int) error {
 	if !ok {
 		return formatAPIError(res)
 	}
-	return printJSON(entry)
+	var out any = entry
+	if showSize {
+		if out, err = withSizeField(entry, measureContent(entry.Content)); err != nil {
+			return err
+		}
+	}
+	if err := printJSON(out); err != nil {
+		return err
+	}
+	if downloadDir == "" {
+		return nil
+	}
+	return downloadAttachments(client, entry.Tags, downloadDir)
 }
 
 // runEntryUpdateCmd updates a diary entry by ID.
@@ -255,19 +488,23 @@ func runEntryUpdateCmd(apiURL, credPath, entryID, content, title, entryType, tag
 		req.Importance = moltnetapi.OptInt{Value: importance, Set: true}
 	}
 
-	res, err := client.UpdateDiaryEntryById(context.Background(),
-		moltnetapi.OptUpdateDiaryEntryByIdReq{Value: req, Set: true},
-		moltnetapi.UpdateDiaryEntryByIdParams{EntryId: entryUUID})
+	entry, err := updateEntryRecorded(client, entryUUID, req, localFingerprint(credPath))
 	if err != nil {
-		return fmt.Errorf("entry update: %w", formatTransportError(err))
-	}
-	entry, ok := res.(*moltnetapi.DiaryEntry)
-	if !ok {
-		return formatAPIError(res)
+		return fmt.Errorf("entry update: %w", err)
 	}
 	return printJSON(entry)
 }
 
+// localFingerprint returns the configured agent fingerprint, or "" when
+// the config cannot be read. Used to attribute local history records.
+func localFingerprint(credPath string) string {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return ""
+	}
+	return creds.Keys.Fingerprint
+}
+
 // runEntryDeleteCmd deletes a diary entry by ID.
 func runEntryDeleteCmd(apiURL, credPath, entryID string) error {
 	entryUUID, err := uuid.Parse(entryID)
@@ -307,10 +544,23 @@ type entrySearchOptions struct {
 	taskCorrelationID        string
 	taskAttempt              int
 	taskAttemptChanged       bool
+	explain                  bool
+	// format is "json" (default) or "context"; see writeSearchContext.
+	format          string
+	contextTemplate string
+	maxTokens       int
 }
 
 // runEntrySearchCmd searches diary entries.
 func runEntrySearchCmd(apiURL, credPath string, opts entrySearchOptions) error {
+	if err := validateSearchFormat(opts.format, opts.explain); err != nil {
+		return err
+	}
+	if opts.format == "context" {
+		if _, err := parseContextTemplate(opts.contextTemplate); err != nil {
+			return err
+		}
+	}
 	req := moltnetapi.SearchDiaryReq{}
 	if opts.query != "" {
 		req.Query = moltnetapi.OptString{Value: opts.query, Set: true}
@@ -375,6 +625,13 @@ func runEntrySearchCmd(apiURL, credPath string, opts entrySearchOptions) error {
 	if !ok {
 		return formatAPIError(res)
 	}
+	if opts.explain {
+		explained := explainSearchResults(opts.query, results)
+		return printJSON(&explained)
+	}
+	if opts.format == "context" {
+		return writeSearchContext(os.Stdout, opts.query, results, opts.contextTemplate, opts.maxTokens)
+	}
 	return printJSON(results)
 }
 
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// The network has no blob store for diary entries, so an attachment is
+// inlined, base64-encoded, into an entry of its own tagged
+// attachmentBlobTag. The entry it belongs to carries an
+// attachment:<blob-entry-id> tag per file.
+const (
+	attachmentTagPrefix = "attachment:"
+	attachmentBlobTag   = "attachment-blob"
+	attachmentHeader    = "moltnet-attachment v1"
+
+	// maxAttachmentBytes caps a single file before encoding, whatever the
+	// network's entry size limit.
+	maxAttachmentBytes = 256 << 10
+
+	attachmentLineWidth = 76
+)
+
+// attachmentMeta describes an inlined file; it is the header of the blob
+// entry's content.
+type attachmentMeta struct {
+	Name   string
+	Type   string
+	Size   int
+	SHA256 string
+}
+
+// encodeAttachment renders a file as blob entry content: header lines,
+// a blank line, then base64 wrapped at attachmentLineWidth.
+func encodeAttachment(name string, data []byte) string {
+	name = strings.NewReplacer("\r", " ", "\n", " ").Replace(name)
+	sum := sha256.Sum256(data)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\nname: %s\ntype: %s\nsize: %d\nsha256: %s\n\n",
+		attachmentHeader, name, attachmentMediaType(name, data), len(data), hex.EncodeToString(sum[:]))
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > attachmentLineWidth {
+		b.WriteString(enc[:attachmentLineWidth])
+		b.WriteByte('\n')
+		enc = enc[attachmentLineWidth:]
+	}
+	b.WriteString(enc)
+	return b.String()
+}
+
+func attachmentMediaType(name string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// decodeAttachment parses blob entry content and checks the file against
+// its recorded size and digest.
+func decodeAttachment(content string) (attachmentMeta, []byte, error) {
+	var meta attachmentMeta
+	head, body, ok := strings.Cut(content, "\n\n")
+	if !ok {
+		return meta, nil, fmt.Errorf("not an attachment entry")
+	}
+	scanner := bufio.NewScanner(strings.NewReader(head))
+	if !scanner.Scan() || scanner.Text() != attachmentHeader {
+		return meta, nil, fmt.Errorf("not an attachment entry")
+	}
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), ": ")
+		switch key {
+		case "name":
+			meta.Name = value
+		case "type":
+			meta.Type = value
+		case "size":
+			meta.Size, _ = strconv.Atoi(value)
+		case "sha256":
+			meta.SHA256 = value
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return meta, nil, fmt.Errorf("decode attachment: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if len(data) != meta.Size || hex.EncodeToString(sum[:]) != meta.SHA256 {
+		return meta, nil, fmt.Errorf("attachment %s is corrupted (size or sha256 mismatch)", meta.Name)
+	}
+	return meta, data, nil
+}
+
+// readAttachments loads and encodes every file before anything is
+// uploaded, so one oversized file fails the command without side effects.
+func readAttachments(apiURL string, paths []string) (map[string]string, error) {
+	encoded := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("attachment: %w", err)
+		}
+		if len(data) > maxAttachmentBytes {
+			return nil, fmt.Errorf("attachment %s is %s, over the %s limit", path, formatByteSize(int64(len(data))), formatByteSize(maxAttachmentBytes))
+		}
+		content := encodeAttachment(filepath.Base(path), data)
+		if err := checkEntryContentSize(apiURL, content); err != nil {
+			return nil, fmt.Errorf("attachment %s: %w", path, err)
+		}
+		encoded[path] = content
+	}
+	return encoded, nil
+}
+
+// uploadAttachments creates one blob entry per file and returns the
+// attachment tags for the owning entry and the created blob IDs.
+func uploadAttachments(client *moltnetapi.Client, apiURL string, diaryID uuid.UUID, paths []string) ([]string, []uuid.UUID, error) {
+	if len(paths) == 0 {
+		return nil, nil, nil
+	}
+	encoded, err := readAttachments(apiURL, paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tags []string
+	var created []uuid.UUID
+	for _, path := range paths {
+		res, err := client.CreateDiaryEntry(context.Background(), &moltnetapi.CreateDiaryEntryReq{
+			Content: encoded[path],
+			Title:   moltnetapi.NewOptString("Attachment: " + filepath.Base(path)),
+			Tags:    []string{attachmentBlobTag},
+		}, moltnetapi.CreateDiaryEntryParams{DiaryId: diaryID})
+		if err == nil {
+			if blob, ok := res.(*moltnetapi.DiaryEntry); ok {
+				tags = append(tags, attachmentTagPrefix+blob.ID.String())
+				created = append(created, blob.ID)
+				continue
+			}
+			err = formatAPIError(res)
+		} else {
+			err = formatTransportError(err)
+		}
+		left := deleteAttachmentBlobs(client, created)
+		return nil, nil, withOrphanedAttachments(fmt.Errorf("upload attachment %s: %w", path, err), left)
+	}
+	return tags, created, nil
+}
+
+// deleteAttachmentBlobs removes blob entries left behind when the owning
+// entry could not be created, and returns those it could not remove.
+func deleteAttachmentBlobs(client *moltnetapi.Client, ids []uuid.UUID) []uuid.UUID {
+	var left []uuid.UUID
+	for _, id := range ids {
+		if _, err := client.DeleteDiaryEntryById(context.Background(), moltnetapi.DeleteDiaryEntryByIdParams{EntryId: id}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remove orphaned attachment entry %s: %v\n", id, formatTransportError(err))
+			left = append(left, id)
+		}
+	}
+	return left
+}
+
+// withOrphanedAttachments names, in err, the blob entries that outlived a
+// failed create, so they can be deleted by hand.
+func withOrphanedAttachments(err error, left []uuid.UUID) error {
+	if len(left) == 0 {
+		return err
+	}
+	ids := make([]string, len(left))
+	for i, id := range left {
+		ids[i] = id.String()
+	}
+	return fmt.Errorf("%w (attachment entries left behind, remove them with 'moltnet entry delete': %s)", err, strings.Join(ids, ", "))
+}
+
+// downloadAttachments writes the files named by an entry's attachment tags
+// into dir. An existing file is left alone and reported.
+func downloadAttachments(client *moltnetapi.Client, tags []string, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("download attachments: %w", err)
+	}
+	for _, tag := range tags {
+		raw, ok := strings.CutPrefix(tag, attachmentTagPrefix)
+		if !ok {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			continue
+		}
+		res, err := client.GetDiaryEntryById(context.Background(), moltnetapi.GetDiaryEntryByIdParams{EntryId: id})
+		if err != nil {
+			return fmt.Errorf("download attachment %s: %w", id, formatTransportError(err))
+		}
+		blob, ok := res.(*moltnetapi.DiaryEntryWithRelations)
+		if !ok {
+			return fmt.Errorf("download attachment %s: %w", id, formatAPIError(res))
+		}
+		meta, data, err := decodeAttachment(blob.Content)
+		if err != nil {
+			return fmt.Errorf("download attachment %s: %w", id, err)
+		}
+		name := filepath.Base(meta.Name)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			name = id.String()
+		}
+		path := filepath.Join(dir, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			fmt.Fprintf(os.Stderr, "Skipped %s: file exists\n", path)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("download attachment %s: %w", id, err)
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return fmt.Errorf("download attachment %s: %w", id, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("download attachment %s: %w", id, err)
+		}
+		fmt.Fprintf(os.Stderr, "Saved %s (%s, %s)\n", path, meta.Type, formatByteSize(int64(len(data))))
+	}
+	return nil
+}
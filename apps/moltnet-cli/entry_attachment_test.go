@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// attachmentStubHandler stores created entries so they can be fetched back.
+type attachmentStubHandler struct {
+	stubDiaryHandler
+	entries map[uuid.UUID]*moltnetapi.DiaryEntry
+}
+
+func (h *attachmentStubHandler) CreateDiaryEntry(_ context.Context, req *moltnetapi.CreateDiaryEntryReq, _ moltnetapi.CreateDiaryEntryParams) (moltnetapi.CreateDiaryEntryRes, error) {
+	e := newTestEntry(req.Content)
+	e.ID, e.Tags = uuid.New(), req.Tags
+	h.entries[e.ID] = e
+	return e, nil
+}
+
+func (h *attachmentStubHandler) GetDiaryEntryById(_ context.Context, params moltnetapi.GetDiaryEntryByIdParams) (moltnetapi.GetDiaryEntryByIdRes, error) {
+	e, ok := h.entries[params.EntryId]
+	if !ok {
+		return &moltnetapi.GetDiaryEntryByIdNotFound{}, nil
+	}
+	r := newTestEntryWithRelations(e.Content)
+	r.ID, r.Tags = e.ID, e.Tags
+	return r, nil
+}
+
+func TestAttachmentEncoding_RoundTrip(t *testing.T) {
+	t.Parallel()
+	data := bytes.Repeat([]byte{0, 1, 2, 250}, 100)
+
+	content := encodeAttachment("dump.bin", data)
+	meta, got, err := decodeAttachment(content)
+
+	if err != nil {
+		t.Fatalf("decodeAttachment() error: %v", err)
+	}
+	if meta.Name != "dump.bin" || meta.Size != len(data) || !bytes.Equal(got, data) {
+		t.Errorf("round trip mismatch: %+v", meta)
+	}
+	tampered := strings.Replace(content, "AAEC", "AAED", 1)
+	if _, _, err := decodeAttachment(tampered); err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Errorf("expected corruption error, got %v", err)
+	}
+}
+
+func TestEntryAttachments_CreateAndDownload(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := &attachmentStubHandler{entries: map[uuid.UUID]*moltnetapi.DiaryEntry{}}
+	srv, credPath := newCLICommandTestServer(t, h)
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(src, []byte("build log"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runEntryCreateCmd(srv.URL, credPath, testDiaryID.String(), entryCreateOptions{content: "see attached", tags: "ci", attach: []string{src}})
+
+	if err != nil {
+		t.Fatalf("runEntryCreateCmd() error: %v", err)
+	}
+	var owner *moltnetapi.DiaryEntry
+	for _, e := range h.entries {
+		if e.Content == "see attached" {
+			owner = e
+		}
+	}
+	if owner == nil || len(owner.Tags) != 2 || owner.Tags[0] != "ci" || !strings.HasPrefix(owner.Tags[1], attachmentTagPrefix) {
+		t.Fatalf("owner entry tags = %v", owner)
+	}
+	blobID := uuid.MustParse(strings.TrimPrefix(owner.Tags[1], attachmentTagPrefix))
+	if !slices.Equal(h.entries[blobID].Tags, []string{attachmentBlobTag}) {
+		t.Errorf("blob entry tags = %v", h.entries[blobID].Tags)
+	}
+
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := runEntryGetCmd(srv.URL, credPath, owner.ID.String(), "", 1, dir, false); err != nil {
+		t.Fatalf("runEntryGetCmd() error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "notes.txt"))
+	if err != nil || string(got) != "build log" {
+		t.Errorf("downloaded %q, %v", got, err)
+	}
+}
+
+func TestEntryAttachments_DryRunPreviewsEntry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var preview bytes.Buffer
+	w := io.Writer(&preview)
+	dryRunOutput.Store(&w)
+	t.Cleanup(func() { dryRunOutput.Store(nil) })
+	h := &attachmentStubHandler{entries: map[uuid.UUID]*moltnetapi.DiaryEntry{}}
+	srv, credPath := newCLICommandTestServer(t, h)
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(src, []byte("build log"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runEntryCreateCmd(srv.URL, credPath, testDiaryID.String(), entryCreateOptions{content: "see attached", attach: []string{src}})
+
+	if !errors.Is(err, errDryRun) {
+		t.Fatalf("expected dry-run, got %v", err)
+	}
+	if len(h.entries) != 0 {
+		t.Errorf("dry run created %d entries", len(h.entries))
+	}
+	if !strings.Contains(preview.String(), "see attached") {
+		t.Errorf("preview is not the entry create: %s", preview.String())
+	}
+}
+
+// failingOwnerHandler accepts attachment blobs but rejects the entry that
+// owns them, and refuses to delete the blobs afterwards.
+type failingOwnerHandler struct {
+	attachmentStubHandler
+}
+
+func (h *failingOwnerHandler) CreateDiaryEntry(ctx context.Context, req *moltnetapi.CreateDiaryEntryReq, params moltnetapi.CreateDiaryEntryParams) (moltnetapi.CreateDiaryEntryRes, error) {
+	if !slices.Contains(req.Tags, attachmentBlobTag) {
+		return nil, errors.New("create rejected")
+	}
+	return h.attachmentStubHandler.CreateDiaryEntry(ctx, req, params)
+}
+
+func (h *failingOwnerHandler) DeleteDiaryEntryById(_ context.Context, _ moltnetapi.DeleteDiaryEntryByIdParams) (moltnetapi.DeleteDiaryEntryByIdRes, error) {
+	return nil, errors.New("delete rejected")
+}
+
+func TestEntryAttachments_ReportsOrphanedBlobs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := &failingOwnerHandler{attachmentStubHandler{entries: map[uuid.UUID]*moltnetapi.DiaryEntry{}}}
+	srv, credPath := newCLICommandTestServer(t, h)
+	src := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(src, []byte("build log"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runEntryCreateCmd(srv.URL, credPath, testDiaryID.String(), entryCreateOptions{content: "see attached", attach: []string{src}})
+
+	if err == nil || len(h.entries) != 1 {
+		t.Fatalf("err = %v with %d blobs, want a failed create after one upload", err, len(h.entries))
+	}
+	for id := range h.entries {
+		if !strings.Contains(err.Error(), id.String()) {
+			t.Errorf("error %q does not name the orphaned blob %s", err, id)
+		}
+	}
+}
+
+func TestReadAttachments_TooLarge(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, make([]byte, maxAttachmentBytes+1), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := readAttachments("http://127.0.0.1:0", []string{path})
+
+	if err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+}
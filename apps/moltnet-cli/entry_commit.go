@@ -59,10 +59,10 @@ func runEntryCommitCmd(w io.Writer, apiURL, credPath, diaryID, rationale, risk,
 		entryTitle = "Accountable commit: " + firstSentence(rationale)
 	}
 
-	if creds.OAuth2.ClientID == "" || creds.OAuth2.ClientSecret == "" {
-		return fmt.Errorf("credentials missing client_id or client_secret — run 'moltnet register'")
+	if err := requireClientCredentials(creds, credPath); err != nil {
+		return err
 	}
-	tm := NewTokenManager(apiURL, creds.OAuth2.ClientID, creds.OAuth2.ClientSecret)
+	tm := newTokenManagerForCreds(apiURL, creds)
 	client, err := newAuthedClient(apiURL, tm)
 	if err != nil {
 		return err
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// contentSize is what an entry's content costs in a context window, for
+// --show-size. Tokens uses the same estimate as --max-tokens
+// (estimateTokens), so sizes and budgets agree.
+type contentSize struct {
+	Chars  int `json:"chars"`
+	Words  int `json:"words"`
+	Tokens int `json:"tokens"`
+}
+
+func measureContent(s string) contentSize {
+	return contentSize{Chars: utf8.RuneCountInString(s), Words: len(strings.Fields(s)), Tokens: estimateTokens(s)}
+}
+
+func (s *contentSize) add(o contentSize) {
+	s.Chars += o.Chars
+	s.Words += o.Words
+	s.Tokens += o.Tokens
+}
+
+// withSizeField encodes v, which must encode as a JSON object, with a
+// trailing "size" field. Splicing keeps the generated encoder's field order.
+func withSizeField(v any, size contentSize) (json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	sizeJSON, err := json.Marshal(size)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) < 2 || raw[0] != '{' || raw[len(raw)-1] != '}' {
+		return nil, fmt.Errorf("add size: %T does not encode as an object", v)
+	}
+	out := append([]byte{}, raw[:len(raw)-1]...)
+	if len(bytes.TrimSpace(out)) > 1 {
+		out = append(out, ',')
+	}
+	out = append(out, `"size":`...)
+	out = append(out, sizeJSON...)
+	return append(out, '}'), nil
+}
+
+// sizedEntryList is a DiaryList whose items carry their size, with the sum
+// over the listed page.
+type sizedEntryList struct {
+	Items     []json.RawMessage `json:"items"`
+	Limit     float64           `json:"limit"`
+	Offset    float64           `json:"offset"`
+	Total     float64           `json:"total"`
+	TotalSize contentSize       `json:"totalSize"`
+}
+
+func sizeEntryList(list *moltnetapi.DiaryList) (*sizedEntryList, error) {
+	out := &sizedEntryList{Items: make([]json.RawMessage, 0, len(list.Items)), Limit: list.Limit, Offset: list.Offset, Total: list.Total}
+	for i := range list.Items {
+		size := measureContent(list.Items[i].Content)
+		item, err := withSizeField(&list.Items[i], size)
+		if err != nil {
+			return nil, err
+		}
+		out.Items = append(out.Items, item)
+		out.TotalSize.add(size)
+	}
+	return out, nil
+}
+
+// printEntrySizeTotal prints the total of a sized list for people reading
+// a table or a long JSON document.
+func printEntrySizeTotal(w io.Writer, list *sizedEntryList) {
+	fmt.Fprintf(w, "%d entr%s: %d chars, %d words, ~%d tokens\n",
+		len(list.Items), pluralIes(len(list.Items)), list.TotalSize.Chars, list.TotalSize.Words, list.TotalSize.Tokens)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+func TestMeasureContent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		content string
+		want    contentSize
+	}{
+		{"", contentSize{}},
+		{"one two  three\nfour", contentSize{Chars: 19, Words: 4, Tokens: 5}},
+		{"héllo", contentSize{Chars: 5, Words: 1, Tokens: 2}},
+	}
+	for _, tt := range tests {
+		if got := measureContent(tt.content); got != tt.want {
+			t.Errorf("measureContent(%q) = %+v, want %+v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestWithSizeField(t *testing.T) {
+	t.Parallel()
+	entry := newTestEntry("some content")
+	raw, err := withSizeField(entry, measureContent(entry.Content))
+	if err != nil {
+		t.Fatalf("withSizeField() error: %v", err)
+	}
+	plain, _ := json.Marshal(entry)
+	if !strings.HasPrefix(string(raw), string(plain[:len(plain)-1])+`,"size":{`) {
+		t.Errorf("size not appended after the entry's own fields: %s", raw)
+	}
+	var decoded struct {
+		ID   string      `json:"id"`
+		Size contentSize `json:"size"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("result is not valid JSON: %v\n%s", err, raw)
+	}
+	if decoded.ID != entry.ID.String() || decoded.Size.Words != 2 {
+		t.Errorf("decoded = %+v", decoded)
+	}
+
+	if raw, err := withSizeField(map[string]any{}, contentSize{}); err != nil || string(raw) != `{"size":{"chars":0,"words":0,"tokens":0}}` {
+		t.Errorf("empty object: %s, %v", raw, err)
+	}
+	if _, err := withSizeField([]string{"x"}, contentSize{}); err == nil {
+		t.Error("array: expected an error")
+	}
+}
+
+func TestSizeEntryList(t *testing.T) {
+	t.Parallel()
+	list := &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{*newTestEntry("four words right here"), *newTestEntry("two words")}, Total: 7, Limit: 2}
+	sized, err := sizeEntryList(list)
+	if err != nil {
+		t.Fatalf("sizeEntryList() error: %v", err)
+	}
+	if want := (contentSize{Chars: 30, Words: 6, Tokens: 9}); sized.TotalSize != want {
+		t.Errorf("TotalSize = %+v, want %+v", sized.TotalSize, want)
+	}
+	if len(sized.Items) != 2 || sized.Total != 7 || sized.Limit != 2 {
+		t.Errorf("sized list = %+v", sized)
+	}
+	var out strings.Builder
+	printEntrySizeTotal(&out, sized)
+	if out.String() != "2 entries: 30 chars, 6 words, ~9 tokens\n" {
+		t.Errorf("total line = %q", out.String())
+	}
+}
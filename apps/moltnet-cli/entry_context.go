@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// defaultContextTemplate renders one search result for --format context.
+const defaultContextTemplate = `[{{.N}}] entry {{.ID}} · {{.EntryType}} · {{.CreatedAt}}{{if .Tags}} · tags: {{join .Tags ", "}}{{end}}
+{{if .Title}}Title: {{.Title}}
+{{end}}{{.Content}}
+`
+
+// charsPerToken is the rough characters-per-token ratio used for
+// --max-tokens. Budgets are estimates; leave headroom for the model.
+const charsPerToken = 4
+
+// contextItem is the data passed to the --context-template.
+type contextItem struct {
+	N          int
+	ID         string
+	Title      string
+	EntryType  string
+	Tags       []string
+	Importance float64
+	CreatedAt  string
+	Content    string
+}
+
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + charsPerToken - 1) / charsPerToken
+}
+
+func parseContextTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultContextTemplate
+	}
+	tmpl, err := template.New("context").Funcs(template.FuncMap{"join": strings.Join}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --context-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// truncateRunes cuts s to at most n runes.
+func truncateRunes(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+	return s
+}
+
+// writeSearchContext formats search results as a numbered context block for
+// an LLM prompt. With maxTokens > 0, whole results are kept while they fit;
+// if even the first does not fit, its content is truncated.
+func writeSearchContext(w io.Writer, query string, results *moltnetapi.DiarySearchResult, tmplText string, maxTokens int) error {
+	tmpl, err := parseContextTemplate(tmplText)
+	if err != nil {
+		return err
+	}
+
+	header := "<memory source=\"moltnet\""
+	if query != "" {
+		header += fmt.Sprintf(" query=%q", query)
+	}
+	header += ">\n"
+	footer := "</memory>\n"
+	budget := maxTokens*charsPerToken - utf8.RuneCountInString(header+footer)
+
+	var b strings.Builder
+	b.WriteString(header)
+	used, omitted := 0, 0
+	for i, e := range results.Results {
+		item := contextItem{
+			N:          i + 1,
+			ID:         e.ID.String(),
+			Title:      e.Title.Or(""),
+			EntryType:  string(e.EntryType),
+			Tags:       e.Tags,
+			Importance: e.Importance,
+			CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+			Content:    e.Content,
+		}
+		block, err := renderContextItem(tmpl, item)
+		if err != nil {
+			return err
+		}
+		n := utf8.RuneCountInString(block)
+		if maxTokens > 0 && used+n > budget {
+			if i == 0 {
+				item.Content = ""
+				overhead, err := renderContextItem(tmpl, item)
+				if err != nil {
+					return err
+				}
+				const marker = " …[truncated]"
+				item.Content = truncateRunes(e.Content, budget-utf8.RuneCountInString(overhead)-utf8.RuneCountInString(marker)) + marker
+				if block, err = renderContextItem(tmpl, item); err != nil {
+					return err
+				}
+				b.WriteString(block)
+				omitted = len(results.Results) - 1
+			} else {
+				omitted = len(results.Results) - i
+			}
+			break
+		}
+		if i > 0 {
+			b.WriteString("\n")
+			used++
+		}
+		b.WriteString(block)
+		used += n
+	}
+	if len(results.Results) == 0 {
+		b.WriteString("(no matching entries)\n")
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&b, "\n(%d more result(s) omitted to fit the %d-token budget)\n", omitted, maxTokens)
+	}
+	b.WriteString(footer)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+func renderContextItem(tmpl *template.Template, item contextItem) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, item); err != nil {
+		return "", fmt.Errorf("render --context-template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+func validateSearchFormat(format string, explain bool) error {
+	switch format {
+	case "", "json":
+		return nil
+	case "context":
+		if explain {
+			return fmt.Errorf("--explain cannot be combined with --format context")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid --format %q (supported: json, context)", format)
+}
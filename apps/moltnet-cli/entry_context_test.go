@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+func testSearchResults(contents ...string) *moltnetapi.DiarySearchResult {
+	res := &moltnetapi.DiarySearchResult{}
+	for _, c := range contents {
+		e := newTestEntry(c)
+		e.ID = uuid.New()
+		e.Tags = []string{"scope:cli"}
+		res.Results = append(res.Results, *e)
+	}
+	res.Total = float64(len(contents))
+	return res
+}
+
+func TestWriteSearchContext_Default(t *testing.T) {
+	t.Parallel()
+	res := testSearchResults("first memory", "second memory")
+	var out bytes.Buffer
+	if err := writeSearchContext(&out, "lockfile", res, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		`<memory source="moltnet" query="lockfile">`,
+		"[1] entry " + res.Results[0].ID.String(),
+		"[2] entry " + res.Results[1].ID.String(),
+		"tags: scope:cli",
+		"second memory",
+		"</memory>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteSearchContext_TokenBudget(t *testing.T) {
+	t.Parallel()
+	long := strings.Repeat("word ", 200)
+	res := testSearchResults("short one", long, "third")
+
+	var out bytes.Buffer
+	if err := writeSearchContext(&out, "", res, "", 60); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "short one") || strings.Contains(got, "[2]") {
+		t.Errorf("budget should keep only the first result:\n%s", got)
+	}
+	if !strings.Contains(got, "2 more result(s) omitted") {
+		t.Errorf("missing omission note:\n%s", got)
+	}
+	if estimateTokens(got) > 60+estimateTokens("\n(2 more result(s) omitted to fit the 60-token budget)\n") {
+		t.Errorf("output exceeds budget: %d tokens", estimateTokens(got))
+	}
+
+	out.Reset()
+	if err := writeSearchContext(&out, "", testSearchResults(long), "", 30); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "…[truncated]") {
+		t.Errorf("oversized first result should be truncated:\n%s", out.String())
+	}
+}
+
+func TestWriteSearchContext_CustomTemplate(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	if err := writeSearchContext(&out, "", testSearchResults("body"), "- {{.N}}: {{.Content}}\n", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "- 1: body\n") {
+		t.Errorf("custom template not applied:\n%s", out.String())
+	}
+	if err := writeSearchContext(&out, "", testSearchResults("body"), "{{.Nope", 0); err == nil {
+		t.Error("expected template parse error")
+	}
+}
+
+func TestValidateSearchFormat(t *testing.T) {
+	t.Parallel()
+	if err := validateSearchFormat("context", true); err == nil {
+		t.Error("context with --explain should be rejected")
+	}
+	if err := validateSearchFormat("yaml", false); err == nil {
+		t.Error("unknown format should be rejected")
+	}
+	if err := validateSearchFormat("json", true); err != nil {
+		t.Errorf("json: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// Defaults for entry create --dedupe.
+const (
+	defaultDedupeWindow    = 20
+	defaultDedupeWithin    = 24 * time.Hour
+	defaultDedupeThreshold = 0.9
+)
+
+// entryDedupe configures the pre-create duplicate check. The zero value
+// disables it.
+type entryDedupe struct {
+	enabled   bool
+	window    int           // number of most recent entries compared
+	within    time.Duration // only entries created this recently; 0 means any age
+	threshold float64       // minimum similarity (0-1) counted as a duplicate
+}
+
+// contentSimilarity scores two contents from 0 to 1: the Jaccard index of
+// their keyword sets (see searchQueryTerms). Case, punctuation, word order
+// and repeated words are ignored, so re-logged observations that differ
+// only in formatting score 1.
+func contentSimilarity(a, b string) float64 {
+	return termSimilarity(searchQueryTerms(a), searchQueryTerms(b), a == b)
+}
+
+// termSimilarity is contentSimilarity over precomputed keyword sets, for
+// callers comparing many pairs. equal reports whether the raw contents are
+// identical, which decides the score when either has no keywords.
+func termSimilarity(ta, tb []string, equal bool) float64 {
+	if len(ta) == 0 || len(tb) == 0 {
+		if equal {
+			return 1
+		}
+		return 0
+	}
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if set[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// findDuplicateEntry returns the most similar of the diary's recent entries
+// when it reaches the threshold, or nil.
+func findDuplicateEntry(client *moltnetapi.Client, diaryID uuid.UUID, content string, d entryDedupe) (*moltnetapi.DiaryEntry, float64, error) {
+	res, err := client.ListDiaryEntries(context.Background(), moltnetapi.ListDiaryEntriesParams{
+		DiaryId: diaryID,
+		Limit:   moltnetapi.NewOptFloat64(float64(d.window)),
+	})
+	if err != nil {
+		return nil, 0, formatTransportError(err)
+	}
+	list, ok := res.(*moltnetapi.DiaryList)
+	if !ok {
+		return nil, 0, formatAPIError(res)
+	}
+	var best *moltnetapi.DiaryEntry
+	var bestScore float64
+	for i := range list.Items {
+		e := &list.Items[i]
+		if d.within > 0 && timeNow().Sub(e.CreatedAt) > d.within {
+			continue
+		}
+		if score := contentSimilarity(content, e.Content); score >= d.threshold && score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	return best, bestScore, nil
+}
+
+// checkDuplicateEntry runs the --dedupe check. It reports the duplicate on
+// w and returns it, or returns nil when the entry should be created. A
+// failed check only warns: deduplication is best effort and must not block
+// (or, with --queue, lose) the write.
+func checkDuplicateEntry(client *moltnetapi.Client, diaryID uuid.UUID, content string, d entryDedupe, w io.Writer) *moltnetapi.DiaryEntry {
+	if !d.enabled {
+		return nil
+	}
+	dup, score, err := findDuplicateEntry(client, diaryID, content, d)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: duplicate check skipped: %v\n", err)
+		return nil
+	}
+	if dup == nil {
+		return nil
+	}
+	fmt.Fprintf(w, "Skipped: near-duplicate of entry %s (similarity %.2f, created %s)\n",
+		dup.ID, score, dup.CreatedAt.Format(time.RFC3339))
+	return dup
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// dedupeStubHandler serves a fixed list of recent entries and counts creates.
+type dedupeStubHandler struct {
+	stubDiaryHandler
+	recent  []moltnetapi.DiaryEntry
+	created int
+}
+
+func (h *dedupeStubHandler) ListDiaryEntries(_ context.Context, _ moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	return &moltnetapi.DiaryList{Items: h.recent, Total: float64(len(h.recent))}, nil
+}
+
+func (h *dedupeStubHandler) CreateDiaryEntry(_ context.Context, req *moltnetapi.CreateDiaryEntryReq, _ moltnetapi.CreateDiaryEntryParams) (moltnetapi.CreateDiaryEntryRes, error) {
+	h.created++
+	return newTestEntry(req.Content), nil
+}
+
+func TestContentSimilarity(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Build is green.", "build is GREEN", 1},
+		{"tests pass on main", "on main: tests pass", 1},
+		{"alpha beta", "gamma delta", 0},
+		{"one two three four", "one two three five", 0.6},
+	}
+	for _, tt := range tests {
+		if got := contentSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("contentSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRunEntryCreateCmd_Dedupe(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+
+	recent := newTestEntry("Build is green on main")
+	recent.CreatedAt = now.Add(-10 * time.Minute)
+	old := newTestEntry("Deploy finished")
+	old.CreatedAt = now.Add(-48 * time.Hour)
+	h := &dedupeStubHandler{recent: []moltnetapi.DiaryEntry{*recent, *old}}
+	srv, credPath := newCLICommandTestServer(t, h)
+	dedupe := entryDedupe{enabled: true, window: defaultDedupeWindow, within: defaultDedupeWithin, threshold: defaultDedupeThreshold}
+
+	tests := []struct {
+		content     string
+		wantCreated int
+	}{
+		{"build is green on main!", 0}, // same words, recent: skipped
+		{"Deploy finished", 1},         // identical but outside --dedupe-within
+		{"Build is red on main", 2},    // similar, below threshold
+	}
+	for _, tt := range tests {
+		if err := runEntryCreateCmd(srv.URL, credPath, testDiaryID.String(), entryCreateOptions{content: tt.content, dedupe: dedupe}); err != nil {
+			t.Fatalf("%q: runEntryCreateCmd() error: %v", tt.content, err)
+		}
+		if h.created != tt.wantCreated {
+			t.Errorf("%q: created = %d, want %d", tt.content, h.created, tt.wantCreated)
+		}
+	}
+}
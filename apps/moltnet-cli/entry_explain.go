@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// explainSnippetRadius is how many characters of context surround each
+// highlighted match in an --explain snippet.
+const explainSnippetRadius = 40
+
+// explainMaxSnippets caps the snippets reported per result.
+const explainMaxSnippets = 3
+
+// searchExplanation describes why a single search result surfaced.
+//
+// The search API does not return highlights, so this is computed
+// client-side by matching query terms against the entry's title, content,
+// and tags. A result with no matched terms most likely surfaced through
+// semantic similarity or filter-only criteria.
+type searchExplanation struct {
+	MatchedTerms   []string `json:"matchedTerms"`
+	UnmatchedTerms []string `json:"unmatchedTerms"`
+	MatchedIn      []string `json:"matchedIn"`
+	Snippets       []string `json:"snippets"`
+	Note           string   `json:"note,omitempty"`
+}
+
+// explainedSearchEntry pairs a search result with its explanation.
+type explainedSearchEntry struct {
+	Entry   moltnetapi.DiaryEntry `json:"entry"`
+	Explain searchExplanation     `json:"explain"`
+}
+
+// explainedSearchResult is the entry search --explain output shape.
+type explainedSearchResult struct {
+	Query   string                 `json:"query"`
+	Terms   []string               `json:"terms"`
+	Total   float64                `json:"total"`
+	Results []explainedSearchEntry `json:"results"`
+}
+
+// searchQueryTerms splits a query into lowercase, de-duplicated keyword
+// terms. Single-character tokens are dropped as noise.
+func searchQueryTerms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != ':'
+	})
+	seen := make(map[string]bool, len(fields))
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.Trim(f, "-_:")
+		if len([]rune(f)) < 2 || seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+	}
+	return terms
+}
+
+// explainSearchResults annotates each result with the query terms it
+// contains and highlighted content snippets.
+func explainSearchResults(query string, results *moltnetapi.DiarySearchResult) explainedSearchResult {
+	terms := searchQueryTerms(query)
+	out := explainedSearchResult{
+		Query:   query,
+		Terms:   terms,
+		Total:   results.Total,
+		Results: make([]explainedSearchEntry, 0, len(results.Results)),
+	}
+	for _, entry := range results.Results {
+		out.Results = append(out.Results, explainedSearchEntry{
+			Entry:   entry,
+			Explain: explainEntry(terms, entry),
+		})
+	}
+	return out
+}
+
+func explainEntry(terms []string, entry moltnetapi.DiaryEntry) searchExplanation {
+	exp := searchExplanation{
+		MatchedTerms:   []string{},
+		UnmatchedTerms: []string{},
+		MatchedIn:      []string{},
+		Snippets:       []string{},
+	}
+	if len(terms) == 0 {
+		exp.Note = "no query terms; matched on filters only"
+		return exp
+	}
+
+	title := ""
+	if v, ok := entry.Title.Get(); ok {
+		title = strings.ToLower(v)
+	}
+	content := strings.ToLower(entry.Content)
+	tags := strings.ToLower(strings.Join(entry.Tags, " "))
+
+	inTitle, inContent, inTags := false, false, false
+	for _, term := range terms {
+		hit := false
+		if strings.Contains(title, term) {
+			inTitle, hit = true, true
+		}
+		if strings.Contains(content, term) {
+			inContent, hit = true, true
+		}
+		if strings.Contains(tags, term) {
+			inTags, hit = true, true
+		}
+		if hit {
+			exp.MatchedTerms = append(exp.MatchedTerms, term)
+		} else {
+			exp.UnmatchedTerms = append(exp.UnmatchedTerms, term)
+		}
+	}
+	if inTitle {
+		exp.MatchedIn = append(exp.MatchedIn, "title")
+	}
+	if inContent {
+		exp.MatchedIn = append(exp.MatchedIn, "content")
+		exp.Snippets = highlightSnippets(entry.Content, exp.MatchedTerms)
+	}
+	if inTags {
+		exp.MatchedIn = append(exp.MatchedIn, "tags")
+	}
+	if len(exp.MatchedTerms) == 0 {
+		exp.Note = "no keyword overlap; likely a semantic match"
+	}
+	return exp
+}
+
+// highlightSnippets returns up to explainMaxSnippets windows of content
+// around the first occurrence of each term, with the term wrapped in **.
+func highlightSnippets(content string, terms []string) []string {
+	lower := strings.ToLower(content)
+	snippets := []string{}
+	if len(lower) != len(content) {
+		// Case folding changed byte offsets; indexes into lower would not
+		// line up with content.
+		return snippets
+	}
+	for _, term := range terms {
+		if len(snippets) >= explainMaxSnippets {
+			break
+		}
+		idx := strings.Index(lower, term)
+		if idx < 0 {
+			continue
+		}
+		end := idx + len(term)
+		start := max(0, idx-explainSnippetRadius)
+		stop := min(len(content), end+explainSnippetRadius)
+		// Keep the window on rune boundaries.
+		for start > 0 && !isRuneStart(content[start]) {
+			start--
+		}
+		for stop < len(content) && !isRuneStart(content[stop]) {
+			stop++
+		}
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("…")
+		}
+		b.WriteString(content[start:idx])
+		b.WriteString("**")
+		b.WriteString(content[idx:end])
+		b.WriteString("**")
+		b.WriteString(content[end:stop])
+		if stop < len(content) {
+			b.WriteString("…")
+		}
+		snippets = append(snippets, strings.Join(strings.Fields(b.String()), " "))
+	}
+	return snippets
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
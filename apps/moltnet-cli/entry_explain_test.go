@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+func TestSearchQueryTerms(t *testing.T) {
+	t.Parallel()
+	got := searchQueryTerms(`Stale lockfile, stale "scope:cli" a`)
+	want := []string{"stale", "lockfile", "scope:cli"}
+	if !slices.Equal(got, want) {
+		t.Errorf("terms: got %v, want %v", got, want)
+	}
+}
+
+func TestExplainSearchResults(t *testing.T) {
+	t.Parallel()
+	keyword := *newTestEntry("The CI run failed because a stale lockfile was left behind by the previous job.")
+	keyword.Title = moltnetapi.NewNilString("Lockfile incident")
+	keyword.Tags = []string{"incident"}
+	semantic := *newTestEntry("Dependency resolution went sideways after the cache expired.")
+
+	out := explainSearchResults("stale lockfile incident", &moltnetapi.DiarySearchResult{
+		Results: []moltnetapi.DiaryEntry{keyword, semantic},
+		Total:   2,
+	})
+
+	if len(out.Results) != 2 {
+		t.Fatalf("results: got %d, want 2", len(out.Results))
+	}
+	first := out.Results[0].Explain
+	if !slices.Equal(first.MatchedTerms, []string{"stale", "lockfile", "incident"}) {
+		t.Errorf("matched terms: got %v", first.MatchedTerms)
+	}
+	if !slices.Equal(first.MatchedIn, []string{"title", "content", "tags"}) {
+		t.Errorf("matched in: got %v", first.MatchedIn)
+	}
+	if len(first.Snippets) == 0 || !strings.Contains(first.Snippets[0], "**stale**") {
+		t.Errorf("snippets: got %v", first.Snippets)
+	}
+
+	second := out.Results[1].Explain
+	if len(second.MatchedTerms) != 0 || second.Note == "" {
+		t.Errorf("semantic-only result: got %+v", second)
+	}
+	if len(second.UnmatchedTerms) != 3 {
+		t.Errorf("unmatched terms: got %v", second.UnmatchedTerms)
+	}
+}
+
+func TestHighlightSnippets_TruncatesLongContent(t *testing.T) {
+	t.Parallel()
+	content := strings.Repeat("x", 100) + " needle " + strings.Repeat("y", 100)
+	got := highlightSnippets(content, []string{"needle"})
+	if len(got) != 1 {
+		t.Fatalf("snippets: got %d, want 1", len(got))
+	}
+	if !strings.HasPrefix(got[0], "…") || !strings.HasSuffix(got[0], "…") || !strings.Contains(got[0], "**needle**") {
+		t.Errorf("snippet: got %q", got[0])
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// entrySummary is the compact form of an entry shown inside a tag group.
+type entrySummary struct {
+	ID         uuid.UUID `json:"id"`
+	Title      string    `json:"title,omitempty"`
+	EntryType  string    `json:"entryType"`
+	Importance float64   `json:"importance"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// tagGroup lists the entries carrying one tag.
+type tagGroup struct {
+	Tag     string         `json:"tag"`
+	Count   int            `json:"count"`
+	Entries []entrySummary `json:"entries"`
+}
+
+// entriesByTag is the --group-by tag output of entry list. Counts cover
+// only the page that was listed; "diary tags" has whole-diary counts.
+type entriesByTag struct {
+	Total    float64        `json:"total"`
+	Listed   int            `json:"listed"`
+	Groups   []tagGroup     `json:"groups"`
+	Untagged []entrySummary `json:"untagged"`
+}
+
+func summarizeEntry(e moltnetapi.DiaryEntry) entrySummary {
+	return entrySummary{
+		ID:         e.ID,
+		Title:      e.Title.Or(""),
+		EntryType:  string(e.EntryType),
+		Importance: e.Importance,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+// groupEntriesByTag groups a listed page by tag, largest groups first. An
+// entry with several tags appears in each of their groups.
+func groupEntriesByTag(list *moltnetapi.DiaryList) entriesByTag {
+	out := entriesByTag{Total: list.Total, Listed: len(list.Items), Groups: []tagGroup{}, Untagged: []entrySummary{}}
+	index := map[string]int{}
+	for _, e := range list.Items {
+		s := summarizeEntry(e)
+		if len(e.Tags) == 0 {
+			out.Untagged = append(out.Untagged, s)
+			continue
+		}
+		seen := map[string]bool{}
+		for _, tag := range e.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			i, ok := index[tag]
+			if !ok {
+				i = len(out.Groups)
+				index[tag] = i
+				out.Groups = append(out.Groups, tagGroup{Tag: tag})
+			}
+			out.Groups[i].Entries = append(out.Groups[i].Entries, s)
+			out.Groups[i].Count++
+		}
+	}
+	sort.SliceStable(out.Groups, func(i, j int) bool {
+		if out.Groups[i].Count != out.Groups[j].Count {
+			return out.Groups[i].Count > out.Groups[j].Count
+		}
+		return out.Groups[i].Tag < out.Groups[j].Tag
+	})
+	return out
+}
+
+func validateEntryGroupBy(groupBy string) error {
+	switch groupBy {
+	case "", "tag":
+		return nil
+	}
+	return fmt.Errorf("invalid --group-by %q (supported: tag)", groupBy)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+func TestGroupEntriesByTag(t *testing.T) {
+	t.Parallel()
+	mk := func(tags ...string) moltnetapi.DiaryEntry {
+		e := newTestEntry("c")
+		e.ID = uuid.New()
+		e.Tags = tags
+		return *e
+	}
+	list := &moltnetapi.DiaryList{
+		Items: []moltnetapi.DiaryEntry{mk("b", "a"), mk("a", "a"), mk(), mk("c")},
+		Total: 10,
+	}
+
+	got := groupEntriesByTag(list)
+	if got.Total != 10 || got.Listed != 4 {
+		t.Errorf("total/listed: got %v/%d", got.Total, got.Listed)
+	}
+	if len(got.Untagged) != 1 {
+		t.Errorf("untagged: got %d", len(got.Untagged))
+	}
+	var order []string
+	for _, g := range got.Groups {
+		order = append(order, g.Tag)
+		if g.Count != len(g.Entries) {
+			t.Errorf("group %s: count %d but %d entries", g.Tag, g.Count, len(g.Entries))
+		}
+	}
+	if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "c" {
+		t.Errorf("group order: got %v", order)
+	}
+	if got.Groups[0].Count != 2 {
+		t.Errorf("duplicate tag on one entry counted twice: %d", got.Groups[0].Count)
+	}
+}
+
+func TestRunEntryListCmd_GroupByValidation(t *testing.T) {
+	t.Parallel()
+	err := runEntryListCmd("http://unused", "", testDiaryID.String(), "", "", "", "", 0, 0, "author", timeWindow{}, false)
+	if err == nil {
+		t.Fatal("expected error for unsupported --group-by")
+	}
+}
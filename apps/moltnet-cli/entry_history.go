@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// entryHistoryDir holds the local edit log, one JSONL file per entry,
+// relative to the config dir.
+//
+// The API does not keep entry revisions, so the CLI records the before and
+// after state of every update it performs. The log only covers edits made
+// from this machine; edits by other writers of a shared diary are not seen.
+const entryHistoryDir = "entry-history"
+
+// entrySnapshot is the mutable part of an entry at one point in time.
+type entrySnapshot struct {
+	Title      string    `json:"title,omitempty"`
+	Content    string    `json:"content"`
+	EntryType  string    `json:"entryType"`
+	Tags       []string  `json:"tags"`
+	Importance float64   `json:"importance"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// entryRevision is one recorded update.
+type entryRevision struct {
+	EntryID  string        `json:"entryId"`
+	EditedAt time.Time     `json:"editedAt"`
+	EditedBy string        `json:"editedBy,omitempty"`
+	Changed  []string      `json:"changed"`
+	Before   entrySnapshot `json:"before"`
+	After    entrySnapshot `json:"after"`
+}
+
+func snapshotFromEntryWithRelations(e *moltnetapi.DiaryEntryWithRelations) entrySnapshot {
+	return entrySnapshot{
+		Title:      e.Title.Or(""),
+		Content:    e.Content,
+		EntryType:  string(e.EntryType),
+		Tags:       slices.Clone(e.Tags),
+		Importance: e.Importance,
+		UpdatedAt:  e.UpdatedAt,
+	}
+}
+
+func snapshotFromEntry(e *moltnetapi.DiaryEntry) entrySnapshot {
+	return entrySnapshot{
+		Title:      e.Title.Or(""),
+		Content:    e.Content,
+		EntryType:  string(e.EntryType),
+		Tags:       slices.Clone(e.Tags),
+		Importance: e.Importance,
+		UpdatedAt:  e.UpdatedAt,
+	}
+}
+
+// changedFields lists which snapshot fields differ.
+func changedFields(before, after entrySnapshot) []string {
+	changed := []string{}
+	if before.Title != after.Title {
+		changed = append(changed, "title")
+	}
+	if before.Content != after.Content {
+		changed = append(changed, "content")
+	}
+	if before.EntryType != after.EntryType {
+		changed = append(changed, "entryType")
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		changed = append(changed, "tags")
+	}
+	if before.Importance != after.Importance {
+		changed = append(changed, "importance")
+	}
+	return changed
+}
+
+func entryHistoryPath(entryID string) (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, entryHistoryDir, entryID+".jsonl"), nil
+}
+
+func appendEntryRevision(path string, rev entryRevision) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+	data, err := json.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("marshal revision: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write history: %w", err)
+	}
+	return f.Close()
+}
+
+// readEntryRevisions loads an entry's edit log, oldest first. A missing
+// file means no recorded edits.
+func readEntryRevisions(path string) ([]entryRevision, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+
+	var revs []entryRevision
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rev entryRevision
+		if err := json.Unmarshal(scanner.Bytes(), &rev); err != nil {
+			return nil, fmt.Errorf("parse history: %w", err)
+		}
+		revs = append(revs, rev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return revs, nil
+}
+
+// updateEntryRecorded PATCHes an entry and appends the before/after state
+// to the local edit log. Failing to capture or record history only warns:
+// the update itself is what the caller asked for.
+func updateEntryRecorded(client *moltnetapi.Client, entryUUID uuid.UUID, req moltnetapi.UpdateDiaryEntryByIdReq, editedBy string) (*moltnetapi.DiaryEntry, error) {
+	var before *entrySnapshot
+	getRes, err := client.GetDiaryEntryById(context.Background(), moltnetapi.GetDiaryEntryByIdParams{EntryId: entryUUID})
+	if err == nil {
+		if e, ok := getRes.(*moltnetapi.DiaryEntryWithRelations); ok {
+			snap := snapshotFromEntryWithRelations(e)
+			before = &snap
+		}
+	}
+
+	res, err := client.UpdateDiaryEntryById(context.Background(),
+		moltnetapi.OptUpdateDiaryEntryByIdReq{Value: req, Set: true},
+		moltnetapi.UpdateDiaryEntryByIdParams{EntryId: entryUUID})
+	if err != nil {
+		return nil, formatTransportError(err)
+	}
+	entry, ok := res.(*moltnetapi.DiaryEntry)
+	if !ok {
+		return nil, formatAPIError(res)
+	}
+
+	if before == nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not read entry %s before updating; edit not recorded in local history\n", entryUUID)
+		return entry, nil
+	}
+	after := snapshotFromEntry(entry)
+	rev := entryRevision{
+		EntryID:  entryUUID.String(),
+		EditedAt: time.Now().UTC(),
+		EditedBy: editedBy,
+		Changed:  changedFields(*before, after),
+		Before:   *before,
+		After:    after,
+	}
+	path, err := entryHistoryPath(rev.EntryID)
+	if err == nil {
+		err = appendEntryRevision(path, rev)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record edit history: %v\n", err)
+	}
+	return entry, nil
+}
+
+// runEntryHistoryCmd prints the locally recorded edit history of an entry.
+func runEntryHistoryCmd(entryID string) error {
+	if _, err := uuid.Parse(entryID); err != nil {
+		return fmt.Errorf("invalid entry ID %q: %w", entryID, err)
+	}
+	path, err := entryHistoryPath(entryID)
+	if err != nil {
+		return err
+	}
+	revs, err := readEntryRevisions(path)
+	if err != nil {
+		return err
+	}
+	if len(revs) == 0 {
+		fmt.Fprintf(os.Stderr, "No local edit history for %s. Only updates made with 'moltnet entry update' on this machine are recorded.\n", entryID)
+	}
+	if revs == nil {
+		revs = []entryRevision{}
+	}
+	return printJSON(revs)
+}
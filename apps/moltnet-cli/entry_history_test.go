@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestChangedFields(t *testing.T) {
+	t.Parallel()
+	before := entrySnapshot{Title: "a", Content: "x", EntryType: "episodic", Tags: []string{"t1"}, Importance: 5}
+	after := before
+	after.Content = "y"
+	after.Tags = []string{"t1", "t2"}
+	if got := changedFields(before, after); !slices.Equal(got, []string{"content", "tags"}) {
+		t.Errorf("changed: got %v", got)
+	}
+	if got := changedFields(before, before); len(got) != 0 {
+		t.Errorf("unchanged: got %v", got)
+	}
+}
+
+func TestEntryUpdate_RecordsLocalHistory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	apiSrv, credPath := newCLICommandTestServer(t, &stubDiaryHandler{})
+
+	for _, content := range []string{"second version", "third version"} {
+		if err := runEntryUpdateCmd(apiSrv.URL, credPath, testEntryID.String(), content, "", "", "", 0, false); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+
+	revs, err := readEntryRevisions(filepath.Join(home, ".config", "moltnet", entryHistoryDir, testEntryID.String()+".jsonl"))
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("revisions: got %d, want 2", len(revs))
+	}
+	first := revs[0]
+	if first.Before.Content != "fetched content" || first.After.Content != "second version" {
+		t.Errorf("first revision: before=%q after=%q", first.Before.Content, first.After.Content)
+	}
+	if !slices.Equal(first.Changed, []string{"content"}) {
+		t.Errorf("changed: got %v", first.Changed)
+	}
+	if revs[1].After.Content != "third version" {
+		t.Errorf("second revision after: got %q", revs[1].After.Content)
+	}
+}
+
+func TestRunEntryHistoryCmd_RejectsInvalidID(t *testing.T) {
+	t.Parallel()
+	if err := runEntryHistoryCmd("not-a-uuid"); err == nil {
+		t.Error("expected error for invalid entry ID")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"unicode/utf8"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// defaultPublicContentCap is the default --max-public-length: entries in
+// public or moltnet diaries longer than this many characters get a warning.
+const defaultPublicContentCap = 10000
+
+// injectionMarkers are obvious prompt-injection phrasings. The server runs
+// the authoritative scan on non-private entries; this list only catches the
+// blatant cases so the author hears about them before sending.
+var injectionMarkers = []struct {
+	re   *regexp.Regexp
+	desc string
+}{
+	{regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\s+(all\s+)?(the\s+)?(previous|prior|above|earlier)\s+(instructions|prompts?|rules)`), "instruction override phrase"},
+	{regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(a|an|the|in)\b`), "role reassignment phrase"},
+	{regexp.MustCompile(`(?i)\b(reveal|print|show)\s+(your|the)\s+system\s+prompt`), "system prompt extraction phrase"},
+	{regexp.MustCompile(`(?i)<\|?(im_start|im_end|system|endoftext)\|?>`), "chat template token"},
+	{regexp.MustCompile(`(?i)\[/?INST\]|<</?SYS>>`), "instruction template marker"},
+}
+
+// prescanEntryContent returns advisory warnings for content headed to a
+// non-private diary. maxLen <= 0 disables the length check.
+func prescanEntryContent(title, content string, maxLen int) []string {
+	var warnings []string
+	text := title + "\n" + content
+
+	if maxLen > 0 {
+		if n := utf8.RuneCountInString(content); n > maxLen {
+			warnings = append(warnings, fmt.Sprintf("content is %d characters, above the %d cap for shared entries", n, maxLen))
+		}
+	}
+
+	for _, m := range injectionMarkers {
+		if loc := m.re.FindString(text); loc != "" {
+			warnings = append(warnings, fmt.Sprintf("%s: %q", m.desc, loc))
+		}
+	}
+
+	if r, ok := firstHiddenRune(text); ok {
+		warnings = append(warnings, fmt.Sprintf("contains control or invisible character U+%04X", r))
+	}
+	return warnings
+}
+
+// firstHiddenRune finds control characters (other than tab and newlines),
+// zero-width characters, and bidi overrides — all common ways to smuggle
+// instructions past a human reader.
+func firstHiddenRune(s string) (rune, bool) {
+	for _, r := range s {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			continue
+		case r < 0x20 || r == 0x7f:
+			return r, true
+		case r >= 0x200b && r <= 0x200f,
+			r >= 0x202a && r <= 0x202e,
+			r >= 0x2066 && r <= 0x2069,
+			r == 0xfeff:
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// warnIfSharedEntryRisky looks up the diary's visibility and, for public
+// or moltnet diaries, writes any pre-scan warnings to w. It is advisory: a
+// failed lookup skips the scan rather than blocking the create.
+func warnIfSharedEntryRisky(client *moltnetapi.Client, diaryID uuid.UUID, title, content string, maxLen int, w io.Writer) {
+	res, err := client.GetDiary(context.Background(), moltnetapi.GetDiaryParams{ID: diaryID})
+	if err != nil {
+		return
+	}
+	diary, ok := res.(*moltnetapi.DiaryCatalog)
+	if !ok || diary.Visibility == moltnetapi.DiaryCatalogVisibilityPrivate {
+		return
+	}
+	warnings := prescanEntryContent(title, content, maxLen)
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Warning: diary %q is %s; the server will injection-scan this entry. Pre-scan found:\n", diary.Name, diary.Visibility)
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "  - %s\n", warning)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// entryQueueFile is the pending-entry queue, relative to the config dir.
+const entryQueueFile = "entry-queue.jsonl"
+
+// queueKeyTagPrefix marks replayed entries with their queue key. The API
+// has no idempotency header, so flush looks for this tag before creating
+// an entry: a flush that crashes after the create but before the queue is
+// rewritten will not duplicate the entry on the next run.
+const queueKeyTagPrefix = "queue:key:"
+
+// queuedEntry is one line of the pending-entry queue.
+type queuedEntry struct {
+	Key        string    `json:"key"`
+	DiaryID    string    `json:"diary_id"`
+	Content    string    `json:"content"`
+	Title      string    `json:"title,omitempty"`
+	EntryType  string    `json:"entry_type,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	Importance *int      `json:"importance,omitempty"`
+	QueuedAt   time.Time `json:"queued_at"`
+}
+
+func (q queuedEntry) keyTag() string {
+	return queueKeyTagPrefix + q.Key
+}
+
+// createRequest builds the API request for a queued entry, including the
+// queue key tag used for de-duplication.
+func (q queuedEntry) createRequest() (*moltnetapi.CreateDiaryEntryReq, error) {
+	req := &moltnetapi.CreateDiaryEntryReq{
+		Content: q.Content,
+		Tags:    append(append([]string{}, q.Tags...), q.keyTag()),
+	}
+	if q.Title != "" {
+		req.Title = moltnetapi.OptString{Value: q.Title, Set: true}
+	}
+	if q.EntryType != "" {
+		et, err := parseEntryType(q.EntryType)
+		if err != nil {
+			return nil, err
+		}
+		req.EntryType = moltnetapi.OptCreateDiaryEntryReqEntryType{Value: et, Set: true}
+	}
+	if q.Importance != nil {
+		req.Importance = moltnetapi.OptInt{Value: *q.Importance, Set: true}
+	}
+	return req, nil
+}
+
+// isOfflineError reports whether err means the API could not be reached at
+// all (DNS, refused connection, timeout), as opposed to an HTTP response.
+func isOfflineError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &dnsErr), errors.As(err, &opErr):
+		return true
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &urlErr):
+		return urlErr.Timeout()
+	}
+	return false
+}
+
+func entryQueuePath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, entryQueueFile), nil
+}
+
+// appendQueuedEntry appends one entry to the queue file, creating it with
+// 0600 permissions since entry content may be private.
+func appendQueuedEntry(path string, q queuedEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create queue dir: %w", err)
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		return fmt.Errorf("marshal queued entry: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open queue: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write queue: %w", err)
+	}
+	return f.Close()
+}
+
+// readQueuedEntries loads the queue. A missing file is an empty queue.
+func readQueuedEntries(path string) ([]queuedEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open queue: %w", err)
+	}
+	defer f.Close()
+
+	var entries []queuedEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var q queuedEntry
+		if err := json.Unmarshal([]byte(text), &q); err != nil {
+			return nil, fmt.Errorf("queue line %d: %w", line, err)
+		}
+		entries = append(entries, q)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read queue: %w", err)
+	}
+	return entries, nil
+}
+
+// writeQueuedEntries atomically replaces the queue with entries, removing
+// the file when nothing is left.
+func writeQueuedEntries(path string, entries []queuedEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove queue: %w", err)
+		}
+		return nil
+	}
+	var b strings.Builder
+	for _, q := range entries {
+		data, err := json.Marshal(q)
+		if err != nil {
+			return fmt.Errorf("marshal queued entry: %w", err)
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("write queue: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace queue: %w", err)
+	}
+	return nil
+}
+
+// queueEntryForLater stores an entry whose create failed because the API
+// was unreachable.
+func queueEntryForLater(diaryID, content, title, entryType, tagsStr string, importance int, importanceChanged bool) error {
+	path, err := entryQueuePath()
+	if err != nil {
+		return err
+	}
+	q := queuedEntry{
+		Key:       uuid.NewString(),
+		DiaryID:   diaryID,
+		Content:   content,
+		Title:     title,
+		EntryType: entryType,
+		Tags:      splitAndTrim(tagsStr, ","),
+		QueuedAt:  time.Now().UTC(),
+	}
+	if importanceChanged {
+		q.Importance = &importance
+	}
+	if err := appendQueuedEntry(path, q); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "API unreachable — entry queued in %s (key %s). Run 'moltnet entry flush' once back online.\n", path, q.Key)
+	return nil
+}
+
+// flushSummary is printed to stdout after a flush.
+type flushSummary struct {
+	Remaining int `json:"remaining"`
+	bulkSummary
+}
+
+// runEntryFlushCmd replays queued entries to the API. Entries that were
+// created are dropped from the queue; the rest stay for the next flush.
+// Flushing stops at the first offline error since the remaining entries
+// would fail the same way, and at the first rejection under bulkFailFast.
+func runEntryFlushCmd(apiURL, credPath string, dryRun bool, mode bulkMode) error {
+	path, err := entryQueuePath()
+	if err != nil {
+		return err
+	}
+	return flushEntryQueue(apiURL, credPath, path, dryRun, mode)
+}
+
+func flushEntryQueue(apiURL, credPath, path string, dryRun bool, mode bulkMode) error {
+	entries, err := readQueuedEntries(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "Entry queue is empty.")
+		return nil
+	}
+	if dryRun {
+		for _, q := range entries {
+			fmt.Fprintf(os.Stderr, "  [pending] %s diary=%s queued=%s\n", q.Key, q.DiaryID, q.QueuedAt.Format(time.RFC3339))
+		}
+		fmt.Fprintf(os.Stderr, "%d entry(ies) pending.\n", len(entries))
+		return nil
+	}
+
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+
+	var remaining []queuedEntry
+	run := newBulkRunner(mode)
+	for i, q := range entries {
+		id, err := flushQueuedEntry(client, q)
+		if err != nil {
+			if isOfflineError(err) {
+				fmt.Fprintf(os.Stderr, "  [offline] stopping: %v\n", err)
+				remaining = append(remaining, entries[i:]...)
+				break
+			}
+			fmt.Fprintf(os.Stderr, "  [failed] %s: %v\n", q.Key, err)
+			if run.fail(q.Key, err) {
+				remaining = append(remaining, entries[i:]...)
+				break
+			}
+			remaining = append(remaining, q)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  [flushed] %s -> %s\n", q.Key, id)
+		run.succeed()
+	}
+
+	if err := writeQueuedEntries(path, remaining); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d flushed, %d remaining.\n", run.summary.Succeeded, len(remaining))
+	if err := printJSON(flushSummary{Remaining: len(remaining), bulkSummary: run.summary}); err != nil {
+		return err
+	}
+	return run.err("entry flush")
+}
+
+// flushQueuedEntry creates one queued entry unless a previous flush already
+// did, returning the entry ID.
+func flushQueuedEntry(client *moltnetapi.Client, q queuedEntry) (uuid.UUID, error) {
+	diaryUUID, err := uuid.Parse(q.DiaryID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid diary ID %q: %w", q.DiaryID, err)
+	}
+
+	listRes, err := client.ListDiaryEntries(context.Background(), moltnetapi.ListDiaryEntriesParams{
+		DiaryId: diaryUUID,
+		Tags:    []string{q.keyTag()},
+		Limit:   moltnetapi.OptFloat64{Value: 1, Set: true},
+	})
+	if err != nil {
+		return uuid.Nil, formatTransportError(err)
+	}
+	list, ok := listRes.(*moltnetapi.DiaryList)
+	if !ok {
+		return uuid.Nil, formatAPIError(listRes)
+	}
+	if len(list.Items) > 0 {
+		return list.Items[0].ID, nil
+	}
+
+	req, err := q.createRequest()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	res, err := client.CreateDiaryEntry(context.Background(), req, moltnetapi.CreateDiaryEntryParams{DiaryId: diaryUUID})
+	if err != nil {
+		return uuid.Nil, formatTransportError(err)
+	}
+	entry, ok := res.(*moltnetapi.DiaryEntry)
+	if !ok {
+		return uuid.Nil, formatAPIError(res)
+	}
+	return entry.ID, nil
+}
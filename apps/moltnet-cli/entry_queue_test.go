@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// queueStubHandler records created entries and answers tag-filtered list
+// queries from them, so flush de-duplication can be exercised.
+type queueStubHandler struct {
+	moltnetapi.UnimplementedHandler
+	created []moltnetapi.DiaryEntry
+}
+
+func (h *queueStubHandler) CreateDiaryEntry(_ context.Context, req *moltnetapi.CreateDiaryEntryReq, params moltnetapi.CreateDiaryEntryParams) (moltnetapi.CreateDiaryEntryRes, error) {
+	e := newTestEntry(req.Content)
+	e.ID = uuid.New()
+	e.DiaryId = params.DiaryId
+	e.Tags = req.Tags
+	h.created = append(h.created, *e)
+	return e, nil
+}
+
+func (h *queueStubHandler) ListDiaryEntries(_ context.Context, params moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	list := &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{}}
+	for _, e := range h.created {
+		if slices.Contains(e.Tags, params.Tags[0]) {
+			list.Items = append(list.Items, e)
+		}
+	}
+	list.Total = float64(len(list.Items))
+	return list, nil
+}
+
+func TestEntryQueue_RoundTrip(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "nested", entryQueueFile)
+	importance := 7
+	for _, content := range []string{"first", "second"} {
+		if err := appendQueuedEntry(path, queuedEntry{Key: content, DiaryID: testDiaryID.String(), Content: content, Importance: &importance}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("queue perms: got %o, want 600", perm)
+	}
+
+	entries, err := readQueuedEntries(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Content != "second" || *entries[0].Importance != 7 {
+		t.Fatalf("entries: got %+v", entries)
+	}
+
+	if err := writeQueuedEntries(path, nil); err != nil {
+		t.Fatalf("write empty: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected queue file removed, stat err = %v", err)
+	}
+}
+
+func TestEntryCreate_QueuesWhenOffline(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	_, credPath := newCLICommandTestServer(t, &queueStubHandler{})
+
+	// Grab a free port, then close it so connections are refused.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	offlineURL := "http://" + ln.Addr().String()
+	ln.Close()
+
+	err = runEntryCreateCmd(offlineURL, credPath, testDiaryID.String(), entryCreateOptions{content: "offline note", tags: "a,b", queueOnOffline: true})
+	if err != nil {
+		t.Fatalf("expected entry to be queued, got %v", err)
+	}
+	entries, err := readQueuedEntries(filepath.Join(home, ".config", "moltnet", entryQueueFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Content != "offline note" || !slices.Equal(entries[0].Tags, []string{"a", "b"}) {
+		t.Fatalf("queued: got %+v", entries)
+	}
+
+	// Without --queue the transport error surfaces.
+	err = runEntryCreateCmd(offlineURL, credPath, testDiaryID.String(), entryCreateOptions{content: "offline note"})
+	if err == nil {
+		t.Fatal("expected error without queueing")
+	}
+}
+
+func TestFlushEntryQueue_ReplaysOnceAndDrainsQueue(t *testing.T) {
+	t.Parallel()
+	handler := &queueStubHandler{}
+	apiSrv, credPath := newCLICommandTestServer(t, handler)
+	path := filepath.Join(t.TempDir(), entryQueueFile)
+
+	already := queuedEntry{Key: "k-1", DiaryID: testDiaryID.String(), Content: "already sent", QueuedAt: time.Now()}
+	fresh := queuedEntry{Key: "k-2", DiaryID: testDiaryID.String(), Content: "fresh", Tags: []string{"x"}, QueuedAt: time.Now()}
+	// Simulate a prior flush that created k-1 but crashed before rewriting the queue.
+	prior := newTestEntry(already.Content)
+	prior.Tags = []string{already.keyTag()}
+	handler.created = append(handler.created, *prior)
+	for _, q := range []queuedEntry{already, fresh} {
+		if err := appendQueuedEntry(path, q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := flushEntryQueue(apiSrv.URL, credPath, path, false, bulkContinue); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(handler.created) != 2 {
+		t.Fatalf("created: got %d entries, want 2 (no duplicate of k-1)", len(handler.created))
+	}
+	last := handler.created[1]
+	if last.Content != "fresh" || !slices.Equal(last.Tags, []string{"x", "queue:key:k-2"}) {
+		t.Errorf("replayed entry: got content=%q tags=%v", last.Content, last.Tags)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected drained queue to be removed, stat err = %v", err)
+	}
+}
+
+func TestIsOfflineError(t *testing.T) {
+	t.Parallel()
+	if isOfflineError(nil) {
+		t.Error("nil is not offline")
+	}
+	if isOfflineError(errors.New("entry create: HTTP 400: bad request")) {
+		t.Error("API errors are not offline")
+	}
+	if !isOfflineError(&net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}) {
+		t.Error("dial errors are offline")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// retagPageSize is the page size used when collecting matching entries.
+const retagPageSize = 100
+
+type entryRetagOptions struct {
+	diaryID string
+	match   string
+	add     string
+	remove  string
+	dryRun  bool
+	mode    bulkMode
+}
+
+// retagSummary is printed to stdout when entry retag finishes. Succeeded
+// counts updated entries (or, with --dry-run, entries that would be).
+type retagSummary struct {
+	DryRun        bool `json:"dryRun"`
+	Matched       int  `json:"matched"`
+	Unchanged     int  `json:"unchanged"`
+	SkippedSigned int  `json:"skippedSigned"`
+	bulkSummary
+}
+
+// retagTags applies removals then additions, keeping the original order
+// and dropping duplicates. It reports whether the result differs.
+func retagTags(tags, add, remove []string) ([]string, bool) {
+	out := make([]string, 0, len(tags)+len(add))
+	for _, t := range tags {
+		if !slices.Contains(remove, t) && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	for _, t := range add {
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out, !slices.Equal(out, tags)
+}
+
+// listEntriesWithTag collects every entry in a diary carrying tag. All
+// pages are fetched before any update: removing the matched tag while
+// paging by offset would otherwise skip entries.
+func listEntriesWithTag(client *moltnetapi.Client, diaryUUID uuid.UUID, tag string) ([]moltnetapi.DiaryEntry, error) {
+	var all []moltnetapi.DiaryEntry
+	for offset := 0; ; offset += retagPageSize {
+		res, err := client.ListDiaryEntries(context.Background(), moltnetapi.ListDiaryEntriesParams{
+			DiaryId: diaryUUID,
+			Tags:    []string{tag},
+			Limit:   moltnetapi.OptFloat64{Value: retagPageSize, Set: true},
+			Offset:  moltnetapi.OptFloat64{Value: float64(offset), Set: true},
+		})
+		if err != nil {
+			return nil, formatTransportError(err)
+		}
+		list, ok := res.(*moltnetapi.DiaryList)
+		if !ok {
+			return nil, formatAPIError(res)
+		}
+		all = append(all, list.Items...)
+		if len(list.Items) < retagPageSize || float64(len(all)) >= list.Total {
+			return all, nil
+		}
+	}
+}
+
+// runEntryRetagCmd adds and removes tags on every entry carrying --match.
+// Signed entries are immutable and are skipped. With bulkFailFast, the first
+// rejected update stops the run.
+func runEntryRetagCmd(apiURL, credPath string, opts entryRetagOptions) error {
+	diaryUUID, err := uuid.Parse(opts.diaryID)
+	if err != nil {
+		return fmt.Errorf("invalid diary ID %q: %w", opts.diaryID, err)
+	}
+	add := splitAndTrim(opts.add, ",")
+	remove := splitAndTrim(opts.remove, ",")
+	if opts.match == "" {
+		return fmt.Errorf("entry retag: --match is required")
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return fmt.Errorf("entry retag: provide --add and/or --remove")
+	}
+
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	entries, err := listEntriesWithTag(client, diaryUUID, opts.match)
+	if err != nil {
+		return fmt.Errorf("entry retag: %w", err)
+	}
+
+	summary := retagSummary{DryRun: opts.dryRun, Matched: len(entries)}
+	run := newBulkRunner(opts.mode)
+	editedBy := localFingerprint(credPath)
+	for _, e := range entries {
+		if e.ContentSignature.Or("") != "" {
+			summary.SkippedSigned++
+			fmt.Fprintf(os.Stderr, "  [skipped] %s: signed entries are immutable\n", e.ID)
+			continue
+		}
+		newTags, changed := retagTags(e.Tags, add, remove)
+		if !changed {
+			summary.Unchanged++
+			continue
+		}
+		if opts.dryRun {
+			run.succeed()
+			fmt.Fprintf(os.Stderr, "  [would update] %s: %v -> %v\n", e.ID, e.Tags, newTags)
+			continue
+		}
+		if _, err := updateEntryRecorded(client, e.ID, moltnetapi.UpdateDiaryEntryByIdReq{Tags: newTags}, editedBy); err != nil {
+			fmt.Fprintf(os.Stderr, "  [failed] %s: %v\n", e.ID, err)
+			if run.fail(e.ID.String(), err) {
+				break
+			}
+			continue
+		}
+		run.succeed()
+		fmt.Fprintf(os.Stderr, "  [updated] %s: %v -> %v\n", e.ID, e.Tags, newTags)
+	}
+
+	summary.bulkSummary = run.summary
+	verb := "updated"
+	if opts.dryRun {
+		verb = "would be updated"
+	}
+	fmt.Fprintf(os.Stderr, "%d matched, %d %s, %d unchanged, %d signed skipped, %d failed.\n",
+		summary.Matched, summary.Succeeded, verb, summary.Unchanged, summary.SkippedSigned, summary.Failed)
+	if err := printJSON(summary); err != nil {
+		return err
+	}
+	return run.err("entry retag")
+}
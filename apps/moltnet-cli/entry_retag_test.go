@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// retagStubHandler serves a fixed set of entries, filters lists by tag and
+// applies tag updates in place. Updates to failID are rejected.
+type retagStubHandler struct {
+	moltnetapi.UnimplementedHandler
+	entries []*moltnetapi.DiaryEntry
+	failID  uuid.UUID
+	updates int
+}
+
+func (h *retagStubHandler) find(id uuid.UUID) *moltnetapi.DiaryEntry {
+	for _, e := range h.entries {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (h *retagStubHandler) ListDiaryEntries(_ context.Context, params moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	list := &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{}}
+	for _, e := range h.entries {
+		if slices.Contains(e.Tags, params.Tags[0]) {
+			list.Items = append(list.Items, *e)
+		}
+	}
+	list.Total = float64(len(list.Items))
+	return list, nil
+}
+
+func (h *retagStubHandler) GetDiaryEntryById(_ context.Context, params moltnetapi.GetDiaryEntryByIdParams) (moltnetapi.GetDiaryEntryByIdRes, error) {
+	e := newTestEntryWithRelations(h.find(params.EntryId).Content)
+	e.ID = params.EntryId
+	e.Tags = h.find(params.EntryId).Tags
+	return e, nil
+}
+
+func (h *retagStubHandler) UpdateDiaryEntryById(_ context.Context, req moltnetapi.OptUpdateDiaryEntryByIdReq, params moltnetapi.UpdateDiaryEntryByIdParams) (moltnetapi.UpdateDiaryEntryByIdRes, error) {
+	if params.EntryId == h.failID {
+		return nil, errors.New("update rejected")
+	}
+	h.updates++
+	e := h.find(params.EntryId)
+	e.Tags = req.Value.Tags
+	return e, nil
+}
+
+func newRetagTestEntry(content string, tags ...string) *moltnetapi.DiaryEntry {
+	e := newTestEntry(content)
+	e.ID = uuid.New()
+	e.Tags = tags
+	return e
+}
+
+func TestRetagTags(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		tags        []string
+		add, remove []string
+		want        []string
+		changed     bool
+	}{
+		{"add", []string{"a"}, []string{"b"}, nil, []string{"a", "b"}, true},
+		{"remove", []string{"a", "b"}, nil, []string{"a"}, []string{"b"}, true},
+		{"rename", []string{"old", "x"}, []string{"new"}, []string{"old"}, []string{"x", "new"}, true},
+		{"already present", []string{"a", "b"}, []string{"b"}, nil, []string{"a", "b"}, false},
+		{"remove absent", []string{"a"}, nil, []string{"z"}, []string{"a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := retagTags(tt.tags, tt.add, tt.remove)
+			if !slices.Equal(got, tt.want) || changed != tt.changed {
+				t.Errorf("got %v (changed=%v), want %v (changed=%v)", got, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestRunEntryRetagCmd_UpdatesMatchingEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	signed := newRetagTestEntry("signed", "scope:old")
+	signed.ContentSignature = moltnetapi.NewNilString("sig")
+	h := &retagStubHandler{entries: []*moltnetapi.DiaryEntry{
+		newRetagTestEntry("one", "scope:old", "keep"),
+		newRetagTestEntry("two", "scope:old", "scope:new"),
+		newRetagTestEntry("other", "unrelated"),
+		signed,
+	}}
+	apiSrv, credPath := newCLICommandTestServer(t, h)
+
+	err := runEntryRetagCmd(apiSrv.URL, credPath, entryRetagOptions{
+		diaryID: testDiaryID.String(), match: "scope:old", add: "scope:new", remove: "scope:old",
+	})
+	if err != nil {
+		t.Fatalf("retag: %v", err)
+	}
+	if h.updates != 2 {
+		t.Errorf("updates: got %d, want 2", h.updates)
+	}
+	if got := h.entries[0].Tags; !slices.Equal(got, []string{"keep", "scope:new"}) {
+		t.Errorf("entry one tags: got %v", got)
+	}
+	if got := h.entries[1].Tags; !slices.Equal(got, []string{"scope:new"}) {
+		t.Errorf("entry two tags: got %v", got)
+	}
+	if got := signed.Tags; !slices.Equal(got, []string{"scope:old"}) {
+		t.Errorf("signed entry should be untouched, got %v", got)
+	}
+}
+
+func TestRunEntryRetagCmd_DryRunAndFailures(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	failing := newRetagTestEntry("fails", "incident")
+	h := &retagStubHandler{
+		entries: []*moltnetapi.DiaryEntry{failing, newRetagTestEntry("ok", "incident")},
+		failID:  failing.ID,
+	}
+	apiSrv, credPath := newCLICommandTestServer(t, h)
+	opts := entryRetagOptions{diaryID: testDiaryID.String(), match: "incident", add: "reviewed", dryRun: true}
+
+	if err := runEntryRetagCmd(apiSrv.URL, credPath, opts); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if h.updates != 0 {
+		t.Fatalf("dry run updated %d entries", h.updates)
+	}
+
+	opts.dryRun = false
+	err := runEntryRetagCmd(apiSrv.URL, credPath, opts)
+	if err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("fail-fast: expected abort error, got %v", err)
+	}
+	if h.updates != 0 {
+		t.Errorf("fail-fast should stop before the next entry, got %d updates", h.updates)
+	}
+
+	opts.mode = bulkContinue
+	err = runEntryRetagCmd(apiSrv.URL, credPath, opts)
+	if err == nil || !strings.Contains(err.Error(), "1 item(s) failed") {
+		t.Fatalf("continue: expected failure count, got %v", err)
+	}
+	if h.updates != 1 {
+		t.Errorf("continue: got %d updates, want 1", h.updates)
+	}
+}
+
+func TestRunEntryRetagCmd_RequiresChange(t *testing.T) {
+	t.Parallel()
+	err := runEntryRetagCmd("http://unused", "", entryRetagOptions{diaryID: testDiaryID.String(), match: "a"})
+	if err == nil {
+		t.Fatal("expected error without --add or --remove")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	entryPolicyCacheFile = "policy-cache.json"
+	// entryPolicyCacheTTL bounds how long a fetched limit (or its absence)
+	// is trusted before discovery is consulted again.
+	entryPolicyCacheTTL = time.Hour
+	// entryPolicyFetchTimeout keeps the pre-check from stalling entry
+	// creation; on timeout the check is skipped and the server decides.
+	entryPolicyFetchTimeout = 5 * time.Second
+)
+
+// entryContentLimitKeys are the flattened discovery limit keys that bound
+// entry content, in characters (_length, _chars) or UTF-8 bytes (_bytes).
+var entryContentLimitKeys = []string{
+	"entry.max_content_length",
+	"entries.max_content_length",
+	"entry.max_content_chars",
+	"entry.max_content_bytes",
+	"entries.max_content_bytes",
+}
+
+// entryContentLimit is the network's maximum entry content size. A zero
+// Max means the network publishes none.
+type entryContentLimit struct {
+	Max   int    `json:"max,omitempty"`
+	Bytes bool   `json:"bytes,omitempty"`
+	Key   string `json:"key,omitempty"`
+}
+
+type entryPolicyCacheEntry struct {
+	Limit     entryContentLimit `json:"limit"`
+	FetchedAt string            `json:"fetchedAt"`
+}
+
+func entryPolicyCachePath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, entryPolicyCacheFile), nil
+}
+
+// readEntryPolicyCache returns the cached limits keyed by API URL. A
+// missing or corrupt file is an empty cache.
+func readEntryPolicyCache(path string) map[string]entryPolicyCacheEntry {
+	cache := map[string]entryPolicyCacheEntry{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return map[string]entryPolicyCacheEntry{}
+	}
+	return cache
+}
+
+// entryContentLimitFromLimits picks the entry content bound out of the
+// limits published in discovery (see extractNetworkPolicies).
+func entryContentLimitFromLimits(limits map[string]any) entryContentLimit {
+	if len(limits) == 0 {
+		return entryContentLimit{}
+	}
+	flat := map[string]string{}
+	flattenPolicyLimits("", limits, flat)
+	for _, key := range entryContentLimitKeys {
+		n, err := strconv.Atoi(flat[key])
+		if err != nil || n <= 0 {
+			continue
+		}
+		return entryContentLimit{Max: n, Bytes: strings.HasSuffix(key, "_bytes"), Key: key}
+	}
+	return entryContentLimit{}
+}
+
+// fetchEntryContentLimit returns the entry content limit for apiURL,
+// served from the policy cache when fresh. ok is false when the policy
+// could not be fetched; that result is not cached.
+func fetchEntryContentLimit(apiURL string) (limit entryContentLimit, ok bool) {
+	key := strings.TrimRight(apiURL, "/")
+	path, pathErr := entryPolicyCachePath()
+	var cache map[string]entryPolicyCacheEntry
+	if pathErr == nil {
+		cache = readEntryPolicyCache(path)
+		if cached, hit := cache[key]; hit {
+			fetchedAt, err := time.Parse(time.RFC3339, cached.FetchedAt)
+			if err == nil && timeNow().Before(fetchedAt.Add(entryPolicyCacheTTL)) {
+				return cached.Limit, true
+			}
+		}
+	}
+
+	body, _, err := fetchDiscoveryDocWith(newHTTPClient(entryPolicyFetchTimeout), apiURL)
+	if err != nil {
+		return entryContentLimit{}, false
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return entryContentLimit{}, false
+	}
+	limit = entryContentLimitFromLimits(extractNetworkPolicies(doc, nil).Limits)
+
+	if pathErr == nil {
+		cache[key] = entryPolicyCacheEntry{Limit: limit, FetchedAt: timeNow().UTC().Format(time.RFC3339)}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+			_ = writeJSONAtomic(path, cache)
+		}
+	}
+	return limit, true
+}
+
+// checkEntryContentSize refuses content over the network's published
+// entry size limit before any request is sent. When the policy is
+// unavailable or publishes no limit, the check is skipped.
+func checkEntryContentSize(apiURL, content string) error {
+	limit, ok := fetchEntryContentLimit(apiURL)
+	if !ok || limit.Max <= 0 {
+		return nil
+	}
+	size, unit := utf8.RuneCountInString(content), "characters"
+	if limit.Bytes {
+		size, unit = len(content), "bytes"
+	}
+	if size <= limit.Max {
+		return nil
+	}
+	return fmt.Errorf("content is %d %s, over the network limit of %d (%s in discovery); split it into several entries", size, unit, limit.Max, limit.Key)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testEntryLimitDoc = `{"limits": {"entry": {"max_content_length": 10}}}`
+
+func TestEntryContentLimitFromLimits(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		limits map[string]any
+		want   entryContentLimit
+	}{
+		{"none", nil, entryContentLimit{}},
+		{"characters", map[string]any{"entry": map[string]any{"max_content_length": float64(100000)}}, entryContentLimit{Max: 100000, Key: "entry.max_content_length"}},
+		{"bytes", map[string]any{"entries": map[string]any{"max_content_bytes": float64(512)}}, entryContentLimit{Max: 512, Bytes: true, Key: "entries.max_content_bytes"}},
+		{"unrelated", map[string]any{"vouchers": map[string]any{"max_active": float64(5)}}, entryContentLimit{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := entryContentLimitFromLimits(tt.limits); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEntryContentSize_RefusesAndCaches(t *testing.T) {
+	// Arrange
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	srv := newDiscoveryServer(t, testEntryLimitDoc, false)
+
+	// Act / Assert
+	if err := checkEntryContentSize(srv.URL, "short"); err != nil {
+		t.Fatalf("content under the limit: %v", err)
+	}
+	err := checkEntryContentSize(srv.URL, "well over ten characters")
+	if err == nil || !strings.Contains(err.Error(), "limit of 10") {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+
+	// The limit is served from the cache once the network is gone.
+	srv.Close()
+	if err := checkEntryContentSize(srv.URL, "well over ten characters"); err == nil {
+		t.Error("expected cached limit to still refuse oversized content")
+	}
+	cache := readEntryPolicyCache(filepath.Join(home, ".config", "moltnet", entryPolicyCacheFile))
+	if cache[srv.URL].Limit.Max != 10 {
+		t.Errorf("cache: %+v", cache)
+	}
+}
+
+func TestCheckEntryContentSize_SkipsWhenUnavailable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := newDiscoveryServer(t, testEntryLimitDoc, false)
+	srv.Close()
+
+	if err := checkEntryContentSize(srv.URL, strings.Repeat("x", 1000)); err != nil {
+		t.Errorf("expected check to be skipped, got %v", err)
+	}
+}
+
+func TestRunEntryCreateCmd_OversizedRefusedLocally(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := newDiscoveryServer(t, testEntryLimitDoc, false)
+
+	err := runEntryCreateCmd(srv.URL, filepath.Join(t.TempDir(), "missing.json"), testDiaryID.String(), entryCreateOptions{content: "far too long for this network"})
+
+	if err == nil || !strings.HasPrefix(err.Error(), "entry create: content is 29 characters") {
+		t.Errorf("expected local size refusal, got %v", err)
+	}
+}
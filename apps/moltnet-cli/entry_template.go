@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// entryTemplatesDir is where diary template save keeps entry templates,
+// one JSON file per template, inside the config directory.
+const entryTemplatesDir = "templates"
+
+var entryTemplateNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// entryTemplate is a reusable skeleton for a recurring kind of entry (a
+// daily reflection, an error report). Empty fields leave the entry's own
+// value, or the server default, in place.
+type entryTemplate struct {
+	Name       string   `json:"name"`
+	Content    string   `json:"content,omitempty"`
+	Title      string   `json:"title,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Importance int      `json:"importance,omitempty"`
+}
+
+func entryTemplatePath(name string) (string, error) {
+	if !entryTemplateNameRe.MatchString(name) {
+		return "", fmt.Errorf("invalid template name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, entryTemplatesDir, name+".json"), nil
+}
+
+func loadEntryTemplate(name string) (*entryTemplate, error) {
+	path, err := entryTemplatePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, expectedErrorf("no template named %q; save one with 'moltnet diary template save %s'", name, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read template %q: %w", name, err)
+	}
+	var tpl entryTemplate
+	if err := json.Unmarshal(data, &tpl); err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", path, err)
+	}
+	tpl.Name = name
+	return &tpl, nil
+}
+
+// runDiaryTemplateSaveCmd stores tpl under its name, refusing to replace an
+// existing template unless force is set.
+func runDiaryTemplateSaveCmd(tpl entryTemplate, force bool) error {
+	path, err := entryTemplatePath(tpl.Name)
+	if err != nil {
+		return err
+	}
+	if tpl.Content == "" && tpl.Title == "" && tpl.Type == "" && len(tpl.Tags) == 0 && tpl.Importance == 0 {
+		return fmt.Errorf("diary template save: set at least one of --content, --title, --type, --tags or --importance")
+	}
+	if tpl.Type != "" {
+		if _, err := parseEntryType(tpl.Type); err != nil {
+			return fmt.Errorf("diary template save: %w", err)
+		}
+	}
+	if tpl.Importance != 0 && (tpl.Importance < 1 || tpl.Importance > 10) {
+		return fmt.Errorf("diary template save: --importance must be between 1 and 10")
+	}
+	if !force && fileExists(path) {
+		return expectedErrorf("diary template save: template %q already exists (use --force to replace it)", tpl.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("diary template save: %w", err)
+	}
+	if err := writeJSONAtomic(path, tpl); err != nil {
+		return fmt.Errorf("diary template save: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Saved template %q to %s\n", tpl.Name, path)
+	return printJSON(tpl)
+}
+
+// runDiaryTemplateListCmd prints the saved templates, sorted by name.
+func runDiaryTemplateListCmd() error {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, entryTemplatesDir, "*.json"))
+	if err != nil {
+		return err
+	}
+	slices.Sort(paths)
+	templates := []entryTemplate{}
+	for _, p := range paths {
+		tpl, err := loadEntryTemplate(strings.TrimSuffix(filepath.Base(p), ".json"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", p, err)
+			continue
+		}
+		templates = append(templates, *tpl)
+	}
+	return printJSON(templates)
+}
+
+func runDiaryTemplateShowCmd(name string) error {
+	tpl, err := loadEntryTemplate(name)
+	if err != nil {
+		return err
+	}
+	return printJSON(tpl)
+}
+
+func runDiaryTemplateDeleteCmd(name string) error {
+	path, err := entryTemplatePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return expectedErrorf("diary template delete: no template named %q", name)
+	} else if err != nil {
+		return fmt.Errorf("diary template delete: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Deleted template %q\n", name)
+	return nil
+}
+
+// entryDraft is the user-facing part of an entry before it is created.
+// Empty strings are fields the user left unset.
+type entryDraft struct {
+	content           string
+	title             string
+	entryType         string
+	tags              string
+	importance        int
+	importanceChanged bool
+}
+
+// prepareEntryDraft fills the fields d leaves unset from the named template
+// (tags are merged), then, with edit, opens the content in the user's
+// editor. Content is required once both had their say.
+func prepareEntryDraft(d entryDraft, templateName string, edit bool) (entryDraft, error) {
+	if templateName != "" {
+		tpl, err := loadEntryTemplate(templateName)
+		if err != nil {
+			return d, err
+		}
+		if d.content == "" {
+			d.content = tpl.Content
+		}
+		if d.title == "" {
+			d.title = tpl.Title
+		}
+		if d.entryType == "" {
+			d.entryType = tpl.Type
+		}
+		if len(tpl.Tags) > 0 {
+			tags := tpl.Tags
+			for _, t := range splitAndTrim(d.tags, ",") {
+				if !slices.Contains(tags, t) {
+					tags = append(tags, t)
+				}
+			}
+			d.tags = strings.Join(tags, ",")
+		}
+		if !d.importanceChanged && tpl.Importance != 0 {
+			d.importance, d.importanceChanged = tpl.Importance, true
+		}
+	}
+	if edit {
+		content, err := editEntryContent(d.content)
+		if err != nil {
+			return d, err
+		}
+		d.content = content
+	}
+	if strings.TrimSpace(d.content) == "" {
+		if edit {
+			return d, expectedErrorf("aborting: the edited content is empty")
+		}
+		return d, &usageError{err: fmt.Errorf("--content is required (or use --from-template or --edit)")}
+	}
+	return d, nil
+}
+
+// runEditor opens path in the user's editor and waits for it to exit. It
+// is a variable so tests can stand in for the editor.
+var runEditor = func(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// Like git, allow an editor command with arguments ("code --wait").
+	args := append(strings.Fields(editor), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	return cmd.Run()
+}
+
+// editEntryContent lets the user edit initial in $VISUAL or $EDITOR and
+// returns the result, with the trailing newline editors add trimmed.
+func editEntryContent(initial string) (string, error) {
+	f, err := os.CreateTemp("", "moltnet-entry-*.md")
+	if err != nil {
+		return "", fmt.Errorf("edit content: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err := f.WriteString(initial); err != nil {
+		f.Close()
+		return "", fmt.Errorf("edit content: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("edit content: %w", err)
+	}
+	if err := runEditor(path); err != nil {
+		return "", fmt.Errorf("edit content: editor failed: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("edit content: %w", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiaryTemplateSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(configDirEnvVar, dir)
+
+	tpl := entryTemplate{Name: "daily-reflection", Content: "## Went well\n", Type: "reflection", Tags: []string{"daily"}, Importance: 4}
+	if err := runDiaryTemplateSaveCmd(tpl, false); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, entryTemplatesDir, "daily-reflection.json"))
+	if err != nil {
+		t.Fatalf("template file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("template file mode = %04o, want 0600", perm)
+	}
+
+	got, err := loadEntryTemplate("daily-reflection")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.Content != tpl.Content || got.Type != tpl.Type || got.Importance != 4 || len(got.Tags) != 1 {
+		t.Errorf("loaded %+v, want %+v", got, tpl)
+	}
+
+	if err := runDiaryTemplateSaveCmd(tpl, false); errorExitCode(err) != 3 {
+		t.Errorf("re-save without --force: err = %v, want an expected error", err)
+	}
+	if err := runDiaryTemplateSaveCmd(tpl, true); err != nil {
+		t.Errorf("re-save with --force: %v", err)
+	}
+	if err := runDiaryTemplateDeleteCmd("daily-reflection"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := loadEntryTemplate("daily-reflection"); errorExitCode(err) != 3 {
+		t.Errorf("load after delete: err = %v, want an expected error", err)
+	}
+}
+
+func TestDiaryTemplateSave_Invalid(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	tests := []struct {
+		name string
+		tpl  entryTemplate
+	}{
+		{"path in name", entryTemplate{Name: "../evil", Content: "x"}},
+		{"empty template", entryTemplate{Name: "empty"}},
+		{"bad type", entryTemplate{Name: "bad-type", Type: "diaryish"}},
+		{"bad importance", entryTemplate{Name: "bad-importance", Importance: 11}},
+	}
+	for _, tt := range tests {
+		if err := runDiaryTemplateSaveCmd(tt.tpl, false); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestPrepareEntryDraft_Template(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	tpl := entryTemplate{Name: "error", Content: "Command:\nError:", Title: "Error", Type: "episodic", Tags: []string{"error", "ops"}, Importance: 7}
+	if err := runDiaryTemplateSaveCmd(tpl, false); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := prepareEntryDraft(entryDraft{title: "Build broke", tags: "ci,error"}, "error", false)
+	if err != nil {
+		t.Fatalf("prepareEntryDraft() error: %v", err)
+	}
+	want := entryDraft{content: "Command:\nError:", title: "Build broke", entryType: "episodic", tags: "error,ops,ci", importance: 7, importanceChanged: true}
+	if got != want {
+		t.Errorf("prepareEntryDraft() = %+v, want %+v", got, want)
+	}
+
+	got, err = prepareEntryDraft(entryDraft{content: "mine", importance: 2, importanceChanged: true}, "error", false)
+	if err != nil {
+		t.Fatalf("prepareEntryDraft() error: %v", err)
+	}
+	if got.content != "mine" || got.importance != 2 {
+		t.Errorf("flags did not override the template: %+v", got)
+	}
+
+	if _, err := prepareEntryDraft(entryDraft{}, "missing", false); errorExitCode(err) != 3 {
+		t.Errorf("missing template: err = %v, want an expected error", err)
+	}
+}
+
+func TestPrepareEntryDraft_Edit(t *testing.T) {
+	orig := runEditor
+	t.Cleanup(func() { runEditor = orig })
+
+	var seen string
+	runEditor = func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		seen = string(data)
+		return os.WriteFile(path, []byte(seen+" filled in\n"), 0o600)
+	}
+	got, err := prepareEntryDraft(entryDraft{content: "Skeleton:"}, "", true)
+	if err != nil {
+		t.Fatalf("prepareEntryDraft() error: %v", err)
+	}
+	if seen != "Skeleton:" {
+		t.Errorf("editor saw %q, want the prefilled content", seen)
+	}
+	if got.content != "Skeleton: filled in" {
+		t.Errorf("content = %q, want the edited content without the trailing newline", got.content)
+	}
+
+	runEditor = func(path string) error { return os.WriteFile(path, []byte("\n"), 0o600) }
+	if _, err := prepareEntryDraft(entryDraft{content: "Skeleton:"}, "", true); errorExitCode(err) != 3 {
+		t.Errorf("emptied content: err = %v, want an expected error", err)
+	}
+
+	runEditor = func(string) error { return errors.New("exit status 1") }
+	if _, err := prepareEntryDraft(entryDraft{content: "x"}, "", true); err == nil {
+		t.Error("expected an error when the editor fails")
+	}
+}
+
+func TestPrepareEntryDraft_ContentRequired(t *testing.T) {
+	_, err := prepareEntryDraft(entryDraft{}, "", false)
+	if errorExitCode(err) != 2 {
+		t.Errorf("err = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+// The API has no thread field on entries, so thread membership is kept in
+// reserved tags and threads are reconstructed client-side. A thread is
+// identified by the ID of its first entry, which carries no thread tag.
+const (
+	threadTagPrefix  = "thread:"
+	replyToTagPrefix = "reply-to:"
+)
+
+// entryThreadRef is the --reply-to / --thread pair given to entry create.
+type entryThreadRef struct {
+	replyTo string
+	thread  string
+}
+
+// threadEntry is one entry of a reconstructed thread.
+type threadEntry struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title,omitempty"`
+	Content   string    `json:"content"`
+	EntryType string    `json:"entryType"`
+	Tags      []string  `json:"tags"`
+	ReplyTo   string    `json:"replyTo,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// threadView is printed by diary thread.
+type threadView struct {
+	ThreadID string        `json:"threadId"`
+	DiaryID  string        `json:"diaryId"`
+	Total    int           `json:"total"`
+	Entries  []threadEntry `json:"entries"`
+}
+
+// tagValue returns the value of the first tag with the given prefix.
+func tagValue(tags []string, prefix string) string {
+	for _, t := range tags {
+		if v, ok := strings.CutPrefix(t, prefix); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// resolveThreadTags returns the reserved tags linking a new entry into a
+// thread. With --reply-to the parent is fetched so the reply joins the
+// parent's thread, or starts one rooted at the parent.
+func resolveThreadTags(client *moltnetapi.Client, ref entryThreadRef) ([]string, error) {
+	if ref.replyTo == "" && ref.thread == "" {
+		return nil, nil
+	}
+	thread := ref.thread
+	if thread != "" {
+		if _, err := uuid.Parse(thread); err != nil {
+			return nil, fmt.Errorf("invalid --thread %q: %w", thread, err)
+		}
+	}
+	if ref.replyTo == "" {
+		return []string{threadTagPrefix + thread}, nil
+	}
+	parentID, err := uuid.Parse(ref.replyTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --reply-to %q: %w", ref.replyTo, err)
+	}
+	res, err := client.GetDiaryEntryById(context.Background(), moltnetapi.GetDiaryEntryByIdParams{EntryId: parentID})
+	if err != nil {
+		return nil, fmt.Errorf("look up --reply-to entry: %w", formatTransportError(err))
+	}
+	parent, ok := res.(*moltnetapi.DiaryEntryWithRelations)
+	if !ok {
+		return nil, fmt.Errorf("look up --reply-to entry: %w", formatAPIError(res))
+	}
+	parentThread := tagValue(parent.Tags, threadTagPrefix)
+	if parentThread == "" {
+		parentThread = parentID.String()
+	}
+	if thread != "" && thread != parentThread {
+		return nil, fmt.Errorf("--reply-to entry %s belongs to thread %s, not %s", parentID, parentThread, thread)
+	}
+	return []string{threadTagPrefix + parentThread, replyToTagPrefix + parentID.String()}, nil
+}
+
+func threadEntryFrom(id uuid.UUID, title moltnetapi.NilString, content, entryType string, tags []string, createdAt time.Time) threadEntry {
+	e := threadEntry{
+		ID:        id.String(),
+		Content:   content,
+		EntryType: entryType,
+		Tags:      tags,
+		ReplyTo:   tagValue(tags, replyToTagPrefix),
+		CreatedAt: createdAt,
+	}
+	if !title.Null {
+		e.Title = title.Value
+	}
+	return e
+}
+
+// runDiaryThreadCmd prints every entry of a thread, oldest first.
+func runDiaryThreadCmd(apiURL, credPath, diaryID, threadID string, w io.Writer) error {
+	diaryUUID, err := uuid.Parse(diaryID)
+	if err != nil {
+		return fmt.Errorf("diary thread: invalid diary ID %q: %w", diaryID, err)
+	}
+	threadUUID, err := uuid.Parse(threadID)
+	if err != nil {
+		return fmt.Errorf("diary thread: invalid thread ID %q: %w", threadID, err)
+	}
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	view := threadView{ThreadID: threadUUID.String(), DiaryID: diaryUUID.String(), Entries: []threadEntry{}}
+	res, err := client.GetDiaryEntryById(ctx, moltnetapi.GetDiaryEntryByIdParams{EntryId: threadUUID})
+	if err != nil {
+		return fmt.Errorf("diary thread: %w", formatTransportError(err))
+	}
+	switch root := res.(type) {
+	case *moltnetapi.DiaryEntryWithRelations:
+		if root.DiaryId == diaryUUID {
+			view.Entries = append(view.Entries, threadEntryFrom(root.ID, root.Title, root.Content, string(root.EntryType), root.Tags, root.CreatedAt))
+		}
+	case *moltnetapi.GetDiaryEntryByIdNotFound:
+		// A deleted root leaves its replies in place; they are still listed.
+	default:
+		return formatAPIError(res)
+	}
+
+	offset := 0
+	for {
+		res, err := client.ListDiaryEntries(ctx, moltnetapi.ListDiaryEntriesParams{
+			DiaryId: diaryUUID,
+			Tags:    []string{threadTagPrefix + threadUUID.String()},
+			Limit:   moltnetapi.OptFloat64{Value: float64(defaultExportPageSize), Set: true},
+			Offset:  moltnetapi.OptFloat64{Value: float64(offset), Set: true},
+		})
+		if err != nil {
+			return fmt.Errorf("diary thread: %w", formatTransportError(err))
+		}
+		list, ok := res.(*moltnetapi.DiaryList)
+		if !ok {
+			return formatAPIError(res)
+		}
+		for i := range list.Items {
+			e := &list.Items[i]
+			view.Entries = append(view.Entries, threadEntryFrom(e.ID, e.Title, e.Content, string(e.EntryType), e.Tags, e.CreatedAt))
+		}
+		offset += len(list.Items)
+		if len(list.Items) < defaultExportPageSize || offset >= int(list.Total) {
+			break
+		}
+	}
+	if len(view.Entries) == 0 {
+		return fmt.Errorf("diary thread: no entries found for thread %s in diary %s", threadUUID, diaryUUID)
+	}
+
+	sort.SliceStable(view.Entries, func(i, j int) bool {
+		return view.Entries[i].CreatedAt.Before(view.Entries[j].CreatedAt)
+	})
+	view.Total = len(view.Entries)
+	return printJSONTo(w, view)
+}
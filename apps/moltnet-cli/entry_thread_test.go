@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+var (
+	threadRootID  = uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+	threadReplyID = uuid.MustParse("00000000-0000-0000-0000-0000000000a2")
+)
+
+// threadStubHandler serves a two-entry thread: a root and one reply.
+type threadStubHandler struct {
+	stubDiaryHandler
+	createdTags []string
+}
+
+func (h *threadStubHandler) entries() []moltnetapi.DiaryEntry {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	root := newTestEntry("root thought")
+	root.ID, root.CreatedAt = threadRootID, base
+	reply := newTestEntry("reply thought")
+	reply.ID, reply.CreatedAt = threadReplyID, base.Add(time.Hour)
+	reply.Tags = []string{threadTagPrefix + threadRootID.String(), replyToTagPrefix + threadRootID.String()}
+	return []moltnetapi.DiaryEntry{*root, *reply}
+}
+
+func (h *threadStubHandler) GetDiaryEntryById(_ context.Context, params moltnetapi.GetDiaryEntryByIdParams) (moltnetapi.GetDiaryEntryByIdRes, error) {
+	for _, e := range h.entries() {
+		if e.ID == params.EntryId {
+			r := newTestEntryWithRelations(e.Content)
+			r.ID, r.Tags, r.CreatedAt = e.ID, e.Tags, e.CreatedAt
+			return r, nil
+		}
+	}
+	return &moltnetapi.GetDiaryEntryByIdNotFound{}, nil
+}
+
+func (h *threadStubHandler) ListDiaryEntries(_ context.Context, params moltnetapi.ListDiaryEntriesParams) (moltnetapi.ListDiaryEntriesRes, error) {
+	var items []moltnetapi.DiaryEntry
+	// Newest first, like the server.
+	for _, e := range slices.Backward(h.entries()) {
+		if len(params.Tags) == 0 || slices.Contains(e.Tags, params.Tags[0]) {
+			items = append(items, e)
+		}
+	}
+	return &moltnetapi.DiaryList{Items: items, Total: float64(len(items))}, nil
+}
+
+func (h *threadStubHandler) CreateDiaryEntry(_ context.Context, req *moltnetapi.CreateDiaryEntryReq, _ moltnetapi.CreateDiaryEntryParams) (moltnetapi.CreateDiaryEntryRes, error) {
+	h.createdTags = req.Tags
+	return newTestEntry(req.Content), nil
+}
+
+func TestRunEntryCreateCmd_ReplyToJoinsParentThread(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	h := &threadStubHandler{}
+	srv, credPath := newCLICommandTestServer(t, h)
+
+	err := runEntryCreateCmd(srv.URL, credPath, testDiaryID.String(), entryCreateOptions{
+		content: "second reply",
+		tags:    "idea",
+		thread:  entryThreadRef{replyTo: threadReplyID.String()},
+	})
+
+	if err != nil {
+		t.Fatalf("runEntryCreateCmd() error: %v", err)
+	}
+	want := []string{"idea", threadTagPrefix + threadRootID.String(), replyToTagPrefix + threadReplyID.String()}
+	if !slices.Equal(h.createdTags, want) {
+		t.Errorf("tags = %v, want %v", h.createdTags, want)
+	}
+}
+
+func TestResolveThreadTags_RejectsMismatchedThread(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv, credPath := newCLICommandTestServer(t, &threadStubHandler{})
+	client, err := newClientFromCreds(srv.URL, credPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = resolveThreadTags(client, entryThreadRef{replyTo: threadReplyID.String(), thread: testEntryID.String()})
+
+	if err == nil || !strings.Contains(err.Error(), "belongs to thread") {
+		t.Errorf("expected thread mismatch error, got %v", err)
+	}
+}
+
+func TestRunDiaryThreadCmd_OrdersOldestFirst(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv, credPath := newCLICommandTestServer(t, &threadStubHandler{})
+	var out bytes.Buffer
+
+	err := runDiaryThreadCmd(srv.URL, credPath, testDiaryID.String(), threadRootID.String(), &out)
+
+	if err != nil {
+		t.Fatalf("runDiaryThreadCmd() error: %v", err)
+	}
+	var view threadView
+	if err := json.Unmarshal(out.Bytes(), &view); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if view.Total != 2 || view.Entries[0].ID != threadRootID.String() || view.Entries[1].ReplyTo != threadRootID.String() {
+		t.Errorf("unexpected thread %+v", view)
+	}
+}
@@ -134,10 +134,27 @@ func resolveAgentName(moltnetDir, flagValue string) (string, error) {
 		}
 	}
 
-	// Scan for agent directories (subdirs containing moltnet.json)
+	agents, err := listAgentNames(moltnetDir)
+	if err != nil {
+		return "", err
+	}
+
+	switch len(agents) {
+	case 0:
+		return "", fmt.Errorf("no agents found in %s — run 'legreffier init --name <agent>'", moltnetDir)
+	case 1:
+		return agents[0], nil
+	default:
+		return "", fmt.Errorf("multiple agents found: %s — run 'moltnet use <agent>' to set a default", strings.Join(agents, ", "))
+	}
+}
+
+// listAgentNames returns the agent directories (subdirs containing
+// moltnet.json) under moltnetDir, in directory order.
+func listAgentNames(moltnetDir string) ([]string, error) {
 	entries, err := os.ReadDir(moltnetDir)
 	if err != nil {
-		return "", fmt.Errorf("read %s: %w", moltnetDir, err)
+		return nil, fmt.Errorf("read %s: %w", moltnetDir, err)
 	}
 	var agents []string
 	for _, e := range entries {
@@ -148,13 +165,5 @@ func resolveAgentName(moltnetDir, flagValue string) (string, error) {
 			agents = append(agents, e.Name())
 		}
 	}
-
-	switch len(agents) {
-	case 0:
-		return "", fmt.Errorf("no agents found in %s — run 'legreffier init --name <agent>'", moltnetDir)
-	case 1:
-		return agents[0], nil
-	default:
-		return "", fmt.Errorf("multiple agents found: %s — run 'moltnet use <agent>' to set a default", strings.Join(agents, ", "))
-	}
+	return agents, nil
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultEnvPrefix is the prefix of credential environment variables when
+// --env-prefix is not given.
+const defaultEnvPrefix = "MOLTNET_"
+
+// envPrefixOverride holds an explicit --env-prefix set by the root command.
+// An explicit prefix forces file-less mode (see resolveCredentials).
+var envPrefixOverride atomic.Pointer[string]
+
+var envPrefixPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// setEnvPrefix validates and stores --env-prefix. An empty value restores
+// the default prefix.
+func setEnvPrefix(prefix string) error {
+	if prefix == "" {
+		envPrefixOverride.Store(nil)
+		return nil
+	}
+	if !envPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("invalid --env-prefix %q: use letters, digits and underscores, e.g. AGENT_A_", prefix)
+	}
+	envPrefixOverride.Store(&prefix)
+	return nil
+}
+
+// envPrefix returns the active credential variable prefix.
+func envPrefix() string {
+	if p := envPrefixOverride.Load(); p != nil {
+		return *p
+	}
+	return defaultEnvPrefix
+}
+
+// envVar returns the prefixed name of a credential variable, e.g.
+// envVar("CLIENT_ID") is MOLTNET_CLIENT_ID by default.
+func envVar(name string) string {
+	return envPrefix() + name
+}
+
+// credentialEnvVars documents the variables read in file-less mode.
+var credentialEnvVars = []struct {
+	Name     string
+	Required bool
+	Help     string
+}{
+	{"CLIENT_ID", true, "OAuth2 client ID"},
+	{"CLIENT_SECRET", true, "OAuth2 client secret"},
+	{"IDENTITY_ID", false, "Identity ID"},
+	{"PUBLIC_KEY", false, "Ed25519 public key (ed25519:<base64>)"},
+	{"PRIVATE_KEY", false, "Ed25519 private key seed (base64); needed to sign"},
+	{"FINGERPRINT", false, "Key fingerprint"},
+	{"API_URL", false, "API base URL (default: " + defaultAPIURL + ")"},
+	{"REGISTERED_AT", false, "Registration timestamp"},
+}
+
+// writeCredentialEnvVars lists the file-less mode variables under prefix.
+func writeCredentialEnvVars(w io.Writer, prefix string) error {
+	for _, v := range credentialEnvVars {
+		req := ""
+		if v.Required {
+			req = " (required)"
+		}
+		if _, err := fmt.Fprintf(w, "%-28s %s%s\n", prefix+v.Name, v.Help, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// credentialsFromEnv builds credentials from prefixed environment
+// variables. It returns nil, nil when no required variable is set, so
+// optional ones like <PREFIX>API_URL alone do not enable file-less mode.
+func credentialsFromEnv() (*CredentialsFile, error) {
+	get := func(name string) string { return strings.TrimSpace(os.Getenv(envVar(name))) }
+	found := false
+	for _, v := range credentialEnvVars {
+		if v.Required && get(v.Name) != "" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	var missing []string
+	for _, v := range credentialEnvVars {
+		if v.Required && get(v.Name) == "" {
+			missing = append(missing, envVar(v.Name))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missing, ", "))
+	}
+	apiURL := strings.TrimRight(get("API_URL"), "/")
+	creds := &CredentialsFile{
+		SchemaVersion: currentConfigSchemaVersion,
+		IdentityID:    get("IDENTITY_ID"),
+		OAuth2: CredentialsOAuth2{
+			ClientID:     get("CLIENT_ID"),
+			ClientSecret: get("CLIENT_SECRET"),
+		},
+		Keys: CredentialsKeys{
+			PublicKey:   get("PUBLIC_KEY"),
+			PrivateKey:  get("PRIVATE_KEY"),
+			Fingerprint: get("FINGERPRINT"),
+		},
+		RegisteredAt: get("REGISTERED_AT"),
+	}
+	if apiURL != "" {
+		creds.Endpoints = CredentialsEndpoints{API: apiURL, MCP: deriveMCPURL(apiURL)}
+	}
+	return creds, nil
+}
+
+// resolveCredentials finds the credentials for a command. Precedence:
+//  1. credPath, when given;
+//  2. environment variables, when --env-prefix was set explicitly;
+//  3. the default config file;
+//  4. environment variables under the default prefix (file-less mode).
+//
+// It returns nil, nil when no source has credentials.
+func resolveCredentials(credPath string) (*CredentialsFile, error) {
+	if credPath != "" {
+		return ReadConfigFrom(credPath)
+	}
+	if envPrefixOverride.Load() != nil {
+		creds, err := credentialsFromEnv()
+		if err == nil && creds == nil {
+			err = fmt.Errorf("--env-prefix %s: no credentials in environment (set %s and %s)", envPrefix(), envVar("CLIENT_ID"), envVar("CLIENT_SECRET"))
+		}
+		return creds, err
+	}
+	creds, err := ReadConfig()
+	if err != nil || creds != nil {
+		return creds, err
+	}
+	return credentialsFromEnv()
+}
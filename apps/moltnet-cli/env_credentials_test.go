@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetEnvPrefix(t *testing.T) {
+	t.Cleanup(func() { envPrefixOverride.Store(nil) })
+
+	if err := setEnvPrefix("AGENT_A_"); err != nil {
+		t.Fatalf("setEnvPrefix: %v", err)
+	}
+	if got := envVar("CLIENT_ID"); got != "AGENT_A_CLIENT_ID" {
+		t.Errorf("envVar: got %q", got)
+	}
+	if err := setEnvPrefix("bad-prefix"); err == nil {
+		t.Error("expected invalid prefix error")
+	}
+	if err := setEnvPrefix(""); err != nil || envPrefix() != defaultEnvPrefix {
+		t.Errorf("empty prefix should restore default, got %q (%v)", envPrefix(), err)
+	}
+}
+
+func TestResolveCredentials_ExplicitPrefixUsesEnv(t *testing.T) {
+	// Arrange: a config file exists, but the explicit prefix wins.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if _, err := WriteConfigTo(&CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "file-cid", ClientSecret: "file-sec"}}, filepath.Join(home, ".config", "moltnet", "moltnet.json")); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AGENT_B_CLIENT_ID", "env-cid")
+	t.Setenv("AGENT_B_CLIENT_SECRET", "env-sec")
+	t.Setenv("AGENT_B_API_URL", "https://staging.themolt.net/")
+	t.Cleanup(func() { envPrefixOverride.Store(nil) })
+	if err := setEnvPrefix("AGENT_B_"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	creds, err := resolveCredentials("")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("resolveCredentials: %v", err)
+	}
+	if creds.OAuth2.ClientID != "env-cid" || creds.OAuth2.ClientSecret != "env-sec" {
+		t.Errorf("expected env credentials, got %+v", creds.OAuth2)
+	}
+	if creds.Endpoints.API != "https://staging.themolt.net" || creds.Endpoints.MCP == "" {
+		t.Errorf("unexpected endpoints: %+v", creds.Endpoints)
+	}
+	if got := resolveAPIURL(nil, ""); got != "https://staging.themolt.net" {
+		t.Errorf("resolveAPIURL: got %q", got)
+	}
+}
+
+func TestResolveCredentials_ExplicitPrefixMissing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { envPrefixOverride.Store(nil) })
+	if err := setEnvPrefix("AGENT_C_"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := resolveCredentials("")
+
+	if err == nil || !strings.Contains(err.Error(), "AGENT_C_CLIENT_ID") {
+		t.Errorf("expected missing env error naming AGENT_C_CLIENT_ID, got %v", err)
+	}
+}
+
+func TestResolveCredentials_DefaultPrefixFallsBackToEnv(t *testing.T) {
+	// Arrange: no config file.
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MOLTNET_CLIENT_ID", "cid")
+	t.Setenv("MOLTNET_CLIENT_SECRET", "")
+
+	// Act
+	_, err := resolveCredentials("")
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "MOLTNET_CLIENT_SECRET") {
+		t.Fatalf("expected missing MOLTNET_CLIENT_SECRET, got %v", err)
+	}
+	t.Setenv("MOLTNET_CLIENT_SECRET", "sec")
+	creds, err := resolveCredentials("")
+	if err != nil || creds.OAuth2.ClientID != "cid" {
+		t.Errorf("expected env credentials, got %+v, %v", creds, err)
+	}
+}
+
+func TestResolveCredentials_OptionalVarsAloneAreIgnored(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("MOLTNET_CLIENT_ID", "")
+	t.Setenv("MOLTNET_CLIENT_SECRET", "")
+	t.Setenv("MOLTNET_API_URL", "http://localhost:8000")
+
+	creds, err := resolveCredentials("")
+
+	if err != nil || creds != nil {
+		t.Errorf("expected no credentials, got %+v, %v", creds, err)
+	}
+}
+
+func TestEnvVarsCmd_ListsPrefixedNames(t *testing.T) {
+	t.Cleanup(func() { envPrefixOverride.Store(nil) })
+
+	stdout, _, err := executeCommand(NewRootCmd("test", ""), "env", "vars", "--env-prefix", "AGENT_A_")
+
+	if err != nil {
+		t.Fatalf("env vars: %v", err)
+	}
+	for _, v := range credentialEnvVars {
+		if !strings.Contains(stdout, "AGENT_A_"+v.Name) {
+			t.Errorf("expected AGENT_A_%s in output:\n%s", v.Name, stdout)
+		}
+	}
+}
+
+func TestConfigExportEnv_UsesPrefix(t *testing.T) {
+	t.Cleanup(func() { envPrefixOverride.Store(nil) })
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "sec"}}, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := setEnvPrefix("AGENT_A_"); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+
+	if err := runConfigExportEnvCmd(&out, path, "", false); err != nil {
+		t.Fatalf("export-env: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "AGENT_A_CLIENT_ID=cid") || strings.Contains(out.String(), "MOLTNET_CLIENT_ID") {
+		t.Errorf("expected AGENT_A_ prefixed output, got:\n%s", out.String())
+	}
+}
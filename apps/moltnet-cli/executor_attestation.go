@@ -36,6 +36,10 @@ func BuildExecutorCompleteAttestationPayload(taskID string, attemptN int, output
 	}
 }
 
+// CanonicalJSON is the canonical form executor attestations are signed
+// over, matching canonicalJson in libs/crypto-service: keys sorted by UTF-8
+// bytes, numbers as ECMAScript prints them. It is not RFC 8785, which sorts
+// keys by UTF-16 code units; see canonicalizeJCS for that.
 func CanonicalJSON(v any) (string, error) {
 	var buf bytes.Buffer
 	if err := writeCanonicalJSON(&buf, v); err != nil {
@@ -62,17 +66,11 @@ func BuildExecutorAttestationSigningBytes(payload any) ([]byte, error) {
 }
 
 func SignExecutorAttestation(payload any, privateKeyBase64 string) (string, error) {
-	seed, err := base64.StdEncoding.DecodeString(privateKeyBase64)
-	if err != nil {
-		return "", fmt.Errorf("decode private key: %w", err)
-	}
 	signingBytes, err := BuildExecutorAttestationSigningBytes(payload)
 	if err != nil {
 		return "", err
 	}
-	priv := ed25519.NewKeyFromSeed(seed)
-	sig := ed25519.Sign(priv, signingBytes)
-	return base64.StdEncoding.EncodeToString(sig), nil
+	return signWithSeed(privateKeyBase64, signingBytes)
 }
 
 func VerifyExecutorAttestation(payload any, signatureBase64, publicKey string) (bool, error) {
@@ -111,7 +109,11 @@ func writeCanonicalJSON(buf *bytes.Buffer, v any) error {
 		if !isCanonicalFloat(value) {
 			return fmt.Errorf("canonical JSON does not support non-finite numbers")
 		}
-		buf.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+		s, err := formatCanonicalNumber(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
 	case json.Number:
 		buf.WriteString(value.String())
 	case []any:
@@ -40,48 +40,14 @@ func runGitSetupCmd(credPath, name, email string) error {
 		gitEmail = creds.IdentityID + "@agents.themolt.net"
 	}
 
-	// Build allowed_signers — relative to the config file
-	var configDir string
-	if credPath != "" {
-		configDir = filepath.Dir(credPath)
-	} else {
-		configDir, err = GetConfigDir()
-		if err != nil {
-			return err
-		}
-	}
-	sshDir := filepath.Join(configDir, "ssh")
-	if err := os.MkdirAll(sshDir, 0o700); err != nil {
-		return fmt.Errorf("create ssh dir: %w", err)
+	configDir, err := gitConfigDir(credPath)
+	if err != nil {
+		return err
 	}
-	allowedSignersPath := filepath.Join(sshDir, "allowed_signers")
 	allowedSigners := fmt.Sprintf("%s %s\n", gitEmail, strings.TrimSpace(string(pubKeyContent)))
-	if err := os.WriteFile(allowedSignersPath, []byte(allowedSigners), 0o644); err != nil {
-		return fmt.Errorf("write allowed_signers: %w", err)
-	}
-
-	// Build gitconfig
-	gitconfig := fmt.Sprintf(`[user]
-	name = %s
-	email = %s
-	signingkey = %s
-
-[gpg]
-	format = ssh
-
-[gpg "ssh"]
-	allowedSignersFile = %s
-
-[commit]
-	gpgsign = true
-
-[tag]
-	gpgsign = true
-`, gitName, gitEmail, creds.SSH.PublicKeyPath, allowedSignersPath)
-
-	gitconfigPath := filepath.Join(configDir, "gitconfig")
-	if err := os.WriteFile(gitconfigPath, []byte(gitconfig), 0o644); err != nil {
-		return fmt.Errorf("write gitconfig: %w", err)
+	allowedSignersPath, gitconfigPath, err := writeGitSigningConfig(configDir, gitName, gitEmail, creds.SSH.PublicKeyPath, allowedSigners)
+	if err != nil {
+		return err
 	}
 
 	// Update config
@@ -111,6 +77,55 @@ func runGitSetupCmd(credPath, name, email string) error {
 	return nil
 }
 
+// gitConfigDir is where git setup writes gitconfig and ssh/allowed_signers:
+// next to the config file.
+func gitConfigDir(credPath string) (string, error) {
+	if credPath != "" {
+		return filepath.Dir(credPath), nil
+	}
+	return GetConfigDir()
+}
+
+func gitAllowedSignersPath(configDir string) string {
+	return filepath.Join(configDir, "ssh", "allowed_signers")
+}
+
+// writeGitSigningConfig writes ssh/allowed_signers with the given content
+// and a gitconfig that signs commits and tags with signingKeyPath.
+func writeGitSigningConfig(configDir, gitName, gitEmail, signingKeyPath, allowedSigners string) (allowedSignersPath, gitconfigPath string, err error) {
+	allowedSignersPath = gitAllowedSignersPath(configDir)
+	if err := os.MkdirAll(filepath.Dir(allowedSignersPath), 0o700); err != nil {
+		return "", "", fmt.Errorf("create ssh dir: %w", err)
+	}
+	if err := os.WriteFile(allowedSignersPath, []byte(allowedSigners), 0o644); err != nil {
+		return "", "", fmt.Errorf("write allowed_signers: %w", err)
+	}
+
+	gitconfig := fmt.Sprintf(`[user]
+	name = %s
+	email = %s
+	signingkey = %s
+
+[gpg]
+	format = ssh
+
+[gpg "ssh"]
+	allowedSignersFile = %s
+
+[commit]
+	gpgsign = true
+
+[tag]
+	gpgsign = true
+`, gitName, gitEmail, signingKeyPath, allowedSignersPath)
+
+	gitconfigPath = filepath.Join(configDir, "gitconfig")
+	if err := os.WriteFile(gitconfigPath, []byte(gitconfig), 0o644); err != nil {
+		return "", "", fmt.Errorf("write gitconfig: %w", err)
+	}
+	return allowedSignersPath, gitconfigPath, nil
+}
+
 // runGitSetup is the legacy flag-parsing entry point, preserved for existing tests.
 func runGitSetup(args []string) error {
 	fs := flag.NewFlagSet("git setup", flag.ExitOnError)
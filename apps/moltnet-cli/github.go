@@ -158,7 +158,7 @@ func lookupBotUser(appSlug string) (int64, string, error) {
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := newHTTPClient(0).Do(req)
 	if err != nil {
 		return 0, "", fmt.Errorf("GitHub API request: %w", err)
 	}
@@ -262,7 +262,7 @@ var timeNow = time.Now
 func getCachedInstallationToken(appID, privateKeyPath, installationID string) (string, error) {
 	details, err := getCachedInstallationTokenDetails(
 		context.Background(),
-		http.DefaultClient,
+		newHTTPClient(0),
 		appID,
 		privateKeyPath,
 		installationID,
@@ -374,7 +374,7 @@ func writeJSONAtomic(path string, value any) error {
 func getInstallationToken(appID, privateKeyPath, installationID string) (string, string, error) {
 	details, err := getInstallationTokenDetails(
 		context.Background(),
-		http.DefaultClient,
+		newHTTPClient(0),
 		appID,
 		privateKeyPath,
 		installationID,
@@ -179,7 +179,7 @@ func loadGitHubGuardPermissions(ctx context.Context, credentialsPath string) (ma
 
 	details, err := getCachedInstallationTokenDetailsWithFailureTTL(
 		ctx,
-		&http.Client{Timeout: githubGuardPermissionTimeout},
+		newHTTPClient(githubGuardPermissionTimeout),
 		creds.GitHub.AppID,
 		creds.GitHub.PrivateKeyPath,
 		creds.GitHub.InstallationID,
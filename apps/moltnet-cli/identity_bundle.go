@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	identityBundleFormat  = "moltnet-identity-bundle"
+	identityBundleVersion = 1
+	identityBundleCipher  = "xsalsa20poly1305"
+
+	// identityPassphraseEnvVar supplies the bundle passphrase
+	// non-interactively, e.g. from a secret manager.
+	identityPassphraseEnvVar = "MOLTNET_IDENTITY_PASSPHRASE"
+
+	minIdentityPassphraseLen = 12
+)
+
+// Default scrypt cost for new bundles. The parameters are stored in the
+// bundle, so they can be raised later without breaking old files.
+const (
+	identityScryptN = 1 << 15
+	identityScryptR = 8
+	identityScryptP = 1
+)
+
+// Largest scrypt cost a bundle may ask for. The parameters come from the
+// file, so without a cap a crafted bundle could make the derivation
+// allocate gigabytes (128*N*r bytes) or spin for minutes before the
+// passphrase is even checked.
+const (
+	maxIdentityScryptN = 1 << 20
+	maxIdentityScryptR = 16
+	maxIdentityScryptP = 4
+)
+
+// identityBundle is the on-disk, passphrase-encrypted identity file.
+// Only the fingerprint and public key are readable without the passphrase.
+type identityBundle struct {
+	Format      string            `json:"format"`
+	V           int               `json:"v"`
+	Fingerprint string            `json:"fingerprint"`
+	PublicKey   string            `json:"public_key"`
+	KDF         identityBundleKDF `json:"kdf"`
+	Cipher      string            `json:"cipher"`
+	Nonce       string            `json:"nonce"`
+	Ciphertext  string            `json:"ciphertext"`
+}
+
+type identityBundleKDF struct {
+	Name string `json:"name"`
+	Salt string `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// identityBundlePayload is the encrypted content: everything needed to
+// rebuild moltnet.json on another machine. Machine-local sections (git,
+// ssh, github key paths) are left out; they point at files that do not
+// travel with the bundle.
+type identityBundlePayload struct {
+	IdentityID   string               `json:"identity_id"`
+	OAuth2       CredentialsOAuth2    `json:"oauth2"`
+	Keys         CredentialsKeys      `json:"keys"`
+	Endpoints    CredentialsEndpoints `json:"endpoints"`
+	RegisteredAt string               `json:"registered_at"`
+}
+
+// checkCost rejects scrypt parameters outside the supported range.
+func (kdf identityBundleKDF) checkCost() error {
+	if kdf.N < 2 || kdf.N > maxIdentityScryptN || kdf.N&(kdf.N-1) != 0 {
+		return fmt.Errorf("scrypt n=%d must be a power of two no greater than %d", kdf.N, maxIdentityScryptN)
+	}
+	if kdf.R < 1 || kdf.R > maxIdentityScryptR {
+		return fmt.Errorf("scrypt r=%d must be between 1 and %d", kdf.R, maxIdentityScryptR)
+	}
+	if kdf.P < 1 || kdf.P > maxIdentityScryptP {
+		return fmt.Errorf("scrypt p=%d must be between 1 and %d", kdf.P, maxIdentityScryptP)
+	}
+	return nil
+}
+
+func deriveBundleKey(passphrase []byte, kdf identityBundleKDF) (*[32]byte, error) {
+	if kdf.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation %q", kdf.Name)
+	}
+	if err := kdf.checkCost(); err != nil {
+		return nil, fmt.Errorf("unsupported key derivation cost: %w", err)
+	}
+	salt, err := base64.StdEncoding.DecodeString(kdf.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decode salt: %w", err)
+	}
+	raw, err := scrypt.Key(passphrase, salt, kdf.N, kdf.R, kdf.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	defer zeroBytes(raw)
+	var key [32]byte
+	copy(key[:], raw)
+	return &key, nil
+}
+
+// sealIdentityBundle encrypts the identity in creds under passphrase.
+func sealIdentityBundle(creds *CredentialsFile, passphrase []byte) (*identityBundle, error) {
+	if creds.Keys.PrivateKey == "" {
+		return nil, fmt.Errorf("credentials have no private key to export")
+	}
+	plaintext, err := json.Marshal(identityBundlePayload{
+		IdentityID:   creds.IdentityID,
+		OAuth2:       creds.OAuth2,
+		Keys:         creds.Keys,
+		Endpoints:    creds.Endpoints,
+		RegisteredAt: creds.RegisteredAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal identity: %w", err)
+	}
+	defer zeroBytes(plaintext)
+	salt := make([]byte, 16)
+	var nonce [24]byte
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	kdf := identityBundleKDF{
+		Name: "scrypt",
+		Salt: base64.StdEncoding.EncodeToString(salt),
+		N:    identityScryptN,
+		R:    identityScryptR,
+		P:    identityScryptP,
+	}
+	key, err := deriveBundleKey(passphrase, kdf)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key[:])
+	return &identityBundle{
+		Format:      identityBundleFormat,
+		V:           identityBundleVersion,
+		Fingerprint: creds.Keys.Fingerprint,
+		PublicKey:   creds.Keys.PublicKey,
+		KDF:         kdf,
+		Cipher:      identityBundleCipher,
+		Nonce:       base64.StdEncoding.EncodeToString(nonce[:]),
+		Ciphertext:  base64.StdEncoding.EncodeToString(secretbox.Seal(nil, plaintext, &nonce, key)),
+	}, nil
+}
+
+// openIdentityBundle decrypts a bundle and checks that the private seed
+// really belongs to the public key it claims.
+func openIdentityBundle(b *identityBundle, passphrase []byte) (*identityBundlePayload, error) {
+	if b.Format != identityBundleFormat {
+		return nil, fmt.Errorf("not a moltnet identity bundle")
+	}
+	if b.V != identityBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (this CLI reads version %d); upgrade moltnet", b.V, identityBundleVersion)
+	}
+	if b.Cipher != identityBundleCipher {
+		return nil, fmt.Errorf("unsupported cipher %q", b.Cipher)
+	}
+	nonceBytes, err := base64.StdEncoding.DecodeString(b.Nonce)
+	if err != nil || len(nonceBytes) != 24 {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(b.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decode ciphertext: %w", err)
+	}
+	key, err := deriveBundleKey(passphrase, b.KDF)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key[:])
+	var nonce [24]byte
+	copy(nonce[:], nonceBytes)
+	plaintext, ok := secretbox.Open(nil, ciphertext, &nonce, key)
+	if !ok {
+		return nil, fmt.Errorf("wrong passphrase or corrupted bundle")
+	}
+	defer zeroBytes(plaintext)
+	var p identityBundlePayload
+	if err := json.Unmarshal(plaintext, &p); err != nil {
+		return nil, fmt.Errorf("parse identity: %w", err)
+	}
+
+	var kp *KeyPair
+	err = withSeed(p.Keys.PrivateKey, func(seed []byte) error {
+		var err error
+		kp, err = KeyPairFromSeed(seed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if kp.PublicKey != p.Keys.PublicKey || kp.PublicKey != b.PublicKey {
+		return nil, fmt.Errorf("bundle private key does not match its public key")
+	}
+	p.Keys.Fingerprint = kp.Fingerprint
+	return &p, nil
+}
+
+// readIdentityPassphrase returns the bundle passphrase from a file, the
+// MOLTNET_IDENTITY_PASSPHRASE variable, or a terminal prompt, in that
+// order. confirm asks twice when prompting.
+func readIdentityPassphrase(passphraseFile string, confirm bool) ([]byte, error) {
+	if passphraseFile != "" {
+		var data []byte
+		var err error
+		if passphraseFile == "-" {
+			data, err = io.ReadAll(bufio.NewReader(os.Stdin))
+		} else {
+			data, err = os.ReadFile(passphraseFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase: %w", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+	if v := os.Getenv(identityPassphraseEnvVar); v != "" {
+		return []byte(v), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no passphrase: use --passphrase-file or set %s", identityPassphraseEnvVar)
+	}
+	fmt.Fprint(os.Stderr, "Bundle passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase: %w", err)
+		}
+		if string(again) != string(pass) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// runExportIdentityCmd writes the current identity to an encrypted bundle.
+func runExportIdentityCmd(credPath, out, passphraseFile string, force bool, w io.Writer) error {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(out); err == nil && !force {
+		return expectedErrorf("export-identity: %s already exists (use --force to overwrite)", out)
+	}
+	passphrase, err := readIdentityPassphrase(passphraseFile, true)
+	if err != nil {
+		return fmt.Errorf("export-identity: %w", err)
+	}
+	if len([]rune(string(passphrase))) < minIdentityPassphraseLen {
+		return fmt.Errorf("export-identity: passphrase must be at least %d characters", minIdentityPassphraseLen)
+	}
+	bundle, err := sealIdentityBundle(creds, passphrase)
+	if err != nil {
+		return fmt.Errorf("export-identity: %w", err)
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return fmt.Errorf("export-identity: %w", err)
+	}
+	if err := writePrivateFile(out, append(data, '\n')); err != nil {
+		return fmt.Errorf("export-identity: %w", err)
+	}
+	fmt.Fprintf(w, "Exported identity %s to %s\n", bundle.Fingerprint, out)
+	fmt.Fprintf(w, "Move it with 'moltnet import-identity %s' on the new machine, then delete this copy.\n", out)
+	return nil
+}
+
+// runImportIdentityCmd decrypts a bundle and writes moltnet.json to dest
+// (the default config path when empty).
+func runImportIdentityCmd(bundlePath, dest, passphraseFile string, force bool, w io.Writer) error {
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return fmt.Errorf("import-identity: %w", err)
+	}
+	var bundle identityBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("import-identity: parse %s: %w", bundlePath, err)
+	}
+	if dest == "" {
+		if dest, err = GetConfigPath(); err != nil {
+			return fmt.Errorf("import-identity: %w", err)
+		}
+	}
+	existing, err := os.ReadFile(dest)
+	switch {
+	case err == nil && !force:
+		return expectedErrorf("import-identity: %s already exists (use --force to replace it)", dest)
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("import-identity: %w", err)
+	}
+	// Checked before prompting, so a crafted bundle is refused up front.
+	if bundle.KDF.Name == "scrypt" {
+		if err := bundle.KDF.checkCost(); err != nil {
+			return expectedErrorf("import-identity: %s: unsupported key derivation cost: %v", bundlePath, err)
+		}
+	}
+	passphrase, err := readIdentityPassphrase(passphraseFile, false)
+	if err != nil {
+		return fmt.Errorf("import-identity: %w", err)
+	}
+	p, err := openIdentityBundle(&bundle, passphrase)
+	if err != nil {
+		return fmt.Errorf("import-identity: %w", err)
+	}
+	warnCISecretWrite(os.Stderr, "credentials")
+	// --force replaces an identity that may exist nowhere else, so the
+	// old config is kept next to it first, never over an earlier backup.
+	if existing != nil {
+		backup := dest + ".bak"
+		if _, err := os.Lstat(backup); err == nil {
+			backup = dest + "." + timeNow().UTC().Format("20060102T150405Z") + ".bak"
+		}
+		if _, err := os.Lstat(backup); err == nil {
+			return expectedErrorf("import-identity: backup %s already exists; move it aside and retry", backup)
+		}
+		if err := writePrivateFile(backup, existing); err != nil {
+			return fmt.Errorf("import-identity: back up %s: %w", dest, err)
+		}
+		fmt.Fprintf(w, "Backed up the existing config to %s\n", backup)
+	}
+	path, err := WriteConfigTo(&CredentialsFile{
+		IdentityID:   p.IdentityID,
+		OAuth2:       p.OAuth2,
+		Keys:         p.Keys,
+		Endpoints:    p.Endpoints,
+		RegisteredAt: p.RegisteredAt,
+	}, dest)
+	if err != nil {
+		return fmt.Errorf("import-identity: %w", err)
+	}
+	fmt.Fprintf(w, "Imported identity %s to %s\n", p.Keys.Fingerprint, path)
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeIdentityTestConfig(t *testing.T) (string, *KeyPair) {
+	t.Helper()
+	kp, err := KeyPairFromSeed(bytes.Repeat([]byte{9}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	_, err = WriteConfigTo(&CredentialsFile{
+		IdentityID: "id-1",
+		OAuth2:     CredentialsOAuth2{ClientID: "cid", ClientSecret: "csecret"},
+		Keys:       CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey, Fingerprint: kp.Fingerprint},
+		Endpoints:  CredentialsEndpoints{API: "https://api.themolt.net", MCP: "https://mcp.themolt.net/mcp"},
+		Git:        &GitSection{Name: "agent", ConfigPath: "/home/agent/.gitconfig"},
+	}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, kp
+}
+
+func TestIdentityBundle_RoundTrip(t *testing.T) {
+	// Arrange
+	t.Setenv(identityPassphraseEnvVar, "correct horse battery staple")
+	clearCIEnv(t)
+	src, kp := writeIdentityTestConfig(t)
+	bundlePath := filepath.Join(t.TempDir(), "agent.bundle")
+	dest := filepath.Join(t.TempDir(), "restored", "moltnet.json")
+	if err := runExportIdentityCmd(src, bundlePath, "", false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	raw, _ := os.ReadFile(bundlePath)
+	if strings.Contains(string(raw), kp.PrivateKey) || strings.Contains(string(raw), "csecret") {
+		t.Fatal("bundle leaks secrets in plaintext")
+	}
+
+	// Act
+	err := runImportIdentityCmd(bundlePath, dest, "", false, &bytes.Buffer{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	restored, err := ReadConfigFrom(dest)
+	if err != nil || restored == nil {
+		t.Fatalf("read restored config: %v", err)
+	}
+	if restored.Keys.PrivateKey != kp.PrivateKey || restored.OAuth2.ClientSecret != "csecret" || restored.Endpoints.API != "https://api.themolt.net" {
+		t.Errorf("identity not restored: %+v", restored)
+	}
+	if restored.Git != nil {
+		t.Error("machine-local git section should not travel in the bundle")
+	}
+}
+
+func TestIdentityBundle_WrongPassphraseAndTampering(t *testing.T) {
+	src, _ := writeIdentityTestConfig(t)
+	creds, err := ReadConfigFrom(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := sealIdentityBundle(creds, []byte("first passphrase"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	if _, err := openIdentityBundle(bundle, []byte("other passphrase")); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("expected wrong passphrase error, got %v", err)
+	}
+
+	other, _ := KeyPairFromSeed(bytes.Repeat([]byte{3}, 32))
+	tampered := *bundle
+	tampered.PublicKey = other.PublicKey
+	if _, err := openIdentityBundle(&tampered, []byte("first passphrase")); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected key mismatch error, got %v", err)
+	}
+
+	future := *bundle
+	future.V = identityBundleVersion + 1
+	if _, err := openIdentityBundle(&future, []byte("first passphrase")); err == nil || !strings.Contains(err.Error(), "unsupported bundle version") {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
+
+func TestRunImportIdentityCmd_RefusesOverwrite(t *testing.T) {
+	dest, _ := writeIdentityTestConfig(t)
+	bundlePath := filepath.Join(t.TempDir(), "agent.bundle")
+	data, _ := json.Marshal(identityBundle{Format: identityBundleFormat, V: identityBundleVersion})
+	if err := os.WriteFile(bundlePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runImportIdentityCmd(bundlePath, dest, "", false, &bytes.Buffer{})
+
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected overwrite refusal, got %v", err)
+	}
+}
+
+func TestDeriveBundleKey_RejectsExcessiveCost(t *testing.T) {
+	t.Parallel()
+	for _, kdf := range []identityBundleKDF{
+		{N: 1 << 30, R: 8, P: 1},
+		{N: 1<<15 + 1, R: 8, P: 1},
+		{N: 1 << 15, R: 1 << 20, P: 1},
+		{N: 1 << 15, R: 8, P: 64},
+		{N: 1 << 15, R: 0, P: 1},
+	} {
+		kdf.Name, kdf.Salt = "scrypt", "c2FsdA=="
+		if _, err := deriveBundleKey([]byte("passphrase"), kdf); err == nil || !strings.Contains(err.Error(), "cost") {
+			t.Errorf("deriveBundleKey(n=%d r=%d p=%d) = %v, want a cost error", kdf.N, kdf.R, kdf.P, err)
+		}
+	}
+}
+
+func TestRunImportIdentityCmd_RejectsCraftedCostBeforePrompting(t *testing.T) {
+	dest := filepath.Join(t.TempDir(), "moltnet.json")
+	bundlePath := filepath.Join(t.TempDir(), "agent.bundle")
+	data, _ := json.Marshal(identityBundle{
+		Format: identityBundleFormat,
+		V:      identityBundleVersion,
+		KDF:    identityBundleKDF{Name: "scrypt", Salt: "c2FsdA==", N: 1 << 40, R: 1 << 20, P: 1 << 10},
+	})
+	if err := os.WriteFile(bundlePath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// No passphrase is available: reaching the prompt would fail
+	// differently.
+	t.Setenv(identityPassphraseEnvVar, "")
+
+	err := runImportIdentityCmd(bundlePath, dest, "", false, &bytes.Buffer{})
+
+	if errorExitCode(err) != exitCodeExpected || !strings.Contains(err.Error(), "cost") {
+		t.Errorf("expected a cost refusal, got %v", err)
+	}
+}
+
+func TestRunImportIdentityCmd_ForceKeepsBackup(t *testing.T) {
+	t.Setenv(identityPassphraseEnvVar, "correct horse battery staple")
+	clearCIEnv(t)
+	src, _ := writeIdentityTestConfig(t)
+	bundlePath := filepath.Join(t.TempDir(), "agent.bundle")
+	if err := runExportIdentityCmd(src, bundlePath, "", false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	dest := filepath.Join(t.TempDir(), "moltnet.json")
+	previous := []byte(`{"identity_id": "previous-agent"}`)
+	if err := os.WriteFile(dest, previous, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runImportIdentityCmd(bundlePath, dest, "", true, &bytes.Buffer{}); err != nil {
+		t.Fatalf("import --force: %v", err)
+	}
+
+	backup, err := os.ReadFile(dest + ".bak")
+	if err != nil || !bytes.Equal(backup, previous) {
+		t.Errorf("backup = %q (%v), want the previous config", backup, err)
+	}
+	if info, err := os.Stat(dest + ".bak"); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("backup mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+	if restored, _ := ReadConfigFrom(dest); restored == nil || restored.IdentityID != "id-1" {
+		t.Errorf("imported config = %+v", restored)
+	}
+}
+
+func TestRunImportIdentityCmd_ForceKeepsEarlierBackup(t *testing.T) {
+	t.Setenv(identityPassphraseEnvVar, "correct horse battery staple")
+	clearCIEnv(t)
+	orig := timeNow
+	timeNow = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = orig })
+	src, _ := writeIdentityTestConfig(t)
+	bundlePath := filepath.Join(t.TempDir(), "agent.bundle")
+	if err := runExportIdentityCmd(src, bundlePath, "", false, &bytes.Buffer{}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	dest := filepath.Join(t.TempDir(), "moltnet.json")
+	earlier := []byte(`{"identity_id": "earliest-agent"}`)
+	if err := os.WriteFile(dest+".bak", earlier, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	previous := []byte(`{"identity_id": "previous-agent"}`)
+	if err := os.WriteFile(dest, previous, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runImportIdentityCmd(bundlePath, dest, "", true, &bytes.Buffer{}); err != nil {
+		t.Fatalf("import --force: %v", err)
+	}
+
+	if kept, _ := os.ReadFile(dest + ".bak"); !bytes.Equal(kept, earlier) {
+		t.Errorf("earlier backup overwritten with %q", kept)
+	}
+	if backup, err := os.ReadFile(dest + ".20260301T120000Z.bak"); err != nil || !bytes.Equal(backup, previous) {
+		t.Errorf("timestamped backup = %q (%v), want the previous config", backup, err)
+	}
+
+	// A second import in the same second has nowhere left to back up to.
+	err := runImportIdentityCmd(bundlePath, dest, "", true, &bytes.Buffer{})
+	if err == nil || errorExitCode(err) != 3 || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("error = %v, want exit code 3 for an existing backup", err)
+	}
+}
+
+func TestRunExportIdentityCmd_ShortPassphrase(t *testing.T) {
+	src, _ := writeIdentityTestConfig(t)
+	passFile := filepath.Join(t.TempDir(), "pass")
+	if err := os.WriteFile(passFile, []byte("short\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runExportIdentityCmd(src, filepath.Join(t.TempDir(), "b"), passFile, false, &bytes.Buffer{})
+
+	if err == nil || !strings.Contains(err.Error(), "at least") {
+		t.Errorf("expected passphrase length error, got %v", err)
+	}
+}
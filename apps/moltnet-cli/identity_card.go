@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// identityCardType tags the identity card schema. Cards are meant for
+// out-of-band trust exchange: shown as QR codes or pasted between humans,
+// then checked with verifyIdentityCard.
+const identityCardType = "moltnet.identity-card/v1"
+
+// identityCardNonce domain-separates card self-signatures from other
+// SignForRequest payloads.
+const identityCardNonce = "moltnet:identity-card"
+
+// identityCard is the registered identity plus a self-signature by the
+// matching private key, proving the holder controls the key.
+type identityCard struct {
+	Type        string    `json:"type"`
+	IdentityID  string    `json:"identityId,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	PublicKey   string    `json:"publicKey"`
+	IssuedAt    time.Time `json:"issuedAt"`
+	Signature   string    `json:"signature,omitempty"`
+}
+
+// signingPayload is the card JSON without its signature. Field order is
+// fixed by the struct, so the bytes are stable across encoders.
+func (c identityCard) signingPayload() (string, error) {
+	c.Signature = ""
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("marshal identity card: %w", err)
+	}
+	return string(data), nil
+}
+
+// newIdentityCard builds and self-signs a card for identity. The local key
+// must be the one registered on the network.
+func newIdentityCard(identity *moltnetapi.CryptoIdentity, creds *CredentialsFile, now time.Time) (*identityCard, error) {
+	if creds.Keys.PrivateKey == "" {
+		return nil, fmt.Errorf("identity card: no private key in credentials; a card must be signed by the registered key")
+	}
+	if creds.Keys.PublicKey != identity.PublicKey {
+		return nil, fmt.Errorf("identity card: local public key does not match the registered key (fingerprint %s)", identity.Fingerprint)
+	}
+	card := &identityCard{
+		Type:        identityCardType,
+		IdentityID:  identity.IdentityId.String(),
+		Fingerprint: identity.Fingerprint,
+		PublicKey:   identity.PublicKey,
+		IssuedAt:    now.UTC().Truncate(time.Second),
+	}
+	payload, err := card.signingPayload()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := SignForRequest(payload, identityCardNonce, creds.Keys.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("identity card: sign: %w", err)
+	}
+	card.Signature = sig
+	return card, nil
+}
+
+// identityCardFromProfile renders a directory profile as an unsigned
+// identity card, so a looked-up peer and an exported self-card share one
+// schema. The directory does not return the identity ID or any
+// attestation, so both are left empty; IssuedAt is the lookup time.
+func identityCardFromProfile(profile *moltnetapi.AgentProfile, now time.Time) *identityCard {
+	return &identityCard{
+		Type:        identityCardType,
+		Fingerprint: profile.Fingerprint,
+		PublicKey:   profile.PublicKey,
+		IssuedAt:    now.UTC().Truncate(time.Second),
+	}
+}
+
+// verifyIdentityCardBinding checks the card type and that the fingerprint
+// belongs to the public key.
+func verifyIdentityCardBinding(card *identityCard) error {
+	if card.Type != identityCardType {
+		return fmt.Errorf("unsupported identity card type %q", card.Type)
+	}
+	pub, err := ParsePublicKey(card.PublicKey)
+	if err != nil {
+		return err
+	}
+	if fp := Fingerprint(pub); fp != card.Fingerprint {
+		return fmt.Errorf("fingerprint %s does not match public key (expected %s)", card.Fingerprint, fp)
+	}
+	return nil
+}
+
+// verifyIdentityCard checks the card type, that the fingerprint belongs to
+// the public key, and the self-signature.
+func verifyIdentityCard(card *identityCard) error {
+	if err := verifyIdentityCardBinding(card); err != nil {
+		return err
+	}
+	payload, err := card.signingPayload()
+	if err != nil {
+		return err
+	}
+	ok, err := VerifyForRequest(payload, identityCardNonce, card.Signature, card.PublicKey)
+	if errors.Is(err, ErrBadSignatureEncoding) || errors.Is(err, ErrBadPublicKey) {
+		return fmt.Errorf("identity card is malformed: %w", err)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("identity card signature is invalid")
+	}
+	return nil
+}
+
+// writeIdentityCardQR renders the compact card JSON as a QR code: to w as
+// half-height block characters when terminal is set, and to pngPath as an
+// image when non-empty.
+func writeIdentityCardQR(card *identityCard, w io.Writer, terminal bool, pngPath string) error {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return fmt.Errorf("marshal identity card: %w", err)
+	}
+	qr, err := qrcode.New(string(data), qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("encode QR code: %w", err)
+	}
+	if terminal {
+		fmt.Fprint(w, qr.ToSmallString(false))
+		fmt.Fprintf(w, "Fingerprint: %s\n", card.Fingerprint)
+	}
+	if pngPath != "" {
+		png, err := qr.PNG(512)
+		if err != nil {
+			return fmt.Errorf("render QR PNG: %w", err)
+		}
+		if err := os.WriteFile(pngPath, png, 0o644); err != nil {
+			return fmt.Errorf("write QR PNG: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Identity card QR written to %s\n", pngPath)
+	}
+	return nil
+}
+
+// runCryptoIdentityCardCmd fetches the registered identity and prints it as
+// a signed identity card (JSON), optionally rendered as a QR code.
+func runCryptoIdentityCardCmd(apiURL, credPath string, showQR bool, pngPath string, w io.Writer) error {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return err
+	}
+	res, err := client.GetCryptoIdentity(context.Background())
+	if err != nil {
+		return fmt.Errorf("crypto identity: %w", formatTransportError(err))
+	}
+	identity, ok := res.(*moltnetapi.CryptoIdentity)
+	if !ok {
+		return formatAPIError(res)
+	}
+	card, err := newIdentityCard(identity, creds, time.Now())
+	if err != nil {
+		return err
+	}
+	if showQR || pngPath != "" {
+		return writeIdentityCardQR(card, w, showQR, pngPath)
+	}
+	return printJSONTo(w, card)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+	"github.com/google/uuid"
+)
+
+func testIdentityCardFixture(t *testing.T) (*moltnetapi.CryptoIdentity, *CredentialsFile) {
+	t.Helper()
+	kp, err := KeyPairFromSeed(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := &moltnetapi.CryptoIdentity{
+		Fingerprint: kp.Fingerprint,
+		PublicKey:   kp.PublicKey,
+		IdentityId:  uuid.MustParse("00000000-0000-0000-0000-000000000099"),
+	}
+	creds := &CredentialsFile{
+		OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec"},
+		Keys:   CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey, Fingerprint: kp.Fingerprint},
+	}
+	return identity, creds
+}
+
+func TestIdentityCard_SignAndVerify(t *testing.T) {
+	t.Parallel()
+	identity, creds := testIdentityCardFixture(t)
+	card, err := newIdentityCard(identity, creds, time.Date(2026, 10, 14, 12, 0, 0, 5, time.UTC))
+	if err != nil {
+		t.Fatalf("newIdentityCard: %v", err)
+	}
+	if err := verifyIdentityCard(card); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// Round-trip through JSON, as a scanner or paste would.
+	data, _ := json.Marshal(card)
+	var decoded identityCard
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyIdentityCard(&decoded); err != nil {
+		t.Errorf("verify after round-trip: %v", err)
+	}
+
+	tampered := *card
+	tampered.IdentityID = uuid.NewString()
+	if err := verifyIdentityCard(&tampered); err == nil {
+		t.Error("tampered card should fail verification")
+	}
+	tampered = *card
+	tampered.Fingerprint = "0000-0000-0000-0000"
+	if err := verifyIdentityCard(&tampered); err == nil || !strings.Contains(err.Error(), "fingerprint") {
+		t.Errorf("wrong fingerprint: got %v", err)
+	}
+}
+
+func TestNewIdentityCard_RequiresRegisteredKey(t *testing.T) {
+	t.Parallel()
+	identity, creds := testIdentityCardFixture(t)
+	other, _ := GenerateKeyPair()
+	mismatched := *creds
+	mismatched.Keys.PublicKey = other.PublicKey
+	if _, err := newIdentityCard(identity, &mismatched, time.Now()); err == nil {
+		t.Error("expected error when local key differs from the registered one")
+	}
+	noKey := *creds
+	noKey.Keys.PrivateKey = ""
+	if _, err := newIdentityCard(identity, &noKey, time.Now()); err == nil {
+		t.Error("expected error without a private key")
+	}
+}
+
+type identityCardStubHandler struct {
+	moltnetapi.UnimplementedHandler
+	identity *moltnetapi.CryptoIdentity
+}
+
+func (h *identityCardStubHandler) GetCryptoIdentity(_ context.Context) (moltnetapi.GetCryptoIdentityRes, error) {
+	return h.identity, nil
+}
+
+func TestRunCryptoIdentityCardCmd_QR(t *testing.T) {
+	t.Parallel()
+	identity, creds := testIdentityCardFixture(t)
+	apiSrv, credPath := newCLICommandTestServer(t, &identityCardStubHandler{identity: identity})
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runCryptoIdentityCardCmd(apiSrv.URL, credPath, false, "", &out); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	var card identityCard
+	if err := json.Unmarshal(out.Bytes(), &card); err != nil {
+		t.Fatalf("parse card: %v\n%s", err, out.String())
+	}
+	if err := verifyIdentityCard(&card); err != nil {
+		t.Errorf("exported card: %v", err)
+	}
+
+	out.Reset()
+	pngPath := filepath.Join(t.TempDir(), "card.png")
+	if err := runCryptoIdentityCardCmd(apiSrv.URL, credPath, true, pngPath, &out); err != nil {
+		t.Fatalf("qr: %v", err)
+	}
+	if !strings.Contains(out.String(), "█") || !strings.Contains(out.String(), identity.Fingerprint) {
+		t.Errorf("terminal QR output missing blocks or fingerprint:\n%s", out.String())
+	}
+	png, err := os.ReadFile(pngPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("qr-png did not write a PNG")
+	}
+}
+
+func TestIdentityCardFromProfile(t *testing.T) {
+	t.Parallel()
+	kp, err := KeyPairFromSeed(bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := identityCardFromProfile(&moltnetapi.AgentProfile{Fingerprint: kp.Fingerprint, PublicKey: kp.PublicKey}, time.Date(2026, 10, 14, 12, 0, 0, 5, time.UTC))
+
+	if card.Type != identityCardType || card.Signature != "" || card.IdentityID != "" || !card.IssuedAt.Equal(time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected card %+v", card)
+	}
+	if err := verifyIdentityCardBinding(card); err != nil {
+		t.Errorf("binding: %v", err)
+	}
+	data, _ := json.Marshal(card)
+	if strings.Contains(string(data), "identityId") || strings.Contains(string(data), "signature") {
+		t.Errorf("directory card should omit identityId and signature: %s", data)
+	}
+
+	forged := identityCardFromProfile(&moltnetapi.AgentProfile{Fingerprint: "AAAA-BBBB-CCCC-DDDD", PublicKey: kp.PublicKey}, time.Now())
+	if err := printAgentProfile(&moltnetapi.AgentProfile{Fingerprint: forged.Fingerprint, PublicKey: forged.PublicKey}, "card"); err == nil {
+		t.Error("expected fingerprint mismatch to be rejected")
+	}
+	if err := printAgentProfile(&moltnetapi.AgentProfile{}, "yaml"); err == nil || !strings.Contains(err.Error(), "--format") {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Kinds of file crypto identity --compare reads.
+const (
+	identitySourceCard   = "card"   // identity card (crypto identity --export, agents lookup --format card)
+	identitySourceConfig = "config" // moltnet.json
+	identitySourceBundle = "bundle" // export-identity bundle; only its public part
+)
+
+// identityFields is one side of an identity comparison.
+type identityFields struct {
+	IdentityID  string `json:"identityId,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+	PublicKey   string `json:"publicKey"`
+	// privateKey, when present, must derive PublicKey.
+	privateKey string
+}
+
+// identityFieldCheck compares one field of the two sides. Status is
+// "match", "mismatch", or "missing" when the file does not carry it.
+type identityFieldCheck struct {
+	Field  string `json:"field"`
+	Local  string `json:"local"`
+	Other  string `json:"other,omitempty"`
+	Status string `json:"status"`
+}
+
+// identityCompareReport is the output of crypto identity --compare.
+// Verdict is "same" only when every field both sides carry matches and
+// neither side is internally inconsistent.
+type identityCompareReport struct {
+	Verdict  string               `json:"verdict"`
+	File     string               `json:"file"`
+	Kind     string               `json:"kind"`
+	Checks   []identityFieldCheck `json:"checks"`
+	Problems []string             `json:"problems,omitempty"`
+}
+
+// readComparedIdentity reads an identity card, a moltnet.json or an
+// identity bundle. A card's self-signature is verified when it has one;
+// directory cards are unsigned and only checked for their key binding.
+func readComparedIdentity(path string) (identityFields, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return identityFields{}, "", fmt.Errorf("read %s: %w", path, err)
+	}
+	var probe struct {
+		Type   string          `json:"type"`
+		Format string          `json:"format"`
+		Keys   json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return identityFields{}, "", expectedErrorf("%s is not JSON: %v", path, err)
+	}
+	switch {
+	case probe.Type != "":
+		var card identityCard
+		if err := json.Unmarshal(data, &card); err != nil {
+			return identityFields{}, "", expectedErrorf("parse identity card %s: %v", path, err)
+		}
+		check := verifyIdentityCardBinding
+		if card.Signature != "" {
+			check = verifyIdentityCard
+		}
+		if err := check(&card); err != nil {
+			return identityFields{}, "", expectedErrorf("identity card %s: %w", path, err)
+		}
+		return identityFields{IdentityID: card.IdentityID, Fingerprint: card.Fingerprint, PublicKey: card.PublicKey}, identitySourceCard, nil
+	case probe.Format == identityBundleFormat:
+		var b identityBundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			return identityFields{}, "", expectedErrorf("parse identity bundle %s: %v", path, err)
+		}
+		return identityFields{Fingerprint: b.Fingerprint, PublicKey: b.PublicKey}, identitySourceBundle, nil
+	case probe.Keys != nil:
+		creds, err := ReadConfigFrom(path)
+		if err != nil || creds == nil {
+			return identityFields{}, "", expectedErrorf("read config %s: %v", path, err)
+		}
+		return credentialsIdentityFields(creds), identitySourceConfig, nil
+	}
+	return identityFields{}, "", expectedErrorf("%s is not an identity card, moltnet.json or identity bundle", path)
+}
+
+func credentialsIdentityFields(creds *CredentialsFile) identityFields {
+	return identityFields{
+		IdentityID:  creds.IdentityID,
+		Fingerprint: creds.Keys.Fingerprint,
+		PublicKey:   creds.Keys.PublicKey,
+		privateKey:  creds.Keys.PrivateKey,
+	}
+}
+
+// identityKeyProblems lists the ways one side contradicts itself: a
+// fingerprint that is not the public key's, or a private key that does
+// not derive the public key.
+func identityKeyProblems(side string, f identityFields) []string {
+	var problems []string
+	pub, err := ParsePublicKey(f.PublicKey)
+	switch {
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("%s public key: %v", side, err))
+	case f.Fingerprint != "" && Fingerprint(pub) != f.Fingerprint:
+		problems = append(problems, fmt.Sprintf("%s fingerprint %s does not match its public key (expected %s)", side, f.Fingerprint, Fingerprint(pub)))
+	}
+	if f.privateKey != "" {
+		var kp *KeyPair
+		err := withSeed(f.privateKey, func(seed []byte) error {
+			var err error
+			kp, err = KeyPairFromSeed(seed)
+			return err
+		})
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s private key: %v", side, err))
+		} else if kp.PublicKey != f.PublicKey {
+			problems = append(problems, fmt.Sprintf("%s private key does not match its public key", side))
+		}
+	}
+	return problems
+}
+
+// compareIdentities builds the report for local against other.
+func compareIdentities(local, other identityFields) identityCompareReport {
+	report := identityCompareReport{Verdict: "same"}
+	for _, f := range []struct{ name, local, other string }{
+		{"identityId", local.IdentityID, other.IdentityID},
+		{"publicKey", local.PublicKey, other.PublicKey},
+		{"fingerprint", local.Fingerprint, other.Fingerprint},
+	} {
+		check := identityFieldCheck{Field: f.name, Local: f.local, Other: f.other, Status: "match"}
+		switch {
+		case f.other == "":
+			check.Status = "missing"
+		case f.local != f.other:
+			check.Status = "mismatch"
+			report.Verdict = "different"
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.Problems = append(identityKeyProblems("local", local), identityKeyProblems("file", other)...)
+	if len(report.Problems) > 0 {
+		report.Verdict = "different"
+	}
+	return report
+}
+
+// runCryptoIdentityCompareCmd compares the local identity with the one in
+// path, without contacting the network, and fails with an expected error
+// unless they are the same. Fields the file does not carry (a bundle's or
+// directory card's identity ID) are reported as missing, not as a
+// difference.
+func runCryptoIdentityCompareCmd(credPath, path string, w io.Writer) error {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	other, kind, err := readComparedIdentity(path)
+	if err != nil {
+		return fmt.Errorf("crypto identity --compare: %w", err)
+	}
+	report := compareIdentities(credentialsIdentityFields(creds), other)
+	report.File, report.Kind = path, kind
+	if err := printJSONTo(w, report); err != nil {
+		return err
+	}
+	if report.Verdict != "same" {
+		fmt.Fprintf(os.Stderr, "DIFFERENT: %s does not hold identity %s\n", path, creds.Keys.Fingerprint)
+		return expectedErrorf("crypto identity --compare: identities differ")
+	}
+	fmt.Fprintf(os.Stderr, "SAME: %s holds identity %s\n", path, creds.Keys.Fingerprint)
+	return nil
+}
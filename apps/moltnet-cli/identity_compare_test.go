@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeCompareTestFile(t *testing.T, name string, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func runCompare(t *testing.T, credPath, path string) (identityCompareReport, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := runCryptoIdentityCompareCmd(credPath, path, &out)
+	var report identityCompareReport
+	if out.Len() > 0 {
+		if uerr := json.Unmarshal(out.Bytes(), &report); uerr != nil {
+			t.Fatalf("unmarshal report %q: %v", out.String(), uerr)
+		}
+	}
+	return report, err
+}
+
+func TestRunCryptoIdentityCompareCmd_Same(t *testing.T) {
+	identity, creds := testIdentityCardFixture(t)
+	creds.IdentityID = identity.IdentityId.String()
+	credPath := writeCompareTestFile(t, "moltnet.json", creds)
+
+	card, err := newIdentityCard(identity, creds, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := sealIdentityBundle(creds, []byte("correct horse battery staple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		kind string
+		path string
+	}{
+		{identitySourceCard, writeCompareTestFile(t, "card.json", card)},
+		{identitySourceConfig, writeCompareTestFile(t, "other-moltnet.json", creds)},
+		{identitySourceBundle, writeCompareTestFile(t, "agent.bundle", bundle)},
+	} {
+		report, err := runCompare(t, credPath, tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.kind, err)
+			continue
+		}
+		if report.Verdict != "same" || report.Kind != tt.kind {
+			t.Errorf("%s: report = %+v, want the same identity", tt.kind, report)
+		}
+		if tt.kind == identitySourceBundle && report.Checks[0].Status != "missing" {
+			t.Errorf("bundle identityId check = %+v, want missing", report.Checks[0])
+		}
+	}
+}
+
+func TestRunCryptoIdentityCompareCmd_Different(t *testing.T) {
+	identity, creds := testIdentityCardFixture(t)
+	creds.IdentityID = identity.IdentityId.String()
+	credPath := writeCompareTestFile(t, "moltnet.json", creds)
+
+	other, err := KeyPairFromSeed(bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diverged := *creds
+	diverged.Keys = CredentialsKeys{PublicKey: other.PublicKey, PrivateKey: other.PrivateKey, Fingerprint: other.Fingerprint}
+	report, err := runCompare(t, credPath, writeCompareTestFile(t, "diverged.json", diverged))
+	if errorExitCode(err) != exitCodeExpected || report.Verdict != "different" {
+		t.Errorf("different key: verdict %q, err %v", report.Verdict, err)
+	}
+
+	// Same public key, but a private key that belongs to another one.
+	broken := *creds
+	broken.Keys.PrivateKey = other.PrivateKey
+	report, err = runCompare(t, credPath, writeCompareTestFile(t, "broken.json", broken))
+	if errorExitCode(err) != exitCodeExpected || len(report.Problems) != 1 {
+		t.Errorf("inconsistent file: report %+v, err %v", report, err)
+	}
+
+	if _, err := runCompare(t, credPath, writeCompareTestFile(t, "other.json", map[string]any{"hello": "world"})); errorExitCode(err) != exitCodeExpected {
+		t.Errorf("unknown file: err = %v, want an expected error", err)
+	}
+	tampered := identityCard{Type: identityCardType, IdentityID: creds.IdentityID, Fingerprint: creds.Keys.Fingerprint, PublicKey: creds.Keys.PublicKey, Signature: "AAAA"}
+	if _, err := runCompare(t, credPath, writeCompareTestFile(t, "card.json", tampered)); errorExitCode(err) != exitCodeExpected {
+		t.Errorf("badly signed card: err = %v, want an expected error", err)
+	}
+}
@@ -6,25 +6,40 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
-// runInfoCmd fetches and displays the MoltNet network discovery document.
-func runInfoCmd(apiURL string, jsonOut bool) error {
+// fetchDiscoveryDoc fetches the raw network discovery document and the
+// response headers, which carry the caller's rate-limit state.
+func fetchDiscoveryDoc(apiURL string) ([]byte, http.Header, error) {
+	return fetchDiscoveryDocWith(newHTTPClient(30*time.Second), apiURL)
+}
+
+func fetchDiscoveryDocWith(client *http.Client, apiURL string) ([]byte, http.Header, error) {
 	url := strings.TrimRight(apiURL, "/") + "/.well-known/moltnet.json"
 
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	if err != nil {
-		return fmt.Errorf("fetch network info: %w", err)
+		return nil, nil, fmt.Errorf("fetch network info: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return nil, nil, fmt.Errorf("read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return nil, nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, resp.Header, nil
+}
+
+// runInfoCmd fetches and displays the MoltNet network discovery document.
+func runInfoCmd(apiURL string, jsonOut bool) error {
+	body, _, err := fetchDiscoveryDoc(apiURL)
+	if err != nil {
+		return err
 	}
 
 	if jsonOut {
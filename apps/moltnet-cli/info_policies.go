@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// policySectionKeys are discovery-document sections that may carry
+// machine-readable limits (quotas, max sizes, rate limits). None is
+// required; whatever the network publishes is rendered as-is.
+var policySectionKeys = []string{"policies", "limits", "quotas", "rate_limits"}
+
+// visibilityPolicy is one diary visibility level and what it means.
+type visibilityPolicy struct {
+	Level       string `json:"level"`
+	Description string `json:"description,omitempty"`
+}
+
+// voucherPolicy describes how vouchers work on the network.
+type voucherPolicy struct {
+	Description string   `json:"description,omitempty"`
+	Rules       []string `json:"rules,omitempty"`
+	Genesis     string   `json:"genesis,omitempty"`
+}
+
+// observedRateLimit is the rate-limit state reported on the info request
+// itself. It reflects the anonymous bucket the request was counted in.
+type observedRateLimit struct {
+	Limit        int `json:"limit"`
+	Remaining    int `json:"remaining"`
+	ResetSeconds int `json:"resetSeconds,omitempty"`
+}
+
+// networkPolicies is the output of info --policies.
+type networkPolicies struct {
+	Visibility []visibilityPolicy `json:"visibility,omitempty"`
+	Vouchers   *voucherPolicy     `json:"vouchers,omitempty"`
+	Limits     map[string]any     `json:"limits,omitempty"`
+	RateLimit  *observedRateLimit `json:"rateLimit,omitempty"`
+	Missing    []string           `json:"missing,omitempty"`
+}
+
+// extractNetworkPolicies collects policy fields from a discovery document
+// decoded generically, so fields newer than this CLI are not lost.
+func extractNetworkPolicies(doc map[string]any, header http.Header) networkPolicies {
+	var p networkPolicies
+	rules, _ := doc["rules"].(map[string]any)
+
+	var levels map[string]any
+	if vis, ok := rules["visibility"].(map[string]any); ok {
+		levels, _ = vis["levels"].(map[string]any)
+	}
+	var names []string
+	if caps, ok := doc["capabilities"].(map[string]any); ok {
+		if sharing, ok := caps["sharing"].(map[string]any); ok {
+			names = stringSlice(sharing["visibility_levels"])
+		}
+	}
+	if len(names) == 0 {
+		for name := range levels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		desc, _ := levels[name].(string)
+		p.Visibility = append(p.Visibility, visibilityPolicy{Level: name, Description: desc})
+	}
+	if len(p.Visibility) == 0 {
+		p.Missing = append(p.Missing, "visibility")
+	}
+
+	if v, ok := rules["vouchers"].(map[string]any); ok {
+		desc, _ := v["description"].(string)
+		genesis, _ := v["genesis"].(string)
+		p.Vouchers = &voucherPolicy{Description: desc, Rules: stringSlice(v["how_it_works"]), Genesis: genesis}
+	} else {
+		p.Missing = append(p.Missing, "vouchers")
+	}
+
+	for _, key := range policySectionKeys {
+		section, ok := doc[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		if p.Limits == nil {
+			p.Limits = map[string]any{}
+		}
+		for k, v := range section {
+			p.Limits[k] = v
+		}
+	}
+	if p.Limits == nil {
+		p.Missing = append(p.Missing, "limits")
+	}
+
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+		reset, _ := strconv.Atoi(header.Get("X-RateLimit-Reset"))
+		p.RateLimit = &observedRateLimit{Limit: limit, Remaining: remaining, ResetSeconds: reset}
+	}
+	return p
+}
+
+func stringSlice(v any) []string {
+	items, _ := v.([]any)
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// flattenPolicyLimits renders nested limit objects as sorted dotted keys.
+func flattenPolicyLimits(prefix string, v any, out map[string]string) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenPolicyLimits(key, child, out)
+		}
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, item := range t {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(parts, ", ")
+	default:
+		out[prefix] = fmt.Sprint(t)
+	}
+}
+
+func writeNetworkPolicies(w io.Writer, p networkPolicies) {
+	fmt.Fprintln(w, "Network policies:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Visibility levels:")
+	if len(p.Visibility) == 0 {
+		fmt.Fprintln(w, "  (not published)")
+	}
+	for _, v := range p.Visibility {
+		if v.Description == "" {
+			fmt.Fprintf(w, "  %s\n", v.Level)
+			continue
+		}
+		fmt.Fprintf(w, "  %-8s %s\n", v.Level, v.Description)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Vouchers:")
+	if p.Vouchers == nil {
+		fmt.Fprintln(w, "  (not published)")
+	} else {
+		if p.Vouchers.Description != "" {
+			fmt.Fprintf(w, "  %s\n", p.Vouchers.Description)
+		}
+		for _, r := range p.Vouchers.Rules {
+			fmt.Fprintf(w, "  - %s\n", r)
+		}
+		if p.Vouchers.Genesis != "" {
+			fmt.Fprintf(w, "  %s\n", p.Vouchers.Genesis)
+		}
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Limits and quotas:")
+	if len(p.Limits) == 0 {
+		fmt.Fprintln(w, "  (not published by this network)")
+	} else {
+		flat := map[string]string{}
+		flattenPolicyLimits("", p.Limits, flat)
+		keys := make([]string, 0, len(flat))
+		for k := range flat {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %s: %s\n", k, flat[k])
+		}
+	}
+
+	if p.RateLimit != nil {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Rate limit (this request): %d/%d remaining", p.RateLimit.Remaining, p.RateLimit.Limit)
+		if p.RateLimit.ResetSeconds > 0 {
+			fmt.Fprintf(w, ", resets in %ds", p.RateLimit.ResetSeconds)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// runInfoPoliciesCmd fetches the discovery document and renders the
+// network's policies: visibility levels, voucher rules, any published
+// limits, and the rate-limit state seen on the request.
+func runInfoPoliciesCmd(apiURL string, jsonOut bool, w io.Writer) error {
+	body, header, err := fetchDiscoveryDoc(apiURL)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("parse network info: %w", err)
+	}
+	policies := extractNetworkPolicies(doc, header)
+	if jsonOut {
+		return printJSONTo(w, policies)
+	}
+	writeNetworkPolicies(w, policies)
+	return nil
+}
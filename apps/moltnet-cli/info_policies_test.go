@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDiscoveryDoc = `{
+  "network": {"name": "MoltNet"},
+  "capabilities": {"sharing": {"visibility_levels": ["private", "moltnet", "public"]}},
+  "rules": {
+    "visibility": {"levels": {"private": "Only you", "moltnet": "Registered agents", "public": "Everyone"}},
+    "vouchers": {"description": "Invite-only web of trust", "how_it_works": ["Issue a code", "Share it"], "genesis": "Genesis agents bootstrap the network"}
+  },
+  "limits": {"entry": {"max_content_bytes": 100000}, "vouchers": {"max_active": 5}}
+}`
+
+func newDiscoveryServer(t *testing.T, doc string, rateLimited bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/moltnet.json" {
+			http.NotFound(w, r)
+			return
+		}
+		if rateLimited {
+			w.Header().Set("X-RateLimit-Limit", "100")
+			w.Header().Set("X-RateLimit-Remaining", "99")
+			w.Header().Set("X-RateLimit-Reset", "60")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(doc)) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRunInfoPoliciesCmd_JSON(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	srv := newDiscoveryServer(t, testDiscoveryDoc, true)
+	var out bytes.Buffer
+
+	// Act
+	err := runInfoPoliciesCmd(srv.URL, true, &out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runInfoPoliciesCmd() error: %v", err)
+	}
+	var got networkPolicies
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got.Visibility) != 3 || got.Visibility[0].Level != "private" || got.Visibility[0].Description != "Only you" {
+		t.Errorf("visibility: %+v", got.Visibility)
+	}
+	if got.Vouchers == nil || len(got.Vouchers.Rules) != 2 {
+		t.Errorf("vouchers: %+v", got.Vouchers)
+	}
+	if got.Limits["vouchers"] == nil || got.Limits["entry"] == nil {
+		t.Errorf("limits: %+v", got.Limits)
+	}
+	if got.RateLimit == nil || got.RateLimit.Limit != 100 || got.RateLimit.Remaining != 99 || got.RateLimit.ResetSeconds != 60 {
+		t.Errorf("rate limit: %+v", got.RateLimit)
+	}
+	if len(got.Missing) != 0 {
+		t.Errorf("expected nothing missing, got %v", got.Missing)
+	}
+}
+
+func TestRunInfoPoliciesCmd_TextFallsBackWhenAbsent(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	srv := newDiscoveryServer(t, `{"network": {"name": "MoltNet"}}`, false)
+	var out bytes.Buffer
+
+	// Act
+	err := runInfoPoliciesCmd(srv.URL, false, &out)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runInfoPoliciesCmd() error: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "(not published by this network)") || !strings.Contains(text, "Vouchers:\n  (not published)") {
+		t.Errorf("expected not-published markers, got:\n%s", text)
+	}
+	if strings.Contains(text, "Rate limit") {
+		t.Errorf("rate limit should be omitted without headers:\n%s", text)
+	}
+}
+
+func TestWriteNetworkPolicies_FlattensLimits(t *testing.T) {
+	t.Parallel()
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(testDiscoveryDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+
+	writeNetworkPolicies(&out, extractNetworkPolicies(doc, http.Header{}))
+
+	for _, want := range []string{"entry.max_content_bytes: 100000", "vouchers.max_active: 5", "  - Issue a code"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// statsHeadlines are the figures info --stats lists first, each with the
+// keys a stats document may use for it. Everything else the endpoint
+// returns is listed after them.
+var statsHeadlines = []struct {
+	label string
+	keys  []string
+}{
+	{"Agents", []string{"agents", "total_agents", "agents_count", "agent_count"}},
+	{"Diary entries", []string{"entries", "diary_entries", "total_entries", "entries_count"}},
+	{"Active vouchers", []string{"active_vouchers", "vouchers_active", "vouchers"}},
+}
+
+// networkStats is the output of info --stats. Stats is nil when the network
+// does not publish a stats endpoint.
+type networkStats struct {
+	URL   string         `json:"url,omitempty"`
+	Stats map[string]any `json:"stats"`
+}
+
+// statsEndpointURL returns the stats endpoint the discovery document
+// advertises under endpoints.stats (an object with url, or a bare string),
+// resolved against apiURL when relative.
+func statsEndpointURL(doc map[string]any, apiURL string) (string, bool) {
+	endpoints, _ := doc["endpoints"].(map[string]any)
+	var raw string
+	switch v := endpoints["stats"].(type) {
+	case string:
+		raw = v
+	case map[string]any:
+		raw, _ = v["url"].(string)
+	}
+	if raw == "" {
+		return "", false
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	base, err := url.Parse(strings.TrimRight(apiURL, "/") + "/")
+	if err != nil {
+		return "", false
+	}
+	return base.ResolveReference(ref).String(), true
+}
+
+// fetchNetworkStats reads the advertised stats endpoint. A network without
+// one, or whose endpoint is gone (404), yields empty stats rather than an
+// error.
+func fetchNetworkStats(apiURL string) (networkStats, error) {
+	body, _, err := fetchDiscoveryDoc(apiURL)
+	if err != nil {
+		return networkStats{}, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return networkStats{}, fmt.Errorf("parse network info: %w", err)
+	}
+	statsURL, ok := statsEndpointURL(doc, apiURL)
+	if !ok {
+		return networkStats{}, nil
+	}
+	resp, err := newHTTPClient(30 * time.Second).Get(statsURL)
+	if err != nil {
+		return networkStats{}, fmt.Errorf("fetch network stats: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return networkStats{URL: statsURL}, nil
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return networkStats{}, fmt.Errorf("read network stats: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return networkStats{}, fmt.Errorf("fetch network stats: unexpected status %d: %s", resp.StatusCode, string(data))
+	}
+	// Keep counts as written: float64 would print large ones in e-notation.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var stats map[string]any
+	if err := dec.Decode(&stats); err != nil {
+		return networkStats{}, fmt.Errorf("parse network stats: %w", err)
+	}
+	if inner, ok := stats["stats"].(map[string]any); ok {
+		stats = inner
+	}
+	return networkStats{URL: statsURL, Stats: stats}, nil
+}
+
+// statsHeadline returns the scalar value stored under the first of keys
+// present.
+func statsHeadline(stats map[string]any, keys []string) (string, any, bool) {
+	for _, k := range keys {
+		switch v := stats[k].(type) {
+		case nil, map[string]any, []any:
+		default:
+			return k, v, true
+		}
+	}
+	return "", nil, false
+}
+
+func writeNetworkStats(w io.Writer, s networkStats) {
+	fmt.Fprintln(w, "Network stats:")
+	if s.Stats == nil {
+		fmt.Fprintln(w, "  (not published by this network)")
+		return
+	}
+	flat := map[string]string{}
+	flattenPolicyLimits("", s.Stats, flat)
+	for _, h := range statsHeadlines {
+		if key, _, ok := statsHeadline(s.Stats, h.keys); ok {
+			fmt.Fprintf(w, "  %-16s %s\n", h.label+":", flat[key])
+			delete(flat, key)
+		}
+	}
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s: %s\n", k, flat[k])
+	}
+}
+
+// runInfoStatsCmd renders the network's live stats, or with agentsCount
+// only the number of agents.
+func runInfoStatsCmd(apiURL string, jsonOut, agentsCount bool, w io.Writer) error {
+	stats, err := fetchNetworkStats(apiURL)
+	if err != nil {
+		return err
+	}
+	if agentsCount {
+		_, n, ok := statsHeadline(stats.Stats, statsHeadlines[0].keys)
+		if !ok {
+			return expectedErrorf("info: this network does not publish an agent count")
+		}
+		if jsonOut {
+			return printJSONTo(w, map[string]any{"agents": n})
+		}
+		_, err := fmt.Fprintln(w, n)
+		return err
+	}
+	if jsonOut {
+		return printJSONTo(w, stats)
+	}
+	writeNetworkStats(w, stats)
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newStatsServer(t *testing.T, advertise string, stats string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/moltnet.json":
+			doc := `{"network": {"name": "MoltNet"}, "endpoints": {` + advertise + `}}`
+			w.Write([]byte(doc)) //nolint:errcheck
+		case "/stats":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(stats)) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRunInfoStatsCmd(t *testing.T) {
+	t.Parallel()
+	srv := newStatsServer(t, `"stats": {"url": "/stats"}`,
+		`{"stats": {"total_agents": 1234567, "diary_entries": 42, "active_vouchers": 7, "packs": {"rendered": 3}}}`)
+
+	var out bytes.Buffer
+	if err := runInfoStatsCmd(srv.URL, false, false, &out); err != nil {
+		t.Fatalf("runInfoStatsCmd() error: %v", err)
+	}
+	want := "Network stats:\n" +
+		"  Agents:          1234567\n" +
+		"  Diary entries:   42\n" +
+		"  Active vouchers: 7\n" +
+		"  packs.rendered: 3\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := runInfoStatsCmd(srv.URL, false, true, &out); err != nil {
+		t.Fatalf("--agents-count error: %v", err)
+	}
+	if out.String() != "1234567\n" {
+		t.Errorf("--agents-count = %q", out.String())
+	}
+
+	out.Reset()
+	if err := runInfoStatsCmd(srv.URL, true, false, &out); err != nil {
+		t.Fatalf("--json error: %v", err)
+	}
+	var got networkStats
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.URL != srv.URL+"/stats" || got.Stats["diary_entries"] != float64(42) {
+		t.Errorf("--json = %+v", got)
+	}
+}
+
+func TestRunInfoStatsCmd_NotPublished(t *testing.T) {
+	t.Parallel()
+	for _, advertise := range []string{`"rest": {"url": "/"}`, `"stats": "/gone"`} {
+		srv := newStatsServer(t, advertise, "")
+		var out bytes.Buffer
+		if err := runInfoStatsCmd(srv.URL, false, false, &out); err != nil {
+			t.Fatalf("%s: runInfoStatsCmd() error: %v", advertise, err)
+		}
+		if !strings.Contains(out.String(), "not published") {
+			t.Errorf("%s: output = %q", advertise, out.String())
+		}
+		err := runInfoStatsCmd(srv.URL, false, true, &bytes.Buffer{})
+		if errorExitCode(err) != exitCodeExpected {
+			t.Errorf("%s: --agents-count error = %v, want an expected error", advertise, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// loginOptions carries the login command flags.
+type loginOptions struct {
+	apiURL      string
+	voucher     string
+	voucherFile string
+	gitName     string
+	gitEmail    string
+	mcpDir      string
+	noGit       bool
+	noMCP       bool
+	maskSecrets bool
+}
+
+// loginPrompter asks the questions of the login flow. The terminal one is
+// replaced in tests.
+type loginPrompter interface {
+	// confirm asks a yes/no question; an empty answer is def.
+	confirm(question string, def bool) (bool, error)
+	// line asks for a value; an empty answer is def.
+	line(question, def string) (string, error)
+	// secret asks for a value without echoing it.
+	secret(question string) (string, error)
+}
+
+type terminalPrompter struct {
+	in *bufio.Reader
+}
+
+func newTerminalPrompter() (loginPrompter, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, &usageError{err: fmt.Errorf("login: stdin is not a terminal; pass --assume-yes with --voucher-file (or %s) to run unattended, or use 'moltnet register'", voucherEnvVar)}
+	}
+	return &terminalPrompter{in: bufio.NewReader(os.Stdin)}, nil
+}
+
+func (p *terminalPrompter) confirm(question string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	answer, err := p.line(question+" "+hint, "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func (p *terminalPrompter) line(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s ", question)
+	}
+	answer, err := p.in.ReadString('\n')
+	if err != nil && answer == "" {
+		if err == io.EOF {
+			return "", expectedErrorf("login: aborted")
+		}
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+func (p *terminalPrompter) secret(question string) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s: ", question)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", strings.ToLower(question), err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// assumeYesPrompter answers every question with its default, for --assume-yes.
+type assumeYesPrompter struct{}
+
+func (assumeYesPrompter) confirm(_ string, def bool) (bool, error) { return def, nil }
+func (assumeYesPrompter) line(_, def string) (string, error)       { return def, nil }
+func (assumeYesPrompter) secret(question string) (string, error) {
+	return "", &usageError{err: fmt.Errorf("login: --assume-yes cannot prompt for the %s; pass --voucher-file or set %s", strings.ToLower(question), voucherEnvVar)}
+}
+
+// newLoginPrompter returns the prompter for a login run. Tests replace it.
+var newLoginPrompter = func(yes bool) (loginPrompter, error) {
+	if yes {
+		return assumeYesPrompter{}, nil
+	}
+	return newTerminalPrompter()
+}
+
+// runLoginCmd walks a new user through onboarding: register with a voucher,
+// export the SSH key, set up git signing and write .mcp.json, confirming
+// each step. It reuses register, ssh-key, git setup and the MCP config
+// writer, and skips steps whose result is already in place, so running it
+// again after a failure picks up where it stopped. Login always works on
+// the default config (MOLTNET_CONFIG_DIR relocates it).
+func runLoginCmd(w io.Writer, opts loginOptions) error {
+	p, err := newLoginPrompter(assumingYes())
+	if err != nil {
+		return err
+	}
+
+	creds, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+	if creds != nil {
+		fmt.Fprintf(w, "Already registered as %s (fingerprint %s); skipping registration.\n", creds.IdentityID, creds.Keys.Fingerprint)
+	} else {
+		if creds, err = loginRegister(w, p, opts); err != nil || creds == nil {
+			return err
+		}
+	}
+
+	if err := loginSSHKey(w, p, creds); err != nil {
+		return err
+	}
+	if creds, err = ReadConfig(); err != nil {
+		return err
+	}
+	if err := loginGitSetup(w, p, creds, opts); err != nil {
+		return err
+	}
+	if err := loginMcpConfig(w, p, creds, opts); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "\nDone. Check the setup any time with 'moltnet doctor'.")
+	return nil
+}
+
+// loginRegister registers a new identity. The voucher comes from the flags
+// or MOLTNET_VOUCHER and is prompted for (without echo) otherwise. It
+// returns nil credentials when the user declines.
+func loginRegister(w io.Writer, p loginPrompter, opts loginOptions) (*CredentialsFile, error) {
+	ok, err := p.confirm(fmt.Sprintf("No identity found. Register a new agent with %s?", opts.apiURL), true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		fmt.Fprintln(w, "Nothing to do without an identity; run 'moltnet login' again when you have a voucher.")
+		return nil, nil
+	}
+	voucher, err := resolveVoucher(opts.voucher, opts.voucherFile, os.Stdin)
+	if err != nil {
+		if opts.voucher != "" || opts.voucherFile != "" {
+			return nil, err
+		}
+		if voucher, err = p.secret("Voucher code"); err != nil {
+			return nil, err
+		}
+		if voucher == "" {
+			return nil, &usageError{err: fmt.Errorf("login: a voucher code is required to register")}
+		}
+	}
+	// The MCP config is its own step below, so it can be confirmed.
+	if err := runRegisterCmd(registerOptions{apiURL: opts.apiURL, voucher: voucher, noMCP: true}); err != nil {
+		return nil, err
+	}
+	return ReadConfig()
+}
+
+func loginSSHKey(w io.Writer, p loginPrompter, creds *CredentialsFile) error {
+	if creds.SSH != nil {
+		if pubSSH, err := ToSSHPublicKey(creds.Keys.PublicKey); err == nil && verifyExportedSSHKey(creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath, pubSSH) == nil {
+			fmt.Fprintf(w, "SSH key already exported to %s.\n", creds.SSH.PublicKeyPath)
+			return nil
+		}
+	}
+	ok, err := p.confirm("Export the identity key as an SSH key (for git commit signing)?", true)
+	if err != nil || !ok {
+		return err
+	}
+	if err := runSSHKeyExportCmd("", ""); err != nil {
+		return fmt.Errorf("login: ssh-key: %w", err)
+	}
+	return nil
+}
+
+func loginGitSetup(w io.Writer, p loginPrompter, creds *CredentialsFile, opts loginOptions) error {
+	switch {
+	case opts.noGit:
+		return nil
+	case creds.Git != nil:
+		fmt.Fprintf(w, "Git identity already configured in %s.\n", creds.Git.ConfigPath)
+		return nil
+	case creds.SSH == nil:
+		fmt.Fprintln(w, "Skipping git setup: no SSH key exported.")
+		return nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		fmt.Fprintln(w, "Skipping git setup: git is not installed.")
+		return nil
+	}
+	ok, err := p.confirm("Set up a signing git identity for this agent?", true)
+	if err != nil || !ok {
+		return err
+	}
+	name, email := opts.gitName, opts.gitEmail
+	if name == "" {
+		if name, err = p.line("Git author name (empty for the generated one)", ""); err != nil {
+			return err
+		}
+	}
+	if email == "" {
+		if email, err = p.line("Git author email (empty for the generated one)", ""); err != nil {
+			return err
+		}
+	}
+	if err := runGitSetupCmd("", name, email); err != nil {
+		return fmt.Errorf("login: git setup: %w", err)
+	}
+	return nil
+}
+
+func loginMcpConfig(w io.Writer, p loginPrompter, creds *CredentialsFile, opts loginOptions) error {
+	if opts.noMCP {
+		return nil
+	}
+	mcpPath, err := resolveMcpConfigPath(opts.mcpDir)
+	if err != nil {
+		return err
+	}
+	if server, ok, err := readMcpServer(mcpPath); err == nil && ok && len(mcpConfigProblems(server, creds)) == 0 {
+		fmt.Fprintf(w, "MCP config %s is up to date.\n", mcpPath)
+		return nil
+	}
+	ok, err := p.confirm(fmt.Sprintf("Write the moltnet MCP server to %s?", mcpPath), true)
+	if err != nil || !ok {
+		return err
+	}
+	mcpURL := deriveMCPURL(strings.TrimRight(opts.apiURL, "/"))
+	if creds.Endpoints.MCP != "" {
+		mcpURL = creds.Endpoints.MCP
+	}
+	mcpConfig := BuildMcpConfig(mcpURL, creds.OAuth2.ClientID, creds.OAuth2.ClientSecret)
+	if opts.maskSecrets {
+		mcpConfig = BuildMaskedMcpConfig(mcpURL)
+	}
+	if _, err := WriteMcpConfig(mcpConfig, opts.mcpDir); err != nil {
+		return fmt.Errorf("login: write MCP config: %w", err)
+	}
+	fmt.Fprintf(w, "MCP config written to %s\n", mcpPath)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptedPrompter answers confirmations from a queue and records every
+// question asked.
+type scriptedPrompter struct {
+	t       *testing.T
+	answers []bool
+	secrets []string
+	asked   []string
+}
+
+func (p *scriptedPrompter) confirm(question string, _ bool) (bool, error) {
+	p.asked = append(p.asked, question)
+	if len(p.answers) == 0 {
+		p.t.Fatalf("unexpected question %q", question)
+	}
+	a := p.answers[0]
+	p.answers = p.answers[1:]
+	return a, nil
+}
+
+func (p *scriptedPrompter) line(question, def string) (string, error) {
+	p.asked = append(p.asked, question)
+	return def, nil
+}
+
+func (p *scriptedPrompter) secret(question string) (string, error) {
+	p.asked = append(p.asked, question)
+	if len(p.secrets) == 0 {
+		p.t.Fatalf("unexpected secret prompt %q", question)
+	}
+	s := p.secrets[0]
+	p.secrets = p.secrets[1:]
+	return s, nil
+}
+
+func useLoginPrompter(t *testing.T, p loginPrompter) {
+	t.Helper()
+	orig := newLoginPrompter
+	newLoginPrompter = func(bool) (loginPrompter, error) { return p, nil }
+	t.Cleanup(func() { newLoginPrompter = orig })
+}
+
+func TestRunLoginCmd_FullFlow(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv(voucherEnvVar, "")
+	t.Setenv(configDirEnvVar, t.TempDir())
+	mcpDir := t.TempDir()
+	fail := false
+	srv, submitted := newFlakyRegisterServer(t, &fail)
+
+	p := &scriptedPrompter{t: t, answers: []bool{true, true, true, true}, secrets: []string{"the-voucher"}}
+	useLoginPrompter(t, p)
+	var out bytes.Buffer
+	err := runLoginCmd(&out, loginOptions{apiURL: srv.URL, mcpDir: mcpDir, gitName: "Agent", gitEmail: "agent@example.com"})
+	if err != nil {
+		t.Fatalf("runLoginCmd() error: %v\n%s", err, out.String())
+	}
+	if len(*submitted) != 1 {
+		t.Fatalf("registrations = %d, want 1", len(*submitted))
+	}
+
+	creds, err := ReadConfig()
+	if err != nil || creds == nil {
+		t.Fatalf("ReadConfig() = %v, %v", creds, err)
+	}
+	if creds.SSH == nil || !fileExists(creds.SSH.PrivateKeyPath) {
+		t.Errorf("SSH key not exported: %+v", creds.SSH)
+	}
+	if creds.Git == nil || creds.Git.Name != "Agent" || creds.Git.Email != "agent@example.com" {
+		t.Errorf("git setup = %+v, want the given name and email", creds.Git)
+	}
+	if server, ok, err := readMcpServer(filepath.Join(mcpDir, ".mcp.json")); err != nil || !ok || server.Headers["X-Client-Id"] != "cid" {
+		t.Errorf(".mcp.json entry = %+v, %v, %v", server, ok, err)
+	}
+
+	// A second run finds everything in place and asks nothing.
+	p2 := &scriptedPrompter{t: t}
+	useLoginPrompter(t, p2)
+	out.Reset()
+	if err := runLoginCmd(&out, loginOptions{apiURL: srv.URL, mcpDir: mcpDir}); err != nil {
+		t.Fatalf("second runLoginCmd() error: %v", err)
+	}
+	if len(p2.asked) != 0 {
+		t.Errorf("second run asked %v, want nothing", p2.asked)
+	}
+	if !strings.Contains(out.String(), "Already registered") {
+		t.Errorf("output = %q, want the registration skipped", out.String())
+	}
+}
+
+func TestRunLoginCmd_DeclinedSteps(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv(configDirEnvVar, t.TempDir())
+	t.Setenv(voucherEnvVar, "env-voucher")
+	mcpDir := t.TempDir()
+	fail := false
+	srv, _ := newFlakyRegisterServer(t, &fail)
+
+	// Register, then decline the SSH key and the MCP config.
+	useLoginPrompter(t, &scriptedPrompter{t: t, answers: []bool{true, false, false}})
+	if err := runLoginCmd(&bytes.Buffer{}, loginOptions{apiURL: srv.URL, mcpDir: mcpDir}); err != nil {
+		t.Fatalf("runLoginCmd() error: %v", err)
+	}
+	creds, _ := ReadConfig()
+	if creds == nil || creds.SSH != nil || creds.Git != nil {
+		t.Errorf("creds = %+v, want registered without SSH or git", creds)
+	}
+	if fileExists(filepath.Join(mcpDir, ".mcp.json")) {
+		t.Error(".mcp.json written although declined")
+	}
+}
+
+func TestRunLoginCmd_DeclineRegistration(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	useLoginPrompter(t, &scriptedPrompter{t: t, answers: []bool{false}})
+	if err := runLoginCmd(&bytes.Buffer{}, loginOptions{apiURL: "http://127.0.0.1:1"}); err != nil {
+		t.Fatalf("runLoginCmd() error: %v", err)
+	}
+	if creds, _ := ReadConfig(); creds != nil {
+		t.Error("a config was written although registration was declined")
+	}
+}
+
+func TestRunLoginCmd_YesNeedsVoucher(t *testing.T) {
+	setAssumeYes(true)
+	t.Cleanup(func() { setAssumeYes(false) })
+	t.Setenv(configDirEnvVar, t.TempDir())
+	t.Setenv(voucherEnvVar, "")
+	err := runLoginCmd(&bytes.Buffer{}, loginOptions{apiURL: "http://127.0.0.1:1"})
+	if code := errorExitCode(err); code != 2 {
+		t.Fatalf("exit code = %d (%v), want 2", code, err)
+	}
+}
+
+func TestLoginCmd_RejectsCredentialsFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moltnet.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { requestedAPIVersion.Store(nil) })
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "login", "--credentials", path); err == nil || !strings.Contains(err.Error(), "--credentials") {
+		t.Errorf("err = %v, want --credentials refused", err)
+	}
+}
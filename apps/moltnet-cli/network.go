@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+)
+
+// builtinNetworks are the --network names known without any config. The
+// config's networks section adds to and overrides them.
+var builtinNetworks = map[string]string{
+	"prod":  defaultAPIURL,
+	"local": "http://localhost:8000",
+}
+
+// networkAPIURL holds the API URL --network resolved to, if set.
+var networkAPIURL atomic.Pointer[string]
+
+// setNetwork resolves --network against the config and builtinNetworks and
+// stores the URL for resolveAPIURL. An empty name clears it.
+func setNetwork(name, credPath string) error {
+	if name == "" {
+		networkAPIURL.Store(nil)
+		return nil
+	}
+	var custom map[string]string
+	if creds, err := resolveCredentials(credPath); err == nil && creds != nil {
+		custom = creds.Networks
+	}
+	apiURL, ok := lookupNetwork(name, custom)
+	if !ok {
+		return &usageError{err: fmt.Errorf("unknown --network %q (known: %s); add it with 'moltnet config set networks.%s <url>'",
+			name, strings.Join(networkNames(custom), ", "), name)}
+	}
+	networkAPIURL.Store(&apiURL)
+	return nil
+}
+
+func lookupNetwork(name string, custom map[string]string) (string, bool) {
+	apiURL, ok := custom[name]
+	if !ok {
+		apiURL, ok = builtinNetworks[name]
+	}
+	return strings.TrimRight(apiURL, "/"), ok && apiURL != ""
+}
+
+func networkNames(custom map[string]string) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, m := range []map[string]string{builtinNetworks, custom} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagOrNetworkAPIURL is the API URL for commands that do not read it from
+// credentials (e.g. register): --api-url if given, else --network, else
+// the flag default.
+func flagOrNetworkAPIURL(cmd *cobra.Command) string {
+	apiURL, _ := cmd.Flags().GetString("api-url")
+	if u := networkAPIURL.Load(); u != nil && !cmd.Flags().Changed("api-url") {
+		return *u
+	}
+	return apiURL
+}
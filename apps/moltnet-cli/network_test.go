@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetNetwork(t *testing.T) {
+	t.Cleanup(func() { networkAPIURL.Store(nil) })
+	credPath := writeCredsWithAPI(t, "http://localhost:8080")
+	if err := runConfigSetCmd(credPath, "networks.staging", "https://staging.example.test/"); err != nil {
+		t.Fatalf("config set networks.staging: %v", err)
+	}
+	if err := runConfigSetCmd(credPath, "networks.local", "http://localhost:9000"); err != nil {
+		t.Fatalf("config set networks.local: %v", err)
+	}
+
+	tests := []struct {
+		network string
+		want    string
+	}{
+		{"", "http://localhost:8080"},               // endpoints.api
+		{"prod", defaultAPIURL},                     // built-in beats endpoints.api
+		{"staging", "https://staging.example.test"}, // custom, trailing slash trimmed
+		{"local", "http://localhost:9000"},          // custom overrides the built-in
+	}
+	for _, tt := range tests {
+		if err := setNetwork(tt.network, credPath); err != nil {
+			t.Fatalf("setNetwork(%q): %v", tt.network, err)
+		}
+		if got := resolveAPIURL(newCmdWithAPIFlag(), credPath); got != tt.want {
+			t.Errorf("--network %q: resolveAPIURL = %q, want %q", tt.network, got, tt.want)
+		}
+	}
+
+	// --api-url still wins over --network.
+	cmd := newCmdWithAPIFlag()
+	if err := cmd.Flags().Set("api-url", "https://explicit.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveAPIURL(cmd, credPath); got != "https://explicit.example.com" {
+		t.Errorf("explicit --api-url: got %q", got)
+	}
+
+	err := setNetwork("qa", credPath)
+	if errorExitCode(err) != exitCodeUsage || !strings.Contains(err.Error(), "known: local, prod, staging") {
+		t.Errorf("unknown network: got %v", err)
+	}
+}
+
+func TestConfigSetNetworks_Validates(t *testing.T) {
+	t.Parallel()
+	credPath := writeCredsWithAPI(t, "")
+	for _, key := range []string{"networks.staging", "networks.a.b"} {
+		value := "not a url"
+		if key == "networks.a.b" {
+			value = "https://ok.example.test"
+		}
+		if err := runConfigSetCmd(credPath, key, value); err == nil {
+			t.Errorf("config set %s %q: expected an error", key, value)
+		}
+	}
+	data, err := os.ReadFile(credPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "networks") {
+		t.Errorf("rejected values were written: %s", data)
+	}
+}
+
+func TestRootCmd_NetworkAndAPIURLExclusive(t *testing.T) {
+	t.Cleanup(func() { networkAPIURL.Store(nil) })
+	root := NewRootCmd("test", "")
+	root.SetArgs([]string{"--network", "prod", "--api-url", "https://x.example.test", "version"})
+	root.SetOut(&strings.Builder{})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), "none of the others can be") {
+		t.Errorf("expected a mutually exclusive flags error, got %v", err)
+	}
+}
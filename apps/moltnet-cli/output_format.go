@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"text/template"
+)
+
+// Values of the root --output flag.
+const (
+	outputFormatJSON     = "json"     // indented JSON, the default
+	outputFormatJSONL    = "jsonl"    // one compact JSON value per line
+	outputFormatTemplate = "template" // the value rendered through --template
+	outputFormatTable    = "table"    // aligned columns; see writeTable
+	outputFormatText     = "text"     // plain text where a command has it (sign), JSON elsewhere
+)
+
+// collectionKeys are the fields list and search responses carry their
+// items in. With --output jsonl each item becomes its own line.
+var collectionKeys = []string{"items", "results", "entries", "vouchers", "members", "grants", "messages", "groups"}
+
+// outputFormat holds the --output set by the root command, and
+// outputTemplate the parsed --template that goes with --output template.
+var (
+	outputFormat   atomic.Pointer[string]
+	outputTemplate atomic.Pointer[template.Template]
+)
+
+// setOutputFormat validates and stores --output and --template. Empty
+// values restore the default.
+func setOutputFormat(raw, templateText string) error {
+	switch raw {
+	case "", outputFormatJSON, outputFormatJSONL, outputFormatTable, outputFormatText:
+		if templateText != "" {
+			return fmt.Errorf("--template needs --output %s", outputFormatTemplate)
+		}
+		outputFormat.Store(&raw)
+		outputTemplate.Store(nil)
+		return nil
+	case outputFormatTemplate:
+		if templateText == "" {
+			return fmt.Errorf("--output %s needs --template", outputFormatTemplate)
+		}
+		tmpl, err := template.New("output").Funcs(outputTemplateFuncs).Parse(templateText)
+		if err != nil {
+			return fmt.Errorf("invalid --template: %w", err)
+		}
+		outputFormat.Store(&raw)
+		outputTemplate.Store(tmpl)
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q: want %s, %s, %s, %s or %s", raw, outputFormatJSON, outputFormatJSONL, outputFormatTable, outputFormatTemplate, outputFormatText)
+}
+
+// outputTemplateFuncs are available to --template on top of the
+// text/template builtins.
+var outputTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join": func(items []any, sep string) string {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// writeTemplate renders v through --template. The template sees v as its
+// JSON form decoded generically, so fields are addressed by their JSON
+// names ({{.fingerprint}}) and collections with {{range .items}}. A
+// trailing newline is added when the output lacks one.
+func writeTemplate(w io.Writer, tmpl *template.Template, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("render --template: %w", err)
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// currentOutputFormat returns the --output in effect.
+func currentOutputFormat() string {
+	if p := outputFormat.Load(); p != nil && *p != "" {
+		return *p
+	}
+	return outputFormatJSON
+}
+
+// requestedOutputFormat returns --output as given, empty when unset, for
+// commands whose default is not JSON.
+func requestedOutputFormat() string {
+	if p := outputFormat.Load(); p != nil {
+		return *p
+	}
+	return ""
+}
+
+// writeJSONLines writes v as JSON Lines: the elements of a top-level array,
+// or of a response's collection field (see collectionKeys), one per line.
+// Paging metadata such as total is dropped. Any other value is written as a
+// single line.
+func writeJSONLines(w io.Writer, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	for _, item := range jsonLineItems(raw) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, item); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonLineItems splits an encoded value into the values written as lines.
+func jsonLineItems(raw json.RawMessage) []json.RawMessage {
+	if bytes.Equal(raw, []byte("null")) {
+		return []json.RawMessage{raw}
+	}
+	var items []json.RawMessage
+	if json.Unmarshal(raw, &items) == nil {
+		return items
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) == nil {
+		for _, key := range collectionKeys {
+			if field, ok := obj[key]; ok && json.Unmarshal(field, &items) == nil {
+				return items
+			}
+		}
+	}
+	return []json.RawMessage{raw}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"list response", &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{}, Total: 0}, ""},
+		{"array", []map[string]int{{"a": 1}, {"b": 2}}, "{\"a\":1}\n{\"b\":2}\n"},
+		{"collection field", map[string]any{"total": 2, "results": []any{"x", map[string]any{"y": true}}}, "\"x\"\n{\"y\":true}\n"},
+		{"single object", map[string]any{"id": "e1", "tags": []string{"a"}}, "{\"id\":\"e1\",\"tags\":[\"a\"]}\n"},
+		{"null", nil, "null\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := writeJSONLines(&out, tt.v); err != nil {
+			t.Fatalf("%s: writeJSONLines() error: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+}
+
+func TestSetOutputFormat_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct{ output, tmpl string }{
+		{"yaml", ""},
+		{"template", ""},
+		{"json", "{{.id}}"},
+		{"template", "{{.id"},
+	} {
+		if err := setOutputFormat(tt.output, tt.tmpl); err == nil {
+			t.Errorf("setOutputFormat(%q, %q): expected an error", tt.output, tt.tmpl)
+		}
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	t.Parallel()
+	list := &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{*newTestEntry("first"), *newTestEntry("second")}, Total: 2}
+	tests := []struct {
+		name string
+		tmpl string
+		v    any
+		want string
+	}{
+		{"single object", "{{.fingerprint}} {{.identity_id}}", map[string]any{"fingerprint": "A1B2-C3D4", "identity_id": "id-1"}, "A1B2-C3D4 id-1\n"},
+		{"range over a collection", `{{range .items}}{{.content}}{{"\n"}}{{end}}total={{.total}}`, list, "first\nsecond\ntotal=2\n"},
+		{"numbers keep their form", "{{.count}}", map[string]any{"count": 1234567}, "1234567\n"},
+		{"json and join", `{{json .tags}} {{join .tags ","}}`, map[string]any{"tags": []string{"a", "b"}}, "[\"a\",\"b\"] a,b\n"},
+	}
+	for _, tt := range tests {
+		tmpl, err := template.New("t").Funcs(outputTemplateFuncs).Parse(tt.tmpl)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", tt.name, err)
+		}
+		var out bytes.Buffer
+		if err := writeTemplate(&out, tmpl, tt.v); err != nil {
+			t.Fatalf("%s: writeTemplate() error: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// defaultTableMaxWidth is the longest cell --output table prints before
+// truncating; entry bodies and embeddings would otherwise fill the screen.
+const defaultTableMaxWidth = 40
+
+// tableOptions are the --columns and --max-width that go with --output
+// table. A nil columns list means every field of the first row.
+type tableOptions struct {
+	columns  []string
+	maxWidth int
+}
+
+var outputTable atomic.Pointer[tableOptions]
+
+// setTableOptions validates and stores --columns and --max-width. Both
+// need --output table, so it runs after setOutputFormat. A max width of 0
+// disables truncation; an empty value restores the default.
+func setTableOptions(columns, maxWidth string) error {
+	if currentOutputFormat() != outputFormatTable {
+		if columns != "" || maxWidth != "" {
+			return fmt.Errorf("--columns and --max-width need --output %s", outputFormatTable)
+		}
+		outputTable.Store(nil)
+		return nil
+	}
+	opts := tableOptions{columns: splitAndTrim(columns, ","), maxWidth: defaultTableMaxWidth}
+	if maxWidth != "" {
+		n, err := strconv.Atoi(maxWidth)
+		if err != nil || n < 0 || (n > 0 && n < 2) {
+			return fmt.Errorf("invalid --max-width %q: want 0 (no limit) or a width of at least 2", maxWidth)
+		}
+		opts.maxWidth = n
+	}
+	outputTable.Store(&opts)
+	return nil
+}
+
+// currentTableOptions returns the table settings in effect.
+func currentTableOptions() tableOptions {
+	if p := outputTable.Load(); p != nil {
+		return *p
+	}
+	return tableOptions{maxWidth: defaultTableMaxWidth}
+}
+
+// writeTable renders v as an aligned table: one row per item of a list or
+// search response (see jsonLineItems), or a single row for any other
+// object. Nested values are shown as compact JSON, lists of scalars joined
+// with commas, and every cell is kept on one line and cut to opts.maxWidth.
+func writeTable(w io.Writer, v any, opts tableOptions) error {
+	raw, err := json.Marshal(v)
+	if err != nil || bytes.Equal(raw, []byte("null")) {
+		return err
+	}
+	var rows []map[string]any
+	var fieldOrder []string
+	for _, item := range jsonLineItems(raw) {
+		row, keys, ok := decodeTableRow(item)
+		if !ok {
+			row, keys = map[string]any{"value": decodeTableValue(item)}, []string{"value"}
+		}
+		if fieldOrder == nil {
+			fieldOrder = keys
+		}
+		rows = append(rows, row)
+	}
+
+	columns := opts.columns
+	if len(columns) == 0 {
+		columns = fieldOrder
+	}
+	for _, col := range columns {
+		if len(rows) > 0 && !tableColumnKnown(rows, col) {
+			return fmt.Errorf("unknown --columns field %q (available: %s)", col, strings.Join(fieldOrder, ", "))
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = strings.ToUpper(col)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			val, _ := tableField(row, col)
+			cells[i] = truncateCell(formatTableCell(val), opts.maxWidth)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// decodeTableRow decodes an object, returning its keys in document order.
+func decodeTableRow(item json.RawMessage) (map[string]any, []string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(item))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, false
+	}
+	row := map[string]any{}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, false
+		}
+		key, _ := tok.(string)
+		var val any
+		if err := dec.Decode(&val); err != nil {
+			return nil, nil, false
+		}
+		if _, dup := row[key]; !dup {
+			keys = append(keys, key)
+		}
+		row[key] = val
+	}
+	return row, keys, true
+}
+
+func decodeTableValue(item json.RawMessage) any {
+	dec := json.NewDecoder(bytes.NewReader(item))
+	dec.UseNumber()
+	var v any
+	_ = dec.Decode(&v)
+	return v
+}
+
+// tableField looks up a column, following dots into nested objects
+// (creator.fingerprint).
+func tableField(row map[string]any, col string) (any, bool) {
+	if v, ok := row[col]; ok {
+		return v, true
+	}
+	var cur any = row
+	for _, part := range strings.Split(col, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func tableColumnKnown(rows []map[string]any, col string) bool {
+	for _, row := range rows {
+		if _, ok := tableField(row, col); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTableCell renders a decoded JSON value on a single line.
+func formatTableCell(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.Join(strings.Fields(val), " ")
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			switch item.(type) {
+			case map[string]any, []any:
+				b, _ := json.Marshal(val)
+				return string(b)
+			}
+			parts = append(parts, formatTableCell(item))
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// truncateCell cuts s to max runes, ending in an ellipsis. 0 keeps s whole.
+func truncateCell(s string, max int) string {
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-1]) + "…"
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+	first := newTestEntry("a long body\nspanning   several lines of text")
+	first.Tags = []string{"scope:cli", "incident"}
+	list := &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{*first, *newTestEntry("short")}, Total: 2}
+	tests := []struct {
+		name string
+		v    any
+		opts tableOptions
+		want string
+	}{
+		{
+			"selected columns, truncated and kept on one line",
+			list,
+			tableOptions{columns: []string{"content", "tags", "creator.fingerprint"}, maxWidth: 12},
+			"CONTENT       TAGS          CREATOR.FINGERPRINT\n" +
+				"a long body…  scope:cli,i…  A1B2-C3D4-E…\n" +
+				"short                       A1B2-C3D4-E…\n",
+		},
+		{
+			"single object",
+			map[string]any{"name": "n", "count": 3},
+			tableOptions{maxWidth: 0},
+			"COUNT  NAME\n3      n\n",
+		},
+		{"empty collection", &moltnetapi.DiaryList{Items: []moltnetapi.DiaryEntry{}}, tableOptions{}, ""},
+		{"scalars", []string{"x", "y"}, tableOptions{}, "VALUE\nx\ny\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := writeTable(&out, tt.v, tt.opts); err != nil {
+			t.Fatalf("%s: writeTable() error: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, out.String(), tt.want)
+		}
+	}
+}
+
+func TestWriteTable_UnknownColumn(t *testing.T) {
+	t.Parallel()
+	err := writeTable(&bytes.Buffer{}, map[string]any{"id": "e1"}, tableOptions{columns: []string{"title"}})
+	if err == nil || !strings.Contains(err.Error(), `"title"`) || !strings.Contains(err.Error(), "id") {
+		t.Errorf("error = %v, want the unknown column and the available ones", err)
+	}
+}
+
+func TestTruncateCell(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"much longer text", 8, "much lo…"},
+		{"héllo wörld", 5, "héll…"},
+		{"no limit at all", 0, "no limit at all"},
+	}
+	for _, tt := range tests {
+		if got := truncateCell(tt.s, tt.max); got != tt.want {
+			t.Errorf("truncateCell(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestSetTableOptions(t *testing.T) {
+	t.Cleanup(func() {
+		_ = setOutputFormat("", "")
+		_ = setTableOptions("", "")
+	})
+	if err := setOutputFormat("json", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := setTableOptions("id", ""); err == nil {
+		t.Error("--columns without --output table: expected an error")
+	}
+	if err := setOutputFormat("table", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"-1", "1", "wide"} {
+		if err := setTableOptions("", bad); err == nil {
+			t.Errorf("--max-width %q: expected an error", bad)
+		}
+	}
+	if err := setTableOptions("id, title", "20"); err != nil {
+		t.Fatalf("setTableOptions() error: %v", err)
+	}
+	if got := currentTableOptions(); strings.Join(got.columns, "|") != "id|title" || got.maxWidth != 20 {
+		t.Errorf("options = %+v", got)
+	}
+}
@@ -517,7 +517,7 @@ func runPackListCmd(apiURL, credPath, diaryID, containsEntry string, includeRend
 		return fmt.Errorf("exactly one of --diary-id or --contains-entry must be provided")
 	}
 
-	client, err := newClientFromCreds(apiURL, credPath)
+	client, err := newClientFromCreds(apiURL, credPath, withLargeResponses())
 	if err != nil {
 		return err
 	}
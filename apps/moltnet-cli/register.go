@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -34,11 +37,131 @@ type ProblemDetails struct {
 	Detail string `json:"detail,omitempty"`
 }
 
+// Voucher redemption failures. Vouchers are single-use, so none of these is
+// retried; they only make the reason unambiguous.
+var (
+	errVoucherRedeemed = errors.New("voucher already redeemed")
+	errVoucherExpired  = errors.New("voucher expired")
+	errVoucherNotFound = errors.New("voucher not found")
+)
+
+// voucherProblemPrefix is the problem type namespace for voucher errors.
+const voucherProblemPrefix = "urn:moltnet:problem:voucher-"
+
+// voucherProblemError maps a registration problem to one of the voucher
+// errors, or returns nil when it is not about the voucher. The specific
+// urn:moltnet:problem:voucher-* types are matched first; servers that only
+// report registration-failed are matched on the detail text. A redemption
+// that fails inside the final transaction means another agent won the race.
+func voucherProblemError(problem ProblemDetails) error {
+	detail := strings.ToLower(problem.Detail)
+	if slug, ok := strings.CutPrefix(problem.Type, voucherProblemPrefix); ok {
+		switch slug {
+		case "redeemed", "already-redeemed":
+			return errVoucherRedeemed
+		case "expired":
+			return errVoucherExpired
+		case "not-found":
+			return errVoucherNotFound
+		}
+		// Generic voucher-* types (e.g. voucher-invalid) fall through to
+		// the detail text.
+	} else if !strings.Contains(detail, "voucher") {
+		return nil
+	}
+	switch {
+	case strings.Contains(detail, "redeemed"), strings.Contains(detail, "redemption failed"):
+		return errVoucherRedeemed
+	case strings.Contains(detail, "expired"):
+		return errVoucherExpired
+	case strings.Contains(detail, "not found"):
+		return errVoucherNotFound
+	}
+	return nil
+}
+
+func voucherErrorHint(err error) string {
+	switch {
+	case errors.Is(err, errVoucherRedeemed):
+		return "vouchers are single-use and another agent redeemed this one first; ask your sponsor for a new voucher ('moltnet vouch issue')"
+	case errors.Is(err, errVoucherExpired):
+		return "ask your sponsor for a new voucher ('moltnet vouch issue')"
+	default:
+		return "check the code for typos; it is a 64-character hex string"
+	}
+}
+
 // RegisterResult holds everything needed after registration.
 type RegisterResult struct {
-	KeyPair  *KeyPair
-	Response *RegisterResponse
-	APIUrl   string
+	KeyPair      *KeyPair
+	Response     *RegisterResponse
+	APIUrl       string
+	RegisteredAt time.Time
+	// Scopes are the default OAuth scopes to request, from --scope.
+	Scopes []string
+}
+
+// FingerprintMismatch reports whether the server computed a different
+// fingerprint than the one derived locally from the generated public key.
+// The local value is authoritative: it is what signatures are checked against.
+func (r *RegisterResult) FingerprintMismatch() bool {
+	return r.Response.Fingerprint != "" && r.Response.Fingerprint != r.KeyPair.Fingerprint
+}
+
+// ToConfig converts the result into the moltnet.json shape. It is the single
+// source for both the written config file and the --json output, so the two
+// cannot drift apart.
+func (r *RegisterResult) ToConfig() *CredentialsFile {
+	return &CredentialsFile{
+		IdentityID: r.Response.IdentityID,
+		OAuth2: CredentialsOAuth2{
+			ClientID:     r.Response.ClientID,
+			ClientSecret: r.Response.ClientSecret,
+			Scopes:       r.Scopes,
+		},
+		Keys: CredentialsKeys{
+			PublicKey:   r.KeyPair.PublicKey,
+			PrivateKey:  r.KeyPair.PrivateKey,
+			Fingerprint: r.KeyPair.Fingerprint,
+		},
+		Endpoints: CredentialsEndpoints{
+			API: r.APIUrl,
+			MCP: deriveMCPURL(r.APIUrl),
+		},
+		RegisteredAt: r.RegisteredAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// registerResultJSON is the stable, flat shape printed by register --json.
+type registerResultJSON struct {
+	IdentityID   string   `json:"identity_id"`
+	Fingerprint  string   `json:"fingerprint"`
+	PublicKey    string   `json:"public_key"`
+	PrivateKey   string   `json:"private_key"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	APIURL       string   `json:"api_url"`
+	MCPURL       string   `json:"mcp_url"`
+	RegisteredAt string   `json:"registered_at"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// MarshalJSON renders the result in the flat register --json shape, derived
+// from ToConfig.
+func (r *RegisterResult) MarshalJSON() ([]byte, error) {
+	cfg := r.ToConfig()
+	return json.Marshal(registerResultJSON{
+		IdentityID:   cfg.IdentityID,
+		Fingerprint:  cfg.Keys.Fingerprint,
+		PublicKey:    cfg.Keys.PublicKey,
+		PrivateKey:   cfg.Keys.PrivateKey,
+		ClientID:     cfg.OAuth2.ClientID,
+		ClientSecret: cfg.OAuth2.ClientSecret,
+		APIURL:       cfg.Endpoints.API,
+		MCPURL:       cfg.Endpoints.MCP,
+		RegisteredAt: cfg.RegisteredAt,
+		Scopes:       cfg.OAuth2.Scopes,
+	})
 }
 
 // DoRegister generates a keypair and registers with the API.
@@ -47,7 +170,19 @@ func DoRegister(apiURL string, voucherCode string) (*RegisterResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	return DoRegisterWithKeyPair(apiURL, voucherCode, kp)
+}
 
+// DoRegisterWithKeyPair registers an existing keypair with the API. The
+// private key may be empty when only the public half is available, as in
+// two-phase registration where the seed stays on an offline machine.
+func DoRegisterWithKeyPair(apiURL string, voucherCode string, kp *KeyPair) (*RegisterResult, error) {
+	return doRegisterWith(apiURL, voucherCode, kp, nil)
+}
+
+// doRegisterWith is DoRegisterWithKeyPair over the given base transport;
+// nil means baseTransport.
+func doRegisterWith(apiURL string, voucherCode string, kp *KeyPair, base http.RoundTripper) (*RegisterResult, error) {
 	reqBody := RegisterRequest{
 		PublicKey:   kp.PublicKey,
 		VoucherCode: voucherCode,
@@ -58,9 +193,15 @@ func DoRegister(apiURL string, voucherCode string) (*RegisterResult, error) {
 	}
 
 	reqURL := apiURL + "/auth/register"
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: newResponseLimitTransport(newAPIVersionTransport(base, currentAPIVersion()), currentMaxResponseSize(false)),
+	}
 	resp, err := client.Post(reqURL, "application/json", bytes.NewReader(body))
 	if err != nil {
+		if errors.Is(err, errNetworkPinMismatch) {
+			return nil, expectedErrorf("refusing to register: %w", err)
+		}
 		return nil, fmt.Errorf("request failed: %w", formatTransportError(err))
 	}
 	defer resp.Body.Close()
@@ -75,6 +216,9 @@ func DoRegister(apiURL string, voucherCode string) (*RegisterResult, error) {
 		if err := json.Unmarshal(respBody, &problem); err != nil {
 			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
 		}
+		if verr := voucherProblemError(problem); verr != nil {
+			return nil, fmt.Errorf("registration failed: %w — %s", verr, voucherErrorHint(verr))
+		}
 		detail := problem.Title
 		if problem.Detail != "" {
 			detail = problem.Title + ": " + problem.Detail
@@ -88,78 +232,361 @@ func DoRegister(apiURL string, voucherCode string) (*RegisterResult, error) {
 	}
 
 	return &RegisterResult{
-		KeyPair:  kp,
-		Response: &regResp,
-		APIUrl:   apiURL,
+		KeyPair:      kp,
+		Response:     &regResp,
+		APIUrl:       apiURL,
+		RegisteredAt: time.Now(),
 	}, nil
 }
 
-// runRegisterCmd registers a new agent identity with the given parameters.
-func runRegisterCmd(apiURL, voucher string, jsonOut, noMCP bool) error {
-	url := strings.TrimRight(apiURL, "/")
+// keyPairFromPublicKey builds a public-only KeyPair from an "ed25519:<base64>"
+// string, validating its length and deriving the fingerprint locally.
+func keyPairFromPublicKey(publicKey string) (*KeyPair, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	if !strings.HasPrefix(publicKey, "ed25519:") {
+		return nil, fmt.Errorf("public key must start with 'ed25519:'")
+	}
+	pub, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(pub))
+	}
+	return &KeyPair{PublicKey: publicKey, Fingerprint: Fingerprint(pub)}, nil
+}
 
-	fmt.Fprintf(os.Stderr, "Generating Ed25519 keypair...\n")
-	result, err := DoRegister(url, voucher)
+// writeSeedFile stores the base64 private seed at path with 0600
+// permissions. It refuses to overwrite an existing file so a second run
+// cannot silently destroy a key that was already shared.
+func writeSeedFile(path string, kp *KeyPair) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
 	if err != nil {
-		return err
+		return fmt.Errorf("write key file: %w", err)
 	}
+	if _, err := f.WriteString(kp.PrivateKey + "\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("write key file: %w", err)
+	}
+	return f.Close()
+}
 
-	fmt.Fprintf(os.Stderr, "Registered as %s (fingerprint: %s)\n",
-		result.Response.IdentityID, result.KeyPair.Fingerprint)
+// readSeedFile loads a keypair from a seed file written by writeSeedFile.
+func readSeedFile(path string) (*KeyPair, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode key file: %w", err)
+	}
+	return KeyPairFromSeed(seed)
+}
+
+// printKeyResult is the --print-key-only --json shape.
+type printKeyResult struct {
+	PublicKey   string `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+	KeyFile     string `json:"key_file"`
+}
+
+// runRegisterPrintKeyOnly generates a keypair without contacting the API.
+// The seed goes to keyFile; only the public key is printed, so the output
+// is exactly what needs to cross to the networked machine.
+func runRegisterPrintKeyOnly(keyFile string, jsonOut bool, w io.Writer) error {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	warnCISecretWrite(os.Stderr, "the private seed ("+keyFile+")")
+	if err := writeSeedFile(keyFile, kp); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Private seed written to %s (keep it offline)\n", keyFile)
+	fmt.Fprintf(os.Stderr, "Fingerprint: %s\n", kp.Fingerprint)
 
 	if jsonOut {
-		return outputJSON(result)
+		return printJSONTo(w, printKeyResult{
+			PublicKey:   kp.PublicKey,
+			Fingerprint: kp.Fingerprint,
+			KeyFile:     keyFile,
+		})
+	}
+	_, err = fmt.Fprintln(w, kp.PublicKey)
+	return err
+}
+
+// resolveRegisterKeyPair returns the keypair to register: a fresh one by
+// default, or the --submit-public-key value, upgraded to a full keypair when
+// --key-file holds the matching seed.
+func resolveRegisterKeyPair(publicKey, keyFile string) (*KeyPair, error) {
+	if publicKey == "" {
+		if keyFile != "" {
+			return readSeedFile(keyFile)
+		}
+		return GenerateKeyPair()
+	}
+
+	pubOnly, err := keyPairFromPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyFile == "" {
+		return pubOnly, nil
+	}
+	kp, err := readSeedFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if kp.PublicKey != pubOnly.PublicKey {
+		return nil, fmt.Errorf("key file %s does not match --submit-public-key (fingerprint %s, want %s)",
+			keyFile, kp.Fingerprint, pubOnly.Fingerprint)
+	}
+	return kp, nil
+}
+
+// voucherEnvVar lets bootstrap scripts hand over a voucher without putting
+// it on the command line.
+const voucherEnvVar = "MOLTNET_VOUCHER"
+
+// resolveVoucher returns the voucher code from the first configured source:
+// --voucher-file, --voucher ("-" reads stdin), then MOLTNET_VOUCHER.
+//
+// Vouchers are single-use secrets. Passing them inline leaks them into shell
+// history and process listings, so the file, stdin, and env sources exist to
+// keep the code off argv entirely.
+func resolveVoucher(flagValue, voucherFile string, stdin io.Reader) (string, error) {
+	if flagValue != "" && voucherFile != "" {
+		return "", fmt.Errorf("use either --voucher or --voucher-file, not both")
+	}
+
+	var raw string
+	switch {
+	case voucherFile != "":
+		data, err := os.ReadFile(voucherFile)
+		if err != nil {
+			return "", fmt.Errorf("read voucher file: %w", err)
+		}
+		raw = string(data)
+	case flagValue == "-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("read voucher from stdin: %w", err)
+		}
+		raw = string(data)
+	case flagValue != "":
+		raw = flagValue
+	default:
+		raw = os.Getenv(voucherEnvVar)
+	}
+
+	voucher := strings.TrimSpace(raw)
+	if voucher == "" {
+		return "", fmt.Errorf("voucher code required — pass --voucher, --voucher-file, or set %s", voucherEnvVar)
+	}
+	return voucher, nil
+}
+
+// registerOptions carries the register command flags.
+type registerOptions struct {
+	apiURL      string
+	voucher     string
+	jsonOut     bool
+	noMCP       bool
+	maskSecrets bool
+	// publicKey registers an already-generated key (--submit-public-key).
+	publicKey string
+	// keyFile holds the private seed for publicKey, if available locally.
+	keyFile string
+	// writeFiles allows writing credentials to disk on a CI runner.
+	writeFiles bool
+	// scopes are persisted as oauth2.scopes, the scopes later token
+	// grants request.
+	scopes []string
+	// recoverKey is a recovery file left by an unfinished registration
+	// (--recover-key); its key is registered instead of a new one.
+	recoverKey string
+	// pinNetwork is the --pin-network certificate fingerprint; see
+	// verifyPinnedNetwork.
+	pinNetwork string
+}
+
+// runRegisterCmd registers a new agent identity with the given parameters.
+func runRegisterCmd(opts registerOptions) error {
+	url := strings.TrimRight(opts.apiURL, "/")
+
+	// Checked before registering: the voucher is single-use, so refusing
+	// afterwards would strand the new identity.
+	if !opts.jsonOut && !opts.writeFiles {
+		if provider, ok := detectCI(); ok {
+			return expectedErrorf("running in %s: register would write plaintext credentials and .mcp.json to the runner's disk; pass --json to capture them into a secret store, or --write-files to write them anyway (%s=0 disables this check)", provider, ciOverrideEnvVar)
+		}
+	}
+	if !opts.jsonOut {
+		warnCISecretWrite(os.Stderr, "credentials and .mcp.json")
+	}
+
+	// With --pin-network the network is verified before a key is generated
+	// or the voucher sent, and the registration itself goes over a pinned
+	// connection, so neither can reach an impostor.
+	var base http.RoundTripper
+	if opts.pinNetwork != "" {
+		pin, err := parseNetworkPin(opts.pinNetwork)
+		if err != nil {
+			return &usageError{err: fmt.Errorf("register: %w", err)}
+		}
+		pinned, err := newPinnedTransport(pin)
+		if err != nil {
+			return err
+		}
+		if err := verifyPinnedNetwork(url, pinned); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Verified %s: certificate %s, discovery document consistent\n", url, formatNetworkPin(pin))
+		base = pinned
+	}
+
+	// A fresh key is saved to a recovery file before it is submitted and
+	// the file is removed once the credentials are stored, so no failure
+	// in between strands a key the server may already have accepted. A
+	// leftover file blocks generating yet another key.
+	generate := opts.publicKey == "" && opts.keyFile == "" && opts.recoverKey == ""
+	if generate {
+		if pending, _ := pendingRegistrations(); len(pending) > 0 {
+			return expectedErrorf("an earlier registration did not finish (%s): retry it with 'moltnet register --recover-key %s', or delete the file to register a new key",
+				strings.Join(pending, ", "), pending[0])
+		}
+		fmt.Fprintf(os.Stderr, "Generating Ed25519 keypair...\n")
+	}
+	var kp *KeyPair
+	var recoveryPath string
+	var result *RegisterResult
+	var err error
+	if opts.recoverKey != "" {
+		var pending *pendingRegistration
+		if kp, pending, err = readPendingRegistration(opts.recoverKey); err != nil {
+			return err
+		}
+		otherNetwork := pending.APIURL != "" && strings.TrimRight(pending.APIURL, "/") != url
+		recoveryPath = opts.recoverKey
+		if pending.registered() {
+			if otherNetwork {
+				fmt.Fprintf(os.Stderr, "Warning: %s was registered with %s, storing its credentials for that network, not %s\n", opts.recoverKey, pending.APIURL, url)
+			}
+			// The server already issued credentials for this key; only
+			// storing them failed.
+			result = pendingRegistrationResult(kp, pending, url)
+			fmt.Fprintf(os.Stderr, "Key %s is already registered as %s; storing its saved credentials...\n", kp.Fingerprint, result.Response.IdentityID)
+		} else {
+			if otherNetwork {
+				fmt.Fprintf(os.Stderr, "Warning: %s was generated for %s, registering with %s\n", opts.recoverKey, pending.APIURL, url)
+			}
+			fmt.Fprintf(os.Stderr, "Retrying registration of key %s...\n", kp.Fingerprint)
+		}
+	} else if kp, err = resolveRegisterKeyPair(opts.publicKey, opts.keyFile); err != nil {
+		return err
+	}
+	// The recovery file is written in every output mode, --json included:
+	// it is the only copy of the credentials if printing or storing them
+	// fails. On a CI runner that still puts the seed, and then the client
+	// secret, on the runner's disk until the credentials are printed.
+	if opts.jsonOut && result == nil {
+		path := recoveryPath
+		if generate {
+			path, _ = pendingRegistrationPath(kp.Fingerprint)
+		}
+		if path != "" {
+			warnCISecretWrite(os.Stderr, "the recovery file ("+path+")")
+		}
+	}
+	if generate {
+		if recoveryPath, err = writePendingRegistration(kp, url); err != nil {
+			return fmt.Errorf("%w (nothing was registered)", err)
+		}
+	}
+	// credentialsSaved is set once the issued credentials are recorded in
+	// the recovery file.
+	credentialsSaved := result != nil
+	if result == nil {
+		if opts.voucher == "" {
+			return fmt.Errorf("voucher code required — pass --voucher, --voucher-file, or set %s", voucherEnvVar)
+		}
+		if result, err = doRegisterWith(url, opts.voucher, kp, base); err != nil {
+			if recoveryPath != "" {
+				return fmt.Errorf("%w; %s", err, recoverKeyHint(recoveryPath))
+			}
+			return err
+		}
+		if recoveryPath != "" {
+			if err := recordPendingCredentials(recoveryPath, result); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not save the client credentials in %s: %v\n", recoveryPath, err)
+			} else {
+				credentialsSaved = true
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Registered as %s (fingerprint: %s)\n",
+			result.Response.IdentityID, result.KeyPair.Fingerprint)
+		if result.FingerprintMismatch() {
+			fmt.Fprintf(os.Stderr, "Warning: server reported fingerprint %s, keeping locally derived %s\n",
+				result.Response.Fingerprint, result.KeyPair.Fingerprint)
+		}
+	}
+	result.Scopes = opts.scopes
+
+	if opts.jsonOut {
+		if err := outputJSON(result); err != nil {
+			if credentialsSaved {
+				return fmt.Errorf("print credentials: %w; %s", err, recoverCredentialsHint(recoveryPath))
+			}
+			return fmt.Errorf("print credentials: %w", err)
+		}
+		removePendingRegistration(recoveryPath)
+		return nil
 	}
 
 	// Write credentials
-	credPath, err := WriteConfig(&CredentialsFile{
-		IdentityID: result.Response.IdentityID,
-		OAuth2: CredentialsOAuth2{
-			ClientID:     result.Response.ClientID,
-			ClientSecret: result.Response.ClientSecret,
-		},
-		Keys: CredentialsKeys{
-			PublicKey:   result.KeyPair.PublicKey,
-			PrivateKey:  result.KeyPair.PrivateKey,
-			Fingerprint: result.KeyPair.Fingerprint,
-		},
-		Endpoints: CredentialsEndpoints{
-			API: result.APIUrl,
-			MCP: deriveMCPURL(url),
-		},
-		RegisteredAt: time.Now().UTC().Format(time.RFC3339Nano),
-	})
+	credPath, err := WriteConfig(result.ToConfig())
 	if err != nil {
+		if credentialsSaved {
+			return fmt.Errorf("write credentials: %w; registered as %s, %s", err, result.Response.IdentityID, recoverCredentialsHint(recoveryPath))
+		}
+		// Nowhere else holds the secret: hand it over before failing.
+		fmt.Fprintf(os.Stderr, "Registered as %s, but the credentials could not be stored. Keep them now, they cannot be issued again:\n  client_id:     %s\n  client_secret: %s\n",
+			result.Response.IdentityID, result.Response.ClientID, result.Response.ClientSecret)
 		return fmt.Errorf("write credentials: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "Credentials written to %s\n", credPath)
+	removePendingRegistration(recoveryPath)
+	if kp.PrivateKey == "" {
+		fmt.Fprintf(os.Stderr, "Warning: no private key in %s — copy the seed into keys.private_key before signing\n", credPath)
+	}
 
 	// Write MCP config
-	if !noMCP {
-		mcpURL := deriveMCPURL(url)
+	if !opts.noMCP {
+		// The recovered credentials belong to the network the key was
+		// registered with, which need not be the one --api-url names.
+		mcpURL := deriveMCPURL(result.APIUrl)
 		mcpConfig := BuildMcpConfig(mcpURL, result.Response.ClientID, result.Response.ClientSecret)
+		if opts.maskSecrets {
+			mcpConfig = BuildMaskedMcpConfig(mcpURL)
+		}
 		mcpPath, err := WriteMcpConfig(mcpConfig, "")
 		if err != nil {
 			return fmt.Errorf("write MCP config: %w", err)
 		}
 		fmt.Fprintf(os.Stderr, "MCP config written to %s\n", mcpPath)
+		if opts.maskSecrets {
+			fmt.Fprintf(os.Stderr, "MCP config references $%s and $%s — set them in the MCP host environment (values are in %s)\n",
+				mcpClientIDEnvVar, mcpClientSecretEnvVar, credPath)
+		}
 	}
 
 	return nil
 }
 
 func outputJSON(result *RegisterResult) error {
-	out := map[string]interface{}{
-		"identity_id":   result.Response.IdentityID,
-		"fingerprint":   result.KeyPair.Fingerprint,
-		"public_key":    result.KeyPair.PublicKey,
-		"private_key":   result.KeyPair.PrivateKey,
-		"client_id":     result.Response.ClientID,
-		"client_secret": result.Response.ClientSecret,
-		"api_url":       result.APIUrl,
-		"mcp_url":       deriveMCPURL(result.APIUrl),
-	}
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
-	return enc.Encode(out)
+	return enc.Encode(result)
 }
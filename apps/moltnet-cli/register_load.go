@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// registerLoadOptions carries the hidden register --count load-test flags.
+//
+// This is a testing aid for operators of self-hosted networks: it burns one
+// voucher per registration and throws the resulting credentials away.
+type registerLoadOptions struct {
+	apiURL      string
+	voucherFile string
+	count       int
+	concurrency int
+}
+
+// registerLoadReport summarises a load run.
+type registerLoadReport struct {
+	Count            int                 `json:"count"`
+	Concurrency      int                 `json:"concurrency"`
+	Succeeded        int                 `json:"succeeded"`
+	Failed           int                 `json:"failed"`
+	DurationMs       float64             `json:"durationMs"`
+	ThroughputPerSec float64             `json:"throughputPerSec"`
+	LatencyMs        registerLoadLatency `json:"latencyMs"`
+	Errors           map[string]int      `json:"errors"`
+}
+
+type registerLoadLatency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// readVoucherLines reads one voucher per line, skipping blanks and
+// #-comments.
+func readVoucherLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read voucher file: %w", err)
+	}
+	defer f.Close()
+
+	var vouchers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		vouchers = append(vouchers, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read voucher file: %w", err)
+	}
+	return vouchers, nil
+}
+
+// latencyPercentile returns the nearest-rank percentile of sorted samples.
+func latencyPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// runRegisterLoadCmd registers opts.count agents with up to opts.concurrency
+// requests in flight and writes a JSON report to w.
+func runRegisterLoadCmd(opts registerLoadOptions, w io.Writer) error {
+	if opts.voucherFile == "" {
+		return fmt.Errorf("register --count requires --voucher-file with one voucher per line")
+	}
+	if opts.concurrency <= 0 {
+		opts.concurrency = 1
+	}
+	vouchers, err := readVoucherLines(opts.voucherFile)
+	if err != nil {
+		return err
+	}
+	if len(vouchers) < opts.count {
+		return fmt.Errorf("register --count %d needs %d vouchers, %s has %d", opts.count, opts.count, opts.voucherFile, len(vouchers))
+	}
+	url := strings.TrimRight(opts.apiURL, "/")
+
+	fmt.Fprintf(os.Stderr, "Load test: registering %d agents against %s (concurrency %d). Credentials are discarded.\n", opts.count, url, opts.concurrency)
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errs      = map[string]int{}
+		wg        sync.WaitGroup
+	)
+	jobs := make(chan string)
+	start := time.Now()
+	for range opts.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for voucher := range jobs {
+				t0 := time.Now()
+				_, err := DoRegister(url, voucher)
+				elapsed := time.Since(t0)
+				mu.Lock()
+				if err != nil {
+					errs[err.Error()]++
+				} else {
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, v := range vouchers[:opts.count] {
+		jobs <- v
+	}
+	close(jobs)
+	wg.Wait()
+	total := time.Since(start)
+
+	slices.Sort(latencies)
+	report := registerLoadReport{
+		Count:       opts.count,
+		Concurrency: opts.concurrency,
+		Succeeded:   len(latencies),
+		Failed:      opts.count - len(latencies),
+		DurationMs:  durationMs(total),
+		Errors:      errs,
+	}
+	if total > 0 {
+		report.ThroughputPerSec = float64(report.Succeeded) / total.Seconds()
+	}
+	if n := len(latencies); n > 0 {
+		report.LatencyMs = registerLoadLatency{
+			P50: durationMs(latencyPercentile(latencies, 50)),
+			P90: durationMs(latencyPercentile(latencies, 90)),
+			P99: durationMs(latencyPercentile(latencies, 99)),
+			Max: durationMs(latencies[n-1]),
+		}
+	}
+	return printJSONTo(w, report)
+}
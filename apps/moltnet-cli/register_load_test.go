@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	t.Parallel()
+	var samples []time.Duration
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond} {
+		if got := latencyPercentile(samples, p); got != want {
+			t.Errorf("p%v: got %v, want %v", p, got, want)
+		}
+	}
+	if got := latencyPercentile(nil, 50); got != 0 {
+		t.Errorf("empty: got %v", got)
+	}
+}
+
+func TestRunRegisterLoadCmd_ReportsSuccessesAndErrors(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req RegisterRequest
+		json.NewDecoder(r.Body).Decode(&req) //nolint:errcheck
+		w.Header().Set("Content-Type", "application/json")
+		if req.VoucherCode == "used" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ProblemDetails{Title: "Invalid voucher", Status: 403}) //nolint:errcheck
+			return
+		}
+		json.NewEncoder(w).Encode(RegisterResponse{IdentityID: "id", ClientID: "cid", ClientSecret: "sec"}) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	voucherFile := filepath.Join(t.TempDir(), "vouchers.txt")
+	content := "# load test vouchers\nv1\nv2\n\nused\nv3\nextra\n"
+	if err := os.WriteFile(voucherFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := runRegisterLoadCmd(registerLoadOptions{apiURL: server.URL, voucherFile: voucherFile, count: 4, concurrency: 2}, &out)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("requests: got %d, want 4", got)
+	}
+	var report registerLoadReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("parse report: %v\n%s", err, out.String())
+	}
+	if report.Succeeded != 3 || report.Failed != 1 {
+		t.Errorf("succeeded=%d failed=%d, want 3/1", report.Succeeded, report.Failed)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("errors: got %v", report.Errors)
+	}
+	if report.LatencyMs.Max < report.LatencyMs.P50 {
+		t.Errorf("latency max %v below p50 %v", report.LatencyMs.Max, report.LatencyMs.P50)
+	}
+}
+
+func TestRunRegisterLoadCmd_NeedsEnoughVouchers(t *testing.T) {
+	t.Parallel()
+	voucherFile := filepath.Join(t.TempDir(), "vouchers.txt")
+	if err := os.WriteFile(voucherFile, []byte("only-one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := runRegisterLoadCmd(registerLoadOptions{apiURL: "http://unused", voucherFile: voucherFile, count: 2}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "needs 2 vouchers") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestRegisterCmd_LoadFlagsHidden(t *testing.T) {
+	t.Parallel()
+	cmd := newRegisterCmd()
+	for _, name := range []string{"count", "concurrency"} {
+		if f := cmd.Flags().Lookup(name); f == nil || !f.Hidden {
+			t.Errorf("--%s should exist and be hidden", name)
+		}
+	}
+}
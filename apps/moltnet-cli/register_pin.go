@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// errNetworkPinMismatch is returned by a pinned connection whose server
+// presents a certificate other than the pinned one.
+var errNetworkPinMismatch = errors.New("server certificate does not match --pin-network")
+
+// parseNetworkPin parses a --pin-network value: the SHA-256 fingerprint of
+// the server's TLS leaf certificate, as hex with or without colons and an
+// optional "sha256:" prefix (the form "openssl x509 -fingerprint -sha256"
+// prints is accepted).
+func parseNetworkPin(raw string) ([]byte, error) {
+	s := strings.TrimSpace(raw)
+	if v, ok := strings.CutPrefix(strings.ToLower(s), "sha256:"); ok {
+		s = v
+	} else if _, v, ok := strings.Cut(s, "="); ok && strings.HasPrefix(strings.ToLower(s), "sha256 fingerprint") {
+		s = v
+	}
+	pin, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("invalid --pin-network %q: want the SHA-256 fingerprint of the server certificate (64 hex digits)", raw)
+	}
+	return pin, nil
+}
+
+// formatNetworkPin renders a certificate fingerprint the way --pin-network
+// takes it.
+func formatNetworkPin(pin []byte) string {
+	return "sha256:" + hex.EncodeToString(pin)
+}
+
+// newPinnedTransport returns a base transport that only completes TLS
+// handshakes with a server whose leaf certificate hashes to pin. The pin
+// replaces CA verification, so a self-hosted network with a self-signed
+// certificate can be pinned too; it is stricter than any CA would be.
+func newPinnedTransport(pin []byte) (*http.Transport, error) {
+	// A transport of its own: the pinned TLS config must not reach the
+	// shared one.
+	t := buildBaseTransport()
+	t.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Checked in VerifyConnection instead, against the pin.
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("%w: no certificate presented", errNetworkPinMismatch)
+			}
+			got := sha256.Sum256(cs.PeerCertificates[0].Raw)
+			if !bytes.Equal(got[:], pin) {
+				return fmt.Errorf("%w: %s presented %s", errNetworkPinMismatch, cs.ServerName, formatNetworkPin(got[:]))
+			}
+			return nil
+		},
+	}
+	return t, nil
+}
+
+// verifyPinnedNetwork checks, before anything secret is sent, that apiURL
+// is the network the caller pinned: the discovery document must be served
+// over a connection presenting the pinned certificate, and the REST
+// endpoint it publishes must be apiURL's origin, so a pinned host cannot
+// front for another network. The discovery document carries no signing
+// key to check a response signature against, so the certificate is the
+// network's identity here.
+func verifyPinnedNetwork(apiURL string, base http.RoundTripper) error {
+	api, err := url.Parse(apiURL)
+	if err != nil || api.Scheme != "https" || api.Host == "" {
+		return &usageError{err: fmt.Errorf("register: --pin-network needs an https API URL, got %q", apiURL)}
+	}
+	client := &http.Client{Timeout: 30 * time.Second, Transport: newResponseLimitTransport(base, currentMaxResponseSize(false))}
+	body, _, err := fetchDiscoveryDocWith(client, apiURL)
+	if err != nil {
+		if errors.Is(err, errNetworkPinMismatch) {
+			return expectedErrorf("register: refusing to register: %w", err)
+		}
+		return fmt.Errorf("register: verify network: %w", formatTransportError(err))
+	}
+	var doc struct {
+		Endpoints struct {
+			Rest struct {
+				URL string `json:"url"`
+			} `json:"rest"`
+		} `json:"endpoints"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return expectedErrorf("register: refusing to register: %s serves an invalid discovery document: %v", apiURL, err)
+	}
+	published := doc.Endpoints.Rest.URL
+	rest, err := url.Parse(published)
+	if published == "" || err != nil {
+		return expectedErrorf("register: refusing to register: the discovery document of %s publishes no REST endpoint", apiURL)
+	}
+	if !sameOrigin(api, rest) {
+		return expectedErrorf("register: refusing to register: %s publishes %s as its REST endpoint; register against that network instead", apiURL, published)
+	}
+	return nil
+}
+
+// sameOrigin reports whether a and b share scheme, host and port.
+func sameOrigin(a, b *url.URL) bool {
+	port := func(u *url.URL) string {
+		if p := u.Port(); p != "" {
+			return p
+		}
+		if u.Scheme == "https" {
+			return "443"
+		}
+		return "80"
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Hostname(), b.Hostname()) && port(a) == port(b)
+}
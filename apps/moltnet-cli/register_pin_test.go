@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newPinnedNetworkServer serves a discovery document publishing restURL
+// (the server's own URL when empty) and records registrations.
+func newPinnedNetworkServer(t *testing.T, restURL string) (*httptest.Server, string, *int) {
+	t.Helper()
+	registered := 0
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/moltnet.json":
+			rest := restURL
+			if rest == "" {
+				rest = srv.URL
+			}
+			json.NewEncoder(w).Encode(map[string]any{"endpoints": map[string]any{"rest": map[string]any{"url": rest}}}) //nolint:errcheck
+		case "/auth/register":
+			registered++
+			var req RegisterRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			json.NewEncoder(w).Encode(RegisterResponse{IdentityID: "uuid-123", PublicKey: req.PublicKey, ClientID: "cid", ClientSecret: "csec"}) //nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	return srv, formatNetworkPin(sum[:]), &registered
+}
+
+func TestParseNetworkPin(t *testing.T) {
+	t.Parallel()
+	hexPin := strings.Repeat("ab", 32)
+	colons := strings.TrimSuffix(strings.Repeat("AB:", 32), ":")
+	for _, raw := range []string{hexPin, "sha256:" + hexPin, colons, "SHA256 Fingerprint=" + colons} {
+		pin, err := parseNetworkPin(raw)
+		if err != nil {
+			t.Errorf("parseNetworkPin(%q) error: %v", raw, err)
+			continue
+		}
+		if got := formatNetworkPin(pin); got != "sha256:"+hexPin {
+			t.Errorf("parseNetworkPin(%q) = %s", raw, got)
+		}
+	}
+	for _, raw := range []string{"", "sha256:abcd", strings.Repeat("zz", 32)} {
+		if _, err := parseNetworkPin(raw); err == nil {
+			t.Errorf("parseNetworkPin(%q): expected an error", raw)
+		}
+	}
+}
+
+func TestRunRegisterCmd_PinNetwork(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv(configDirEnvVar, t.TempDir())
+	srv, pin, registered := newPinnedNetworkServer(t, "")
+
+	if err := runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", noMCP: true, pinNetwork: pin}); err != nil {
+		t.Fatalf("runRegisterCmd() error: %v", err)
+	}
+	if *registered != 1 {
+		t.Errorf("registrations = %d, want 1", *registered)
+	}
+}
+
+func TestRunRegisterCmd_PinNetworkRefuses(t *testing.T) {
+	clearCIEnv(t)
+	srv, pin, registered := newPinnedNetworkServer(t, "")
+	other, otherPin, otherRegistered := newPinnedNetworkServer(t, "https://api.themolt.net")
+
+	tests := []struct {
+		name   string
+		apiURL string
+		pin    string
+		code   int
+	}{
+		{"wrong certificate", srv.URL, "sha256:" + strings.Repeat("00", 32), exitCodeExpected},
+		{"discovery names another network", other.URL, otherPin, exitCodeExpected},
+		{"plain http", strings.Replace(srv.URL, "https://", "http://", 1), pin, exitCodeUsage},
+		{"malformed pin", srv.URL, "not-a-pin", exitCodeUsage},
+	}
+	for _, tt := range tests {
+		t.Setenv(configDirEnvVar, t.TempDir())
+		err := runRegisterCmd(registerOptions{apiURL: tt.apiURL, voucher: "v", noMCP: true, pinNetwork: tt.pin})
+		if code := errorExitCode(err); code != tt.code {
+			t.Errorf("%s: exit code = %d (%v), want %d", tt.name, code, err, tt.code)
+		}
+		if pending, _ := pendingRegistrations(); len(pending) != 0 {
+			t.Errorf("%s: a key was generated before the network was verified: %v", tt.name, pending)
+		}
+	}
+	if *registered != 0 || *otherRegistered != 0 {
+		t.Errorf("registrations = %d, %d, want none", *registered, *otherRegistered)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pendingRegisterPrefix names the recovery files register leaves in the
+// config directory while a freshly generated key is being registered.
+const pendingRegisterPrefix = "pending-register-"
+
+// pendingRegistration is a generated keypair whose registration has not
+// been confirmed. It is written before the key is submitted and removed
+// once the credentials are safely stored, so a failure in between (a
+// dropped connection after the server accepted the key, a full disk)
+// never loses a key the network may already know. Once the server
+// answers, the issued client credentials are recorded in it too: the
+// voucher is spent by then, so they cannot be obtained again.
+type pendingRegistration struct {
+	PublicKey    string    `json:"public_key"`
+	PrivateKey   string    `json:"private_key"`
+	Fingerprint  string    `json:"fingerprint"`
+	APIURL       string    `json:"api_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	IdentityID   string    `json:"identity_id,omitempty"`
+	ClientID     string    `json:"client_id,omitempty"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	RegisteredAt time.Time `json:"registered_at,omitzero"`
+}
+
+// registered reports whether the server already accepted the key and the
+// file holds the credentials it issued.
+func (p *pendingRegistration) registered() bool {
+	return p.ClientID != "" && p.ClientSecret != ""
+}
+
+func pendingRegistrationPath(fingerprint string) (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, pendingRegisterPrefix+fingerprint+".json"), nil
+}
+
+// pendingRegistrations lists the recovery files in the config directory.
+func pendingRegistrations() ([]string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return filepath.Glob(filepath.Join(dir, pendingRegisterPrefix+"*.json"))
+}
+
+// writePendingRegistration saves kp as a recovery file (0600) and returns
+// its path. Like writeSeedFile it never overwrites an existing file.
+func writePendingRegistration(kp *KeyPair, apiURL string) (string, error) {
+	path, err := pendingRegistrationPath(kp.Fingerprint)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(pendingRegistration{
+		PublicKey:   kp.PublicKey,
+		PrivateKey:  kp.PrivateKey,
+		Fingerprint: kp.Fingerprint,
+		APIURL:      apiURL,
+		CreatedAt:   timeNow().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("write recovery file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("write recovery file: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("write recovery file: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return "", fmt.Errorf("write recovery file: %w", err)
+	}
+	return path, f.Close()
+}
+
+// recordPendingCredentials adds the credentials of a successful
+// registration to the recovery file at path, replacing it through a 0600
+// temp file.
+func recordPendingCredentials(path string, result *RegisterResult) error {
+	_, pending, err := readPendingRegistration(path)
+	if err != nil {
+		return err
+	}
+	pending.IdentityID = result.Response.IdentityID
+	pending.ClientID = result.Response.ClientID
+	pending.ClientSecret = result.Response.ClientSecret
+	pending.RegisteredAt = result.RegisteredAt.UTC()
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write recovery file: %w", err)
+	}
+	if err := writePrivateFile(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("write recovery file: %w", err)
+	}
+	return nil
+}
+
+// pendingRegistrationResult rebuilds the result of a registration whose
+// credentials were recorded in a recovery file.
+func pendingRegistrationResult(kp *KeyPair, pending *pendingRegistration, apiURL string) *RegisterResult {
+	if pending.APIURL != "" {
+		apiURL = strings.TrimRight(pending.APIURL, "/")
+	}
+	return &RegisterResult{
+		KeyPair: kp,
+		Response: &RegisterResponse{
+			IdentityID:   pending.IdentityID,
+			Fingerprint:  kp.Fingerprint,
+			PublicKey:    kp.PublicKey,
+			ClientID:     pending.ClientID,
+			ClientSecret: pending.ClientSecret,
+		},
+		APIUrl:       apiURL,
+		RegisteredAt: pending.RegisteredAt,
+	}
+}
+
+// pendingRegistrationDone reports whether the recovery file at path
+// already holds issued credentials, so finishing it needs no voucher.
+func pendingRegistrationDone(path string) bool {
+	_, pending, err := readPendingRegistration(path)
+	return err == nil && pending.registered()
+}
+
+// readPendingRegistration loads a recovery file and checks its seed still
+// derives the recorded public key.
+func readPendingRegistration(path string) (*KeyPair, *pendingRegistration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read recovery file: %w", err)
+	}
+	var pending pendingRegistration
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return nil, nil, fmt.Errorf("parse recovery file %s: %w", path, err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(pending.PrivateKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("recovery file %s: decode private key: %w", path, err)
+	}
+	kp, err := KeyPairFromSeed(seed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("recovery file %s: %w", path, err)
+	}
+	if kp.PublicKey != pending.PublicKey {
+		return nil, nil, fmt.Errorf("recovery file %s is corrupt: its private key does not match public_key", path)
+	}
+	return kp, &pending, nil
+}
+
+// removePendingRegistration deletes a recovery file once the credentials
+// it protected are stored. A failure only leaves a stale file behind,
+// which the next register reports.
+func removePendingRegistration(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: could not remove %s: %v\n", path, err)
+	}
+}
+
+// recoverCredentialsHint tells the user where the credentials of a
+// completed registration are kept and how to finish it.
+func recoverCredentialsHint(path string) string {
+	return fmt.Sprintf("the client credentials are saved in %s; finish with 'moltnet register --recover-key %s' (no voucher needed)", path, path)
+}
+
+// recoverKeyHint tells the user how to retry with a preserved key.
+func recoverKeyHint(path string) string {
+	return fmt.Sprintf("the key is kept in %s; retry with 'moltnet register --recover-key %s' instead of registering a new key", path, path)
+}
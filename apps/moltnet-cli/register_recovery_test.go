@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFlakyRegisterServer fails registration while *fail is set and
+// records each submitted public key.
+func newFlakyRegisterServer(t *testing.T, fail *bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var submitted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req RegisterRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		submitted = append(submitted, req.PublicKey)
+		if *fail {
+			http.Error(w, "upstream timeout", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(RegisterResponse{IdentityID: "uuid-123", PublicKey: req.PublicKey, ClientID: "cid", ClientSecret: "csec"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &submitted
+}
+
+func TestRunRegisterCmd_PreservesKeyAfterFailure(t *testing.T) {
+	clearCIEnv(t)
+	dir := t.TempDir()
+	t.Setenv(configDirEnvVar, dir)
+	fail := true
+	srv, submitted := newFlakyRegisterServer(t, &fail)
+
+	err := runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", noMCP: true})
+	if err == nil || !strings.Contains(err.Error(), "--recover-key") {
+		t.Fatalf("error = %v, want a --recover-key hint", err)
+	}
+	pending, _ := pendingRegistrations()
+	if len(pending) != 1 {
+		t.Fatalf("recovery files = %v, want one", pending)
+	}
+	if info, _ := os.Stat(pending[0]); info.Mode().Perm() != 0o600 {
+		t.Errorf("recovery file mode = %o, want 600", info.Mode().Perm())
+	}
+	kp, _, err := readPendingRegistration(pending[0])
+	if err != nil {
+		t.Fatalf("readPendingRegistration() error: %v", err)
+	}
+	if kp.PublicKey != (*submitted)[0] {
+		t.Errorf("saved key %s is not the submitted %s", kp.PublicKey, (*submitted)[0])
+	}
+
+	// A plain retry must not mint a second key.
+	fail = false
+	err = runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", noMCP: true})
+	if code := errorExitCode(err); code != 3 || !strings.Contains(err.Error(), pending[0]) {
+		t.Fatalf("retry without --recover-key: exit %d, err %v", code, err)
+	}
+	if len(*submitted) != 1 {
+		t.Fatalf("a new key was submitted: %v", *submitted)
+	}
+
+	if err := runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", noMCP: true, recoverKey: pending[0]}); err != nil {
+		t.Fatalf("--recover-key: %v", err)
+	}
+	if (*submitted)[1] != kp.PublicKey {
+		t.Errorf("recovered registration submitted %s, want %s", (*submitted)[1], kp.PublicKey)
+	}
+	if fileExists(pending[0]) {
+		t.Error("recovery file kept after a successful registration")
+	}
+	creds, _ := ReadConfigFrom(filepath.Join(dir, "moltnet.json"))
+	if creds == nil || creds.Keys.PrivateKey != kp.PrivateKey {
+		t.Errorf("config does not hold the recovered key: %+v", creds)
+	}
+}
+
+func TestRunRegisterCmd_RemovesRecoveryFileOnSuccess(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv(configDirEnvVar, t.TempDir())
+	fail := false
+	srv, _ := newFlakyRegisterServer(t, &fail)
+
+	if err := runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", noMCP: true}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if pending, _ := pendingRegistrations(); len(pending) != 0 {
+		t.Errorf("recovery files left behind: %v", pending)
+	}
+}
+
+func TestRunRegisterCmd_RecoversCredentialsAfterWriteFailure(t *testing.T) {
+	clearCIEnv(t)
+	dir := t.TempDir()
+	t.Setenv(configDirEnvVar, dir)
+	fail := false
+	srv, submitted := newFlakyRegisterServer(t, &fail)
+
+	// A directory where moltnet.json should go makes WriteConfig fail
+	// after the server has issued the credentials.
+	configPath := filepath.Join(dir, "moltnet.json")
+	if err := os.Mkdir(configPath, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	err := runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", noMCP: true})
+	if err == nil || !strings.Contains(err.Error(), "--recover-key") {
+		t.Fatalf("error = %v, want a --recover-key hint", err)
+	}
+	pending, _ := pendingRegistrations()
+	if len(pending) != 1 || !strings.Contains(err.Error(), pending[0]) {
+		t.Fatalf("recovery files = %v, error %v", pending, err)
+	}
+	if info, _ := os.Stat(pending[0]); info.Mode().Perm() != 0o600 {
+		t.Errorf("recovery file mode = %o, want 600", info.Mode().Perm())
+	}
+	_, saved, err := readPendingRegistration(pending[0])
+	if err != nil {
+		t.Fatalf("readPendingRegistration() error: %v", err)
+	}
+	if saved.IdentityID != "uuid-123" || saved.ClientID != "cid" || saved.ClientSecret != "csec" {
+		t.Fatalf("recovery file credentials = %+v, want the issued ones", saved)
+	}
+
+	// Finishing needs neither the spent voucher nor another registration.
+	if err := os.Remove(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := runRegisterCmd(registerOptions{apiURL: srv.URL, noMCP: true, recoverKey: pending[0]}); err != nil {
+		t.Fatalf("--recover-key: %v", err)
+	}
+	if len(*submitted) != 1 {
+		t.Errorf("the key was submitted again: %v", *submitted)
+	}
+	creds, _ := ReadConfigFrom(configPath)
+	if creds == nil || creds.IdentityID != "uuid-123" || creds.OAuth2.ClientSecret != "csec" || creds.Keys.PrivateKey != saved.PrivateKey {
+		t.Errorf("config does not hold the recovered credentials: %+v", creds)
+	}
+	if fileExists(pending[0]) {
+		t.Error("recovery file kept after the credentials were stored")
+	}
+}
+
+func TestRunRegisterCmd_JSONKeepsRecoveryFileUntilPrinted(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv(configDirEnvVar, t.TempDir())
+	fail := true
+	srv, _ := newFlakyRegisterServer(t, &fail)
+
+	if err := runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", jsonOut: true}); err == nil {
+		t.Fatal("expected the registration to fail")
+	}
+	if pending, _ := pendingRegistrations(); len(pending) != 1 {
+		t.Fatalf("--json recovery files = %v, want one", pending)
+	}
+}
+
+func TestRunRegisterCmd_JSONWarnsAboutRecoveryFileInCI(t *testing.T) {
+	clearCIEnv(t)
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv(configDirEnvVar, t.TempDir())
+	fail := true
+	srv, _ := newFlakyRegisterServer(t, &fail)
+
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	os.Stderr = w
+	t.Cleanup(func() { os.Stderr = oldStderr })
+	_ = runRegisterCmd(registerOptions{apiURL: srv.URL, voucher: "v", jsonOut: true})
+	w.Close()
+	os.Stderr = oldStderr
+	var out bytes.Buffer
+	io.Copy(&out, r) //nolint:errcheck
+
+	pending, _ := pendingRegistrations()
+	if len(pending) != 1 {
+		t.Fatalf("recovery files = %v, want one", pending)
+	}
+	if !strings.Contains(out.String(), "running in GitHub Actions") || !strings.Contains(out.String(), "the recovery file ("+pending[0]+")") {
+		t.Errorf("expected a CI warning naming the recovery file, got:\n%s", out.String())
+	}
+}
+
+func TestRunRegisterCmd_RecoveredMCPConfigUsesRegisteredNetwork(t *testing.T) {
+	clearCIEnv(t)
+	dir := t.TempDir()
+	t.Setenv(configDirEnvVar, dir)
+	t.Chdir(t.TempDir())
+
+	// A finished registration against one network, recovered while
+	// --api-url names another: no request is made, so neither is served.
+	kp, _ := KeyPairFromSeed(make([]byte, 32))
+	path, err := writePendingRegistration(kp, "https://api.registered.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := &RegisterResult{KeyPair: kp, Response: &RegisterResponse{IdentityID: "uuid-123", ClientID: "cid", ClientSecret: "csec"}}
+	if err := recordPendingCredentials(path, issued); err != nil {
+		t.Fatal(err)
+	}
+	if err := runRegisterCmd(registerOptions{apiURL: "https://api.other.example", recoverKey: path}); err != nil {
+		t.Fatalf("--recover-key: %v", err)
+	}
+
+	creds, _ := ReadConfigFrom(filepath.Join(dir, "moltnet.json"))
+	if creds == nil || creds.Endpoints.API != "https://api.registered.example" {
+		t.Errorf("config endpoints = %+v, want the registered network", creds)
+	}
+	data, err := os.ReadFile(".mcp.json")
+	if err != nil {
+		t.Fatalf("read .mcp.json: %v", err)
+	}
+	var mcp McpConfig
+	if err := json.Unmarshal(data, &mcp); err != nil {
+		t.Fatalf("parse .mcp.json: %v", err)
+	}
+	if got, want := mcp.McpServers["moltnet"].URL, deriveMCPURL("https://api.registered.example"); got != want {
+		t.Errorf(".mcp.json url = %q, want %q", got, want)
+	}
+}
+
+func TestReadPendingRegistration_RejectsMismatchedKey(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	kp, _ := KeyPairFromSeed(make([]byte, 32))
+	path, err := writePendingRegistration(kp, "https://api.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writePendingRegistration(kp, "https://api.example.com"); err == nil {
+		t.Error("second write overwrote the recovery file")
+	}
+	seed := make([]byte, 32)
+	seed[0] = 1
+	other, _ := KeyPairFromSeed(seed)
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(data), kp.PrivateKey, other.PrivateKey, 1)), 0o600)
+
+	if _, _, err := readPendingRegistration(path); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("error = %v, want a key mismatch", err)
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDoRegister_Success(t *testing.T) {
@@ -101,3 +106,278 @@ func TestDoRegister_NetworkFailure(t *testing.T) {
 		t.Fatal("expected error")
 	}
 }
+
+func TestResolveVoucher_Sources(t *testing.T) {
+	dir := t.TempDir()
+	voucherPath := filepath.Join(dir, "voucher.txt")
+	if err := os.WriteFile(voucherPath, []byte("file-voucher\n"), 0o600); err != nil {
+		t.Fatalf("write voucher file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		flag    string
+		file    string
+		stdin   string
+		env     string
+		want    string
+		wantErr string
+	}{
+		{name: "inline flag", flag: "inline-voucher", want: "inline-voucher"},
+		{name: "stdin", flag: "-", stdin: "  stdin-voucher\n", want: "stdin-voucher"},
+		{name: "file", file: voucherPath, want: "file-voucher"},
+		{name: "env fallback", env: "env-voucher", want: "env-voucher"},
+		{name: "flag wins over env", flag: "inline-voucher", env: "env-voucher", want: "inline-voucher"},
+		{name: "flag and file conflict", flag: "a", file: voucherPath, wantErr: "not both"},
+		{name: "empty stdin", flag: "-", stdin: "\n", wantErr: "voucher code required"},
+		{name: "nothing set", wantErr: "voucher code required"},
+		{name: "missing file", file: filepath.Join(dir, "missing"), wantErr: "read voucher file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(voucherEnvVar, tt.env)
+			got, err := resolveVoucher(tt.flag, tt.file, strings.NewReader(tt.stdin))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error: got %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("voucher: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func testRegisterResult(t *testing.T) *RegisterResult {
+	t.Helper()
+	kp, err := KeyPairFromSeed(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("keypair: %v", err)
+	}
+	return &RegisterResult{
+		KeyPair: kp,
+		Response: &RegisterResponse{
+			IdentityID:   "uuid-123",
+			Fingerprint:  kp.Fingerprint,
+			PublicKey:    kp.PublicKey,
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+		},
+		APIUrl:       "https://api.themolt.net",
+		RegisteredAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func TestRegisterResult_JSONMatchesConfig(t *testing.T) {
+	result := testRegisterResult(t)
+	cfg := result.ToConfig()
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out map[string]string
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	want := map[string]string{
+		"identity_id":   cfg.IdentityID,
+		"fingerprint":   cfg.Keys.Fingerprint,
+		"public_key":    cfg.Keys.PublicKey,
+		"private_key":   cfg.Keys.PrivateKey,
+		"client_id":     cfg.OAuth2.ClientID,
+		"client_secret": cfg.OAuth2.ClientSecret,
+		"api_url":       cfg.Endpoints.API,
+		"mcp_url":       cfg.Endpoints.MCP,
+		"registered_at": "2026-01-02T03:04:05Z",
+	}
+	if len(out) != len(want) {
+		t.Errorf("field count: got %d, want %d (%v)", len(out), len(want), out)
+	}
+	for k, v := range want {
+		if out[k] != v {
+			t.Errorf("%s: got %q, want %q", k, out[k], v)
+		}
+	}
+	if cfg.Endpoints.MCP != "https://mcp.themolt.net/mcp" {
+		t.Errorf("mcp endpoint: got %s", cfg.Endpoints.MCP)
+	}
+}
+
+func TestRegisterResult_Scopes(t *testing.T) {
+	result := testRegisterResult(t)
+	result.Scopes = []string{"diary:read", "entry:read"}
+
+	if got := result.ToConfig().OAuth2.Scopes; len(got) != 2 || got[0] != "diary:read" {
+		t.Errorf("config scopes = %v, want %v", got, result.Scopes)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out struct {
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(out.Scopes) != 2 {
+		t.Errorf("--json scopes = %v, want %v", out.Scopes, result.Scopes)
+	}
+}
+
+func TestRegisterResult_FingerprintMismatch(t *testing.T) {
+	result := testRegisterResult(t)
+	if result.FingerprintMismatch() {
+		t.Error("matching fingerprints reported as mismatch")
+	}
+	result.Response.Fingerprint = "AAAA-BBBB-CCCC-DDDD"
+	if !result.FingerprintMismatch() {
+		t.Error("expected mismatch")
+	}
+	if got := result.ToConfig().Keys.Fingerprint; got != result.KeyPair.Fingerprint {
+		t.Errorf("config fingerprint: got %s, want locally derived %s", got, result.KeyPair.Fingerprint)
+	}
+}
+
+func TestRegisterPrintKeyOnly_WritesSeedAndPrintsPublicKey(t *testing.T) {
+	t.Parallel()
+	keyFile := filepath.Join(t.TempDir(), "moltnet.seed")
+
+	root := NewRootCmd("test", "")
+	out, _, err := executeCommand(root, "register", "--print-key-only", "--key-file", keyFile)
+	if err != nil {
+		t.Fatalf("print-key-only: %v", err)
+	}
+
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatalf("stat key file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("key file perms: got %o, want 600", perm)
+	}
+	kp, err := readSeedFile(keyFile)
+	if err != nil {
+		t.Fatalf("read seed: %v", err)
+	}
+	if got := strings.TrimSpace(out); got != kp.PublicKey {
+		t.Errorf("stdout: got %q, want %q", got, kp.PublicKey)
+	}
+
+	// A second run must not clobber the existing seed.
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "register", "--print-key-only", "--key-file", keyFile); err == nil {
+		t.Error("expected error when key file exists")
+	}
+}
+
+func TestResolveRegisterKeyPair_SubmitPublicKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	kp, err := KeyPairFromSeed(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "moltnet.seed")
+	if err := writeSeedFile(keyFile, kp); err != nil {
+		t.Fatal(err)
+	}
+
+	pubOnly, err := resolveRegisterKeyPair(kp.PublicKey, "")
+	if err != nil {
+		t.Fatalf("public only: %v", err)
+	}
+	if pubOnly.PrivateKey != "" || pubOnly.Fingerprint != kp.Fingerprint {
+		t.Errorf("public only: got %+v", pubOnly)
+	}
+
+	full, err := resolveRegisterKeyPair(kp.PublicKey, keyFile)
+	if err != nil {
+		t.Fatalf("with key file: %v", err)
+	}
+	if full.PrivateKey != kp.PrivateKey {
+		t.Error("expected seed loaded from key file")
+	}
+
+	other, _ := GenerateKeyPair()
+	if _, err := resolveRegisterKeyPair(other.PublicKey, keyFile); err == nil {
+		t.Error("expected mismatch error")
+	}
+	if _, err := resolveRegisterKeyPair("ed25519:AAAA", ""); err == nil {
+		t.Error("expected length error")
+	}
+}
+
+func TestDoRegisterWithKeyPair_SendsGivenPublicKey(t *testing.T) {
+	kp, _ := KeyPairFromSeed(make([]byte, 32))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req RegisterRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.PublicKey != kp.PublicKey {
+			t.Errorf("public key: got %s, want %s", req.PublicKey, kp.PublicKey)
+		}
+		json.NewEncoder(w).Encode(RegisterResponse{IdentityID: "uuid-123", Fingerprint: kp.Fingerprint})
+	}))
+	defer server.Close()
+
+	pubOnly, _ := keyPairFromPublicKey(kp.PublicKey)
+	result, err := DoRegisterWithKeyPair(server.URL, "v", pubOnly)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if result.FingerprintMismatch() {
+		t.Error("unexpected fingerprint mismatch")
+	}
+}
+
+func TestDoRegister_VoucherProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		problem ProblemDetails
+		want    error
+	}{
+		{"typed redeemed", 403, ProblemDetails{Type: "urn:moltnet:problem:voucher-redeemed", Title: "Forbidden"}, errVoucherRedeemed},
+		{"typed expired", 403, ProblemDetails{Type: "urn:moltnet:problem:voucher-expired", Title: "Forbidden"}, errVoucherExpired},
+		{"typed not found", 403, ProblemDetails{Type: "urn:moltnet:problem:voucher-not-found", Title: "Forbidden"}, errVoucherNotFound},
+		{"generic invalid", 403, ProblemDetails{Type: "urn:moltnet:problem:voucher-invalid", Title: "Invalid voucher", Detail: "Already redeemed"}, errVoucherRedeemed},
+		{"registration-failed detail", 403, ProblemDetails{Type: "https://themolt.net/problems/registration-failed", Title: "Registration Failed", Detail: "Voucher has expired"}, errVoucherExpired},
+		{"lost redemption race", 502, ProblemDetails{Type: "https://themolt.net/problems/upstream-error", Title: "Upstream Error", Detail: "Voucher redemption failed during transaction"}, errVoucherRedeemed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(tt.problem)
+			}))
+			defer server.Close()
+
+			_, err := DoRegister(server.URL, "voucher")
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("DoRegister() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoRegister_NonVoucherProblemKeepsDetail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(ProblemDetails{Type: "https://themolt.net/problems/upstream-error", Title: "Upstream Error", Detail: "Kratos unavailable"})
+	}))
+	defer server.Close()
+
+	_, err := DoRegister(server.URL, "voucher")
+
+	if err == nil || !strings.Contains(err.Error(), "Kratos unavailable") || errors.Is(err, errVoucherRedeemed) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
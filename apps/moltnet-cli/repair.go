@@ -13,16 +13,100 @@ import (
 type ConfigIssue struct {
 	Field   string
 	Problem string
-	Action  string // "fixed", "warning", "migrate"
+	Action  string // "fixed", "warning", "migrate", "archive"
+}
+
+// schemaChangesSince returns the registered migrations after fromVersion.
+func schemaChangesSince(fromVersion int) []configMigration {
+	var changes []configMigration
+	for _, m := range configMigrations {
+		if m.Version > fromVersion {
+			changes = append(changes, m)
+		}
+	}
+	return changes
+}
+
+// printSchemaNotes lists the schema changes that apply to a config at
+// fromVersion and, on a real run, what the schema migration changed.
+// Other repairs are reported where they are made, not as migrations.
+func printSchemaNotes(fromVersion int, migrated *configMigrationResult, dryRun bool) {
+	changes := schemaChangesSince(fromVersion)
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nMigration notes (v%d -> v%d):\n", fromVersion, currentConfigSchemaVersion)
+	for _, c := range changes {
+		for _, note := range c.Notes {
+			fmt.Fprintf(os.Stderr, "  v%d: %s\n", c.Version, note)
+		}
+	}
+	if dryRun || migrated == nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Migrated:")
+	fmt.Fprintf(os.Stderr, "  schema_version: v%d -> v%d\n", migrated.FromVersion, currentConfigSchemaVersion)
+	for _, ch := range migrated.Changes {
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", ch.Field, ch.Detail)
+	}
+}
+
+// repairSummary counts what a repair run found and fixed.
+type repairSummary struct {
+	Issues int
+	Fixed  int
 }
 
 // runConfigRepairCmd is the flag-free business logic for config repair.
-func runConfigRepairCmd(credPath string, dryRun bool) error {
+// mcpDir is where .mcp.json is looked up (empty = working directory).
+// sinceVersion overrides the schema version migration notes are computed
+// from (0 = the version recorded in the config). archiveLegacy renames a
+// conflicting legacy credentials.json out of the way.
+func runConfigRepairCmd(credPath, mcpDir string, dryRun bool, sinceVersion int, archiveLegacy bool) error {
+	_, err := repairConfigFile(credPath, mcpDir, dryRun, sinceVersion, archiveLegacy)
+	return err
+}
+
+// repairConfigFile validates and repairs one config, reporting progress on
+// stderr and returning the issue and fix counts.
+func repairConfigFile(credPath, mcpDir string, dryRun bool, sinceVersion int, archiveLegacy bool) (repairSummary, error) {
+	var summary repairSummary
 	resolvedPath, creds, issues, err := loadAndValidate(credPath)
 	if err != nil {
-		return err
+		return summary, err
+	}
+
+	// Detect a legacy credentials.json for another identity behind the
+	// auto-discovered moltnet.json: it takes over if moltnet.json goes away.
+	var conflictPath string
+	if credPath == "" {
+		if iss, path := detectLegacyConfigConflict(resolvedPath, creds); iss != nil {
+			issues = append(issues, *iss)
+			conflictPath = path
+		} else if path != "" {
+			fmt.Fprintf(os.Stderr, "%s is for the same identity as %s; nothing to migrate or archive (it can be deleted).\n", path, filepath.Base(resolvedPath))
+		}
+	}
+	fromVersion := configSchemaVersion(creds)
+	if creds.migrated != nil {
+		fromVersion = creds.migrated.FromVersion
+	}
+	if sinceVersion > 0 {
+		fromVersion = sinceVersion
 	}
 
+	// Detect .mcp.json drift (URL or client credentials no longer matching
+	// the config after a re-register or secret rotation).
+	mcpPath, err := resolveMcpConfigPath(mcpDir)
+	if err != nil {
+		return summary, err
+	}
+	mcpIssues, err := inspectMcpConfig(mcpPath, creds)
+	if err != nil {
+		issues = append(issues, ConfigIssue{Field: "mcp", Problem: err.Error(), Action: "warning"})
+	}
+	issues = append(issues, mcpIssues...)
+
 	// Detect #1396 token pollution in git config files (outside moltnet.json).
 	// On a real (non-dry) run these are stripped in place below.
 	candidates := gitConfigCandidates(creds)
@@ -47,22 +131,41 @@ func runConfigRepairCmd(credPath string, dryRun bool) error {
 		})
 	}
 
+	summary.Issues = len(issues)
 	if len(issues) == 0 {
 		fmt.Fprintln(os.Stderr, "Config is valid, no issues found.")
-		return nil
+		return summary, nil
 	}
 
 	fmt.Fprintf(os.Stderr, "Found %d issue(s):\n", len(issues))
 	for _, iss := range issues {
 		fmt.Fprintf(os.Stderr, "  [%s] %s: %s\n", iss.Action, iss.Field, iss.Problem)
 	}
+	if conflictPath != "" {
+		fmt.Fprintf(os.Stderr, "\nWARNING: %s holds a different identity than %s.\n", conflictPath, resolvedPath)
+		fmt.Fprintf(os.Stderr, "If %s is removed, the CLI silently switches to that other identity.\n", filepath.Base(resolvedPath))
+		if !archiveLegacy || dryRun {
+			fmt.Fprintln(os.Stderr, "Run 'moltnet config repair --archive-legacy' to move it aside.")
+		}
+	}
 
 	if dryRun {
-		return nil
+		printSchemaNotes(fromVersion, creds.migrated, true)
+		return summary, nil
 	}
 
 	fixed := 0
 
+	if conflictPath != "" && archiveLegacy {
+		archived, err := archiveLegacyConfig(conflictPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [warning] could not archive %s: %v\n", conflictPath, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "  [fixed] archived %s to %s\n", conflictPath, archived)
+			fixed++
+		}
+	}
+
 	// Strip token pollution from git config files (file mutations, independent
 	// of the moltnet.json struct rewrite below).
 	for _, p := range tokenPaths {
@@ -90,18 +193,30 @@ func runConfigRepairCmd(credPath string, dryRun bool) error {
 		}
 	}
 
+	// Rewrite the moltnet entry of .mcp.json from the (already fixed) config.
+	if len(mcpIssues) > 0 {
+		changed, err := repairMcpConfig(mcpPath, creds)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [warning] could not repair %s: %v\n", mcpPath, err)
+		} else if changed {
+			fmt.Fprintf(os.Stderr, "  [fixed] refreshed moltnet server entry in %s\n", mcpPath)
+			fixed++
+		}
+	}
+
 	// Apply moltnet.json fixes. Only struct-level changes (in-memory "fixed"
-	// edits and "migrate") gate the WriteConfigTo below; git-config scrubs are
-	// already persisted above and must not force a redundant moltnet.json write.
+	// edits and "migrate") gate the WriteConfigTo below; git-config and
+	// .mcp.json fixes are already persisted above and must not force a
+	// redundant moltnet.json write.
 	jsonChanged := false
 	for _, iss := range issues {
-		if iss.Field == "git-config" {
+		if iss.Field == "git-config" || iss.Field == "mcp" {
 			continue
 		}
 		if iss.Action == "migrate" {
 			newPath, err := migrateConfig(resolvedPath, creds)
 			if err != nil {
-				return fmt.Errorf("migrate: %w", err)
+				return summary, fmt.Errorf("migrate: %w", err)
 			}
 			resolvedPath = newPath
 			jsonChanged = true
@@ -119,15 +234,17 @@ func runConfigRepairCmd(credPath string, dryRun bool) error {
 			writePath = credPath
 		}
 		if _, err := WriteConfigTo(creds, writePath); err != nil {
-			return fmt.Errorf("write config: %w", err)
+			return summary, fmt.Errorf("write config: %w", err)
 		}
 	}
 
+	summary.Fixed = fixed
+	printSchemaNotes(fromVersion, creds.migrated, false)
 	if fixed > 0 {
 		fmt.Fprintf(os.Stderr, "\n%d issue(s) fixed.\n", fixed)
 	}
 
-	return nil
+	return summary, nil
 }
 
 // loadAndValidate reads the config and returns all issues found.
@@ -182,6 +299,25 @@ func loadAndValidate(credPath string) (string, *CredentialsFile, []ConfigIssue,
 		}
 	}
 
+	// Schema version: ReadConfigFrom already migrated older configs in
+	// memory; surface each step so the rewrite below persists it.
+	if m := creds.migrated; m != nil {
+		issues = append(issues, ConfigIssue{
+			Field:   "schema_version",
+			Problem: fmt.Sprintf("v%d — migrated to v%d", m.FromVersion, currentConfigSchemaVersion),
+			Action:  "fixed",
+		})
+		for _, ch := range m.Changes {
+			issues = append(issues, ConfigIssue{Field: ch.Field, Problem: ch.Detail, Action: "fixed"})
+		}
+	} else if v := configSchemaVersion(creds); v > currentConfigSchemaVersion {
+		issues = append(issues, ConfigIssue{
+			Field:   "schema_version",
+			Problem: fmt.Sprintf("v%d is newer than this CLI supports (v%d) — upgrade moltnet", v, currentConfigSchemaVersion),
+			Action:  "warning",
+		})
+	}
+
 	// Required fields
 	if creds.IdentityID == "" {
 		issues = append(issues, ConfigIssue{Field: "identity_id", Problem: "missing", Action: "warning"})
@@ -291,11 +427,54 @@ func checkFilePath(issues *[]ConfigIssue, field, path string) {
 func runConfigRepair(args []string) error {
 	fs := flag.NewFlagSet("config repair", flag.ExitOnError)
 	credPath := fs.String("credentials", "", "Path to moltnet.json")
+	mcpDir := fs.String("mcp-dir", "", "Directory containing .mcp.json")
 	dryRun := fs.Bool("dry-run", false, "Report issues without fixing them")
+	sinceVersion := fs.Int("since-version", 0, "Show migration notes since this schema version")
+	archiveLegacy := fs.Bool("archive-legacy", false, "Archive a legacy credentials.json holding another identity")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
-	return runConfigRepairCmd(*credPath, *dryRun)
+	return runConfigRepairCmd(*credPath, *mcpDir, *dryRun, *sinceVersion, *archiveLegacy)
+}
+
+// detectLegacyConfigConflict reports a credentials.json next to the
+// moltnet.json in use when it belongs to a different identity, and returns
+// its path. A legacy file for the same identity is not an issue: it is
+// returned with a nil issue, as there is nothing to migrate.
+func detectLegacyConfigConflict(configPath string, creds *CredentialsFile) (*ConfigIssue, string) {
+	if filepath.Base(configPath) != "moltnet.json" {
+		return nil, ""
+	}
+	legacyPath := filepath.Join(filepath.Dir(configPath), "credentials.json")
+	legacy, err := ReadConfigFrom(legacyPath)
+	if err != nil {
+		return &ConfigIssue{Field: "file", Problem: fmt.Sprintf("unreadable legacy %s: %v", legacyPath, err), Action: "warning"}, ""
+	}
+	if legacy == nil {
+		return nil, ""
+	}
+	if legacy.IdentityID == creds.IdentityID {
+		return nil, legacyPath
+	}
+	return &ConfigIssue{
+		Field:   "file",
+		Problem: fmt.Sprintf("legacy %s is for identity %q, not %q; it would take over if moltnet.json were removed", legacyPath, legacy.IdentityID, creds.IdentityID),
+		Action:  "archive",
+	}, legacyPath
+}
+
+// archiveLegacyConfig renames a legacy config aside with a timestamp
+// suffix so it is no longer picked up, and returns the new path.
+func archiveLegacyConfig(path string) (string, error) {
+	archived := path + ".archived-" + timeNow().UTC().Format("20060102T150405Z")
+	if _, err := os.Stat(archived); err == nil {
+		return "", fmt.Errorf("%s already exists", archived)
+	}
+	if err := os.Rename(path, archived); err != nil {
+		return "", err
+	}
+	invalidateConfigCache(path)
+	return archived, nil
 }
 
 // repairGitConfigTokens strips embedded GitHub tokens from a git config file.
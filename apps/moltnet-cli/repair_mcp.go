@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mcpServerName is the key of our entry in .mcp.json's mcpServers map.
+const mcpServerName = "moltnet"
+
+// resolveMcpConfigPath returns the .mcp.json path inside dir, defaulting to
+// the working directory — the same place register writes it.
+func resolveMcpConfigPath(dir string) (string, error) {
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("get cwd: %w", err)
+		}
+		dir = cwd
+	}
+	return filepath.Join(dir, ".mcp.json"), nil
+}
+
+// readMcpServer returns the moltnet entry of the .mcp.json at path. ok is
+// false when the file or the entry does not exist.
+func readMcpServer(path string) (server McpServerConfig, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return McpServerConfig{}, false, nil
+		}
+		return McpServerConfig{}, false, fmt.Errorf("read %s: %w", path, err)
+	}
+	var cfg McpConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return McpServerConfig{}, false, fmt.Errorf("parse %s: %w", path, err)
+	}
+	server, ok = cfg.McpServers[mcpServerName]
+	return server, ok, nil
+}
+
+// isMcpEnvPlaceholder reports whether a header value is a ${env:NAME}
+// reference written by register --mask-secrets.
+func isMcpEnvPlaceholder(value string) bool {
+	return strings.HasPrefix(value, "${env:") && strings.HasSuffix(value, "}")
+}
+
+// mcpConfigProblems compares the moltnet entry of .mcp.json against the
+// current credentials. Masked headers are left alone: their values live in
+// the MCP host's environment, which the CLI cannot inspect.
+func mcpConfigProblems(server McpServerConfig, creds *CredentialsFile) []string {
+	var problems []string
+	if want := creds.Endpoints.MCP; want != "" && server.URL != want {
+		problems = append(problems, fmt.Sprintf("url %q does not match endpoints.mcp %q", server.URL, want))
+	}
+	checkHeader := func(name, want string) {
+		got := server.Headers[name]
+		if isMcpEnvPlaceholder(got) || want == "" {
+			return
+		}
+		if got != want {
+			problems = append(problems, fmt.Sprintf("%s does not match current credentials", name))
+		}
+	}
+	checkHeader("X-Client-Id", creds.OAuth2.ClientID)
+	checkHeader("X-Client-Secret", creds.OAuth2.ClientSecret)
+	return problems
+}
+
+// inspectMcpConfig reports drift between .mcp.json and the credentials as
+// repair issues. A missing file or entry is not an issue: writing .mcp.json
+// is optional (register --no-mcp).
+func inspectMcpConfig(path string, creds *CredentialsFile) ([]ConfigIssue, error) {
+	server, ok, err := readMcpServer(path)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var issues []ConfigIssue
+	for _, problem := range mcpConfigProblems(server, creds) {
+		issues = append(issues, ConfigIssue{
+			Field:   "mcp",
+			Problem: fmt.Sprintf("%s: %s", path, problem),
+			Action:  "fixed",
+		})
+	}
+	return issues, nil
+}
+
+// repairMcpConfig rewrites the moltnet entry of .mcp.json from the current
+// credentials, preserving other servers and keeping masked headers masked.
+// Returns true if the file was modified.
+func repairMcpConfig(path string, creds *CredentialsFile) (bool, error) {
+	server, ok, err := readMcpServer(path)
+	if err != nil || !ok {
+		return false, err
+	}
+	if len(mcpConfigProblems(server, creds)) == 0 {
+		return false, nil
+	}
+	mcpURL := creds.Endpoints.MCP
+	if mcpURL == "" {
+		mcpURL = server.URL
+	}
+	cfg := BuildMcpConfig(mcpURL, creds.OAuth2.ClientID, creds.OAuth2.ClientSecret)
+	if isMcpEnvPlaceholder(server.Headers["X-Client-Secret"]) {
+		cfg = BuildMaskedMcpConfig(mcpURL)
+	}
+	if _, err := WriteMcpConfig(cfg, filepath.Dir(path)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
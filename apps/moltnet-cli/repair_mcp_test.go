@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mcpRepairCreds() *CredentialsFile {
+	return &CredentialsFile{
+		OAuth2: CredentialsOAuth2{ClientID: "new-id", ClientSecret: "new-secret"},
+		Endpoints: CredentialsEndpoints{
+			API: "https://api.themolt.net",
+			MCP: "https://mcp.themolt.net/mcp",
+		},
+	}
+}
+
+func TestInspectMcpConfig_MissingFileIsNotAnIssue(t *testing.T) {
+	issues, err := inspectMcpConfig(filepath.Join(t.TempDir(), ".mcp.json"), mcpRepairCreds())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestInspectMcpConfig_InSync(t *testing.T) {
+	dir := t.TempDir()
+	creds := mcpRepairCreds()
+	path, err := WriteMcpConfig(BuildMcpConfig(creds.Endpoints.MCP, creds.OAuth2.ClientID, creds.OAuth2.ClientSecret), dir)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	issues, err := inspectMcpConfig(path, creds)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestRepairMcpConfig_FixesStaleEntry(t *testing.T) {
+	dir := t.TempDir()
+	stale := `{
+  "mcpServers": {
+    "moltnet": {"type": "http", "url": "https://mcp.old.net/mcp", "headers": {"X-Client-Id": "old-id", "X-Client-Secret": "old-secret"}},
+    "other": {"type": "http", "url": "http://other.com/mcp"}
+  }
+}
+`
+	path := filepath.Join(dir, ".mcp.json")
+	if err := os.WriteFile(path, []byte(stale), 0o644); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	creds := mcpRepairCreds()
+
+	issues, err := inspectMcpConfig(path, creds)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues (url, id, secret), got %d: %v", len(issues), issues)
+	}
+
+	changed, err := repairMcpConfig(path, creds)
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if !changed {
+		t.Fatal("expected repair to report a change")
+	}
+
+	server, ok, err := readMcpServer(path)
+	if err != nil || !ok {
+		t.Fatalf("read back: ok=%v err=%v", ok, err)
+	}
+	if server.URL != creds.Endpoints.MCP {
+		t.Errorf("url: got %s", server.URL)
+	}
+	if server.Headers["X-Client-Id"] != "new-id" || server.Headers["X-Client-Secret"] != "new-secret" {
+		t.Errorf("headers not refreshed: %v", server.Headers)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"other"`) {
+		t.Error("unrelated server was dropped")
+	}
+
+	changed, err = repairMcpConfig(path, creds)
+	if err != nil || changed {
+		t.Fatalf("second pass: want (false,nil) got (%v,%v)", changed, err)
+	}
+}
+
+func TestRepairMcpConfig_KeepsMaskedHeaders(t *testing.T) {
+	dir := t.TempDir()
+	path, err := WriteMcpConfig(BuildMaskedMcpConfig("https://mcp.old.net/mcp"), dir)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	creds := mcpRepairCreds()
+
+	issues, err := inspectMcpConfig(path, creds)
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if len(issues) != 1 || !strings.Contains(issues[0].Problem, "url") {
+		t.Fatalf("expected a single url issue, got %v", issues)
+	}
+
+	if _, err := repairMcpConfig(path, creds); err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	server, _, _ := readMcpServer(path)
+	if server.URL != creds.Endpoints.MCP {
+		t.Errorf("url: got %s", server.URL)
+	}
+	if !isMcpEnvPlaceholder(server.Headers["X-Client-Secret"]) {
+		t.Errorf("masked secret was inlined: %q", server.Headers["X-Client-Secret"])
+	}
+}
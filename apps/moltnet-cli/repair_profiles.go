@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// runConfigRepairAllProfilesCmd validates and repairs every agent profile
+// (.moltnet/<agent>/moltnet.json) found from dir, then prints a per-profile
+// summary.
+//
+// Each profile's .mcp.json is looked up in its own agent directory rather
+// than the working directory: a shared repo-root .mcp.json can only hold
+// one moltnet entry, and repairing it once per profile would leave it
+// pointing at whichever agent happened to be last.
+//
+// Under bulkFailFast the first profile that fails stops the run.
+func runConfigRepairAllProfilesCmd(dir string, dryRun bool, sinceVersion int, mode bulkMode) error {
+	moltnetDir, err := resolveMoltnetDir(dir)
+	if err != nil {
+		return err
+	}
+	agents, err := listAgentNames(moltnetDir)
+	if err != nil {
+		return err
+	}
+	if len(agents) == 0 {
+		return fmt.Errorf("no agents found in %s — run 'legreffier init --name <agent>'", moltnetDir)
+	}
+
+	type profileResult struct {
+		name    string
+		summary repairSummary
+		err     error
+	}
+	results := make([]profileResult, 0, len(agents))
+	run := newBulkRunner(mode)
+	for _, name := range agents {
+		agentDir := filepath.Join(moltnetDir, name)
+		fmt.Fprintf(os.Stderr, "== %s ==\n", name)
+		summary, err := repairConfigFile(filepath.Join(agentDir, "moltnet.json"), agentDir, dryRun, sinceVersion, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  [error] %v\n", err)
+		}
+		fmt.Fprintln(os.Stderr)
+		results = append(results, profileResult{name: name, summary: summary, err: err})
+		if err != nil {
+			if run.fail(name, err) {
+				break
+			}
+			continue
+		}
+		run.succeed()
+	}
+
+	fmt.Fprintf(os.Stderr, "Summary (%d of %d profile(s)):\n", len(results), len(agents))
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			fmt.Fprintf(os.Stderr, "  %-20s error: %v\n", r.name, r.err)
+		case r.summary.Issues == 0:
+			fmt.Fprintf(os.Stderr, "  %-20s ok\n", r.name)
+		case dryRun:
+			fmt.Fprintf(os.Stderr, "  %-20s %d issue(s)\n", r.name, r.summary.Issues)
+		default:
+			fmt.Fprintf(os.Stderr, "  %-20s %d issue(s), %d fixed\n", r.name, r.summary.Issues, r.summary.Fixed)
+		}
+	}
+	if err := printJSON(run.summary); err != nil {
+		return err
+	}
+	return run.err("config repair")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConfigRepairAllProfiles_RepairsEachProfile(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"alpha", "beta"} {
+		agentDir := filepath.Join(root, ".moltnet", name)
+		if err := os.MkdirAll(agentDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestConfig(t, agentDir, "moltnet.json", CredentialsFile{
+			IdentityID: name,
+			Keys:       CredentialsKeys{PublicKey: "ed25519:abc=", PrivateKey: "abc="},
+			Endpoints:  CredentialsEndpoints{API: "https://api.themolt.net"},
+		})
+	}
+	// A directory without moltnet.json is not a profile.
+	if err := os.MkdirAll(filepath.Join(root, ".moltnet", "scratch"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runConfigRepairAllProfilesCmd(root, false, 0, bulkContinue); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"alpha", "beta"} {
+		updated, err := ReadConfigFrom(filepath.Join(root, ".moltnet", name, "moltnet.json"))
+		if err != nil {
+			t.Fatalf("%s: read config: %v", name, err)
+		}
+		if updated.Endpoints.MCP != "https://mcp.themolt.net/mcp" {
+			t.Errorf("%s: MCP endpoint = %q", name, updated.Endpoints.MCP)
+		}
+	}
+}
+
+func TestRunConfigRepairAllProfiles_ReportsBrokenProfile(t *testing.T) {
+	root := t.TempDir()
+	broken := filepath.Join(root, ".moltnet", "broken")
+	if err := os.MkdirAll(broken, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(broken, "moltnet.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runConfigRepairAllProfilesCmd(root, true, 0, bulkContinue)
+	if err == nil || !strings.Contains(err.Error(), "1 item(s) failed") {
+		t.Fatalf("expected per-profile failure, got %v", err)
+	}
+}
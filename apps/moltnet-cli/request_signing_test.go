@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestSigningTransport_ServerCanVerify(t *testing.T) {
+	t.Parallel()
+	// Arrange
+	kp, err := KeyPairFromSeed(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := newRequestSigner(&CredentialsFile{Keys: CredentialsKeys{PrivateKey: kp.PrivateKey, PublicKey: kp.PublicKey}})
+	if err != nil {
+		t.Fatalf("newRequestSigner: %v", err)
+	}
+	signer.now = func() time.Time { return time.Unix(1767225600, 0) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(requestTimestampHeader), 10, 64)
+		msg := requestSigningMessage(r.Method, r.URL.RequestURI(), body, ts)
+		ok, err := VerifyForRequest(msg, r.Header.Get(requestNonceHeader), r.Header.Get(requestSignatureHeader), kp.PublicKey)
+		if err != nil || !ok {
+			t.Errorf("signature did not verify (%v)", err)
+		}
+		if r.Header.Get(requestFingerprintHeader) != kp.Fingerprint {
+			t.Errorf("fingerprint header = %q", r.Header.Get(requestFingerprintHeader))
+		}
+		if ts != 1767225600 || string(body) != `{"content":"hi"}` {
+			t.Errorf("timestamp %d, body %q", ts, body)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: newRequestSigningTransport(nil, signer)}
+
+	// Act
+	resp, err := client.Post(srv.URL+"/diaries/x/entries?limit=1", "application/json", strings.NewReader(`{"content":"hi"}`))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestRequestSigner_FreshNoncePerRequest(t *testing.T) {
+	t.Parallel()
+	kp, _ := KeyPairFromSeed(bytes.Repeat([]byte{1}, 32))
+	signer, _ := newRequestSigner(&CredentialsFile{Keys: CredentialsKeys{PrivateKey: kp.PrivateKey, Fingerprint: kp.Fingerprint}})
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/agents/whoami", nil)
+
+	a, errA := signer.sign(req)
+	b, errB := signer.sign(req)
+
+	if errA != nil || errB != nil {
+		t.Fatalf("sign: %v / %v", errA, errB)
+	}
+	if a.Header.Get(requestNonceHeader) == b.Header.Get(requestNonceHeader) {
+		t.Error("expected distinct nonces")
+	}
+	if req.Header.Get(requestSignatureHeader) != "" {
+		t.Error("sign must not mutate the original request")
+	}
+}
+
+func TestNewRequestSigner_RequiresPrivateKey(t *testing.T) {
+	t.Parallel()
+	if _, err := newRequestSigner(&CredentialsFile{}); err == nil || !strings.Contains(err.Error(), "private_key") {
+		t.Errorf("expected private key error, got %v", err)
+	}
+}
+
+func TestSignRequestsEnabled(t *testing.T) {
+	t.Cleanup(func() { setSignRequests(false) })
+
+	if signRequestsEnabled(&CredentialsFile{}) {
+		t.Error("signing should be off by default")
+	}
+	if !signRequestsEnabled(&CredentialsFile{Requests: &RequestsSection{Sign: true}}) {
+		t.Error("requests.sign should enable signing")
+	}
+	setSignRequests(true)
+	if !signRequestsEnabled(nil) {
+		t.Error("--sign-requests should enable signing")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultMaxResponseSize bounds how much of a response body the CLI will
+// read, so a misbehaving server cannot make it buffer gigabytes.
+const defaultMaxResponseSize int64 = 32 << 20
+
+// largeMaxResponseSize is the default for list and export commands, which
+// legitimately expect big pages; see withLargeResponses.
+const largeMaxResponseSize int64 = 256 << 20
+
+// maxResponseSize holds the --max-response-size set by the root command.
+// When unset the per-command default applies; 0 disables the limit.
+var maxResponseSize atomic.Pointer[int64]
+
+// setMaxResponseSize validates and stores --max-response-size. An empty
+// value restores the defaults.
+func setMaxResponseSize(raw string) error {
+	if raw == "" {
+		maxResponseSize.Store(nil)
+		return nil
+	}
+	n, err := parseByteSize(raw)
+	if err != nil {
+		return fmt.Errorf("invalid --max-response-size %q: want a size like 64MB, 512KB or a byte count (0 disables)", raw)
+	}
+	maxResponseSize.Store(&n)
+	return nil
+}
+
+// currentMaxResponseSize returns the limit for a client: --max-response-size
+// when given, otherwise the large or regular default.
+func currentMaxResponseSize(large bool) int64 {
+	if p := maxResponseSize.Load(); p != nil {
+		return *p
+	}
+	if large {
+		return largeMaxResponseSize
+	}
+	return defaultMaxResponseSize
+}
+
+// parseByteSize parses a byte count with an optional KB/MB/GB suffix
+// (binary multiples; KiB/MiB/GiB are accepted too).
+func parseByteSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/mult {
+		return 0, fmt.Errorf("invalid size %q", raw)
+	}
+	return n * mult, nil
+}
+
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%dGB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// responseTooLargeError is returned when a response body exceeds the limit.
+type responseTooLargeError struct {
+	limit int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeded limit of %s; raise it with --max-response-size", formatByteSize(e.limit))
+}
+
+// limitedBody fails reads once more than limit bytes have been read,
+// instead of silently truncating like io.LimitReader.
+type limitedBody struct {
+	rc    io.ReadCloser
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func newLimitedBody(rc io.ReadCloser, limit int64) *limitedBody {
+	// One byte past the limit tells "exactly at the limit" from "over it".
+	return &limitedBody{rc: rc, r: io.LimitReader(rc, limit+1), limit: limit}
+}
+
+// limitResponseBody applies the limit to a body read outside
+// responseLimitTransport; a limit of 0 or less leaves it unbounded.
+func limitResponseBody(rc io.ReadCloser, limit int64) io.ReadCloser {
+	if limit <= 0 {
+		return rc
+	}
+	return newLimitedBody(rc, limit)
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &responseTooLargeError{limit: b.limit}
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
+
+// responseLimitTransport caps the size of every response body. Responses
+// that announce an oversized Content-Length are rejected before reading.
+type responseLimitTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+// newResponseLimitTransport wraps base with a body size limit; a limit of
+// 0 or less returns base unchanged.
+func newResponseLimitTransport(base http.RoundTripper, limit int64) http.RoundTripper {
+	if base == nil {
+		base = baseTransport()
+	}
+	if limit <= 0 {
+		return base
+	}
+	return &responseLimitTransport{base: base, limit: limit}
+}
+
+func (t *responseLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, &responseTooLargeError{limit: t.limit}
+	}
+	resp.Body = newLimitedBody(resp.Body, t.limit)
+	return resp, nil
+}
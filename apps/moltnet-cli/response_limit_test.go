@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1048576", 1 << 20, false},
+		{"64MB", 64 << 20, false},
+		{"512kb", 512 << 10, false},
+		{"2GiB", 2 << 30, false},
+		{"0", 0, false},
+		{"-1", 0, true},
+		{"lots", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestResponseLimitTransport(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := strings.Repeat("x", 2048)
+		if r.URL.Path == "/chunked" {
+			// Flushing first forces chunked encoding, so no Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+	var tooLarge *responseTooLargeError
+
+	t.Run("content length", func(t *testing.T) {
+		client := &http.Client{Transport: newResponseLimitTransport(nil, 1024)}
+		_, err := client.Get(srv.URL + "/sized")
+		if !errors.As(err, &tooLarge) || !strings.Contains(err.Error(), "1KB") {
+			t.Errorf("expected limit error, got %v", err)
+		}
+	})
+	t.Run("streamed", func(t *testing.T) {
+		client := &http.Client{Transport: newResponseLimitTransport(nil, 1024)}
+		resp, err := client.Get(srv.URL + "/chunked")
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if !errors.As(err, &tooLarge) || len(data) != 1024 {
+			t.Errorf("expected limit error after 1024 bytes, got %d bytes, %v", len(data), err)
+		}
+	})
+	t.Run("within limit", func(t *testing.T) {
+		client := &http.Client{Transport: newResponseLimitTransport(nil, 2048)}
+		resp, err := client.Get(srv.URL + "/chunked")
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		defer resp.Body.Close()
+		if data, err := io.ReadAll(resp.Body); err != nil || len(data) != 2048 {
+			t.Errorf("got %d bytes, %v", len(data), err)
+		}
+	})
+}
+
+func TestCurrentMaxResponseSize(t *testing.T) {
+	t.Cleanup(func() { _ = setMaxResponseSize("") })
+
+	if currentMaxResponseSize(false) != defaultMaxResponseSize || currentMaxResponseSize(true) != largeMaxResponseSize {
+		t.Error("unexpected defaults")
+	}
+	if err := setMaxResponseSize("8MB"); err != nil {
+		t.Fatal(err)
+	}
+	if currentMaxResponseSize(true) != 8<<20 {
+		t.Error("explicit --max-response-size should override the large default")
+	}
+	if err := setMaxResponseSize("big"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
@@ -81,10 +81,10 @@ type retryTransport struct {
 
 // NewRetryTransport creates an http.RoundTripper that retries on 429 (all methods)
 // and 408/5xx (idempotent methods only) with exponential backoff and Retry-After support.
-// Pass nil for base to use http.DefaultTransport. Pass nil for cfg for defaults.
+// Pass nil for base to use baseTransport. Pass nil for cfg for defaults.
 func NewRetryTransport(base http.RoundTripper, cfg *RetryConfig) http.RoundTripper {
 	if base == nil {
-		base = http.DefaultTransport
+		base = baseTransport()
 	}
 	return &retryTransport{base: base, cfg: cfg}
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"crypto/ed25519"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -20,21 +19,20 @@ func runSignCmd(w io.Writer, credPath, apiURL, nonce, requestID string, args []s
 
 	// --request-id: one-shot fetch + sign + submit
 	if requestID != "" {
-		if creds.OAuth2.ClientID == "" || creds.OAuth2.ClientSecret == "" {
-			return fmt.Errorf("credentials missing client_id or client_secret — run 'moltnet register'")
+		if err := requireClientCredentials(creds, credPath); err != nil {
+			return err
 		}
 		client, err := newClientFromCreds(apiURL, credPath)
 		if err != nil {
 			return err
 		}
-		sig, err := signWithRequestID(client, requestID, creds.Keys.PrivateKey)
+		req, sig, err := signRequest(client, requestID, creds.Keys.PrivateKey)
 		if err != nil {
 			return err
 		}
 		fmt.Fprintf(os.Stderr, "Signature submitted for request %s\n", requestID)
 		// Print the base64 signature to stdout so callers can capture it
-		fmt.Fprint(w, sig)
-		return nil
+		return writeSignOutput(w, signOutput{Signature: sig, Nonce: req.Nonce.String(), PublicKey: creds.Keys.PublicKey, RequestID: requestID})
 	}
 
 	// Manual mode: --nonce + message positional arg
@@ -51,9 +49,30 @@ func runSignCmd(w io.Writer, credPath, apiURL, nonce, requestID string, args []s
 	if err != nil {
 		return fmt.Errorf("sign: %w", err)
 	}
+	return writeSignOutput(w, signOutput{Signature: sig, Nonce: nonce, PublicKey: creds.Keys.PublicKey})
+}
 
-	fmt.Fprint(w, sig)
-	return nil
+// signOutput is what sign prints under --output json (or jsonl, table,
+// template).
+type signOutput struct {
+	Signature string `json:"signature"`
+	Nonce     string `json:"nonce"`
+	PublicKey string `json:"public_key"`
+	Algorithm string `json:"algorithm"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeSignOutput prints the bare base64 signature, without a trailing
+// newline, unless --output asks for a structured format. Unlike other
+// commands, sign defaults to text: scripts capture its output as is.
+func writeSignOutput(w io.Writer, out signOutput) error {
+	switch requestedOutputFormat() {
+	case "", outputFormatText:
+		_, err := fmt.Fprint(w, out.Signature)
+		return err
+	}
+	out.Algorithm = "ed25519"
+	return printJSONTo(w, out)
 }
 
 // readPayload gets the payload from args or stdin.
@@ -76,22 +95,15 @@ func readPayload(args []string) (string, error) {
 	return args[0], nil
 }
 
-// loadCredentials reads credentials from the given path or the default location.
+// loadCredentials reads credentials from the given path, the default
+// location, or the environment (see resolveCredentials).
 func loadCredentials(path string) (*CredentialsFile, error) {
-	var creds *CredentialsFile
-	var err error
-
-	if path != "" {
-		creds, err = ReadConfigFrom(path)
-	} else {
-		creds, err = ReadConfig()
-	}
-
+	creds, err := resolveCredentials(path)
 	if err != nil {
 		return nil, fmt.Errorf("read credentials: %w", err)
 	}
 	if creds == nil {
-		return nil, fmt.Errorf("no credentials found — run 'moltnet register' first")
+		return nil, expectedErrorf("no credentials found — run 'moltnet register' first")
 	}
 	return creds, nil
 }
@@ -100,44 +112,45 @@ func loadCredentials(path string) (*CredentialsFile, error) {
 // Use when the server has already computed signing_input (base64-decoded).
 // The private key is stored as a base64-encoded 32-byte seed.
 func signRawBytes(rawBytes []byte, privateKeyBase64 string) (string, error) {
-	seed, err := base64.StdEncoding.DecodeString(privateKeyBase64)
-	if err != nil {
-		return "", fmt.Errorf("decode private key: %w", err)
-	}
-	priv := ed25519.NewKeyFromSeed(seed)
-	sig := ed25519.Sign(priv, rawBytes)
-	return base64.StdEncoding.EncodeToString(sig), nil
+	return signWithSeed(privateKeyBase64, rawBytes)
 }
 
 // signWithRequestID fetches a signing request by ID, signs the payload, and submits the signature.
 // Returns the base64-encoded signature on success.
 func signWithRequestID(client *moltnetapi.Client, requestID, privateKey string) (string, error) {
+	_, sig, err := signRequest(client, requestID, privateKey)
+	return sig, err
+}
+
+// signRequest is signWithRequestID that also returns the signing request,
+// as fetched before signing.
+func signRequest(client *moltnetapi.Client, requestID, privateKey string) (*moltnetapi.SigningRequest, string, error) {
 	rid, err := uuid.Parse(requestID)
 	if err != nil {
-		return "", fmt.Errorf("invalid request ID %q: %w", requestID, err)
+		return nil, "", fmt.Errorf("invalid request ID %q: %w", requestID, err)
 	}
 
 	// Fetch the signing request
 	res, err := client.GetSigningRequest(context.Background(), moltnetapi.GetSigningRequestParams{ID: rid})
 	if err != nil {
-		return "", fmt.Errorf("fetch signing request: %w", formatTransportError(err))
+		return nil, "", fmt.Errorf("fetch signing request: %w", formatTransportError(err))
 	}
 	req, ok := res.(*moltnetapi.SigningRequest)
 	if !ok {
-		return "", formatAPIError(res)
+		return nil, "", formatAPIError(res)
 	}
 	if req.Status != moltnetapi.SigningRequestStatusPending {
-		return "", fmt.Errorf("signing request %s is not pending (status: %s)", requestID, req.Status)
+		return nil, "", expectedErrorf("signing request %s is not pending (status: %s)", requestID, req.Status)
 	}
 
 	// Decode server-provided signing_input and sign the raw bytes directly.
 	rawBytes, err := base64.StdEncoding.DecodeString(req.SigningInput)
 	if err != nil {
-		return "", fmt.Errorf("decode signing_input: %w", formatTransportError(err))
+		return nil, "", fmt.Errorf("decode signing_input: %w", formatTransportError(err))
 	}
 	sig, err := signRawBytes(rawBytes, privateKey)
 	if err != nil {
-		return "", fmt.Errorf("sign: %w", formatTransportError(err))
+		return nil, "", fmt.Errorf("sign: %w", formatTransportError(err))
 	}
 
 	// Submit
@@ -146,7 +159,7 @@ func signWithRequestID(client *moltnetapi.Client, requestID, privateKey string)
 		moltnetapi.SubmitSignatureParams{ID: rid},
 	)
 	if err != nil {
-		return "", fmt.Errorf("submit signature: %w", formatTransportError(err))
+		return nil, "", fmt.Errorf("submit signature: %w", formatTransportError(err))
 	}
-	return sig, nil
+	return req, sig, nil
 }
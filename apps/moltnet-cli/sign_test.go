@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,6 +67,50 @@ func TestRunSignWithCredentialsFile(t *testing.T) {
 	}
 }
 
+func TestRunSignCmd_OutputFormats(t *testing.T) {
+	kp, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("generate keypair: %v", err)
+	}
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+	data, _ := json.Marshal(CredentialsFile{IdentityID: "test-identity", Keys: CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey, Fingerprint: kp.Fingerprint}})
+	if err := os.WriteFile(credPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = setOutputFormat("", "") })
+
+	var bare []string
+	for _, format := range []string{"", "text"} {
+		if err := setOutputFormat(format, ""); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := runSignCmd(&out, credPath, "", "nonce-1", "", []string{"hello"}); err != nil {
+			t.Fatalf("--output %q: %v", format, err)
+		}
+		bare = append(bare, out.String())
+	}
+	if bare[0] == "" || bare[0] != bare[1] || strings.ContainsAny(bare[0], "{\n") {
+		t.Errorf("default and text output = %q, want the same bare signature", bare)
+	}
+
+	if err := setOutputFormat("json", ""); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := runSignCmd(&out, credPath, "", "nonce-1", "", []string{"hello"}); err != nil {
+		t.Fatalf("--output json: %v", err)
+	}
+	var got signOutput
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q: %v", out.String(), err)
+	}
+	want := signOutput{Signature: bare[0], Nonce: "nonce-1", PublicKey: kp.PublicKey, Algorithm: "ed25519"}
+	if got != want {
+		t.Errorf("json output = %+v, want %+v", got, want)
+	}
+}
+
 func TestReadPayloadFromArgs(t *testing.T) {
 	payload, err := readPayload([]string{"hello message"})
 	if err != nil {
@@ -2,7 +2,6 @@ package main
 
 import (
 	"crypto/ed25519"
-	"encoding/base64"
 	"encoding/pem"
 	"flag"
 	"fmt"
@@ -31,22 +30,41 @@ func ToSSHPublicKey(moltnetPublicKey string) (string, error) {
 
 // ToSSHPrivateKey converts a base64-encoded Ed25519 seed to OpenSSH PEM private key format.
 func ToSSHPrivateKey(seedBase64 string) (string, error) {
-	seed, err := base64.StdEncoding.DecodeString(seedBase64)
+	var pemBytes []byte
+	err := withPrivateKey(seedBase64, func(priv ed25519.PrivateKey) error {
+		pemBlock, err := gossh.MarshalPrivateKey(priv, "")
+		if err != nil {
+			return fmt.Errorf("marshal private key: %w", err)
+		}
+		defer zeroBytes(pemBlock.Bytes)
+		pemBytes = pem.EncodeToMemory(pemBlock)
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("decode seed: %w", err)
-	}
-	if len(seed) != ed25519.SeedSize {
-		return "", fmt.Errorf("seed must be 32 bytes, got %d", len(seed))
+		return "", err
 	}
+	defer zeroBytes(pemBytes)
+	return string(pemBytes), nil
+}
 
-	priv := ed25519.NewKeyFromSeed(seed)
-	pemBlock, err := gossh.MarshalPrivateKey(priv, "")
+// writeSSHKeyPair writes the identity keys as an OpenSSH private key and
+// an authorized_keys line.
+func writeSSHKeyPair(keys CredentialsKeys, privPath, pubPath string) error {
+	pubSSH, err := ToSSHPublicKey(keys.PublicKey)
 	if err != nil {
-		return "", fmt.Errorf("marshal private key: %w", err)
+		return fmt.Errorf("convert public key: %w", err)
 	}
-
-	pemBytes := pem.EncodeToMemory(pemBlock)
-	return string(pemBytes), nil
+	privPEM, err := ToSSHPrivateKey(keys.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("convert private key: %w", err)
+	}
+	if err := os.WriteFile(privPath, []byte(privPEM), 0o600); err != nil {
+		return fmt.Errorf("write private key: %w", err)
+	}
+	if err := os.WriteFile(pubPath, []byte(pubSSH+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write public key: %w", err)
+	}
+	return nil
 }
 
 // runSSHKeyExportCmd exports the MoltNet identity as SSH key files.
@@ -73,26 +91,10 @@ func runSSHKeyExportCmd(credPath, outDir string) error {
 		return fmt.Errorf("create output dir: %w", err)
 	}
 
-	// Generate SSH keys
-	pubSSH, err := ToSSHPublicKey(creds.Keys.PublicKey)
-	if err != nil {
-		return fmt.Errorf("convert public key: %w", err)
-	}
-
-	privPEM, err := ToSSHPrivateKey(creds.Keys.PrivateKey)
-	if err != nil {
-		return fmt.Errorf("convert private key: %w", err)
-	}
-
-	// Write files
 	privPath := filepath.Join(dir, "id_ed25519")
-	if err := os.WriteFile(privPath, []byte(privPEM), 0o600); err != nil {
-		return fmt.Errorf("write private key: %w", err)
-	}
-
 	pubPath := filepath.Join(dir, "id_ed25519.pub")
-	if err := os.WriteFile(pubPath, []byte(pubSSH+"\n"), 0o644); err != nil {
-		return fmt.Errorf("write public key: %w", err)
+	if err := writeSSHKeyPair(creds.Keys, privPath, pubPath); err != nil {
+		return err
 	}
 
 	fmt.Fprintf(os.Stderr, "SSH private key written to %s\n", privPath)
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// runMigrateSSHCmd brings the exported SSH key, allowed_signers and
+// gitconfig back in line with the identity key after it changed (a key
+// rotation or a re-import). The key is re-exported to the paths recorded in
+// the config and parsed back to check it matches. In allowed_signers the
+// new key is trusted and each retired one is kept with a valid-before
+// option, so commits signed before the rotation still verify.
+func runMigrateSSHCmd(w io.Writer, credPath string) error {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return err
+	}
+	if creds.SSH == nil {
+		return expectedErrorf("SSH keys not exported — run 'moltnet ssh-key' first")
+	}
+	pubSSH, err := ToSSHPublicKey(creds.Keys.PublicKey)
+	if err != nil {
+		return fmt.Errorf("convert public key: %w", err)
+	}
+	if verifyExportedSSHKey(creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath, pubSSH) == nil {
+		fmt.Fprintf(w, "SSH key %s already matches the identity key\n", creds.SSH.PublicKeyPath)
+		return nil
+	}
+
+	if err := writeSSHKeyPair(creds.Keys, creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath); err != nil {
+		return err
+	}
+	if err := verifyExportedSSHKey(creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath, pubSSH); err != nil {
+		return fmt.Errorf("migrate-ssh: re-exported key does not match the identity key: %w", err)
+	}
+	fmt.Fprintf(w, "SSH key re-exported to %s\n", creds.SSH.PrivateKeyPath)
+
+	if creds.Git == nil {
+		fmt.Fprintln(w, "Git signing is not configured; run 'moltnet git setup' to set it up")
+		return nil
+	}
+	configDir, err := gitConfigDir(credPath)
+	if err != nil {
+		return err
+	}
+	existing, err := os.ReadFile(gitAllowedSignersPath(configDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read allowed_signers: %w", err)
+	}
+	signers := migrateAllowedSigners(string(existing), creds.Git.Email, pubSSH, timeNow())
+	signersPath, gitconfigPath, err := writeGitSigningConfig(configDir, creds.Git.Name, creds.Git.Email, creds.SSH.PublicKeyPath, signers)
+	if err != nil {
+		return err
+	}
+	creds.Git.ConfigPath = gitconfigPath
+	if credPath != "" {
+		_, err = WriteConfigTo(creds, credPath)
+	} else {
+		_, err = WriteConfig(creds)
+	}
+	if err != nil {
+		return fmt.Errorf("update config: %w", err)
+	}
+	fmt.Fprintf(w, "Updated %s and %s\n", signersPath, gitconfigPath)
+	return nil
+}
+
+// verifyExportedSSHKey parses the key files back and checks both hold
+// pubSSH (an authorized_keys line).
+func verifyExportedSSHKey(privPath, pubPath, pubSSH string) error {
+	want, _, _, _, err := gossh.ParseAuthorizedKey([]byte(pubSSH))
+	if err != nil {
+		return fmt.Errorf("parse identity key: %w", err)
+	}
+	pubData, err := os.ReadFile(pubPath)
+	if err != nil {
+		return err
+	}
+	pub, _, _, _, err := gossh.ParseAuthorizedKey(pubData)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", pubPath, err)
+	}
+	if !bytes.Equal(pub.Marshal(), want.Marshal()) {
+		return fmt.Errorf("%s holds a different key", pubPath)
+	}
+	privData, err := os.ReadFile(privPath)
+	if err != nil {
+		return err
+	}
+	signer, err := gossh.ParsePrivateKey(privData)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", privPath, err)
+	}
+	if !bytes.Equal(signer.PublicKey().Marshal(), want.Marshal()) {
+		return fmt.Errorf("%s holds a different key", privPath)
+	}
+	return nil
+}
+
+// migrateAllowedSigners rewrites allowed_signers content for a new key:
+// the new key comes first for email, and every other key line is kept but
+// stamped valid-before now (unless it already has an expiry), so git only
+// accepts it for signatures made before the rotation.
+func migrateAllowedSigners(existing, email, pubSSH string, now time.Time) string {
+	newKey := strings.Join(strings.Fields(pubSSH)[:2], " ")
+	validBefore := fmt.Sprintf("valid-before=%q", now.UTC().Format("20060102150405")+"Z")
+	lines := []string{email + " " + pubSSH}
+	for _, line := range strings.Split(existing, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		keyAt := 1
+		if len(fields) > 1 && !isSSHKeyType(fields[1]) {
+			keyAt = 2
+		}
+		if strings.HasPrefix(line, "#") || len(fields) <= keyAt+1 {
+			lines = append(lines, line)
+			continue
+		}
+		if fields[keyAt]+" "+fields[keyAt+1] == newKey {
+			continue
+		}
+		switch {
+		case keyAt == 1:
+			fields = append(fields[:1], append([]string{validBefore}, fields[1:]...)...)
+		case !strings.Contains(fields[1], "valid-before="):
+			fields[1] = validBefore + "," + fields[1]
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// isSSHKeyType reports whether field is an SSH key algorithm name rather
+// than an allowed_signers options list.
+func isSSHKeyType(field string) bool {
+	for _, prefix := range []string{"ssh-", "ecdsa-sha2-", "sk-", "rsa-sha2-"} {
+		if strings.HasPrefix(field, prefix) {
+			return true
+		}
+	}
+	return false
+}
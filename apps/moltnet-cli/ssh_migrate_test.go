@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateAllowedSigners(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+	existing := "# team signers\n" +
+		"agent@example.com ssh-ed25519 AAAAold\n" +
+		"agent@example.com ssh-ed25519 AAAAnew\n" +
+		`ci@example.com namespaces="git" ssh-ed25519 AAAAci` + "\n" +
+		`old@example.com valid-before="20250101" ssh-ed25519 AAAAexpired` + "\n"
+
+	got := migrateAllowedSigners(existing, "agent@example.com", "ssh-ed25519 AAAAnew", now)
+
+	want := "agent@example.com ssh-ed25519 AAAAnew\n" +
+		"# team signers\n" +
+		`agent@example.com valid-before="20260301123000Z" ssh-ed25519 AAAAold` + "\n" +
+		`ci@example.com valid-before="20260301123000Z",namespaces="git" ssh-ed25519 AAAAci` + "\n" +
+		`old@example.com valid-before="20250101" ssh-ed25519 AAAAexpired` + "\n"
+	if got != want {
+		t.Errorf("migrateAllowedSigners() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRunMigrateSSHCmd(t *testing.T) {
+	// Arrange: export and configure git with one key, then rotate it.
+	tmpDir := t.TempDir()
+	credPath := filepath.Join(tmpDir, "moltnet.json")
+	oldKP, _ := KeyPairFromSeed(make([]byte, 32))
+	creds := &CredentialsFile{
+		IdentityID: "test-agent-12345678",
+		Keys:       CredentialsKeys{PublicKey: oldKP.PublicKey, PrivateKey: oldKP.PrivateKey, Fingerprint: oldKP.Fingerprint},
+	}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := runSSHKeyExportCmd(credPath, ""); err != nil {
+		t.Fatalf("ssh-key: %v", err)
+	}
+	if err := runGitSetupCmd(credPath, "", ""); err != nil {
+		t.Fatalf("git setup: %v", err)
+	}
+	seed := make([]byte, 32)
+	seed[0] = 1
+	newKP, _ := KeyPairFromSeed(seed)
+	creds, err := ReadConfigFrom(credPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.Keys = CredentialsKeys{PublicKey: newKP.PublicKey, PrivateKey: newKP.PrivateKey, Fingerprint: newKP.Fingerprint}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	var out strings.Builder
+	err = runMigrateSSHCmd(&out, credPath)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("runMigrateSSHCmd: %v", err)
+	}
+	newSSH, _ := ToSSHPublicKey(newKP.PublicKey)
+	oldSSH, _ := ToSSHPublicKey(oldKP.PublicKey)
+	if err := verifyExportedSSHKey(creds.SSH.PrivateKeyPath, creds.SSH.PublicKeyPath, newSSH); err != nil {
+		t.Errorf("exported key does not match the new identity key: %v", err)
+	}
+	signers, err := os.ReadFile(filepath.Join(tmpDir, "ssh", "allowed_signers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(signers)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], newSSH) || !strings.Contains(lines[1], "valid-before=") || !strings.HasSuffix(lines[1], oldSSH) {
+		t.Errorf("unexpected allowed_signers:\n%s", signers)
+	}
+	gitconfig, err := os.ReadFile(filepath.Join(tmpDir, "gitconfig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gitconfig), "signingkey = "+creds.SSH.PublicKeyPath) {
+		t.Errorf("gitconfig signingkey not updated:\n%s", gitconfig)
+	}
+
+	// A second run finds nothing to do.
+	out.Reset()
+	if err := runMigrateSSHCmd(&out, credPath); err != nil {
+		t.Fatalf("second runMigrateSSHCmd: %v", err)
+	}
+	if !strings.Contains(out.String(), "already matches") {
+		t.Errorf("expected a no-op on the second run, got %q", out.String())
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// streamPageSize is the page size used when streaming list results.
+const streamPageSize = 100
+
+// ndjsonContentTypes are the media types decoded line by line. Anything
+// else is treated as a single JSON document.
+var ndjsonContentTypes = []string{"application/x-ndjson", "application/jsonl", "application/json-seq"}
+
+// streamClient issues raw authenticated GETs whose bodies are decoded
+// incrementally. The generated client reads whole responses into memory
+// before decoding, which is fine for single resources but not for large
+// listings.
+type streamClient struct {
+	baseURL    string
+	tm         *TokenManager
+	httpClient *http.Client
+}
+
+// newStreamClientFromCreds mirrors newClientFromCreds for streaming
+// requests: the same transport chain (retries, request signing, response
+// cap, --dry-run) without the overall timeout, which would otherwise cut
+// off long bodies mid-read.
+func newStreamClientFromCreds(apiURL, credPath string) (*streamClient, error) {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireClientCredentials(creds, credPath); err != nil {
+		return nil, err
+	}
+	opts, err := credentialClientOptions(creds, []clientOption{withLargeResponses()})
+	if err != nil {
+		return nil, err
+	}
+	tm := newTokenManagerForCreds(apiURL, creds)
+	httpClient := newAPIHTTPClient(tm, opts...)
+	return &streamClient{
+		baseURL:    strings.TrimRight(apiURL, "/"),
+		tm:         tm,
+		httpClient: &http.Client{Transport: httpClient.Transport},
+	}, nil
+}
+
+// GetStream performs an authenticated GET and returns the open response.
+// The caller must close the body. Non-2xx responses are turned into errors.
+func (c *streamClient) GetStream(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	token, err := c.tm.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("get token: %w", err)
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/x-ndjson, application/json;q=0.9")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, formatTransportError(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySnippet))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+// partialStreamError reports a stream that ended mid-item. Items decoded
+// before the break have already been handed to the callback.
+type partialStreamError struct {
+	Decoded int
+	Err     error
+}
+
+func (e *partialStreamError) Error() string {
+	return fmt.Sprintf("stream ended after %d item(s): %v", e.Decoded, e.Err)
+}
+
+func (e *partialStreamError) Unwrap() error { return e.Err }
+
+func isNDJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range ndjsonContentTypes {
+		if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeStream hands each item of a response body to fn as it is read and
+// returns how many were decoded. NDJSON bodies yield one item per line; JSON
+// bodies yield the elements of the array under field, e.g. "items". A body
+// cut off mid-item returns a *partialStreamError.
+func decodeStream(r io.Reader, contentType, field string, fn func(json.RawMessage) error) (int, error) {
+	if isNDJSON(contentType) {
+		return decodeNDJSON(r, fn)
+	}
+	return decodeJSONArrayField(r, field, fn)
+}
+
+func decodeNDJSON(r io.Reader, fn func(json.RawMessage) error) (int, error) {
+	br := bufio.NewReader(r)
+	n := 0
+	for line := 1; ; line++ {
+		raw, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return n, &partialStreamError{Decoded: n, Err: readErr}
+		}
+		// JSON text sequences prefix each record with RS (0x1e).
+		raw = bytes.TrimSpace(bytes.TrimPrefix(raw, []byte{0x1e}))
+		if len(raw) > 0 {
+			if !json.Valid(raw) {
+				if readErr == io.EOF {
+					// A final line without its newline is a truncated record.
+					return n, &partialStreamError{Decoded: n, Err: io.ErrUnexpectedEOF}
+				}
+				return n, fmt.Errorf("stream line %d: invalid JSON", line)
+			}
+			if err := fn(json.RawMessage(raw)); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if readErr == io.EOF {
+			return n, nil
+		}
+	}
+}
+
+func decodeJSONArrayField(r io.Reader, field string, fn func(json.RawMessage) error) (int, error) {
+	dec := json.NewDecoder(r)
+	n := 0
+	partial := func(err error) (int, error) {
+		if isTruncatedJSON(err) {
+			return n, &partialStreamError{Decoded: n, Err: io.ErrUnexpectedEOF}
+		}
+		return n, err
+	}
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return partial(err)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return partial(err)
+		}
+		key, _ := tok.(string)
+		if key != field {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return partial(err)
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return partial(err)
+		}
+		for dec.More() {
+			var item json.RawMessage
+			if err := dec.Decode(&item); err != nil {
+				return partial(err)
+			}
+			if err := fn(item); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return partial(err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return partial(err)
+	}
+	return n, nil
+}
+
+// isTruncatedJSON reports whether a decode error means the input stopped
+// early rather than being malformed.
+func isTruncatedJSON(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var syn *json.SyntaxError
+	return errors.As(err, &syn) && strings.Contains(syn.Error(), "unexpected end of JSON input")
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("unexpected JSON token %v, want %v", tok, want)
+	}
+	return nil
+}
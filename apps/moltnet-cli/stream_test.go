@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func collectStream(t *testing.T, body, contentType string) ([]string, error) {
+	t.Helper()
+	var items []string
+	_, err := decodeStream(strings.NewReader(body), contentType, "items", func(raw json.RawMessage) error {
+		items = append(items, string(raw))
+		return nil
+	})
+	return items, err
+}
+
+func TestDecodeStream_JSONArrayField(t *testing.T) {
+	t.Parallel()
+	body := `{"total": 2, "items": [{"id": 1}, {"id": 2}], "limit": 20}`
+	items, err := collectStream(t, body, "application/json; charset=utf-8")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(items) != 2 || items[1] != `{"id": 2}` {
+		t.Errorf("items: got %v", items)
+	}
+}
+
+func TestDecodeStream_NDJSON(t *testing.T) {
+	t.Parallel()
+	items, err := collectStream(t, "{\"id\":1}\n\n{\"id\":2}\n", "application/x-ndjson")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("items: got %v", items)
+	}
+}
+
+func TestDecodeStream_PartialBodies(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		decoded     int
+	}{
+		{"ndjson truncated last line", "{\"id\":1}\n{\"id\":", "application/x-ndjson", 1},
+		{"json truncated in array", `{"items": [{"id": 1}, {"id": 2`, "application/json", 1},
+		{"json truncated before array", `{"total": 3`, "application/json", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := collectStream(t, tt.body, tt.contentType)
+			var partial *partialStreamError
+			if !errors.As(err, &partial) {
+				t.Fatalf("want partialStreamError, got %v", err)
+			}
+			if partial.Decoded != tt.decoded || len(items) != tt.decoded {
+				t.Errorf("decoded: got %d (%d items), want %d", partial.Decoded, len(items), tt.decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeStream_NDJSONInvalidMiddleLine(t *testing.T) {
+	t.Parallel()
+	_, err := collectStream(t, "{\"id\":1}\nnot json\n{\"id\":2}\n", "application/x-ndjson")
+	var partial *partialStreamError
+	if err == nil || errors.As(err, &partial) {
+		t.Errorf("want plain decode error, got %v", err)
+	}
+}
+
+func TestRunEntryListStreamCmd_PagesAndWritesLines(t *testing.T) {
+	t.Parallel()
+	const total = streamPageSize + 5
+	var pages int
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if got := r.URL.Query()["tags"]; len(got) != 2 {
+			t.Errorf("tags query: got %v", got)
+		}
+		pages++
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		var items []string
+		for i := offset; i < min(offset+limit, total); i++ {
+			items = append(items, fmt.Sprintf(`{"id": %d}`, i))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"items": [%s], "total": %d}`, strings.Join(items, ","), total)
+	}))
+	t.Cleanup(apiSrv.Close)
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec"}}, credPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runEntryListStreamCmd(apiSrv.URL, credPath, testDiaryID.String(), "", "a,b", "", "", 0, 0, &out); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != total || lines[0] != `{"id":0}` {
+		t.Errorf("lines: got %d, first %q", len(lines), lines[0])
+	}
+	if pages != 2 {
+		t.Errorf("pages: got %d, want 2", pages)
+	}
+
+	out.Reset()
+	if err := runEntryListStreamCmd(apiSrv.URL, credPath, testDiaryID.String(), "", "a,b", "", "", 3, 0, &out); err != nil {
+		t.Fatalf("stream with limit: %v", err)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 3 {
+		t.Errorf("limited lines: got %d, want 3", n)
+	}
+}
+
+func TestNewStreamClientFromCreds_SignsRequests(t *testing.T) {
+	kp, err := KeyPairFromSeed(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verified bool
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+		ts, _ := strconv.ParseInt(r.Header.Get(requestTimestampHeader), 10, 64)
+		msg := requestSigningMessage(r.Method, r.URL.RequestURI(), nil, ts)
+		ok, err := VerifyForRequest(msg, r.Header.Get(requestNonceHeader), r.Header.Get(requestSignatureHeader), kp.PublicKey)
+		verified = err == nil && ok && r.Header.Get(requestFingerprintHeader) == kp.Fingerprint
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"items": [], "total": 0}`)
+	}))
+	t.Cleanup(apiSrv.Close)
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+	creds := &CredentialsFile{
+		OAuth2:   CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec"},
+		Keys:     CredentialsKeys{PublicKey: kp.PublicKey, PrivateKey: kp.PrivateKey, Fingerprint: kp.Fingerprint},
+		Requests: &RequestsSection{Sign: true},
+	}
+	if _, err := WriteConfigTo(creds, credPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runEntryListStreamCmd(apiSrv.URL, credPath, testDiaryID.String(), "", "", "", "", 0, 0, &out); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if !verified {
+		t.Error("streamed request did not carry a valid request signature")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeWindow is a --since/--until range. A zero bound is open.
+type timeWindow struct {
+	Since time.Time
+	Until time.Time
+}
+
+// parseTimeWindow parses --since and --until values; see parseTimeBound.
+func parseTimeWindow(since, until string) (timeWindow, error) {
+	var w timeWindow
+	var err error
+	if w.Since, err = parseTimeBound(since); err != nil {
+		return w, fmt.Errorf("invalid --since: %w", err)
+	}
+	if w.Until, err = parseTimeBound(until); err != nil {
+		return w, fmt.Errorf("invalid --until: %w", err)
+	}
+	if !w.Since.IsZero() && !w.Until.IsZero() && w.Until.Before(w.Since) {
+		return w, fmt.Errorf("--until is before --since")
+	}
+	return w, nil
+}
+
+// parseTimeBound accepts an RFC 3339 timestamp, a YYYY-MM-DD date (UTC
+// midnight), or a span back from now: a number followed by m, h, d or w,
+// e.g. 30m or 7d. An empty value is an open bound.
+func parseTimeBound(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	units := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[raw[len(raw)-1]]; ok {
+		if n, err := strconv.Atoi(raw[:len(raw)-1]); err == nil && n >= 0 {
+			return timeNow().Add(-time.Duration(n) * unit), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q: want RFC 3339 (2026-01-02T15:04:05Z), a date (2026-01-02) or a relative span like 7d, 12h, 30m, 2w", raw)
+}
+
+// isOpen reports whether neither bound is set.
+func (w timeWindow) isOpen() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+// contains reports whether t falls in the window; both bounds are inclusive.
+func (w timeWindow) contains(t time.Time) bool {
+	return (w.Since.IsZero() || !t.Before(w.Since)) && (w.Until.IsZero() || !t.After(w.Until))
+}
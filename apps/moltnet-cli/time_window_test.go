@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2026-01-02T15:04:05Z", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"7d", now.Add(-7 * 24 * time.Hour)},
+		{"12h", now.Add(-12 * time.Hour)},
+		{"2w", now.Add(-14 * 24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.raw)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseTimeBound(%q) = %v, %v; want %v", tt.raw, got, err, tt.want)
+		}
+	}
+	for _, raw := range []string{"yesterday", "7y", "-3d", "d"} {
+		if _, err := parseTimeBound(raw); err == nil {
+			t.Errorf("parseTimeBound(%q): expected error", raw)
+		}
+	}
+}
+
+func TestParseTimeWindow_Inverted(t *testing.T) {
+	if _, err := parseTimeWindow("2026-02-01", "2026-01-01"); err == nil {
+		t.Error("expected error when --until is before --since")
+	}
+}
+
+func TestListEntriesInWindow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var recent []moltnetapi.DiaryEntry
+	for i := range 5 { // newest first: day 4 .. day 0
+		e := newTestEntry("day")
+		e.CreatedAt = base.Add(time.Duration(4-i) * 24 * time.Hour)
+		recent = append(recent, *e)
+	}
+	srv, credPath := newCLICommandTestServer(t, &dedupeStubHandler{recent: recent})
+	client, err := newClientFromCreds(srv.URL, credPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	window := timeWindow{Since: base.Add(24 * time.Hour), Until: base.Add(3 * 24 * time.Hour)}
+
+	list, err := listEntriesInWindow(client, moltnetapi.ListDiaryEntriesParams{DiaryId: testDiaryID}, window, 0, 1)
+
+	if err != nil {
+		t.Fatalf("listEntriesInWindow() error: %v", err)
+	}
+	if list.Total != 3 || len(list.Items) != 2 || !list.Items[0].CreatedAt.Equal(base.Add(2*24*time.Hour)) {
+		t.Errorf("total %v, items %d, first %v", list.Total, len(list.Items), list.Items[0].CreatedAt)
+	}
+}
@@ -7,9 +7,43 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// scopeOverride holds the root --oauth-scope, which replaces the config's
+// oauth2.scopes for one invocation.
+var scopeOverride atomic.Pointer[[]string]
+
+// setScopeOverride validates and stores --oauth-scope. An empty value keeps the
+// configured scopes.
+func setScopeOverride(raw string) error {
+	scopes, err := parseOAuthScopes(raw)
+	if err != nil {
+		return err
+	}
+	if len(scopes) == 0 {
+		scopeOverride.Store(nil)
+		return nil
+	}
+	scopeOverride.Store(&scopes)
+	return nil
+}
+
+// parseOAuthScopes splits a comma- or space-separated scope list and
+// checks each is a valid RFC 6749 scope token.
+func parseOAuthScopes(raw string) ([]string, error) {
+	scopes := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for _, scope := range scopes {
+		for _, r := range scope {
+			if r < 0x21 || r > 0x7e || r == '"' || r == '\\' {
+				return nil, fmt.Errorf("invalid scope %q: scopes are printable ASCII without quotes or backslashes", scope)
+			}
+		}
+	}
+	return scopes, nil
+}
+
 // TokenManager obtains and caches an OAuth2 client_credentials token.
 type TokenManager struct {
 	apiURL             string
@@ -17,15 +51,28 @@ type TokenManager struct {
 	clientSecret       string
 	earlyExpirySeconds int
 	httpClient         *http.Client
+	// tokenURL is the token endpoint; empty means the default path under
+	// apiURL, or with discover the result of discoverTokenEndpoint.
+	tokenURL string
+	discover bool
+	// scopes are requested with every grant; none lets the server decide.
+	scopes []string
+	// trustedOrigins may host a discovered token endpoint besides apiURL;
+	// see checkDiscoveredOAuthEndpoint.
+	trustedOrigins []string
 
 	mu        sync.Mutex
 	cached    string
+	scope     string
 	expiresAt time.Time
+	// discovered is set once tokenURL came from discovery.
+	discovered bool
 }
 
 // NewTokenManager creates a TokenManager with a 30-second early-expiry buffer.
 // The HTTP client uses a retry transport: 429 on all methods,
-// 408/5xx on idempotent methods only (GET, HEAD, OPTIONS, PUT).
+// 408/5xx on idempotent methods only (GET, HEAD, OPTIONS, PUT). Every
+// attempt carries the negotiated X-API-Version; see api_version.go.
 func NewTokenManager(apiURL, clientID, clientSecret string) *TokenManager {
 	return &TokenManager{
 		apiURL:             apiURL,
@@ -34,11 +81,46 @@ func NewTokenManager(apiURL, clientID, clientSecret string) *TokenManager {
 		earlyExpirySeconds: 30,
 		httpClient: &http.Client{
 			Timeout:   30 * time.Second,
-			Transport: NewRetryTransport(nil, nil),
+			Transport: NewRetryTransport(newAPIVersionTransport(nil, currentAPIVersion()), nil),
 		},
 	}
 }
 
+// newTokenManagerForCreds creates a TokenManager that honours the
+// config's oauth2.token_endpoint: empty for the API's own /oauth2/token,
+// "discover" to look the endpoint up in the network's metadata, or a URL.
+// It requests the --oauth-scope scopes, else the config's oauth2.scopes.
+func newTokenManagerForCreds(apiURL string, creds *CredentialsFile) *TokenManager {
+	tm := NewTokenManager(apiURL, creds.OAuth2.ClientID, creds.OAuth2.ClientSecret)
+	tm.scopes = creds.OAuth2.Scopes
+	tm.trustedOrigins = creds.OAuth2.TrustedOrigins
+	if p := scopeOverride.Load(); p != nil {
+		tm.scopes = *p
+	}
+	switch endpoint := creds.OAuth2.TokenEndpoint; endpoint {
+	case "":
+	case tokenEndpointDiscover:
+		tm.discover = true
+	default:
+		tm.tokenURL = endpoint
+	}
+	return tm
+}
+
+// tokenEndpoint returns the URL fetchToken posts to, discovering it on
+// first use when enabled. Must be called with t.mu held.
+func (t *TokenManager) tokenEndpoint() string {
+	if t.tokenURL != "" {
+		return t.tokenURL
+	}
+	if !t.discover {
+		return defaultTokenEndpoint(t.apiURL)
+	}
+	t.tokenURL = discoverTokenEndpoint(t.apiURL, t.trustedOrigins)
+	t.discovered = true
+	return t.tokenURL
+}
+
 // GetToken returns a cached token if still valid, or fetches a fresh one.
 func (t *TokenManager) GetToken() (string, error) {
 	t.mu.Lock()
@@ -49,12 +131,29 @@ func (t *TokenManager) GetToken() (string, error) {
 	return t.fetchToken()
 }
 
+// Scope returns the scope granted with the cached token, if the token
+// endpoint reported one.
+func (t *TokenManager) Scope() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scope
+}
+
+// ExpiresAt returns when the cached token stops being reused, which is
+// the server expiry minus the early-expiry buffer.
+func (t *TokenManager) ExpiresAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.expiresAt
+}
+
 // Invalidate clears the cached token, forcing the next GetToken call to fetch a new one.
 // Call this when a request returns HTTP 401.
 func (t *TokenManager) Invalidate() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.cached = ""
+	t.scope = ""
 	t.expiresAt = time.Time{}
 }
 
@@ -65,9 +164,13 @@ func (t *TokenManager) fetchToken() (string, error) {
 	form.Set("grant_type", "client_credentials")
 	form.Set("client_id", t.clientID)
 	form.Set("client_secret", t.clientSecret)
+	if len(t.scopes) > 0 {
+		form.Set("scope", strings.Join(t.scopes, " "))
+	}
 
+	endpoint := t.tokenEndpoint()
 	resp, err := t.httpClient.Post( //nolint:gosec
-		t.apiURL+"/oauth2/token",
+		endpoint,
 		"application/x-www-form-urlencoded",
 		strings.NewReader(form.Encode()),
 	)
@@ -76,6 +179,13 @@ func (t *TokenManager) fetchToken() (string, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound && t.discovered && endpoint != defaultTokenEndpoint(t.apiURL) {
+		// The discovered endpoint moved: drop it and fall back to the
+		// default path for this process; the next run rediscovers.
+		forgetTokenEndpoint(t.apiURL)
+		t.tokenURL = defaultTokenEndpoint(t.apiURL)
+		return t.fetchToken()
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
 	}
@@ -83,8 +193,9 @@ func (t *TokenManager) fetchToken() (string, error) {
 	var payload struct {
 		AccessToken string `json:"access_token"`
 		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+	if err := json.NewDecoder(limitResponseBody(resp.Body, currentMaxResponseSize(false))).Decode(&payload); err != nil {
 		return "", fmt.Errorf("failed to decode token response: %w", err)
 	}
 	if payload.AccessToken == "" {
@@ -93,6 +204,7 @@ func (t *TokenManager) fetchToken() (string, error) {
 
 	ttl := time.Duration(payload.ExpiresIn-t.earlyExpirySeconds) * time.Second
 	t.cached = payload.AccessToken
+	t.scope = payload.Scope
 	if ttl <= 0 {
 		// Token lifetime is shorter than the early expiry buffer — do not cache;
 		// set expiresAt to now so the next call always fetches a fresh token.
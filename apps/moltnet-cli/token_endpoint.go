@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// tokenEndpointDiscover is the oauth2.token_endpoint value that turns
+	// on discovery; any other non-empty value is used as the URL itself.
+	tokenEndpointDiscover = "discover"
+
+	tokenEndpointCacheFile = "token-endpoint-cache.json"
+	// tokenEndpointCacheTTL bounds how long a discovered endpoint is
+	// trusted before the metadata is consulted again.
+	tokenEndpointCacheTTL = 24 * time.Hour
+	// tokenEndpointFetchTimeout keeps discovery from stalling every
+	// authenticated command; on timeout the default path is used.
+	tokenEndpointFetchTimeout = 5 * time.Second
+)
+
+// defaultTokenEndpoint is the token path the MoltNet API serves itself.
+func defaultTokenEndpoint(apiURL string) string {
+	return strings.TrimRight(apiURL, "/") + "/oauth2/token"
+}
+
+type tokenEndpointCacheEntry struct {
+	URL       string `json:"url"`
+	FetchedAt string `json:"fetchedAt"`
+}
+
+func tokenEndpointCachePath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tokenEndpointCacheFile), nil
+}
+
+// readTokenEndpointCache returns the cached endpoints keyed by API origin
+// (see networkOrigin). A missing or corrupt file is an empty cache.
+func readTokenEndpointCache(path string) map[string]tokenEndpointCacheEntry {
+	cache := map[string]tokenEndpointCacheEntry{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return map[string]tokenEndpointCacheEntry{}
+	}
+	return cache
+}
+
+func updateTokenEndpointCache(apiURL string, entry *tokenEndpointCacheEntry) {
+	path, err := tokenEndpointCachePath()
+	if err != nil {
+		return
+	}
+	cache := readTokenEndpointCache(path)
+	key := networkOrigin(apiURL)
+	if entry == nil {
+		delete(cache, key)
+	} else {
+		cache[key] = *entry
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+		_ = writeJSONAtomic(path, cache)
+	}
+}
+
+// cachedTokenEndpoint returns a fresh cached endpoint for apiURL. The
+// cache file is as writable as any other in the config directory, so the
+// entry is held to the same checkDiscoveredOAuthEndpoint rules as a fresh
+// discovery, and dropped if it fails them.
+func cachedTokenEndpoint(apiURL string, trusted []string) (string, bool) {
+	path, err := tokenEndpointCachePath()
+	if err != nil {
+		return "", false
+	}
+	cached, hit := readTokenEndpointCache(path)[networkOrigin(apiURL)]
+	if !hit || cached.URL == "" {
+		return "", false
+	}
+	if err := checkDiscoveredOAuthEndpoint(cached.URL, apiURL, trusted); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring the cached token endpoint %s: %v\n", cached.URL, err)
+		forgetTokenEndpoint(apiURL)
+		return "", false
+	}
+	fetchedAt, err := time.Parse(time.RFC3339, cached.FetchedAt)
+	if err != nil || !timeNow().Before(fetchedAt.Add(tokenEndpointCacheTTL)) {
+		return "", false
+	}
+	return cached.URL, true
+}
+
+// forgetTokenEndpoint drops the cached endpoint for apiURL, e.g. after it
+// stopped answering.
+func forgetTokenEndpoint(apiURL string) {
+	updateTokenEndpointCache(apiURL, nil)
+}
+
+// discoverTokenEndpoint looks the token endpoint up in the OpenID
+// Connect metadata (/.well-known/openid-configuration), then in the
+// MoltNet discovery document (see oauthEndpointsFromDiscovery), and falls
+// back to the default path. The answer is cached per API origin, including
+// the fallback when the metadata was readable but names no endpoint; when
+// neither document could be fetched, or an advertised endpoint was
+// rejected (see checkDiscoveredOAuthEndpoint), nothing is cached.
+func discoverTokenEndpoint(apiURL string, trusted []string) string {
+	if cached, ok := cachedTokenEndpoint(apiURL, trusted); ok {
+		return cached
+	}
+	client := newHTTPClient(tokenEndpointFetchTimeout)
+	var reachable, rejected bool
+	endpoint := ""
+	lookup := func(doc map[string]any) {
+		reachable = true
+		endpoints := oauthEndpointsFromDiscovery(doc, apiURL, trusted)
+		endpoint = endpoints.Token
+		rejected = rejected || endpoints.Rejected > 0
+	}
+	if doc, ok := fetchJSONDocument(client, strings.TrimRight(apiURL, "/")+"/.well-known/openid-configuration"); ok {
+		lookup(doc)
+	}
+	if endpoint == "" {
+		if body, _, err := fetchDiscoveryDocWith(client, apiURL); err == nil {
+			var doc map[string]any
+			if json.Unmarshal(body, &doc) == nil {
+				lookup(doc)
+			}
+		}
+	}
+	if endpoint == "" {
+		endpoint = defaultTokenEndpoint(apiURL)
+	}
+	if reachable && !rejected {
+		updateTokenEndpointCache(apiURL, &tokenEndpointCacheEntry{URL: endpoint, FetchedAt: timeNow().UTC().Format(time.RFC3339)})
+	}
+	return endpoint
+}
+
+// fetchJSONDocument GETs a JSON object. ok is false on any transport,
+// status or decoding failure.
+func fetchJSONDocument(client *http.Client, url string) (map[string]any, bool) {
+	resp, err := client.Get(url) //nolint:gosec
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, false
+	}
+	var doc map[string]any
+	if err := json.NewDecoder(limitResponseBody(resp.Body, currentMaxResponseSize(false))).Decode(&doc); err != nil {
+		return nil, false
+	}
+	return doc, true
+}
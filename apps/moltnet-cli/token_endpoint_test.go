@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTokenDiscoveryServer serves the given documents by path and mints a
+// token on POST to tokenPath, counting metadata fetches.
+func newTokenDiscoveryServer(t *testing.T, docs map[string]any, tokenPath string) (*httptest.Server, *int) {
+	t.Helper()
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == tokenPath {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "tok-discovered", "expires_in": 3600})
+			return
+		}
+		doc, ok := docs[r.URL.Path]
+		if r.Method != http.MethodGet || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fetches++
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &fetches
+}
+
+func TestDiscoverTokenEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		docs    map[string]any
+		trusted []string
+		want    string
+	}{
+		{
+			"openid configuration",
+			map[string]any{"/.well-known/openid-configuration": map[string]any{"token_endpoint": "/hydra/oauth2/token"}},
+			nil,
+			"/hydra/oauth2/token",
+		},
+		{
+			"moltnet discovery document",
+			map[string]any{"/.well-known/moltnet.json": map[string]any{"endpoints": map[string]any{"oauth2": map[string]any{"token_endpoint": "https://auth.example.com/token"}}}},
+			[]string{"https://auth.example.com"},
+			"https://auth.example.com/token",
+		},
+		{
+			"no endpoint published",
+			map[string]any{"/.well-known/moltnet.json": map[string]any{"network": map[string]any{"name": "moltnet"}}},
+			nil,
+			"/oauth2/token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(configDirEnvVar, t.TempDir())
+			srv, fetches := newTokenDiscoveryServer(t, tt.docs, "")
+			want := tt.want
+			if want[0] == '/' {
+				want = srv.URL + want
+			}
+			if got := discoverTokenEndpoint(srv.URL, tt.trusted); got != want {
+				t.Errorf("discoverTokenEndpoint() = %q, want %q", got, want)
+			}
+			before := *fetches
+			if got := discoverTokenEndpoint(srv.URL, tt.trusted); got != want {
+				t.Errorf("cached discoverTokenEndpoint() = %q, want %q", got, want)
+			}
+			if *fetches != before {
+				t.Errorf("second lookup fetched metadata again (%d -> %d)", before, *fetches)
+			}
+		})
+	}
+}
+
+func TestDiscoverTokenEndpoint_CacheExpires(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+
+	srv, fetches := newTokenDiscoveryServer(t, map[string]any{"/.well-known/openid-configuration": map[string]any{"token_endpoint": "/token"}}, "")
+	discoverTokenEndpoint(srv.URL, nil)
+	now = now.Add(tokenEndpointCacheTTL + time.Minute)
+	discoverTokenEndpoint(srv.URL, nil)
+	if *fetches != 2 {
+		t.Errorf("metadata fetches = %d, want 2 after the cache expired", *fetches)
+	}
+}
+
+func TestDiscoverTokenEndpoint_RejectsUntrustedEndpoints(t *testing.T) {
+	for _, endpoint := range []string{
+		"https://evil.example/token",     // another origin
+		"http://auth.example.com/token",  // plain http, even when trusted
+		"ftp://auth.example.com/token",   // not http at all
+		"https://auth.example.com:8443/", // trusted host, other port
+	} {
+		t.Run(endpoint, func(t *testing.T) {
+			t.Setenv(configDirEnvVar, t.TempDir())
+			srv, fetches := newTokenDiscoveryServer(t, map[string]any{"/.well-known/openid-configuration": map[string]any{"token_endpoint": endpoint}}, "")
+			trusted := []string{"https://auth.example.com", "http://auth.example.com"}
+			if got := discoverTokenEndpoint(srv.URL, trusted); got != defaultTokenEndpoint(srv.URL) {
+				t.Errorf("discoverTokenEndpoint() = %q, want the default endpoint", got)
+			}
+			if cached, ok := cachedTokenEndpoint(srv.URL, nil); ok {
+				t.Errorf("cached %q after rejecting %s", cached, endpoint)
+			}
+			before := *fetches
+			discoverTokenEndpoint(srv.URL, trusted)
+			if *fetches == before {
+				t.Error("a rejected endpoint was not re-checked on the next lookup")
+			}
+		})
+	}
+}
+
+func TestCachedTokenEndpoint_RevalidatesEntries(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	apiURL := "https://api.themolt.net"
+	fresh := timeNow().UTC().Format(time.RFC3339)
+
+	// A poisoned entry is not trusted for the rest of its TTL, and is
+	// dropped from the cache.
+	updateTokenEndpointCache(apiURL, &tokenEndpointCacheEntry{URL: "https://evil.example/token", FetchedAt: fresh})
+	if cached, ok := cachedTokenEndpoint(apiURL, nil); ok {
+		t.Errorf("poisoned cache entry used: %q", cached)
+	}
+	path, _ := tokenEndpointCachePath()
+	if len(readTokenEndpointCache(path)) != 0 {
+		t.Error("poisoned cache entry kept")
+	}
+
+	// Entries are keyed by origin, not by the exact API URL.
+	updateTokenEndpointCache(apiURL+"/", &tokenEndpointCacheEntry{URL: apiURL + "/hydra/token", FetchedAt: fresh})
+	if cached, ok := cachedTokenEndpoint("https://API.themolt.net", nil); !ok || cached != apiURL+"/hydra/token" {
+		t.Errorf("cachedTokenEndpoint() = %q, %v; want the entry for the same origin", cached, ok)
+	}
+	if _, ok := readTokenEndpointCache(path)[apiURL]; !ok {
+		t.Errorf("cache keys = %v, want the API origin", readTokenEndpointCache(path))
+	}
+}
+
+func TestTokenManager_DiscoveredEndpoint(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	srv, _ := newTokenDiscoveryServer(t, map[string]any{"/.well-known/openid-configuration": map[string]any{"token_endpoint": "/hydra/token"}}, "/hydra/token")
+
+	creds := &CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec", TokenEndpoint: tokenEndpointDiscover}}
+	token, err := newTokenManagerForCreds(srv.URL, creds).GetToken()
+	if err != nil {
+		t.Fatalf("GetToken() error: %v", err)
+	}
+	if token != "tok-discovered" {
+		t.Errorf("token = %q, want tok-discovered", token)
+	}
+}
+
+func TestTokenManager_StaleDiscoveredEndpoint(t *testing.T) {
+	t.Setenv(configDirEnvVar, t.TempDir())
+	srv, _ := newTokenDiscoveryServer(t, nil, "/oauth2/token")
+	updateTokenEndpointCache(srv.URL, &tokenEndpointCacheEntry{URL: srv.URL + "/gone", FetchedAt: timeNow().UTC().Format(time.RFC3339)})
+
+	creds := &CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec", TokenEndpoint: tokenEndpointDiscover}}
+	if _, err := newTokenManagerForCreds(srv.URL, creds).GetToken(); err != nil {
+		t.Fatalf("GetToken() error: %v", err)
+	}
+	if cached, ok := cachedTokenEndpoint(srv.URL, nil); ok {
+		t.Errorf("stale endpoint still cached: %q", cached)
+	}
+}
+
+func TestNewTokenManagerForCreds(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		setting      string
+		wantURL      string
+		wantDiscover bool
+	}{
+		{"", "https://api.example.com/oauth2/token", false},
+		{tokenEndpointDiscover, "", true},
+		{"https://auth.example.com/token", "https://auth.example.com/token", false},
+	}
+	for _, tt := range tests {
+		creds := &CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec", TokenEndpoint: tt.setting}}
+		tm := newTokenManagerForCreds("https://api.example.com/", creds)
+		if tm.discover != tt.wantDiscover {
+			t.Errorf("%q: discover = %v, want %v", tt.setting, tm.discover, tt.wantDiscover)
+		}
+		if !tt.wantDiscover {
+			if got := tm.tokenEndpoint(); got != tt.wantURL {
+				t.Errorf("%q: tokenEndpoint() = %q, want %q", tt.setting, got, tt.wantURL)
+			}
+		}
+	}
+}
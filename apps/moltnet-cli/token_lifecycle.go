@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// oauthEndpoints are the optional RFC 7009 / RFC 7662 endpoints a network
+// may advertise in its discovery document.
+type oauthEndpoints struct {
+	Token         string
+	Revocation    string
+	Introspection string
+	// Rejected counts advertised endpoints dropped by
+	// checkDiscoveredOAuthEndpoint.
+	Rejected int
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// networkOrigin is the lowercased scheme and host of an API URL, the key
+// per-network state such as the token endpoint cache is stored under.
+func networkOrigin(apiURL string) string {
+	u, err := url.Parse(strings.TrimSpace(apiURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(apiURL, "/")
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
+
+// checkDiscoveredOAuthEndpoint refuses an advertised endpoint the client
+// secret must not be posted to: anything but https (plain http only on a
+// loopback host, for local networks), and any origin other than the
+// API's own or one listed in oauth2.trusted_origins. A tampered discovery
+// response could otherwise collect the secret.
+func checkDiscoveredOAuthEndpoint(endpoint, apiURL string, trusted []string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("not an absolute URL")
+	}
+	switch {
+	case strings.EqualFold(u.Scheme, "https"):
+	case strings.EqualFold(u.Scheme, "http") && isLoopbackHost(u.Hostname()):
+	default:
+		return fmt.Errorf("scheme %q is not https", u.Scheme)
+	}
+	for _, origin := range append([]string{apiURL}, trusted...) {
+		if o, err := url.Parse(origin); err == nil && o.Host != "" && sameOrigin(u, o) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s is neither the API's nor listed in oauth2.trusted_origins", networkOrigin(endpoint))
+}
+
+// oauthEndpointsFromDiscovery reads endpoints.oauth2.{token,revocation,
+// introspection}_endpoint, falling back to the RFC 8414 top-level names.
+// Relative URLs are resolved against apiURL; endpoints that fail
+// checkDiscoveredOAuthEndpoint are dropped with a warning, as if not
+// advertised.
+func oauthEndpointsFromDiscovery(doc map[string]any, apiURL string, trusted []string) oauthEndpoints {
+	var endpoints oauthEndpoints
+	lookup := func(name string) string {
+		if endpoints, ok := doc["endpoints"].(map[string]any); ok {
+			if oauth, ok := endpoints["oauth2"].(map[string]any); ok {
+				if v, ok := oauth[name].(string); ok && v != "" {
+					return v
+				}
+			}
+		}
+		v, _ := doc[name].(string)
+		return v
+	}
+	resolve := func(name string) string {
+		ref := lookup(name)
+		if ref == "" {
+			return ""
+		}
+		endpoint := ref
+		if base, err := url.Parse(strings.TrimRight(apiURL, "/") + "/"); err == nil {
+			if u, err := base.Parse(ref); err == nil {
+				endpoint = u.String()
+			}
+		}
+		if err := checkDiscoveredOAuthEndpoint(endpoint, apiURL, trusted); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring the %s %s advertised by %s: %v\n", name, endpoint, networkOrigin(apiURL), err)
+			endpoints.Rejected++
+			return ""
+		}
+		return endpoint
+	}
+	endpoints.Token = resolve("token_endpoint")
+	endpoints.Revocation = resolve("revocation_endpoint")
+	endpoints.Introspection = resolve("introspection_endpoint")
+	return endpoints
+}
+
+// oauthSession is what the token commands need: the client credentials,
+// a token to act on, and the endpoints advertised by the network.
+type oauthSession struct {
+	clientID     string
+	clientSecret string
+	token        string
+	tm           *TokenManager // nil when --token was given
+	endpoints    oauthEndpoints
+	discoveryErr error
+}
+
+// newOAuthSession loads credentials and discovery. token is the token to
+// act on; empty mints one from the credentials, "-" reads it from in.
+func newOAuthSession(apiURL, credPath, token string, in io.Reader) (*oauthSession, error) {
+	creds, err := loadCredentials(credPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireClientCredentials(creds, credPath); err != nil {
+		return nil, err
+	}
+	s := &oauthSession{clientID: creds.OAuth2.ClientID, clientSecret: creds.OAuth2.ClientSecret}
+
+	if body, _, err := fetchDiscoveryDoc(apiURL); err != nil {
+		s.discoveryErr = err
+	} else {
+		var doc map[string]any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			s.discoveryErr = fmt.Errorf("parse network info: %w", err)
+		} else {
+			s.endpoints = oauthEndpointsFromDiscovery(doc, apiURL, creds.OAuth2.TrustedOrigins)
+		}
+	}
+
+	switch token {
+	case "":
+		s.tm = newTokenManagerForCreds(apiURL, creds)
+		if s.token, err = s.tm.GetToken(); err != nil {
+			return nil, err
+		}
+	case "-":
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("read token from stdin: %w", err)
+		}
+		if s.token = strings.TrimSpace(string(data)); s.token == "" {
+			return nil, errors.New("no token on stdin")
+		}
+	default:
+		s.token = token
+	}
+	return s, nil
+}
+
+// unavailable explains why an endpoint is missing.
+func (s *oauthSession) unavailable(name string) string {
+	if s.discoveryErr != nil {
+		return fmt.Sprintf("%s endpoint unknown: %v", name, s.discoveryErr)
+	}
+	return name + " endpoint not advertised in discovery"
+}
+
+// postTokenForm sends an RFC 7009/7662 request authenticated with
+// client_secret_basic (RFC 6749 §2.3.1).
+func (s *oauthSession) postTokenForm(endpoint string) (*http.Response, error) {
+	form := url.Values{}
+	form.Set("token", s.token)
+	form.Set("token_type_hint", "access_token")
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	return newHTTPClient(30 * time.Second).Do(req)
+}
+
+// tokenRevokeResult is the output of token revoke.
+type tokenRevokeResult struct {
+	Revoked  bool   `json:"revoked"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// runTokenRevokeCmd revokes an access token server-side and drops it from
+// the in-process token cache. Without a revocation endpoint it reports
+// revoked: false and succeeds, so cleanup scripts keep going.
+func runTokenRevokeCmd(apiURL, credPath, token string, in io.Reader, w io.Writer) error {
+	s, err := newOAuthSession(apiURL, credPath, token, in)
+	if err != nil {
+		return fmt.Errorf("token revoke: %w", err)
+	}
+	if s.tm != nil {
+		defer s.tm.Invalidate()
+	}
+	if s.endpoints.Revocation == "" {
+		reason := s.unavailable("revocation")
+		fmt.Fprintf(os.Stderr, "warning: %s; the token stays valid until it expires\n", reason)
+		return printJSONTo(w, tokenRevokeResult{Reason: reason})
+	}
+
+	resp, err := s.postTokenForm(s.endpoints.Revocation)
+	if err != nil {
+		return fmt.Errorf("token revoke: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	// RFC 7009 §2.2: 200 means revoked, or the token was already invalid.
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token revoke: revocation endpoint returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return printJSONTo(w, tokenRevokeResult{Revoked: true, Endpoint: s.endpoints.Revocation})
+}
+
+// tokenIntrospection is the output of token introspect. Source is
+// "introspection" for a server answer and "token-response" when only the
+// locally known grant could be shown.
+type tokenIntrospection struct {
+	Source    string   `json:"source"`
+	Active    *bool    `json:"active,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	ClientID  string   `json:"clientId,omitempty"`
+	Subject   string   `json:"subject,omitempty"`
+	ExpiresAt string   `json:"expiresAt,omitempty"`
+	IssuedAt  string   `json:"issuedAt,omitempty"`
+	Note      string   `json:"note,omitempty"`
+}
+
+func unixTime(v *int64) string {
+	if v == nil || *v == 0 {
+		return ""
+	}
+	return time.Unix(*v, 0).UTC().Format(time.RFC3339)
+}
+
+// runTokenIntrospectCmd shows whether a token is active and its scopes.
+// Without an introspection endpoint it falls back to the scope and expiry
+// from the token response, which only works for a freshly minted token.
+func runTokenIntrospectCmd(apiURL, credPath, token string, in io.Reader, w io.Writer) error {
+	s, err := newOAuthSession(apiURL, credPath, token, in)
+	if err != nil {
+		return fmt.Errorf("token introspect: %w", err)
+	}
+	if s.endpoints.Introspection == "" {
+		reason := s.unavailable("introspection")
+		if s.tm == nil {
+			return fmt.Errorf("token introspect: %s; cannot inspect a token passed with --token", reason)
+		}
+		return printJSONTo(w, tokenIntrospection{
+			Source:    "token-response",
+			Scopes:    strings.Fields(s.tm.Scope()),
+			ExpiresAt: s.tm.ExpiresAt().UTC().Format(time.RFC3339),
+			Note:      reason,
+		})
+	}
+
+	resp, err := s.postTokenForm(s.endpoints.Introspection)
+	if err != nil {
+		return fmt.Errorf("token introspect: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("token introspect: introspection endpoint returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var payload struct {
+		Active   bool   `json:"active"`
+		Scope    string `json:"scope"`
+		ClientID string `json:"client_id"`
+		Sub      string `json:"sub"`
+		Exp      *int64 `json:"exp"`
+		Iat      *int64 `json:"iat"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return fmt.Errorf("token introspect: decode response: %w", err)
+	}
+	return printJSONTo(w, tokenIntrospection{
+		Source:    "introspection",
+		Active:    &payload.Active,
+		Scopes:    strings.Fields(payload.Scope),
+		ClientID:  payload.ClientID,
+		Subject:   payload.Sub,
+		ExpiresAt: unixTime(payload.Exp),
+		IssuedAt:  unixTime(payload.Iat),
+	})
+}
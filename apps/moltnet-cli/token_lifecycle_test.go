@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newOAuthTestServer serves a token endpoint and a discovery document
+// that advertises revocation/introspection only when advertise is set.
+func newOAuthTestServer(t *testing.T, advertise bool) (*httptest.Server, string, *[]string) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		revoked []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth2/token":
+			json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+				"access_token": "minted-token",
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+				"scope":        "diary:read diary:write",
+			})
+		case "/.well-known/moltnet.json":
+			doc := map[string]any{"network": map[string]any{"name": "MoltNet"}}
+			if advertise {
+				doc["endpoints"] = map[string]any{"oauth2": map[string]any{
+					"revocation_endpoint":    "/oauth2/revoke",
+					"introspection_endpoint": "/oauth2/introspect",
+				}}
+			}
+			json.NewEncoder(w).Encode(doc) //nolint:errcheck
+		case "/oauth2/revoke", "/oauth2/introspect":
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "cid" || pass != "csec" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if err := r.ParseForm(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			token := r.PostForm.Get("token")
+			if r.URL.Path == "/oauth2/revoke" {
+				mu.Lock()
+				revoked = append(revoked, token)
+				mu.Unlock()
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{ //nolint:errcheck
+				"active":    token == "minted-token",
+				"scope":     "diary:read",
+				"client_id": "cid",
+				"exp":       1767225600,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	credPath := filepath.Join(t.TempDir(), "moltnet.json")
+	if _, err := WriteConfigTo(&CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec"}}, credPath); err != nil {
+		t.Fatal(err)
+	}
+	return srv, credPath, &revoked
+}
+
+func TestOAuthEndpointsFromDiscovery(t *testing.T) {
+	t.Parallel()
+	doc := map[string]any{
+		"revocation_endpoint": "https://auth.example.com/revoke",
+		"endpoints": map[string]any{"oauth2": map[string]any{
+			"introspection_endpoint": "/oauth2/introspect",
+		}},
+	}
+
+	got := oauthEndpointsFromDiscovery(doc, "https://api.themolt.net/", []string{"https://auth.example.com"})
+
+	if got.Revocation != "https://auth.example.com/revoke" || got.Introspection != "https://api.themolt.net/oauth2/introspect" {
+		t.Errorf("got %+v", got)
+	}
+
+	// Without the trusted origin the cross-origin endpoint is dropped.
+	got = oauthEndpointsFromDiscovery(doc, "https://api.themolt.net/", nil)
+	if got.Revocation != "" || got.Rejected != 1 || got.Introspection == "" {
+		t.Errorf("untrusted origin: got %+v", got)
+	}
+}
+
+func TestCheckDiscoveredOAuthEndpoint(t *testing.T) {
+	t.Parallel()
+	apiURL := "https://api.themolt.net"
+	tests := []struct {
+		endpoint string
+		ok       bool
+	}{
+		{"https://api.themolt.net/oauth2/token", true},
+		{"https://API.themolt.net:443/oauth2/token", true},
+		{"http://api.themolt.net/oauth2/token", false},
+		{"https://api.themolt.net.evil.example/oauth2/token", false},
+		{"https://auth.example.com/token", true}, // trusted
+		{"http://localhost:4444/oauth2/token", false},
+		{"//api.themolt.net/oauth2/token", false},
+	}
+	for _, tt := range tests {
+		err := checkDiscoveredOAuthEndpoint(tt.endpoint, apiURL, []string{"https://auth.example.com"})
+		if (err == nil) != tt.ok {
+			t.Errorf("checkDiscoveredOAuthEndpoint(%q) = %v, want ok=%v", tt.endpoint, err, tt.ok)
+		}
+	}
+	// A local network may use plain http on loopback.
+	if err := checkDiscoveredOAuthEndpoint("http://127.0.0.1:4444/token", "http://127.0.0.1:4444", nil); err != nil {
+		t.Errorf("loopback http: %v", err)
+	}
+}
+
+func TestRunTokenRevokeCmd_Advertised(t *testing.T) {
+	t.Parallel()
+	srv, credPath, revoked := newOAuthTestServer(t, true)
+	var out bytes.Buffer
+
+	err := runTokenRevokeCmd(srv.URL, credPath, "-", strings.NewReader("handed-out-token\n"), &out)
+
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	var got tokenRevokeResult
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || !got.Revoked {
+		t.Errorf("expected revoked: true, got %s (%v)", out.String(), err)
+	}
+	if len(*revoked) != 1 || (*revoked)[0] != "handed-out-token" {
+		t.Errorf("server saw revocations %v", *revoked)
+	}
+}
+
+func TestRunTokenRevokeCmd_NotAdvertised(t *testing.T) {
+	t.Parallel()
+	srv, credPath, revoked := newOAuthTestServer(t, false)
+	var out bytes.Buffer
+
+	err := runTokenRevokeCmd(srv.URL, credPath, "", nil, &out)
+
+	if err != nil {
+		t.Fatalf("revoke should degrade gracefully, got %v", err)
+	}
+	var got tokenRevokeResult
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || got.Revoked || !strings.Contains(got.Reason, "not advertised") {
+		t.Errorf("unexpected result %s (%v)", out.String(), err)
+	}
+	if len(*revoked) != 0 {
+		t.Errorf("nothing should be revoked, got %v", *revoked)
+	}
+}
+
+func TestRunTokenIntrospectCmd_Advertised(t *testing.T) {
+	t.Parallel()
+	srv, credPath, _ := newOAuthTestServer(t, true)
+	var out bytes.Buffer
+
+	err := runTokenIntrospectCmd(srv.URL, credPath, "", nil, &out)
+
+	if err != nil {
+		t.Fatalf("introspect: %v", err)
+	}
+	var got tokenIntrospection
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Source != "introspection" || got.Active == nil || !*got.Active || got.ClientID != "cid" || got.ExpiresAt != "2026-01-01T00:00:00Z" {
+		t.Errorf("unexpected introspection %s", out.String())
+	}
+}
+
+func TestRunTokenIntrospectCmd_FallsBackToTokenResponse(t *testing.T) {
+	t.Parallel()
+	srv, credPath, _ := newOAuthTestServer(t, false)
+	var out bytes.Buffer
+
+	if err := runTokenIntrospectCmd(srv.URL, credPath, "", nil, &out); err != nil {
+		t.Fatalf("introspect: %v", err)
+	}
+	var got tokenIntrospection
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Source != "token-response" || len(got.Scopes) != 2 || got.Active != nil {
+		t.Errorf("unexpected fallback %s", out.String())
+	}
+
+	// An external token cannot be described without the endpoint.
+	if err := runTokenIntrospectCmd(srv.URL, credPath, "other", nil, &out); err == nil {
+		t.Error("expected error introspecting --token without an endpoint")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -153,3 +154,75 @@ func TestTokenManagerAuthError(t *testing.T) {
 		t.Fatal("expected error for 401 response, got nil")
 	}
 }
+
+func TestTokenManagerRequestsScopes(t *testing.T) {
+	var gotScope string
+	var sent bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotScope, sent = r.FormValue("scope"), r.Form.Has("scope")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "tok", "expires_in": 3600, "scope": gotScope})
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { _ = setScopeOverride("") })
+
+	tests := []struct {
+		name     string
+		config   []string
+		override string
+		want     string
+	}{
+		{"none", nil, "", ""},
+		{"config scopes", []string{"diary:read", "entry:read"}, "", "diary:read entry:read"},
+		{"--oauth-scope wins", []string{"diary:write"}, "diary:read,entry:read", "diary:read entry:read"},
+	}
+	for _, tt := range tests {
+		if err := setScopeOverride(tt.override); err != nil {
+			t.Fatalf("%s: setScopeOverride() error: %v", tt.name, err)
+		}
+		creds := &CredentialsFile{OAuth2: CredentialsOAuth2{ClientID: "cid", ClientSecret: "csec", Scopes: tt.config}}
+		tm := newTokenManagerForCreds(srv.URL, creds)
+		if _, err := tm.GetToken(); err != nil {
+			t.Fatalf("%s: GetToken() error: %v", tt.name, err)
+		}
+		if gotScope != tt.want || sent != (tt.want != "") {
+			t.Errorf("%s: scope = %q (sent %v), want %q", tt.name, gotScope, sent, tt.want)
+		}
+		if tm.Scope() != tt.want {
+			t.Errorf("%s: Scope() = %q, want %q", tt.name, tm.Scope(), tt.want)
+		}
+	}
+}
+
+func TestRootCmd_OAuthScopeIgnoresLocalScopeFlags(t *testing.T) {
+	t.Cleanup(func() { _ = setScopeOverride(""); requestedAPIVersion.Store(nil) })
+	// An invalid --diary-id fails in RunE, after the root flags are applied.
+	_, _, _ = executeCommand(NewRootCmd("test", ""), "entry", "commit", "--diary-id", "bad",
+		"--rationale", "text", "--risk", "low", "--scope", "cli", "--operator", "ed", "--tool", "claude")
+	if p := scopeOverride.Load(); p != nil {
+		t.Errorf("entry commit --scope set the token scope override to %v", *p)
+	}
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "--oauth-scope", "diary:read", "version"); err != nil {
+		t.Fatalf("--oauth-scope version: %v", err)
+	}
+	if p := scopeOverride.Load(); p == nil || len(*p) != 1 || (*p)[0] != "diary:read" {
+		t.Errorf("--oauth-scope diary:read: override = %v", p)
+	}
+}
+
+func TestParseOAuthScopes(t *testing.T) {
+	t.Parallel()
+	got, err := parseOAuthScopes(" diary:read,entry:read  openid ")
+	if err != nil {
+		t.Fatalf("parseOAuthScopes() error: %v", err)
+	}
+	if strings.Join(got, "|") != "diary:read|entry:read|openid" {
+		t.Errorf("parseOAuthScopes() = %q", got)
+	}
+	for _, bad := range []string{`say"hi"`, `back\slash`, "café"} {
+		if _, err := parseOAuthScopes(bad); err == nil {
+			t.Errorf("parseOAuthScopes(%q): expected an error", bad)
+		}
+	}
+}
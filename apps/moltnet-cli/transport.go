@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// proxyOverride holds the --proxy URL set by the root command. When unset,
+// requests follow HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment.
+var proxyOverride atomic.Pointer[url.URL]
+
+// setProxyOverride validates and stores --proxy. An empty value restores
+// environment-based proxying.
+func setProxyOverride(raw string) error {
+	if raw == "" {
+		proxyOverride.Store(nil)
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid --proxy %q: want a URL like http://proxy.example:3128", raw)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("invalid --proxy %q: scheme must be http, https, socks5 or socks5h", raw)
+	}
+	proxyOverride.Store(u)
+	return nil
+}
+
+// proxyFunc returns the proxy selector for new transports. --proxy replaces
+// the HTTP(S)_PROXY values but NO_PROXY still applies, so internal hosts
+// bypass the proxy either way.
+func proxyFunc() func(*http.Request) (*url.URL, error) {
+	override := proxyOverride.Load()
+	if override == nil {
+		return http.ProxyFromEnvironment
+	}
+	cfg := httpproxy.Config{
+		HTTPProxy:  override.String(),
+		HTTPSProxy: override.String(),
+		NoProxy:    firstEnv("NO_PROXY", "no_proxy"),
+	}
+	selector := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return selector(req.URL)
+	}
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Connection defaults. Bulk commands and streaming keep several requests
+// in flight to the one API host, so more idle connections per host are
+// kept than net/http's default of 2.
+const (
+	defaultMaxIdleConnsPerHost = 16
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+// transportTuning is the connection reuse and protocol configuration of
+// buildBaseTransport.
+type transportTuning struct {
+	http2               bool
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	tlsHandshakeTimeout time.Duration
+}
+
+func defaultTransportTuning() transportTuning {
+	return transportTuning{
+		http2:               true,
+		maxIdleConnsPerHost: defaultMaxIdleConnsPerHost,
+		idleConnTimeout:     defaultIdleConnTimeout,
+		tlsHandshakeTimeout: defaultTLSHandshakeTimeout,
+	}
+}
+
+// tuning holds the tuning set by the root command; nil means the defaults.
+var tuning atomic.Pointer[transportTuning]
+
+// transportTuningFlags are the raw --http2, --max-idle-conns-per-host,
+// --idle-conn-timeout and --tls-handshake-timeout values. An empty value
+// falls back to its environment variable, then to the default.
+type transportTuningFlags struct {
+	http2               string
+	maxIdleConnsPerHost string
+	idleConnTimeout     string
+	tlsHandshakeTimeout string
+}
+
+// setTransportTuning validates and stores the transport tuning flags.
+func setTransportTuning(f transportTuningFlags) error {
+	t := defaultTransportTuning()
+	if raw := flagOrEnv(f.http2, "MOLTNET_HTTP2"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid --http2 %q: want true or false", raw)
+		}
+		t.http2 = v
+	}
+	if raw := flagOrEnv(f.maxIdleConnsPerHost, "MOLTNET_MAX_IDLE_CONNS_PER_HOST"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid --max-idle-conns-per-host %q: want a non-negative count", raw)
+		}
+		t.maxIdleConnsPerHost = n
+	}
+	for _, d := range []struct {
+		flag, env, raw string
+		dst            *time.Duration
+	}{
+		{"--idle-conn-timeout", "MOLTNET_IDLE_CONN_TIMEOUT", f.idleConnTimeout, &t.idleConnTimeout},
+		{"--tls-handshake-timeout", "MOLTNET_TLS_HANDSHAKE_TIMEOUT", f.tlsHandshakeTimeout, &t.tlsHandshakeTimeout},
+	} {
+		raw := flagOrEnv(d.raw, d.env)
+		if raw == "" {
+			continue
+		}
+		v, err := time.ParseDuration(raw)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid %s %q: want a duration like 30s (0 means no limit)", d.flag, raw)
+		}
+		*d.dst = v
+	}
+	tuning.Store(&t)
+	return nil
+}
+
+func flagOrEnv(flag, env string) string {
+	if flag != "" {
+		return flag
+	}
+	return strings.TrimSpace(os.Getenv(env))
+}
+
+func currentTransportTuning() transportTuning {
+	if p := tuning.Load(); p != nil {
+		return *p
+	}
+	return defaultTransportTuning()
+}
+
+// sharedTransport is the base transport built for the current proxy and
+// tuning settings, reused by every client so idle connections are pooled
+// across them. It is rebuilt when the root flags change those settings.
+var sharedTransport struct {
+	mu      sync.Mutex
+	proxy   *url.URL
+	tuning  *transportTuning
+	noProxy string
+	t       *http.Transport
+}
+
+// baseTransport returns the transport every outbound HTTP client in the
+// CLI is built on; see buildBaseTransport. It is shared: callers wrap it
+// in their own layers and must not modify it (clone it instead, as
+// newPinnedTransport does).
+func baseTransport() http.RoundTripper {
+	proxy, tuned, noProxy := proxyOverride.Load(), tuning.Load(), firstEnv("NO_PROXY", "no_proxy")
+	sharedTransport.mu.Lock()
+	defer sharedTransport.mu.Unlock()
+	st := &sharedTransport
+	if st.t == nil || st.proxy != proxy || st.tuning != tuned || st.noProxy != noProxy {
+		if st.t != nil {
+			st.t.CloseIdleConnections()
+		}
+		st.t, st.proxy, st.tuning, st.noProxy = buildBaseTransport(), proxy, tuned, noProxy
+	}
+	return st.t
+}
+
+// buildBaseTransport returns the default transport with the proxy
+// selection above and the connection tuning from the root flags. With
+// HTTP/2 disabled only HTTP/1.1 is negotiated, for proxies and middleboxes
+// that mishandle h2.
+func buildBaseTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxyFunc()
+	cfg := currentTransportTuning()
+	t.MaxIdleConnsPerHost = cfg.maxIdleConnsPerHost
+	if t.MaxIdleConns < cfg.maxIdleConnsPerHost {
+		t.MaxIdleConns = cfg.maxIdleConnsPerHost
+	}
+	t.IdleConnTimeout = cfg.idleConnTimeout
+	t.TLSHandshakeTimeout = cfg.tlsHandshakeTimeout
+	if !cfg.http2 {
+		t.ForceAttemptHTTP2 = false
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP1(true)
+	}
+	return t
+}
+
+// newHTTPClient returns a plain client on baseTransport with the
+// response size limit applied. A zero timeout means none.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: newResponseLimitTransport(baseTransport(), currentMaxResponseSize(false))}
+}
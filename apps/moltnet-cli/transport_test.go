@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetProxyOverride_Validation(t *testing.T) {
+	t.Cleanup(func() { proxyOverride.Store(nil) })
+	for _, raw := range []string{"http://proxy.example:3128", "socks5://127.0.0.1:1080", ""} {
+		if err := setProxyOverride(raw); err != nil {
+			t.Errorf("%q: unexpected error %v", raw, err)
+		}
+	}
+	for _, raw := range []string{"proxy.example:3128", "ftp://proxy.example", "http://"} {
+		if err := setProxyOverride(raw); err == nil {
+			t.Errorf("%q: expected error", raw)
+		}
+	}
+}
+
+func TestProxyFunc_OverrideHonorsNoProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example")
+	t.Cleanup(func() { proxyOverride.Store(nil) })
+	if err := setProxyOverride("http://proxy.example:3128"); err != nil {
+		t.Fatal(err)
+	}
+	selector := proxyFunc()
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.themolt.net/agents", nil)
+	got, err := selector(req)
+	if err != nil || got == nil || got.Host != "proxy.example:3128" {
+		t.Errorf("public host: got %v, %v", got, err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, "https://internal.example/x", nil)
+	if got, _ := selector(req); got != nil {
+		t.Errorf("NO_PROXY host should bypass the proxy, got %v", got)
+	}
+}
+
+func TestBaseTransport_UsesProxySelector(t *testing.T) {
+	t.Cleanup(func() { proxyOverride.Store(nil) })
+	if err := setProxyOverride("http://proxy.example:3128"); err != nil {
+		t.Fatal(err)
+	}
+	tr, ok := baseTransport().(*http.Transport)
+	if !ok || tr.Proxy == nil {
+		t.Fatal("base transport should be an *http.Transport with a proxy selector")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.themolt.net/", nil)
+	if got, _ := tr.Proxy(req); got == nil || got.Host != "proxy.example:3128" {
+		t.Errorf("proxy: got %v", got)
+	}
+}
+
+func TestBaseTransport_SharedUntilSettingsChange(t *testing.T) {
+	t.Cleanup(func() { tuning.Store(nil) })
+	tuning.Store(nil)
+	first := baseTransport()
+	if baseTransport() != first {
+		t.Fatal("clients should share one base transport")
+	}
+	if err := setTransportTuning(transportTuningFlags{maxIdleConnsPerHost: "4"}); err != nil {
+		t.Fatal(err)
+	}
+	if tr := baseTransport().(*http.Transport); tr == first || tr.MaxIdleConnsPerHost != 4 {
+		t.Errorf("changed tuning should rebuild the base transport, got idle/host %d", tr.MaxIdleConnsPerHost)
+	}
+}
+
+func TestRootCmd_RejectsInvalidProxy(t *testing.T) {
+	t.Cleanup(func() { proxyOverride.Store(nil); requestedAPIVersion.Store(nil) })
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "--proxy", "not a url", "version"); err == nil {
+		t.Error("expected error for invalid --proxy")
+	}
+}
+
+func TestBaseTransport_DefaultTuning(t *testing.T) {
+	t.Cleanup(func() { tuning.Store(nil) })
+	tuning.Store(nil)
+	tr := baseTransport().(*http.Transport)
+	if tr.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost || tr.IdleConnTimeout != defaultIdleConnTimeout || tr.TLSHandshakeTimeout != defaultTLSHandshakeTimeout {
+		t.Errorf("defaults: idle/host %d, idle timeout %v, TLS timeout %v", tr.MaxIdleConnsPerHost, tr.IdleConnTimeout, tr.TLSHandshakeTimeout)
+	}
+	if tr.Protocols != nil || !tr.ForceAttemptHTTP2 {
+		t.Error("HTTP/2 should be negotiated by default")
+	}
+}
+
+func TestSetTransportTuning(t *testing.T) {
+	t.Cleanup(func() { tuning.Store(nil) })
+	t.Setenv("MOLTNET_IDLE_CONN_TIMEOUT", "5s")
+	t.Setenv("MOLTNET_MAX_IDLE_CONNS_PER_HOST", "2")
+	err := setTransportTuning(transportTuningFlags{http2: "false", maxIdleConnsPerHost: "200", tlsHandshakeTimeout: "3s"})
+	if err != nil {
+		t.Fatalf("setTransportTuning() error: %v", err)
+	}
+	tr := baseTransport().(*http.Transport)
+	if tr.MaxIdleConnsPerHost != 200 || tr.MaxIdleConns < 200 {
+		t.Errorf("flag should win over env: idle/host %d, idle %d", tr.MaxIdleConnsPerHost, tr.MaxIdleConns)
+	}
+	if tr.IdleConnTimeout != 5*time.Second {
+		t.Errorf("idle timeout = %v, want the env value 5s", tr.IdleConnTimeout)
+	}
+	if tr.TLSHandshakeTimeout != 3*time.Second {
+		t.Errorf("TLS timeout = %v, want 3s", tr.TLSHandshakeTimeout)
+	}
+	if tr.Protocols == nil || tr.Protocols.HTTP2() || !tr.Protocols.HTTP1() {
+		t.Errorf("protocols = %v, want HTTP/1.1 only", tr.Protocols)
+	}
+
+	for _, f := range []transportTuningFlags{
+		{http2: "sometimes"},
+		{maxIdleConnsPerHost: "-1"},
+		{idleConnTimeout: "soon"},
+		{tlsHandshakeTimeout: "-5s"},
+	} {
+		if err := setTransportTuning(f); err == nil {
+			t.Errorf("%+v: expected error", f)
+		}
+	}
+}
+
+func TestBaseTransport_HTTP2Toggle(t *testing.T) {
+	t.Cleanup(func() { tuning.Store(nil) })
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	for _, tt := range []struct {
+		http2 string
+		want  string
+	}{{"true", "HTTP/2.0"}, {"false", "HTTP/1.1"}} {
+		if err := setTransportTuning(transportTuningFlags{http2: tt.http2}); err != nil {
+			t.Fatal(err)
+		}
+		tr := buildBaseTransport()
+		tr.Proxy = nil
+		tr.TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+		resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err != nil {
+			t.Fatalf("--http2=%s: %v", tt.http2, err)
+		}
+		resp.Body.Close()
+		if resp.Proto != tt.want {
+			t.Errorf("--http2=%s: protocol %s, want %s", tt.http2, resp.Proto, tt.want)
+		}
+	}
+}
+
+func TestRootCmd_RejectsInvalidTransportTuning(t *testing.T) {
+	t.Cleanup(func() { tuning.Store(nil); requestedAPIVersion.Store(nil) })
+	if _, _, err := executeCommand(NewRootCmd("test", ""), "--idle-conn-timeout", "forever", "version"); err == nil {
+		t.Error("expected error for invalid --idle-conn-timeout")
+	}
+}
@@ -3,12 +3,27 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
 )
 
+// voucherView is a voucher as printed by the vouch commands: the server's
+// fields plus the issuer's local note.
+type voucherView struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	IssuedBy  string    `json:"issuedBy,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Active    bool      `json:"active"`
+}
+
+func newVoucherView(v moltnetapi.Voucher, notes voucherNotes) voucherView {
+	return voucherView{Code: v.Code, ExpiresAt: v.ExpiresAt, IssuedBy: v.IssuedBy, Note: notes[v.Code].Note, Active: true}
+}
+
 // runVouchIssueCmd is the flag-free business logic for vouch issue.
-func runVouchIssueCmd(apiURL, credPath string) error {
+func runVouchIssueCmd(apiURL, credPath, note string) error {
 	client, err := newClientFromCreds(apiURL, credPath)
 	if err != nil {
 		return err
@@ -21,22 +36,66 @@ func runVouchIssueCmd(apiURL, credPath string) error {
 	if !ok {
 		return formatAPIError(res)
 	}
-	return printJSON(voucher)
+	notes := voucherNotes{}
+	if note != "" {
+		// The voucher exists either way; losing the note must not hide its code.
+		if err := saveVoucherNote(voucher, note); err != nil {
+			warnVoucherNote(err)
+		} else {
+			notes[voucher.Code] = voucherNote{Note: note}
+		}
+	}
+	return printJSON(newVoucherView(*voucher, notes))
 }
 
 // runVouchListCmd is the flag-free business logic for vouch list.
 func runVouchListCmd(apiURL, credPath string) error {
-	client, err := newClientFromCreds(apiURL, credPath)
+	vouchers, notes, err := fetchActiveVouchers(apiURL, credPath, "vouch list")
+	if err != nil {
+		return err
+	}
+	views := make([]voucherView, 0, len(vouchers))
+	for _, v := range vouchers {
+		views = append(views, newVoucherView(v, notes))
+	}
+	return printJSON(map[string][]voucherView{"vouchers": views})
+}
+
+// runVouchShowCmd prints one voucher. A code that is no longer active
+// (redeemed or expired) is still shown when this machine has a note for it.
+func runVouchShowCmd(apiURL, credPath, code string) error {
+	vouchers, notes, err := fetchActiveVouchers(apiURL, credPath, "vouch show")
 	if err != nil {
 		return err
 	}
+	for _, v := range vouchers {
+		if v.Code == code {
+			return printJSON(newVoucherView(v, notes))
+		}
+	}
+	if n, ok := notes[code]; ok {
+		return printJSON(voucherView{Code: code, ExpiresAt: n.ExpiresAt, Note: n.Note})
+	}
+	return expectedErrorf("vouch show: no active voucher %q", code)
+}
+
+func fetchActiveVouchers(apiURL, credPath, op string) ([]moltnetapi.Voucher, voucherNotes, error) {
+	client, err := newClientFromCreds(apiURL, credPath)
+	if err != nil {
+		return nil, nil, err
+	}
 	res, err := client.ListActiveVouchers(context.Background())
 	if err != nil {
-		return fmt.Errorf("vouch list: %w", formatTransportError(err))
+		return nil, nil, fmt.Errorf("%s: %w", op, formatTransportError(err))
 	}
-	vouchers, ok := res.(*moltnetapi.ListActiveVouchersOK)
+	list, ok := res.(*moltnetapi.ListActiveVouchersOK)
 	if !ok {
-		return formatAPIError(res)
+		return nil, nil, formatAPIError(res)
+	}
+	notes, err := loadVoucherNotes()
+	if err != nil {
+		warnVoucherNote(err)
+		notes = voucherNotes{}
 	}
-	return printJSON(vouchers)
+	return list.Vouchers, notes, nil
 }
@@ -68,3 +68,50 @@ func TestVouchListActive(t *testing.T) {
 		t.Errorf("expected 2 vouchers, got %d", len(list.Vouchers))
 	}
 }
+
+func TestRunVouchIssueCmd_SavesNote(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv, credPath := newCLICommandTestServer(t, &stubVouchHandler{})
+
+	if err := runVouchIssueCmd(srv.URL, credPath, "for Alice's research agent"); err != nil {
+		t.Fatalf("runVouchIssueCmd() error: %v", err)
+	}
+
+	notes, err := loadVoucherNotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes["VOUCHER-123"].Note != "for Alice's research agent" {
+		t.Errorf("notes = %+v", notes)
+	}
+	if err := runVouchShowCmd(srv.URL, credPath, "VOUCHER-123"); err != nil {
+		t.Errorf("show redeemed-but-noted voucher: %v", err)
+	}
+}
+
+func TestRunVouchShowCmd_Unknown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv, credPath := newCLICommandTestServer(t, &stubVouchHandler{})
+
+	err := runVouchShowCmd(srv.URL, credPath, "NOPE")
+
+	if err == nil || errorExitCode(err) != exitCodeExpected {
+		t.Errorf("expected an expected-failure error, got %v", err)
+	}
+}
+
+func TestSaveVoucherNote_PrunesExpired(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := saveVoucherNote(&moltnetapi.Voucher{Code: "OLD", ExpiresAt: time.Now().Add(-30 * 24 * time.Hour)}, "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveVoucherNote(&moltnetapi.Voucher{Code: "NEW", ExpiresAt: time.Now().Add(time.Hour)}, "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, _ := loadVoucherNotes()
+	if _, ok := notes["OLD"]; ok || notes["NEW"].Note != "new" {
+		t.Errorf("notes = %+v", notes)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	moltnetapi "github.com/getlarge/themoltnet/libs/moltnet-api-client"
+)
+
+// voucherNotesFile holds the issuer's notes, keyed by voucher code. The
+// server stores no voucher metadata, so notes live only on the machine
+// that issued the code.
+const voucherNotesFile = "voucher-notes.json"
+
+type voucherNote struct {
+	Note      string    `json:"note"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type voucherNotes map[string]voucherNote
+
+func voucherNotesPath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, voucherNotesFile), nil
+}
+
+// loadVoucherNotes reads the notes file. A missing file means no notes.
+func loadVoucherNotes() (voucherNotes, error) {
+	path, err := voucherNotesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return voucherNotes{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read voucher notes: %w", err)
+	}
+	notes := voucherNotes{}
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return notes, nil
+}
+
+// saveVoucherNote records note for a newly issued voucher and drops notes
+// for vouchers that expired over a week ago, so the file does not grow
+// without bound.
+func saveVoucherNote(v *moltnetapi.Voucher, note string) error {
+	notes, err := loadVoucherNotes()
+	if err != nil {
+		return err
+	}
+	cutoff := timeNow().Add(-7 * 24 * time.Hour)
+	for code, n := range notes {
+		if n.ExpiresAt.Before(cutoff) {
+			delete(notes, code)
+		}
+	}
+	notes[v.Code] = voucherNote{Note: note, ExpiresAt: v.ExpiresAt}
+	path, err := voucherNotesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	return writeJSONAtomic(path, notes)
+}
+
+func warnVoucherNote(err error) {
+	fmt.Fprintf(os.Stderr, "Warning: voucher notes unavailable: %v\n", err)
+}
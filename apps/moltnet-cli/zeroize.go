@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+)
+
+// Key material discipline: a seed or private key decoded into a byte slice
+// is zeroed as soon as the operation that needed it returns, instead of
+// lingering until the GC reuses the memory. Go makes no erasure guarantee
+// (the runtime may have copied the bytes, and base64 strings in the config
+// are immutable), so this narrows what core dumps and memory scraping can
+// find rather than eliminating it.
+
+// zeroBytes overwrites b in place.
+func zeroBytes(b []byte) {
+	clear(b)
+}
+
+// decodeSeed decodes a base64 Ed25519 seed and checks its length. The
+// caller owns the result and must zero it.
+func decodeSeed(seedBase64 string) ([]byte, error) {
+	seed, err := base64.StdEncoding.DecodeString(seedBase64)
+	if err != nil {
+		return nil, fmt.Errorf("decode private key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		zeroBytes(seed)
+		return nil, fmt.Errorf("private key seed must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return seed, nil
+}
+
+// withSeed decodes a base64 Ed25519 seed, passes it to fn and zeroes it
+// when fn returns. fn must not retain the slice.
+func withSeed(seedBase64 string, fn func(seed []byte) error) error {
+	seed, err := decodeSeed(seedBase64)
+	if err != nil {
+		return err
+	}
+	defer zeroBytes(seed)
+	return fn(seed)
+}
+
+// withPrivateKey is withSeed for the expanded Ed25519 private key, which
+// is zeroed along with the seed.
+func withPrivateKey(seedBase64 string, fn func(priv ed25519.PrivateKey) error) error {
+	return withSeed(seedBase64, func(seed []byte) error {
+		priv := ed25519.NewKeyFromSeed(seed)
+		defer zeroBytes(priv)
+		return fn(priv)
+	})
+}
+
+// signWithSeed signs msg with the key derived from a base64 seed and
+// returns the base64 signature.
+func signWithSeed(seedBase64 string, msg []byte) (string, error) {
+	var sig []byte
+	err := withPrivateKey(seedBase64, func(priv ed25519.PrivateKey) error {
+		sig = ed25519.Sign(priv, msg)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
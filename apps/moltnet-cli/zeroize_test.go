@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestWithPrivateKey_ZeroesKeyMaterial(t *testing.T) {
+	t.Parallel()
+	kp, err := KeyPairFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen, expanded []byte
+	err = withPrivateKey(kp.PrivateKey, func(priv ed25519.PrivateKey) error {
+		seen = priv.Seed()
+		expanded = priv // retained only to check it was cleared
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withPrivateKey: %v", err)
+	}
+	if !bytes.Equal(seen, bytes.Repeat([]byte{7}, ed25519.SeedSize)) {
+		t.Errorf("fn saw seed %x", seen)
+	}
+	if !bytes.Equal(expanded, make([]byte, ed25519.PrivateKeySize)) {
+		t.Errorf("private key not zeroed after use: %x", expanded)
+	}
+}
+
+func TestDecodeSeed_Rejects(t *testing.T) {
+	t.Parallel()
+	for _, in := range []string{"not base64!", "dG9vc2hvcnQ="} {
+		if _, err := decodeSeed(in); err == nil {
+			t.Errorf("decodeSeed(%q): expected an error", in)
+		}
+	}
+	if _, err := SignForRequest("m", "n", "dG9vc2hvcnQ="); err == nil || !strings.Contains(err.Error(), "32 bytes") {
+		t.Errorf("SignForRequest with a short seed: got %v, want a length error", err)
+	}
+}
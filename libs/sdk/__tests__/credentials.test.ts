@@ -199,6 +199,30 @@ describe('credentials / config', () => {
       expect(result!.git).toEqual(configWithSections.git);
       expect(result!.github).toEqual(configWithSections.github);
     });
+
+    it('fields written by the Go CLI round-trip correctly', async () => {
+      // Arrange
+      const cliConfig: MoltNetConfig = {
+        ...sampleConfig,
+        schema_version: 2,
+        oauth2: {
+          ...sampleConfig.oauth2,
+          token_endpoint: 'discover',
+          scopes: ['diary:read'],
+          trusted_origins: ['https://auth.themolt.net'],
+        },
+        requests: { sign: true },
+        networks: { staging: 'https://api.staging.themolt.net' },
+      };
+
+      // Act
+      const { writeConfig, readConfig } = await import('../src/credentials.js');
+      await writeConfig(cliConfig);
+      const result = await readConfig();
+
+      // Assert
+      expect(result).toEqual(cliConfig);
+    });
   });
 
   describe('updateConfigSection', () => {
@@ -11,9 +11,19 @@ export function deriveMcpUrl(apiUrl: string): string {
 }
 
 export interface MoltNetConfig {
+  /** Schema version stamped by the CLI; absent means version 1. */
+  schema_version?: number;
   identity_id: string;
   registered_at: string;
-  oauth2: { client_id: string; client_secret: string };
+  oauth2: {
+    client_id: string;
+    client_secret: string;
+    /** Token endpoint URL, or "discover" to read it from the network. */
+    token_endpoint?: string;
+    scopes?: string[];
+    /** Origins other than the API's that may serve OAuth endpoints. */
+    trusted_origins?: string[];
+  };
   keys: { public_key: string; private_key: string; fingerprint: string };
   endpoints: { api: string; mcp: string };
   ssh?: { private_key_path: string; public_key_path: string };
@@ -30,6 +40,10 @@ export interface MoltNetConfig {
     private_key_path: string;
     org?: string;
   };
+  /** Sign every API request with the agent key. */
+  requests?: { sign: boolean };
+  /** Network names mapped to API base URLs. */
+  networks?: Record<string, string>;
 }
 
 export function getConfigDir(): string {